@@ -68,6 +68,13 @@ var ActionFuncs = map[string]map[string]map[string]Action{
 			"s3-stdout":                Action{FnAction: RunS3StdoutSnapshot, ActionCfg: &S3StdoutSnapConfig{}, FnSetupCfg: SetupCpS3StdoutSnap},
 			"netezza-s3":               Action{FnAction: RunDsnS3Snapshot, ActionCfg: &DsnS3SnapConfig{}, FnSetupCfg: SetupCpDsnS3Snap},
 			"netezza-snowflake":        Action{FnAction: RunDsnSnowflakeSnapshot, ActionCfg: &DsnSnowflakeSnapConfig{}, FnSetupCfg: SetupCpDsnSnowflakeSnap},
+			"postgres-postgres":        Action{FnAction: RunDsnSnapshot, ActionCfg: &DsnSnapConfig{}, FnSetupCfg: SetupCpDsnSnap},
+			"postgres-oracle":          Action{FnAction: RunDsnSnapshot, ActionCfg: &DsnSnapConfig{}, FnSetupCfg: SetupCpDsnSnap},
+			"postgres-sqlserver":       Action{FnAction: RunDsnSnapshot, ActionCfg: &DsnSnapConfig{}, FnSetupCfg: SetupCpDsnSnap},
+			"postgres-s3":              Action{FnAction: RunDsnS3Snapshot, ActionCfg: &DsnS3SnapConfig{}, FnSetupCfg: SetupCpDsnS3Snap},
+			"postgres-snowflake":       Action{FnAction: RunDsnSnowflakeSnapshot, ActionCfg: &DsnSnowflakeSnapConfig{}, FnSetupCfg: SetupCpDsnSnowflakeSnap},
+			"oracle-postgres":          Action{FnAction: RunDsnSnapshot, ActionCfg: &DsnSnapConfig{}, FnSetupCfg: SetupCpDsnSnap},
+			"sqlserver-postgres":       Action{FnAction: RunDsnSnapshot, ActionCfg: &DsnSnapConfig{}, FnSetupCfg: SetupCpDsnSnap},
 		},
 		constants.ActionFuncsSubCommandDelta: {
 			// TODO: add commented delta actions!
@@ -83,6 +90,8 @@ var ActionFuncs = map[string]map[string]map[string]Action{
 			"odbc+sqlserver-s3":        Action{FnAction: RunDsnS3Delta, ActionCfg: &DsnS3DeltaConfig{}, FnSetupCfg: SetupCpDsnS3Delta},
 			"odbc+sqlserver-snowflake": Action{FnAction: RunDsnSnowflakeDelta, ActionCfg: &DsnSnowflakeDeltaConfig{}, FnSetupCfg: SetupCpDsnSnowflakeDelta},
 			"s3-snowflake":             Action{FnAction: RunS3SnowflakeDelta, ActionCfg: &S3SnowflakeDeltaConfig{}, FnSetupCfg: SetupCpS3SnowflakeDelta},
+			"postgres-s3":              Action{FnAction: RunDsnS3Delta, ActionCfg: &DsnS3DeltaConfig{}, FnSetupCfg: SetupCpDsnS3Delta},
+			"postgres-snowflake":       Action{FnAction: RunDsnSnowflakeDelta, ActionCfg: &DsnSnowflakeDeltaConfig{}, FnSetupCfg: SetupCpDsnSnowflakeDelta},
 			// "s3-stdout":             Action{FnAction: , ActionCfg: &S3SnowflakeDeltaConfig{}, FnSetupCfg: SetupCpS3SnowflakeDelta},
 		},
 		constants.ActionFuncsSubCommandMeta: { // meta...
@@ -92,6 +101,7 @@ var ActionFuncs = map[string]map[string]map[string]Action{
 			"sqlserver-snowflake":      Action{FnAction: RunTableToSnowflakeDDL, ActionCfg: &TableToSnowTableDDLConfig{}, FnSetupCfg: SetupCpMetaToSnowflake},
 			"netezza-snowflake":        Action{FnAction: RunTableToSnowflakeDDL, ActionCfg: &TableToSnowTableDDLConfig{}, FnSetupCfg: SetupCpMetaToSnowflake},
 			"odbc+sqlserver-snowflake": Action{FnAction: RunTableToSnowflakeDDL, ActionCfg: &TableToSnowTableDDLConfig{}, FnSetupCfg: SetupCpMetaToSnowflake},
+			"postgres-snowflake":       Action{FnAction: RunTableToSnowflakeDDL, ActionCfg: &TableToSnowTableDDLConfig{}, FnSetupCfg: SetupCpMetaToSnowflake},
 		},
 	},
 	constants.ActionFuncsCommandSync: {
@@ -101,6 +111,9 @@ var ActionFuncs = map[string]map[string]map[string]Action{
 			"oracle-snowflake": Action{FnAction: RunOracleSnowflakeSync, ActionCfg: &SyncOracleSnowflakeConfig{}, FnSetupCfg: SetupOracleToSnowflakeSync},
 			// "oracle-sqlserver": Action{FnAction: , ActionCfg: &SyncOracleSnowflakeConfig{}, FnSetupCfg: SetupOracleToSnowflakeSync},
 			// "sqlserver-oracle": Action{FnAction: , ActionCfg: &SyncOracleSnowflakeConfig{}, FnSetupCfg: SetupOracleToSnowflakeSync},
+			"postgres-postgres": Action{FnAction: RunDsnDsnSync, ActionCfg: &SyncDsnDsnConfig{}, FnSetupCfg: SetupDsnToDsnSync},
+			"postgres-oracle":   Action{FnAction: RunDsnDsnSync, ActionCfg: &SyncDsnDsnConfig{}, FnSetupCfg: SetupDsnToDsnSync},
+			"oracle-postgres":   Action{FnAction: RunDsnDsnSync, ActionCfg: &SyncDsnDsnConfig{}, FnSetupCfg: SetupDsnToDsnSync},
 		},
 		constants.ActionFuncsSubCommandEvents: {
 			// The source system needs to support continuous query notifications.
@@ -84,10 +84,26 @@ var jsonDsnS3DeltaDate = `{
             "readDataFromStep2": "filterRows"
           }
         },
+        "checkpointRead": {
+          "type": "CheckpointRead",
+          "data": {
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "outputFieldName4Value": "#maxS3FileDate"
+          }
+        },
+        "joinCheckpoint": {
+          "type": "ChannelCombiner",
+          "data": {
+            "readDataFromStep1": "joinStreams",
+            "readDataFromStep2": "checkpointRead"
+          }
+        },
         "filterRows2": {
           "type": "FilterRows",
           "data": {
-            "readDataFromStep": "joinStreams",
+            "readDataFromStep": "joinCheckpoint",
             "filterType": "GetMax",
             "filterMetadata": "#maxS3FileDate"
           }
@@ -108,6 +124,8 @@ var jsonDsnS3DeltaDate = `{
         "filterRows",
         "dummyRow",
         "joinStreams",
+        "checkpointRead",
+        "joinCheckpoint",
         "filterRows2",
         "metadataInjectGenerateDateRanges"
       ]
@@ -119,7 +137,7 @@ var jsonDsnS3DeltaDate = `{
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "source",
-            "sqlText": "select case when min_date > next_batch_start_date then min_date else next_batch_start_date end \"#nextBatchMinDate\", ${currentDateTime} \"#extractDate\", \"#maxDateAvailable\" from ( select dateadd(day, -1, min(${SQLBatchDriverField})) min_date /* use min-1 since the extractDateRanges SELECT below uses gt '>' instead of gte '>=' */, ${deltaDateTemplateLowDate} next_batch_start_date, max(${SQLBatchDriverField}) \"#maxDateAvailable\" from ${sourceTable} ) a"
+            "sqlText": "select case when min_date > next_batch_start_date then min_date else next_batch_start_date end \"#nextBatchMinDate\", ${currentDateTime} \"#extractDate\", \"#maxDateAvailable\" from ( select ${deltaDateTemplateMinDateLessOneDay} min_date /* use min-1 since the extractDateRanges SELECT below uses gt '>' instead of gte '>=' */, ${deltaDateTemplateLowDate} next_batch_start_date, max(${SQLBatchDriverField}) \"#maxDateAvailable\" from ${sourceTable} ) a"
           }
         },
         "generateDateRanges": {
@@ -163,16 +181,16 @@ var jsonDsnS3DeltaDate = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "sqlInput",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}-${sourceFromDate}-${sourceToDate}-${extractDate}",
             "fileNameSuffixAppendCreationStamp": "false",
             "fileNameSuffixDateTimeFormat": "20060102T150405",
-            "fileNameExtension": "csv",
-            "useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+            "fileNameExtension": "${fileNameExtension}",
+            "useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -214,6 +232,16 @@ var jsonDsnS3DeltaDate = `{
             "bucketRegion": "${tgtS3Region}",
             "removeInputFiles": "true"
           }
+        },
+        "checkpointWrite": {
+          "type": "CheckpointWrite",
+          "data": {
+            "readDataFromStep": "copyManifestToS3",
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "checkpointValue": "${sourceToDate}"
+          }
         }
       },
       "sequence": [
@@ -221,7 +249,8 @@ var jsonDsnS3DeltaDate = `{
         "csvWriter",
         "copyFilesToS3",
         "manifestWriter",
-        "copyManifestToS3"
+        "copyManifestToS3",
+        "checkpointWrite"
       ]
     }
   },
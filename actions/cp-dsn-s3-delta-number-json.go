@@ -96,10 +96,26 @@ var jsonDsnS3DeltaNumber = `{
             "readDataFromStep2": "filterRows"
           }
         },
+        "checkpointRead": {
+          "type": "CheckpointRead",
+          "data": {
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "outputFieldName4Value": "#maxS3FileSequence"
+          }
+        },
+        "joinCheckpoint": {
+          "type": "ChannelCombiner",
+          "data": {
+            "readDataFromStep1": "joinStreams",
+            "readDataFromStep2": "checkpointRead"
+          }
+        },
         "filterRows2": {
           "type": "FilterRows",
           "data": {
-            "readDataFromStep": "joinStreams",
+            "readDataFromStep": "joinCheckpoint",
             "filterType": "GetMax",
             "filterMetadata": "#maxS3FileSequence"
           }
@@ -121,6 +137,8 @@ var jsonDsnS3DeltaNumber = `{
         "dummyRow",
 		"leftPadDummyRow",
         "joinStreams",
+        "checkpointRead",
+        "joinCheckpoint",
         "filterRows2",
         "metadataInjectGenerateNumberRanges"
       ]
@@ -175,16 +193,16 @@ var jsonDsnS3DeltaNumber = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "sqlInput",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}-${sourceFromSequence}-${sourceToSequence}-${extractDate}",
             "fileNameSuffixAppendCreationStamp": "false",
             "fileNameSuffixDateTimeFormat": "20060102T150405",
-            "fileNameExtension": "csv",
-            "useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+            "fileNameExtension": "${fileNameExtension}",
+            "useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -226,6 +244,16 @@ var jsonDsnS3DeltaNumber = `{
             "bucketRegion": "${tgtS3Region}",
             "removeInputFiles": "true"
           }
+        },
+        "checkpointWrite": {
+          "type": "CheckpointWrite",
+          "data": {
+            "readDataFromStep": "copyManifestToS3",
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "checkpointValue": "${sourceToSequence}"
+          }
         }
       },
       "sequence": [
@@ -233,7 +261,8 @@ var jsonDsnS3DeltaNumber = `{
         "csvWriter",
         "copyFilesToS3",
         "manifestWriter",
-        "copyManifestToS3"
+        "copyManifestToS3",
+        "checkpointWrite"
       ]
     }
   },
@@ -25,6 +25,7 @@ type DsnS3DeltaConfig struct {
 	CsvHeaderFields           string `errorTxt:"csv header fields"`
 	CsvMaxFileRows            string `errorTxt:"csv max file rows"`
 	CsvMaxFileBytes           string `errorTxt:"csv max file bytes"`
+	FileFormat                string `errorTxt:"file format"`
 	RepeatInterval            int    `errorTxt:"repeat interval"`
 	ExportConfigType          string
 	ExportIncludeConnections  bool
@@ -37,6 +38,7 @@ type DsnS3DeltaConfig struct {
 	SQLBatchStartSequence string `errorTxt:"SQL batch start sequence (number)" mandatory:"yes"`
 	SQLBatchSizeSeconds   string `errorTxt:"SQL batch size seconds"`
 	SQLBatchSize          string `errorTxt:"SQL batch size days"`
+	CheckpointStore       string `errorTxt:"checkpoint store"`
 }
 
 // SetupCpDsnS3Delta copies values from genericCfg to actionCfg ready for a Oracle to S3 action.
@@ -76,12 +78,14 @@ func SetupCpDsnS3Delta(genericCfg interface{}, actionCfg interface{}) error {
 	tgt.CsvHeaderFields = src.CsvHeaderFields
 	tgt.CsvMaxFileBytes = src.CsvMaxFileBytes
 	tgt.CsvMaxFileRows = src.CsvMaxFileRows
+	tgt.FileFormat = src.FileFormat
 	// Delta specific
 	tgt.SQLBatchDriverField = src.SQLBatchDriverField
 	tgt.SQLBatchStartDateTime = src.SQLBatchStartDateTime
 	tgt.SQLBatchStartSequence = strconv.Itoa(src.SQLBatchStartSequence)
 	tgt.SQLBatchSize = src.SQLBatchSize
 	tgt.SQLBatchSizeSeconds = src.SQLBatchSizeSeconds
+	tgt.CheckpointStore = src.CheckpointStore
 	return nil
 }
 
@@ -149,6 +153,7 @@ func RunDsnS3Delta(cfg interface{}) error {
 	} else if deltaDriverDataType == 1 { // else if the field is DATE or TIMESTAMP...
 		jsonPipe = &jsonDsnS3DeltaDate
 		m["${deltaDateTemplateLowDate}"] = cfgDelta.SrcConnDetails.MustGetDateFilterSql("maxDateInTarget")
+		m["${deltaDateTemplateMinDateLessOneDay}"] = cfgDelta.SrcConnDetails.MustGetDateAddDaysSql(fmt.Sprintf("min(%v)", cfgDelta.SQLBatchDriverField), -1)
 		m["${deltaDateTemplateSourceFromDate}"] = cfgDelta.SrcConnDetails.MustGetDateFilterSql("sourceFromDate")
 		m["${deltaDateTemplateSourceToDate}"] = cfgDelta.SrcConnDetails.MustGetDateFilterSql("sourceToDate")
 		m["${SQLBatchStartDateTime}"] = cfgDelta.SQLBatchStartDateTime
@@ -165,6 +170,11 @@ func RunDsnS3Delta(cfg interface{}) error {
 		cfgDelta.SQLBatchSizeSeconds = strconv.FormatInt(sec, 10)
 	}
 	m["${SQLBatchSizeSeconds}"] = cfgDelta.SQLBatchSizeSeconds
+	// Checkpoints
+	setCheckpointVariables(m, cfgDelta.CheckpointStore, connTgt.Name, connTgt.Prefix, cfgDelta.CsvFileNamePrefix)
+	if err := setFileFormatVariables(log, m, cfgDelta.FileFormat, cfgDelta.SrcConnDetails, &cfgDelta.SrcDsnSchemaTable); err != nil {
+		return err
+	}
 	mustReplaceInStringUsingMapKeyVals(jsonPipe, m)
 	log.Debug("replaced reference JSON for snapshot ", *jsonPipe)
 	// Execute or export the transform.
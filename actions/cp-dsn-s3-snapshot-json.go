@@ -37,16 +37,16 @@ var jsonOdbcS3Snapshot = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "readFromSource",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}",
             "fileNameSuffixAppendCreationStamp": "true",
             "fileNameSuffixDateTimeFormat": "20060102T150405",
-            "fileNameExtension": "csv",
-            "useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+            "fileNameExtension": "${fileNameExtension}",
+            "useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -23,6 +23,7 @@ type DsnS3SnapConfig struct {
 	CsvHeaderFields           string `errorTxt:"csv header fields"`
 	CsvMaxFileRows            string `errorTxt:"csv max file rows"`
 	CsvMaxFileBytes           string `errorTxt:"csv max file bytes"`
+	FileFormat                string `errorTxt:"file format"`
 	RepeatInterval            int    `errorTxt:"repeat interval"`
 	ExportConfigType          string
 	ExportIncludeConnections  bool
@@ -68,6 +69,7 @@ func SetupCpDsnS3Snap(genericCfg interface{}, actionCfg interface{}) error {
 	tgt.CsvHeaderFields = src.CsvHeaderFields
 	tgt.CsvMaxFileBytes = src.CsvMaxFileBytes
 	tgt.CsvMaxFileRows = src.CsvMaxFileRows
+	tgt.FileFormat = src.FileFormat
 	return nil
 }
 
@@ -119,6 +121,9 @@ func RunDsnS3Snapshot(cfg interface{}) error {
 	}
 	m["${csvMaxFileRows}"] = cfgSnap.CsvMaxFileRows
 	m["${csvMaxFileBytes}"] = cfgSnap.CsvMaxFileBytes
+	if err := setFileFormatVariables(log, m, cfgSnap.FileFormat, cfgSnap.SrcConnDetails, &cfgSnap.SrcSchemaTable); err != nil {
+		return err
+	}
 	mustReplaceInStringUsingMapKeyVals(&jsonOdbcS3Snapshot, m)
 	log.Debug("replaced reference JSON for snapshot ", jsonOdbcS3Snapshot)
 	// Execute or export the transform.
@@ -56,7 +56,7 @@ var jsonDsnSnowflakeDeltaDate = `{
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "source",
-			"sqlText": "select case when min_date > next_batch_start_date then min_date else next_batch_start_date end \"#nextBatchMinDate\", ${currentDateTime} \"#extractDate\", \"#maxDateAvailable\" from ( select dateadd(day, -1, min(${SQLBatchDriverField})) min_date /* use min-1 since the extractDateRanges SELECT below uses gt '>' instead of gte '>=' */, ${deltaDateTemplateLowDate} next_batch_start_date, max(${SQLBatchDriverField}) \"#maxDateAvailable\" from ${sourceTable} ) a"
+			"sqlText": "select case when min_date > next_batch_start_date then min_date else next_batch_start_date end \"#nextBatchMinDate\", ${currentDateTime} \"#extractDate\", \"#maxDateAvailable\" from ( select ${deltaDateTemplateMinDateLessOneDay} min_date /* use min-1 since the extractDateRanges SELECT below uses gt '>' instead of gte '>=' */, ${deltaDateTemplateLowDate} next_batch_start_date, max(${SQLBatchDriverField}) \"#maxDateAvailable\" from ${sourceTable} ) a"
           }
         },
         "generateDateRanges": {
@@ -99,16 +99,16 @@ var jsonDsnSnowflakeDeltaDate = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "sqlInput",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}-${sourceFromDate}-${sourceToDate}-${extractDate}",
             "fileNameSuffixAppendCreationStamp": "false",
             "fileNameSuffixDateTimeFormat": "20060102T150405",
-            "fileNameExtension": "csv",
-            "useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+            "fileNameExtension": "${fileNameExtension}",
+            "useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -169,6 +169,7 @@ var jsonDsnSnowflakeDeltaDate = `{
             "logicalConnectionName": "target",
             "fieldName4FileName": "#dataFile",
             "stageName": "${snowflakeStage}",
+            "fileFormat": "${fileFormat}",
             "schemaTableName": "${snowflakeTable}",
 			"keyCols": "${snowflakeTargetKeyColumns}",
             "otherCols": "${snowflakeTargetOtherColumns}"
@@ -99,16 +99,16 @@ var jsonDsnSnowflakeDeltaNumber = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "sqlInput",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}-${sourceFromSequence}-${sourceToSequence}-${extractDate}",
             "fileNameSuffixAppendCreationStamp": "false",
             "fileNameSuffixDateTimeFormat": "20060102T150405",
-            "fileNameExtension": "csv",
-            "useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+            "fileNameExtension": "${fileNameExtension}",
+            "useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -169,6 +169,7 @@ var jsonDsnSnowflakeDeltaNumber = `{
             "logicalConnectionName": "target",
             "fieldName4FileName": "#dataFile",
             "stageName": "${snowflakeStage}",
+            "fileFormat": "${fileFormat}",
             "schemaTableName": "${snowflakeTable}",
 			"keyCols": "${snowflakeTargetKeyColumns}",
             "otherCols": "${snowflakeTargetOtherColumns}"
@@ -33,6 +33,8 @@ type DsnSnowflakeDeltaConfig struct {
 	CsvHeaderFields   string `errorTxt:"csv header fields"`
 	CsvMaxFileRows    string `errorTxt:"csv max file rows"`
 	CsvMaxFileBytes   string `errorTxt:"csv max file bytes"`
+	FileFormat        string `errorTxt:"file format"`
+	AutoEvolve        bool
 	// Generic
 	RepeatInterval            int `errorTxt:"repeat interval"`
 	ExportConfigType          string
@@ -88,6 +90,8 @@ func SetupCpDsnSnowflakeDelta(genericCfg interface{}, actionCfg interface{}) err
 	tgt.CsvHeaderFields = src.CsvHeaderFields
 	tgt.CsvMaxFileBytes = src.CsvMaxFileBytes
 	tgt.CsvMaxFileRows = src.CsvMaxFileRows
+	tgt.FileFormat = src.FileFormat
+	tgt.AutoEvolve = src.AutoEvolve
 	// S3
 	tgt.BucketName = src.BucketName
 	tgt.BucketPrefix = src.BucketPrefix
@@ -182,6 +186,7 @@ func RunDsnSnowflakeDelta(cfg interface{}) error {
 	} else if deltaDriverDataType == 1 { // else if the field is DATE or TIMESTAMP...
 		jsonPipe = &jsonDsnSnowflakeDeltaDate
 		m["${deltaDateTemplateLowDate}"] = cfgDelta.SrcConnDetails.MustGetDateFilterSql("maxDateInTarget")
+		m["${deltaDateTemplateMinDateLessOneDay}"] = cfgDelta.SrcConnDetails.MustGetDateAddDaysSql(fmt.Sprintf("min(%v)", cfgDelta.SQLBatchDriverField), -1)
 		m["${deltaDateTemplateSourceFromDate}"] = cfgDelta.SrcConnDetails.MustGetDateFilterSql("sourceFromDate")
 		m["${deltaDateTemplateSourceToDate}"] = cfgDelta.SrcConnDetails.MustGetDateFilterSql("sourceToDate")
 		// Fix the batch start date time which contains date conversion.
@@ -201,10 +206,18 @@ func RunDsnSnowflakeDelta(cfg interface{}) error {
 		cfgDelta.SQLBatchSizeSeconds = strconv.FormatInt(sec, 10)
 	}
 	m["${SQLBatchSizeSeconds}"] = cfgDelta.SQLBatchSizeSeconds
+	if err := setFileFormatVariables(log, m, cfgDelta.FileFormat, cfgDelta.SrcConnDetails, &cfgDelta.DsnSchemaTable); err != nil {
+		return err
+	}
 	mustReplaceInStringUsingMapKeyVals(jsonPipe, m)
 	log.Debug("replaced reference JSON for incremental ", *jsonPipe)
 	// Execute or export the transform.
 	if cfgDelta.ExportConfigType == "" { // if we should execute the transform...
+		if cfgDelta.AutoEvolve { // if the target table should be evolved to match the source first...
+			if err := evolveSnowflakeTable(log, cfgDelta.SrcConnDetails, &cfgDelta.DsnSchemaTable, cfgDelta.TgtConnDetails, cfgDelta.SnowTableName); err != nil {
+				return err
+			}
+		}
 		ti := transform.NewSafeMapTransformInfo()
 		_, err := transform.LaunchTransformJson(log, ti, *jsonPipe, true, cfgDelta.StatsDumpFrequencySeconds)
 		if err != nil {
@@ -35,16 +35,16 @@ var jsonDsnSnowflakeLoaderSnapshot = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "sqlInput",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}",
 			"fileNameSuffixAppendCreationStamp": "true", 
 			"fileNameSuffixDateTimeFormat": "20060102T150405",
-			"fileNameExtension": "csv",
-			"useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+			"fileNameExtension": "${fileNameExtension}",
+			"useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -103,10 +103,10 @@ var jsonDsnSnowflakeLoaderSnapshot = `{
           "data": {
             "logicalConnectionName": "target",
             "fieldName4FileName": "#dataFile",
-			"use1Transaction": "true",
 			"deleteAllRows": "${deleteTarget}",
             "readDataFromStep": "manifestReader",
             "stageName": "${snowflakeStage}",
+            "fileFormat": "${fileFormat}",
             "schemaTableName": "${snowflakeTable}"
           }
         }
@@ -27,6 +27,8 @@ type DsnSnowflakeSnapConfig struct {
 	CsvHeaderFields           string `errorTxt:"csv header fields"`
 	CsvMaxFileRows            string `errorTxt:"csv max file rows"`
 	CsvMaxFileBytes           string `errorTxt:"csv max file bytes"`
+	FileFormat                string `errorTxt:"file format"`
+	AutoEvolve                bool
 	AppendTarget              bool
 	RepeatInterval            int `errorTxt:"repeat interval"`
 	ExportConfigType          string
@@ -76,6 +78,8 @@ func SetupCpDsnSnowflakeSnap(genericCfg interface{}, actionCfg interface{}) erro
 	tgt.CsvHeaderFields = src.CsvHeaderFields
 	tgt.CsvMaxFileBytes = src.CsvMaxFileBytes
 	tgt.CsvMaxFileRows = src.CsvMaxFileRows
+	tgt.FileFormat = src.FileFormat
+	tgt.AutoEvolve = src.AutoEvolve
 	// S3
 	tgt.BucketName = src.BucketName
 	tgt.BucketPrefix = src.BucketPrefix
@@ -136,10 +140,18 @@ func RunDsnSnowflakeSnapshot(cfg interface{}) error {
 	} else { // else we should execute this transform once...
 		m["${repeatTransform}"] = transform.TransformOnce
 	}
+	if err := setFileFormatVariables(log, m, cfgSnap.FileFormat, cfgSnap.SrcConnDetails, &cfgSnap.DsnSchemaTable); err != nil {
+		return err
+	}
 	mustReplaceInStringUsingMapKeyVals(&jsonDsnSnowflakeLoaderSnapshot, m)
 	log.Debug("replaced reference JSON for snapshot load ", jsonDsnSnowflakeLoaderSnapshot)
 	// Execute or export the transform.
 	if cfgSnap.ExportConfigType == "" { // if we should execute the transform...
+		if cfgSnap.AutoEvolve { // if the target table should be evolved to match the source first...
+			if err := evolveSnowflakeTable(log, cfgSnap.SrcConnDetails, &cfgSnap.DsnSchemaTable, cfgSnap.TgtConnDetails, cfgSnap.SnowTableName); err != nil {
+				return err
+			}
+		}
 		ti := transform.NewSafeMapTransformInfo()
 		_, err := transform.LaunchTransformJson(log, ti, jsonDsnSnowflakeLoaderSnapshot, true, cfgSnap.StatsDumpFrequencySeconds)
 		if err != nil {
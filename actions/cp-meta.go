@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -59,6 +60,7 @@ func SetupCpMetaToSnowflake(genericCfg interface{}, actionCfg interface{}) error
 
 // OracleTableToSnowflakeDDLFunc returns a function that will do ths work of:
 // 1) Read Oracle schema.table definition and generate equivalent Snowflake table creation statement.
+// 2) If the Snowflake table exists already, generate ALTER TABLE statements to evolve it instead.
 // It prints the DDL to STDOUT.
 func RunTableToSnowflakeDDL(cfg interface{}) error {
 	ddlCfg := cfg.(*TableToSnowTableDDLConfig)
@@ -76,24 +78,88 @@ func RunTableToSnowflakeDDL(cfg interface{}) error {
 	if err != nil {
 		return err
 	}
-	// Get the function that fetches column definitions and a mapper for the database connection type.
-	fnGetColumns := td.GetColumnsFunc(ddlCfg.SrcConnDetails)
-	mapper := td.MustGetMapper(ddlCfg.SrcConnDetails)
-	// Run the action.
-	tabDefinition, err := td.GetTableDefinition(log, fnGetColumns, &ddlCfg.SrcSchemaTable)
+	// Get the DDL that creates the target table or evolves it if it exists already.
+	ddl, incompatible, err := getSnowflakeTableDDL(log, ddlCfg.SrcConnDetails, &ddlCfg.SrcSchemaTable, td.GetColumnsFunc(ddlCfg.TgtConnDetails), ddlCfg.SnowSchemaTable)
 	if err != nil {
 		return err
 	}
-	// Convert the table definition to Snowflake.
-	ddl, err := td.ConvertTableDefinitionToSnowflake(log, tabDefinition, ddlCfg.SnowSchemaTable, mapper)
 	// Print and/or execute the DDL.
 	printLogFn := getPrintLogFunc(log, !ddlCfg.ExecuteDDL)
-	printLogFn(strings.TrimRight(ddl, ";"+";"))
-	if ddlCfg.ExecuteDDL { // if we should execute the DDL...
-		fn := func() error {
-			return rdbms.SnowflakeDDLExec(log, shared.GetDsnConnectionDetails(ddlCfg.TgtConnDetails), ddl)
+	for _, msg := range incompatible { // for each column that can't be evolved...
+		log.Warn("incompatible change: ", msg)
+		printLogFn("-- incompatible change: " + msg)
+	}
+	if len(ddl) == 0 {
+		log.Info("Target table ", ddlCfg.SnowSchemaTable.SchemaTable, " is up to date")
+	}
+	for _, stmt := range ddl { // for each statement...
+		printLogFn(strings.TrimRight(stmt, ";"+";"))
+		if ddlCfg.ExecuteDDL { // if we should execute the DDL...
+			stmt := stmt
+			fn := func() error {
+				return rdbms.SnowflakeDDLExec(log, shared.GetDsnConnectionDetails(ddlCfg.TgtConnDetails), stmt)
+			}
+			mustExecFn(log, printLogFn, fn)
+		}
+	}
+	return nil
+}
+
+// getSnowflakeTableDDL reads the definition of source table srcSchemaTable and returns the DDL that creates
+// Snowflake table snowSchemaTable. If the Snowflake table exists already, according to fnGetTgtColumns, the DDL
+// contains ALTER TABLE statements that evolve it instead, plus a description of each column that is incompatible.
+func getSnowflakeTableDDL(
+	log logger.Logger,
+	srcConnDetails *shared.ConnectionDetails,
+	srcSchemaTable *rdbms.SchemaTable,
+	fnGetTgtColumns td.GetColumnsFuncT,
+	snowSchemaTable rdbms.SchemaTable,
+) (ddl []string, incompatible []string, err error) {
+	// Get the function that fetches column definitions and a mapper for the database connection type.
+	fnGetColumns := td.GetColumnsFunc(srcConnDetails)
+	mapper := td.MustGetMapper(srcConnDetails)
+	tabDefinition, err := td.GetTableDefinition(log, fnGetColumns, srcSchemaTable)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Fetch the existing target table definition.
+	tgtDefinition, err := td.GetTableDefinition(log, fnGetTgtColumns, &snowSchemaTable)
+	if errors.Is(err, td.ErrNoColumnMetadata) { // if the target table does not exist...
+		// Convert the table definition to Snowflake.
+		ct, err := td.ConvertTableDefinitionToSnowflake(log, tabDefinition, snowSchemaTable, mapper)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []string{ct}, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	changes := td.GetSnowflakeSchemaChanges(log, tabDefinition, tgtDefinition, snowSchemaTable, mapper)
+	return changes.Statements, changes.Incompatible, nil
+}
+
+// evolveSnowflakeTable runs the same checks as "cp meta" and executes the DDL required for Snowflake table
+// snowSchemaTable to hold the data in source table srcSchemaTable, creating the table if it does not exist.
+// An error is returned if there are incompatible column changes.
+func evolveSnowflakeTable(
+	log logger.Logger,
+	srcConnDetails *shared.ConnectionDetails,
+	srcSchemaTable *rdbms.SchemaTable,
+	tgtConnDetails *shared.ConnectionDetails,
+	snowSchemaTable string,
+) error {
+	ddl, incompatible, err := getSnowflakeTableDDL(log, srcConnDetails, srcSchemaTable, td.GetColumnsFunc(tgtConnDetails), rdbms.SchemaTable{SchemaTable: snowSchemaTable})
+	if err != nil {
+		return err
+	}
+	if len(incompatible) > 0 {
+		return fmt.Errorf("unable to evolve Snowflake table %v due to incompatible changes: %v", snowSchemaTable, strings.Join(incompatible, "; "))
+	}
+	for _, stmt := range ddl { // for each statement...
+		log.Info("Evolving Snowflake table: ", stmt)
+		if err := rdbms.SnowflakeDDLExec(log, shared.GetDsnConnectionDetails(tgtConnDetails), stmt); err != nil {
+			return err
 		}
-		mustExecFn(log, printLogFn, fn)
 	}
 	return nil
 }
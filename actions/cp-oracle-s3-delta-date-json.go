@@ -84,10 +84,26 @@ var jsonOracleS3DeltaDate = `{
             "readDataFromStep2": "filterRows"
           }
         },
+        "checkpointRead": {
+          "type": "CheckpointRead",
+          "data": {
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "outputFieldName4Value": "#maxS3FileDate"
+          }
+        },
+        "joinCheckpoint": {
+          "type": "ChannelCombiner",
+          "data": {
+            "readDataFromStep1": "joinStreams",
+            "readDataFromStep2": "checkpointRead"
+          }
+        },
         "filterRows2": {
           "type": "FilterRows",
           "data": {
-            "readDataFromStep": "joinStreams",
+            "readDataFromStep": "joinCheckpoint",
             "filterType": "GetMax",
             "filterMetadata": "#maxS3FileDate"
           }
@@ -108,6 +124,8 @@ var jsonOracleS3DeltaDate = `{
         "filterRows",
         "dummyRow",
         "joinStreams",
+        "checkpointRead",
+        "joinCheckpoint",
         "filterRows2",
         "metadataInjectGenerateDateRanges"
       ]
@@ -163,16 +181,16 @@ var jsonOracleS3DeltaDate = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "sqlInput",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}-${sourceFromDate}-${sourceToDate}-${extractDate}",
             "fileNameSuffixAppendCreationStamp": "false",
             "fileNameSuffixDateTimeFormat": "20060102T150405",
-            "fileNameExtension": "csv",
-            "useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+            "fileNameExtension": "${fileNameExtension}",
+            "useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -214,6 +232,16 @@ var jsonOracleS3DeltaDate = `{
             "bucketRegion": "${tgtS3Region}",
             "removeInputFiles": "true"
           }
+        },
+        "checkpointWrite": {
+          "type": "CheckpointWrite",
+          "data": {
+            "readDataFromStep": "copyManifestToS3",
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "checkpointValue": "${sourceToDate}"
+          }
         }
       },
       "sequence": [
@@ -221,7 +249,8 @@ var jsonOracleS3DeltaDate = `{
         "csvWriter",
         "copyFilesToS3",
         "manifestWriter",
-        "copyManifestToS3"
+        "copyManifestToS3",
+        "checkpointWrite"
       ]
     }
   },
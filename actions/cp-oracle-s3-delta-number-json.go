@@ -101,10 +101,26 @@ var jsonOracleS3DeltaNumber = `{
             "readDataFromStep2": "filterRows"
           }
         },
+        "checkpointRead": {
+          "type": "CheckpointRead",
+          "data": {
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "outputFieldName4Value": "#maxS3FileSequence"
+          }
+        },
+        "joinCheckpoint": {
+          "type": "ChannelCombiner",
+          "data": {
+            "readDataFromStep1": "joinStreams",
+            "readDataFromStep2": "checkpointRead"
+          }
+        },
         "filterRows2": {
           "type": "FilterRows",
           "data": {
-            "readDataFromStep": "joinStreams",
+            "readDataFromStep": "joinCheckpoint",
             "filterType": "GetMax",
             "filterMetadata": "#maxS3FileSequence"
           }
@@ -126,6 +142,8 @@ var jsonOracleS3DeltaNumber = `{
         "dummyRow",
 		"leftPadDummyRow",
         "joinStreams",
+        "checkpointRead",
+        "joinCheckpoint",
         "filterRows2",
         "metadataInjectGenerateNumberRanges"
       ]
@@ -180,16 +198,16 @@ var jsonOracleS3DeltaNumber = `{
           }
         },
         "csvWriter": {
-          "type": "CSVFileWriter",
+          "type": "${fileWriterType}",
           "data": {
             "readDataFromStep": "sqlInput",
             "outputDir": "",
             "fileNamePrefix": "${fileNamePrefix}-${sourceFromSequence}-${sourceToSequence}-${extractDate}",
             "fileNameSuffixAppendCreationStamp": "false",
             "fileNameSuffixDateTimeFormat": "20060102T150405",
-            "fileNameExtension": "csv",
-            "useGzip": "true",
-            "headerFieldsCSV": "${csvHeaderFields}",
+            "fileNameExtension": "${fileNameExtension}",
+            "useGzip": "${useGzip}",
+            "headerFieldsCSV": "${csvHeaderFields}",${parquetFieldTypes}
             "maxFileRows": "${csvMaxFileRows}",
             "maxFileBytes": "${csvMaxFileBytes}",
             "outputFieldName4FilePath": "#internalFilePath"
@@ -231,6 +249,16 @@ var jsonOracleS3DeltaNumber = `{
             "bucketRegion": "${tgtS3Region}",
             "removeInputFiles": "true"
           }
+        },
+        "checkpointWrite": {
+          "type": "CheckpointWrite",
+          "data": {
+            "readDataFromStep": "copyManifestToS3",
+            "checkpointStore": "${checkpointStore}",
+            "databaseConnectionName": "${checkpointConnection}",
+            "checkpointKey": "${checkpointKey}",
+            "checkpointValue": "${sourceToSequence}"
+          }
         }
       },
       "sequence": [
@@ -238,7 +266,8 @@ var jsonOracleS3DeltaNumber = `{
         "csvWriter",
         "copyFilesToS3",
         "manifestWriter",
-        "copyManifestToS3"
+        "copyManifestToS3",
+        "checkpointWrite"
       ]
     }
   },
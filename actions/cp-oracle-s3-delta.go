@@ -28,6 +28,7 @@ type OraS3DeltaConfig struct {
 	CsvHeaderFields           string `errorTxt:"csv header fields"`
 	CsvMaxFileRows            string `errorTxt:"csv max file rows"`
 	CsvMaxFileBytes           string `errorTxt:"csv max file bytes"`
+	FileFormat                string `errorTxt:"file format"`
 	RepeatInterval            int    `errorTxt:"repeat interval"`
 	ExportConfigType          string
 	ExportIncludeConnections  bool
@@ -40,6 +41,7 @@ type OraS3DeltaConfig struct {
 	SQLBatchStartSequence string `errorTxt:"SQL batch start sequence (number)" mandatory:"yes"`
 	SQLBatchSizeSeconds   string `errorTxt:"SQL batch size seconds"`
 	SQLBatchSize          string `errorTxt:"SQL batch size days"`
+	CheckpointStore       string `errorTxt:"checkpoint store"`
 }
 
 // SetupCpOraS3Snap copies values from genericCfg to actionCfg ready for a Oracle to S3 action.
@@ -79,6 +81,7 @@ func SetupCpOraS3Delta(genericCfg interface{}, actionCfg interface{}) error {
 	tgt.CsvHeaderFields = src.CsvHeaderFields
 	tgt.CsvMaxFileBytes = src.CsvMaxFileBytes
 	tgt.CsvMaxFileRows = src.CsvMaxFileRows
+	tgt.FileFormat = src.FileFormat
 
 	// S3
 	// Richard remove set bucket details as they should come from the target conn.
@@ -92,6 +95,7 @@ func SetupCpOraS3Delta(genericCfg interface{}, actionCfg interface{}) error {
 	tgt.SQLBatchStartSequence = strconv.Itoa(src.SQLBatchStartSequence)
 	tgt.SQLBatchSize = src.SQLBatchSize
 	tgt.SQLBatchSizeSeconds = src.SQLBatchSizeSeconds
+	tgt.CheckpointStore = src.CheckpointStore
 	return nil
 }
 
@@ -170,6 +174,11 @@ func RunOracleS3Delta(cfg interface{}) error {
 		cfgDelta.SQLBatchSizeSeconds = strconv.FormatInt(sec, 10)
 	}
 	m["${SQLBatchSizeSeconds}"] = cfgDelta.SQLBatchSizeSeconds
+	// Checkpoints
+	setCheckpointVariables(m, cfgDelta.CheckpointStore, connTgt.Name, connTgt.Prefix, cfgDelta.CsvFileNamePrefix)
+	if err := setFileFormatVariables(log, m, cfgDelta.FileFormat, cfgDelta.SrcConnDetails, &cfgDelta.SrcOraSchemaTable); err != nil {
+		return err
+	}
 	mustReplaceInStringUsingMapKeyVals(jsonPipe, m)
 	log.Debug("replaced reference JSON for snapshot ", *jsonPipe)
 	// Execute or export the transform.
@@ -32,6 +32,8 @@ type OraSnowflakeDeltaConfig struct {
 	CsvHeaderFields   string `errorTxt:"csv header fields"`
 	CsvMaxFileRows    string `errorTxt:"csv max file rows"`
 	CsvMaxFileBytes   string `errorTxt:"csv max file bytes"`
+	FileFormat        string `errorTxt:"file format"`
+	AutoEvolve        bool
 	// Generic
 	RepeatInterval            int `errorTxt:"repeat interval"`
 	ExportConfigType          string
@@ -87,6 +89,8 @@ func SetupCpOraSnowflakeDelta(genericCfg interface{}, actionCfg interface{}) err
 	tgt.CsvHeaderFields = src.CsvHeaderFields
 	tgt.CsvMaxFileBytes = src.CsvMaxFileBytes
 	tgt.CsvMaxFileRows = src.CsvMaxFileRows
+	tgt.FileFormat = src.FileFormat
+	tgt.AutoEvolve = src.AutoEvolve
 	// S3
 	tgt.BucketName = src.BucketName
 	tgt.BucketPrefix = src.BucketPrefix
@@ -192,10 +196,18 @@ func RunSnowflakeDelta(cfg interface{}) error {
 		cfgDelta.SQLBatchSizeSeconds = strconv.FormatInt(sec, 10)
 	}
 	m["${SQLBatchSizeSeconds}"] = cfgDelta.SQLBatchSizeSeconds
+	if err := setFileFormatVariables(log, m, cfgDelta.FileFormat, cfgDelta.SrcConnDetails, &cfgDelta.OraSchemaTable); err != nil {
+		return err
+	}
 	mustReplaceInStringUsingMapKeyVals(jsonPipe, m)
 	log.Debug("replaced reference JSON for incremental ", *jsonPipe)
 	// Execute or export the transform.
 	if cfgDelta.ExportConfigType == "" { // if we should execute the transform...
+		if cfgDelta.AutoEvolve { // if the target table should be evolved to match the source first...
+			if err := evolveSnowflakeTable(log, cfgDelta.SrcConnDetails, &cfgDelta.OraSchemaTable, cfgDelta.TgtConnDetails, cfgDelta.SnowTableName); err != nil {
+				return err
+			}
+		}
 		ti := transform.NewSafeMapTransformInfo()
 		_, err := transform.LaunchTransformJson(log, ti, *jsonPipe, true, cfgDelta.StatsDumpFrequencySeconds)
 		if err != nil {
@@ -40,7 +40,6 @@ var jsonS3SnowflakeSnap = `{
           "data": {
             "logicalConnectionName": "target",
             "fieldName4FileName": "#dataFile",
-			"use1Transaction": "true",
 			"deleteAllRows": "${deleteTarget}",
             "readDataFromStep": "getS3Files",
             "stageName": "${snowflakeStage}",
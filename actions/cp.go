@@ -20,6 +20,7 @@ type CpConfig struct {
 	CsvHeaderFields   string `errorTxt:"csv header fields"`
 	CsvMaxFileRows    string `errorTxt:"csv max file rows"`
 	CsvMaxFileBytes   string `errorTxt:"csv max file bytes"`
+	FileFormat        string `errorTxt:"file format"`
 	// Delta action specific
 	SQLBatchDriverField    string `errorTxt:"source driver field" mandatory:"yes"`
 	SQLBatchStartSequence  int    `errorTxt:"SQL batch start sequence (number)" mandatory:"yes"`
@@ -27,6 +28,7 @@ type CpConfig struct {
 	SQLBatchSizeSeconds    string `errorTxt:"SQL batch size seconds"`
 	SQLBatchSize           string `errorTxt:"SQL batch size days"`
 	SQLPrimaryKeyFieldsCsv string `errorTxt:"primary key fields CSV"`
+	CheckpointStore        string `errorTxt:"checkpoint store"`
 	// Metadata action specific
 	ExecuteDDL bool
 	// Oracle-Oracle action specific
@@ -34,4 +36,5 @@ type CpConfig struct {
 	ExecBatchSize   string
 	// Target specific
 	AppendTarget bool
+	AutoEvolve   bool
 }
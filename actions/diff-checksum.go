@@ -0,0 +1,293 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+// checksumMinRows is the number of rows in a mismatched bucket below which we stop drilling down and leave the
+// bucket for the row-level diff.
+var checksumMinRows int64 = 10000
+
+// checksumRange is an inclusive range of primary key values.
+type checksumRange struct {
+	lo int64
+	hi int64
+}
+
+// checksumBucket is the row count and aggregate hash of a bucket of records.
+type checksumBucket struct {
+	count int64
+	hash  string
+}
+
+// checksumTable is a table to be compared using bucket checksums.
+type checksumTable struct {
+	db          shared.Connector
+	dialect     string
+	schemaTable string
+}
+
+// checksumDialects maps connection types to the SQL used to compute bucket hashes.
+var checksumDialects = map[string]string{
+	constants.ConnectionTypeOracle:        constants.ConnectionTypeOracle,
+	constants.ConnectionTypeSnowflake:     constants.ConnectionTypeSnowflake,
+	constants.ConnectionTypeSqlServer:     constants.ConnectionTypeSqlServer,
+	constants.ConnectionTypeOdbcSqlServer: constants.ConnectionTypeSqlServer,
+}
+
+// getChecksumDialect returns the dialect used to compute checksums for the source and target connection types.
+// Hashes are only comparable when both databases use the same hash functions, so the dialects must match.
+func getChecksumDialect(srcType string, tgtType string) (string, error) {
+	srcDialect, ok := checksumDialects[srcType]
+	if !ok {
+		return "", fmt.Errorf("checksum chunks are not supported for connection type %q", srcType)
+	}
+	tgtDialect, ok := checksumDialects[tgtType]
+	if !ok {
+		return "", fmt.Errorf("checksum chunks are not supported for connection type %q", tgtType)
+	}
+	if srcDialect != tgtDialect {
+		return "", fmt.Errorf("checksum chunks require source and target databases of the same type, got %q and %q", srcType, tgtType)
+	}
+	return srcDialect, nil
+}
+
+// getChecksumHashSql returns the dialect's aggregate hash of columns.
+func getChecksumHashSql(dialect string, columns []string) string {
+	switch dialect {
+	case constants.ConnectionTypeOracle:
+		return fmt.Sprintf("sum(ora_hash(%v))", strings.Join(columns, " || '|' || "))
+	case constants.ConnectionTypeSnowflake:
+		return fmt.Sprintf("hash_agg(%v)", strings.Join(columns, ", "))
+	default: // else SQL Server...
+		return fmt.Sprintf("checksum_agg(checksum(%v))", strings.Join(columns, ", "))
+	}
+}
+
+// getChecksumBucketSql returns SQL that fetches the row count and aggregate hash of columns per bucket of width
+// key values in range r.
+func getChecksumBucketSql(dialect string, schemaTable string, keyField string, columns []string, r checksumRange, width int64) string {
+	bucket := fmt.Sprintf("floor((%v - %v) / %v)", keyField, r.lo, width)
+	return fmt.Sprintf("select %v, count(*), %v from %v where %v between %v and %v group by %v",
+		bucket, getChecksumHashSql(dialect, columns), schemaTable, keyField, r.lo, r.hi, bucket)
+}
+
+// getChecksumWhereClause returns a WHERE clause that restricts keyField to the ranges, which must be sorted and
+// must not overlap. Adjacent ranges are combined.
+func getChecksumWhereClause(keyField string, ranges []checksumRange) string {
+	var merged []checksumRange
+	for _, r := range ranges {
+		if len(merged) > 0 && merged[len(merged)-1].hi+1 == r.lo { // if this range follows on from the last one...
+			merged[len(merged)-1].hi = r.hi
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	predicates := make([]string, len(merged))
+	for idx, r := range merged {
+		predicates[idx] = fmt.Sprintf("%v between %v and %v", keyField, r.lo, r.hi)
+	}
+	return " where " + strings.Join(predicates, " or ")
+}
+
+// getChecksumKeyRange returns the min and max values of keyField in the table.
+// ok is false if the table is empty.
+func getChecksumKeyRange(t *checksumTable, keyField string) (r checksumRange, ok bool, err error) {
+	sqlText := fmt.Sprintf("select min(%v), max(%v) from %v", keyField, keyField, t.schemaTable)
+	rows, err := t.db.QueryContext(context.Background(), sqlText)
+	if err != nil {
+		return r, false, fmt.Errorf("error fetching the key range using SQL %q: %w", sqlText, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var lo, hi interface{}
+	if rows.Next() {
+		if err = rows.Scan(&lo, &hi); err != nil {
+			return r, false, fmt.Errorf("error scanning the key range: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return r, false, err
+	}
+	if lo == nil || hi == nil { // if the table is empty...
+		return r, false, nil
+	}
+	if r.lo, err = getChecksumInt64(lo); err != nil {
+		return r, false, fmt.Errorf("checksum chunks require an integer primary key: %w", err)
+	}
+	if r.hi, err = getChecksumInt64(hi); err != nil {
+		return r, false, fmt.Errorf("checksum chunks require an integer primary key: %w", err)
+	}
+	return r, true, nil
+}
+
+// getChecksumBuckets returns the row count and aggregate hash per bucket of width key values in range r.
+// Empty buckets are not returned.
+func getChecksumBuckets(t *checksumTable, keyField string, columns []string, r checksumRange, width int64) (map[int64]checksumBucket, error) {
+	sqlText := getChecksumBucketSql(t.dialect, t.schemaTable, keyField, columns, r, width)
+	rows, err := t.db.QueryContext(context.Background(), sqlText)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching bucket checksums using SQL %q: %w", sqlText, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	buckets := make(map[int64]checksumBucket)
+	for rows.Next() {
+		var bucket, count, hash interface{}
+		if err = rows.Scan(&bucket, &count, &hash); err != nil {
+			return nil, fmt.Errorf("error scanning bucket checksums: %w", err)
+		}
+		b, err := getChecksumInt64(bucket)
+		if err != nil {
+			return nil, err
+		}
+		cnt, err := getChecksumInt64(count)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := hash.([]byte); ok {
+			hash = string(v)
+		}
+		buckets[b] = checksumBucket{count: cnt, hash: fmt.Sprint(hash)}
+	}
+	return buckets, rows.Err()
+}
+
+// getChecksumInt64 converts a number scanned from the database into an int64.
+func getChecksumInt64(v interface{}) (int64, error) {
+	switch x := v.(type) {
+	case int64:
+		return x, nil
+	case int32:
+		return int64(x), nil
+	case int:
+		return int64(x), nil
+	case float64:
+		if x != math.Trunc(x) {
+			return 0, fmt.Errorf("value %v is not an integer", x)
+		}
+		return int64(x), nil
+	case []byte:
+		return getChecksumInt64(string(x))
+	case string:
+		if i, err := strconv.ParseInt(x, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(x, 64)
+		if err != nil || f != math.Trunc(f) {
+			return 0, fmt.Errorf("value %q is not an integer", x)
+		}
+		return int64(f), nil
+	default:
+		return 0, fmt.Errorf("unsupported value %v of type %T", v, v)
+	}
+}
+
+// getMismatchedChecksumRanges splits the range of keyField values found in src and tgt into numChunks buckets and
+// compares the row count and aggregate hash of columns per bucket. Mismatched buckets are split again until they
+// contain fewer than checksumMinRows rows or a single key value. The mismatched ranges are returned in key order
+// along with the number of rows in buckets whose checksums match, which are identical in src and tgt.
+func getMismatchedChecksumRanges(log logger.Logger, src *checksumTable, tgt *checksumTable, keyField string, columns []string, numChunks int) (mismatched []checksumRange, verified int64, err error) {
+	if numChunks < 2 {
+		return nil, 0, fmt.Errorf("checksum chunks must be 2 or more, got %v", numChunks)
+	}
+	// Find the range of keys across both tables.
+	srcRange, srcOk, err := getChecksumKeyRange(src, keyField)
+	if err != nil {
+		return nil, 0, err
+	}
+	tgtRange, tgtOk, err := getChecksumKeyRange(tgt, keyField)
+	if err != nil {
+		return nil, 0, err
+	}
+	var r checksumRange
+	switch {
+	case srcOk && tgtOk:
+		r = checksumRange{lo: srcRange.lo, hi: srcRange.hi}
+		if tgtRange.lo < r.lo {
+			r.lo = tgtRange.lo
+		}
+		if tgtRange.hi > r.hi {
+			r.hi = tgtRange.hi
+		}
+	case srcOk:
+		r = srcRange
+	case tgtOk:
+		r = tgtRange
+	default: // else both tables are empty...
+		return nil, 0, nil
+	}
+	if r.hi-r.lo < 0 { // if the range overflows...
+		return nil, 0, fmt.Errorf("the range of %v values is too large for checksum chunks", keyField)
+	}
+	// Compare buckets, drilling down into those that don't match.
+	ranges := []checksumRange{r}
+	for level := 1; len(ranges) > 0; level++ {
+		log.Info("comparing checksums for ", len(ranges), " range(s) at level ", level)
+		var next []checksumRange
+		for _, r := range ranges { // for each range to compare...
+			width := (r.hi-r.lo)/int64(numChunks) + 1
+			srcBuckets, err := getChecksumBuckets(src, keyField, columns, r, width)
+			if err != nil {
+				return nil, 0, err
+			}
+			tgtBuckets, err := getChecksumBuckets(tgt, keyField, columns, r, width)
+			if err != nil {
+				return nil, 0, err
+			}
+			for b := int64(0); b*width <= r.hi-r.lo; b++ { // for each bucket in this range...
+				s, t := srcBuckets[b], tgtBuckets[b]
+				if s == t {
+					verified += s.count
+					continue
+				}
+				sub := checksumRange{lo: r.lo + b*width, hi: r.lo + b*width + width - 1}
+				if sub.hi > r.hi || sub.hi < sub.lo { // if this is the last bucket or it overflows...
+					sub.hi = r.hi
+				}
+				log.Debug("checksum mismatch for ", keyField, " between ", sub.lo, " and ", sub.hi)
+				if width == 1 || (s.count < checksumMinRows && t.count < checksumMinRows) { // if the bucket is small enough...
+					mismatched = append(mismatched, sub)
+				} else {
+					next = append(next, sub)
+				}
+			}
+		}
+		ranges = next
+	}
+	sort.Slice(mismatched, func(i, j int) bool {
+		return mismatched[i].lo < mismatched[j].lo
+	})
+	return mismatched, verified, nil
+}
+
+// openChecksumTables opens connections to the source and target tables for comparing checksums.
+// The caller must close the connections.
+func openChecksumTables(log logger.Logger, cfg *DiffConfig) (src *checksumTable, tgt *checksumTable, err error) {
+	dialect, err := getChecksumDialect(cfg.SrcConnDetails.Type, cfg.TgtConnDetails.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	src = &checksumTable{dialect: dialect, schemaTable: cfg.SrcSchemaTable.SchemaTable}
+	if src.db, err = rdbms.OpenDbConnection(log, *cfg.SrcConnDetails); err != nil {
+		return nil, nil, err
+	}
+	tgt = &checksumTable{dialect: dialect, schemaTable: cfg.TgtSchemaTable.SchemaTable}
+	if tgt.db, err = rdbms.OpenDbConnection(log, *cfg.TgtConnDetails); err != nil {
+		src.db.Close()
+		return nil, nil, err
+	}
+	return src, tgt, nil
+}
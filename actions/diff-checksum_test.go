@@ -0,0 +1,304 @@
+package actions
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+// checksumMockTable is an in-memory table of key values to row hashes that answers the SQL sent by the checksum
+// functions, where the aggregate hash of a bucket is the sum of its row hashes.
+type checksumMockTable struct {
+	rows    map[int64]int64
+	queries []string
+}
+
+var checksumMockBucketRegexp = regexp.MustCompile(`floor\(\(\w+ - (-?\d+)\) / (\d+)\).* between (-?\d+) and (-?\d+)`)
+
+func (m *checksumMockTable) Connect(context.Context) (driver.Conn, error) {
+	return &checksumMockConn{table: m}, nil
+}
+
+func (m *checksumMockTable) Driver() driver.Driver {
+	return nil
+}
+
+func (m *checksumMockTable) query(query string) (*checksumMockRows, error) {
+	m.queries = append(m.queries, query)
+	if strings.HasPrefix(query, "select min(") { // if this is the key range...
+		if len(m.rows) == 0 {
+			return &checksumMockRows{values: [][]driver.Value{{nil, nil}}}, nil
+		}
+		lo, hi := int64(math.MaxInt64), int64(math.MinInt64)
+		for k := range m.rows {
+			if k < lo {
+				lo = k
+			}
+			if k > hi {
+				hi = k
+			}
+		}
+		return &checksumMockRows{values: [][]driver.Value{{lo, hi}}}, nil
+	}
+	match := checksumMockBucketRegexp.FindStringSubmatch(query)
+	if match == nil {
+		return nil, fmt.Errorf("unexpected SQL %q", query)
+	}
+	var n [4]int64
+	for idx := range n {
+		n[idx], _ = strconv.ParseInt(match[idx+1], 10, 64)
+	}
+	lo, width, from, to := n[0], n[1], n[2], n[3]
+	counts := make(map[int64]int64)
+	sums := make(map[int64]int64)
+	for k, h := range m.rows {
+		if k >= from && k <= to {
+			b := (k - lo) / width
+			counts[b]++
+			sums[b] += h
+		}
+	}
+	rows := &checksumMockRows{}
+	for b := range counts {
+		rows.values = append(rows.values, []driver.Value{b, counts[b], strconv.FormatInt(sums[b], 10)})
+	}
+	return rows, nil
+}
+
+type checksumMockConn struct {
+	table *checksumMockTable
+}
+
+func (c *checksumMockConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *checksumMockConn) Close() error {
+	return nil
+}
+
+func (c *checksumMockConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *checksumMockConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.table.query(query)
+}
+
+type checksumMockRows struct {
+	values [][]driver.Value
+	idx    int
+}
+
+func (r *checksumMockRows) Columns() []string {
+	if len(r.values) > 0 && len(r.values[0]) == 2 {
+		return []string{"lo", "hi"}
+	}
+	return []string{"bucket", "count", "hash"}
+}
+
+func (r *checksumMockRows) Close() error {
+	return nil
+}
+
+func (r *checksumMockRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.idx])
+	r.idx++
+	return nil
+}
+
+func newChecksumMockTable(rows map[int64]int64) (*checksumTable, *checksumMockTable) {
+	m := &checksumMockTable{rows: rows}
+	db := &shared.HpConnection{DbSql: sql.OpenDB(m), DbType: constants.ConnectionTypeOracle}
+	return &checksumTable{db: db, dialect: constants.ConnectionTypeOracle, schemaTable: "s.t"}, m
+}
+
+// getChecksumMockRows returns rows for keys lo to hi with a hash of 1 each.
+func getChecksumMockRows(lo int64, hi int64) map[int64]int64 {
+	rows := make(map[int64]int64)
+	for k := lo; k <= hi; k++ {
+		rows[k] = 1
+	}
+	return rows
+}
+
+func TestGetMismatchedChecksumRanges(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	saved := checksumMinRows
+	defer func() { checksumMinRows = saved }()
+	cases := []struct {
+		name          string
+		src           map[int64]int64
+		tgt           map[int64]int64
+		numChunks     int
+		minRows       int64
+		expected      []checksumRange
+		verified      int64
+		bucketQueries int // expected number of bucket queries per table.
+	}{
+		{
+			name:          "identical tables",
+			src:           getChecksumMockRows(0, 99),
+			tgt:           getChecksumMockRows(0, 99),
+			numChunks:     10,
+			minRows:       10000,
+			verified:      100,
+			bucketQueries: 1,
+		},
+		{
+			name:          "both tables empty",
+			src:           map[int64]int64{},
+			tgt:           map[int64]int64{},
+			numChunks:     10,
+			minRows:       10000,
+			verified:      0,
+			bucketQueries: 0,
+		},
+		{
+			name:          "bucket below min rows is not drilled into",
+			src:           getChecksumMockRows(0, 99),
+			tgt:           func() map[int64]int64 { m := getChecksumMockRows(0, 99); m[57] = 2; return m }(),
+			numChunks:     10,
+			minRows:       10000,
+			expected:      []checksumRange{{lo: 50, hi: 59}},
+			verified:      90,
+			bucketQueries: 1,
+		},
+		{
+			name:          "drill down stops at a bucket width of one",
+			src:           getChecksumMockRows(0, 99),
+			tgt:           func() map[int64]int64 { m := getChecksumMockRows(0, 99); m[57] = 2; return m }(),
+			numChunks:     10,
+			minRows:       2,
+			expected:      []checksumRange{{lo: 57, hi: 57}},
+			verified:      99,
+			bucketQueries: 2,
+		},
+		{
+			name:          "last bucket is cut at the end of the range",
+			src:           getChecksumMockRows(0, 104),
+			tgt:           func() map[int64]int64 { m := getChecksumMockRows(0, 104); delete(m, 103); return m }(),
+			numChunks:     10, // width = 104/10 + 1 = 11, so the last bucket is 99 to 109.
+			minRows:       10000,
+			expected:      []checksumRange{{lo: 99, hi: 104}},
+			verified:      99,
+			bucketQueries: 1,
+		},
+		{
+			name:          "key range covers both tables",
+			src:           getChecksumMockRows(10, 19),
+			tgt:           getChecksumMockRows(0, 29),
+			numChunks:     3, // width = 29/3 + 1 = 10.
+			minRows:       10000,
+			expected:      []checksumRange{{lo: 0, hi: 9}, {lo: 20, hi: 29}},
+			verified:      10,
+			bucketQueries: 1,
+		},
+		{
+			name:          "only the target has rows",
+			src:           map[int64]int64{},
+			tgt:           getChecksumMockRows(5, 6),
+			numChunks:     2,
+			minRows:       10000,
+			expected:      []checksumRange{{lo: 5, hi: 5}, {lo: 6, hi: 6}},
+			verified:      0,
+			bucketQueries: 1,
+		},
+	}
+	for _, c := range cases {
+		checksumMinRows = c.minRows
+		src, srcMock := newChecksumMockTable(c.src)
+		tgt, tgtMock := newChecksumMockTable(c.tgt)
+		got, verified, err := getMismatchedChecksumRanges(log, src, tgt, "ID", []string{"NAME"}, c.numChunks)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Fatalf("%v: expected %v; got %v", c.name, c.expected, got)
+		}
+		if verified != c.verified {
+			t.Fatalf("%v: expected %v verified rows; got %v", c.name, c.verified, verified)
+		}
+		for _, m := range []*checksumMockTable{srcMock, tgtMock} {
+			if n := len(m.queries) - 1; n != c.bucketQueries { // ignore the key range query.
+				t.Fatalf("%v: expected %v bucket queries; got %v: %v", c.name, c.bucketQueries, n, m.queries)
+			}
+		}
+	}
+	// Bad inputs.
+	for name, numChunks := range map[string]int{"too few chunks": 1} {
+		src, _ := newChecksumMockTable(getChecksumMockRows(0, 1))
+		tgt, _ := newChecksumMockTable(getChecksumMockRows(0, 1))
+		if _, _, err := getMismatchedChecksumRanges(log, src, tgt, "ID", []string{"NAME"}, numChunks); err == nil {
+			t.Fatalf("%v: expected error", name)
+		}
+	}
+	src, _ := newChecksumMockTable(map[int64]int64{math.MinInt64: 1})
+	tgt, _ := newChecksumMockTable(map[int64]int64{math.MaxInt64: 1})
+	if _, _, err := getMismatchedChecksumRanges(log, src, tgt, "ID", []string{"NAME"}, 10); err == nil {
+		t.Fatal("expected error when the key range overflows")
+	}
+}
+
+func TestGetChecksumWhereClause(t *testing.T) {
+	cases := []struct {
+		ranges   []checksumRange
+		expected string
+	}{
+		{[]checksumRange{{lo: 1, hi: 5}}, " where ID between 1 and 5"},
+		{[]checksumRange{{lo: 1, hi: 5}, {lo: 6, hi: 10}, {lo: 11, hi: 11}}, " where ID between 1 and 11"},
+		{[]checksumRange{{lo: 1, hi: 5}, {lo: 7, hi: 10}}, " where ID between 1 and 5 or ID between 7 and 10"},
+		{[]checksumRange{{lo: -3, hi: -1}, {lo: 0, hi: 2}, {lo: 20, hi: 30}, {lo: 31, hi: 40}}, " where ID between -3 and 2 or ID between 20 and 40"},
+	}
+	for idx, c := range cases {
+		if got := getChecksumWhereClause("ID", c.ranges); got != c.expected {
+			t.Fatalf("case %v expected %q; got %q", idx+1, c.expected, got)
+		}
+	}
+}
+
+func TestGetChecksumInt64(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected int64
+		isError  bool
+	}{
+		{int64(math.MaxInt64), math.MaxInt64, false},
+		{int32(-7), -7, false},
+		{42, 42, false},
+		{float64(1e6), 1000000, false},
+		{1.5, 0, true},
+		{[]byte("123"), 123, false},
+		{"-9", -9, false},
+		{"12.0", 12, false},
+		{"12.5", 0, true},
+		{"abc", 0, true},
+		{true, 0, true},
+		{nil, 0, true},
+	}
+	for _, c := range cases {
+		got, err := getChecksumInt64(c.value)
+		if (err != nil) != c.isError {
+			t.Fatalf("value %v expected error %v; got %v", c.value, c.isError, err)
+		}
+		if got != c.expected {
+			t.Fatalf("value %v expected %v; got %v", c.value, c.expected, got)
+		}
+	}
+}
@@ -31,31 +31,31 @@ var jsonDiffDsnDsn = `{
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "source",
-            "sqlText": "select ${columnListCsv} from ${sourceTable} order by ${SQLPrimaryKeyFieldsCsv}"
+            "sqlText": "select ${columnListCsv} from ${sourceTable}${whereClause}${orderByPrimaryKeys}"
           }
         },
         "getFromTarget": {
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "target",
-            "sqlText": "select ${columnListCsv} from ${targetTable} order by ${SQLPrimaryKeyFieldsCsv}"
+            "sqlText": "select ${columnListCsv} from ${targetTable}${whereClause}${orderByPrimaryKeys}"
           }
         },
         "diff": {
-          "type": "MergeDiff",
+          "type": "${diffStepType}",
           "data": {
             "readOldDataFromStep": "getFromTarget",
             "readNewDataFromStep": "getFromSource",
             "joinKeys": "${keyTokens}",
             "compareKeys": "${otherTokens}",
             "flagFieldName": "#diffStatus",
-            "outputIdenticalRows": "false"
+            "outputIdenticalRows": "${diffOutputIdenticalRows}"${diffOldFieldPrefix}
           }
-        },
+        },${reportStep}
 		"fieldMapper": {
           "type": "FieldMapper",
           "data": {
-            "readDataFromStep": "diff"
+            "readDataFromStep": "${fieldMapperInputStep}"
           },
           "steps": [
             {
@@ -112,7 +112,7 @@ var jsonDiffDsnDsn = `{
       "sequence": [
         "getFromSource",
         "getFromTarget",
-        "diff",
+        "diff",${reportSequence}
 		"fieldMapper",
         "stdout"
       ]
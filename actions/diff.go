@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/relloyd/halfpipe/components"
 	"github.com/relloyd/halfpipe/constants"
 	"github.com/relloyd/halfpipe/helper"
 	"github.com/relloyd/halfpipe/logger"
@@ -16,6 +17,13 @@ import (
 	"github.com/relloyd/halfpipe/transform"
 )
 
+// ErrDifferencesFound is returned by RunDiff when the source and target data differ, so the caller can exit with a
+// non-zero return code.
+var ErrDifferencesFound = errors.New("differences found")
+
+// diffOldFieldPrefix is the prefix used by MergeDiff to add old values to CHANGED records for the diff report.
+const diffOldFieldPrefix = "#old."
+
 type DiffConfig struct {
 	SrcAndTgtConnections
 	SrcConnDetails            *shared.ConnectionDetails
@@ -25,6 +33,10 @@ type DiffConfig struct {
 	SQLPrimaryKeyFieldsCsv    string `errorTxt:"primary key fields CSV" mandatory:"yes"`
 	AbortAfterNumRecords      int
 	OutputAllDiffFields       bool
+	DiffMode                  string
+	ChecksumChunks            int
+	ReportFormat              string
+	ReportFile                string
 	RepeatInterval            int `errorTxt:"repeat interval"`
 	ExportConfigType          string
 	ExportIncludeConnections  bool
@@ -51,6 +63,14 @@ func RunDiff(cfg *DiffConfig) error {
 	if err := helper.ValidateStructIsPopulated(cfg); err != nil {
 		return err
 	}
+	if cfg.ReportFormat != "" { // if the user wants a report...
+		if err := components.ValidateDiffReportFormat(cfg.ReportFormat); err != nil {
+			return err
+		}
+		if cfg.ReportFile == "" {
+			return fmt.Errorf("supply a report file name to write the %v report", cfg.ReportFormat)
+		}
+	}
 	// Get column list for input SQL and (optionally) the CSV header fields.
 	tableCols, err := tabledefinition.GetTableColumns(log, tabledefinition.GetColumnsFunc(cfg.SrcConnDetails), &cfg.SrcSchemaTable)
 	if err != nil {
@@ -60,11 +80,42 @@ func RunDiff(cfg *DiffConfig) error {
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("error looking up fields in object %q", cfg.SrcSchemaTable.SchemaTable))
 	}
+	// Restrict the diff to key ranges whose checksums don't match.
+	m := make(map[string]string)
+	m["${whereClause}"] = ""
+	var checksumVerified int64
+	if cfg.ChecksumChunks > 0 { // if we should compare checksums before diffing records...
+		if cfg.RepeatInterval > 0 || cfg.ExportConfigType != "" {
+			return fmt.Errorf("checksum chunks can't be used with a repeat interval or exported pipe definition")
+		}
+		src, tgt, err := openChecksumTables(log, cfg)
+		if err != nil {
+			return err
+		}
+		keyField := helper.CsvToStringSliceTrimSpaces2(cfg.SQLPrimaryKeyFieldsCsv)[0]
+		ranges, verified, err := getMismatchedChecksumRanges(log, src, tgt, keyField, tableCols, cfg.ChecksumChunks)
+		src.db.Close()
+		tgt.db.Close()
+		if err != nil {
+			return errors.Wrap(err, "unable to compare checksums")
+		}
+		checksumVerified = verified
+		log.Info(checksumVerified, " row(s) verified by matching checksums")
+		if len(ranges) == 0 { // if all checksums match...
+			log.Info("checksums match, no differences found")
+			if cfg.ReportFormat != "" { // if the user wants a report...
+				r := &components.DiffReport{ChecksumVerified: checksumVerified, Differences: make([]components.DiffReportRecord, 0)}
+				return r.WriteFile(cfg.ReportFile, cfg.ReportFormat)
+			}
+			return nil
+		}
+		log.Info("diffing records in ", len(ranges), " key range(s) with mismatched checksums")
+		m["${whereClause}"] = getChecksumWhereClause(keyField, ranges)
+	}
 	// Get specific connections.
 	connSrc := shared.GetDsnConnectionDetails(cfg.SrcConnDetails)
 	connTgt := shared.GetDsnConnectionDetails(cfg.TgtConnDetails)
 	// Set up the transform.
-	m := make(map[string]string)
 	m["${sourceType}"] = cfg.SrcConnDetails.Type
 	m["${sourceEnv}"] = cfg.SrcConnDetails.LogicalName
 	m["${sourceDsn}"] = connSrc.Dsn
@@ -74,6 +125,12 @@ func RunDiff(cfg *DiffConfig) error {
 	m["${SQLPrimaryKeyFieldsCsv}"] = cfg.SQLPrimaryKeyFieldsCsv
 	m["${keyTokens}"] = pkTokens
 	m["${otherTokens}"] = otherTokens
+	if err := setDiffModeReplacements(m, cfg.DiffMode, cfg.SQLPrimaryKeyFieldsCsv); err != nil {
+		return err
+	}
+	if err := setDiffReportReplacements(m, cfg, pkTokens, otherTokens, checksumVerified); err != nil {
+		return err
+	}
 	// Other Target Stuff.
 	m["${targetType}"] = cfg.TgtConnDetails.Type
 	m["${targetEnv}"] = cfg.TgtConnDetails.LogicalName
@@ -114,12 +171,56 @@ func RunDiff(cfg *DiffConfig) error {
 	// Execute or export the transform.
 	if cfg.ExportConfigType == "" { // if we should execute the transform...
 		ti := transform.NewSafeMapTransformInfo()
-		_, err := transform.LaunchTransformJson(log, ti, jsonDiffDsnDsn, true, cfg.StatsDumpFrequencySeconds)
+		guid, err := transform.LaunchTransformJson(log, ti, jsonDiffDsnDsn, true, cfg.StatsDumpFrequencySeconds)
 		if err != nil {
 			return errors.Wrap(err, "unable to unmarshal reference JSON to build the Oracle sync pipe.")
 		}
+		info, _ := ti.Load(guid)
+		// The fieldMapper step reads only the NEW, CHANGED and DELETED records output by the diff step, since the
+		// report step, if any, drops IDENTICAL records.
+		if s, ok := info.GetStepStats("diffTransform", "fieldMapper"); ok && s.TotalRowsProcessed > 0 { // if differences were output...
+			return ErrDifferencesFound
+		}
 	} else { // else we should write the transform to STDOUT...
 		return outputPipeDefinition(log, jsonDiffDsnDsn, cfg.ExportConfigType, cfg.ExportIncludeConnections)
 	}
 	return nil
 }
+
+// setDiffReportReplacements adds the steps and step data required to write a diff report, if one is configured in
+// cfg, to the map of replacements, m, used to render the diff pipe.
+// The report step counts IDENTICAL rows so the diff step must output them, but the report step does not pass
+// them on. The MergeDiff step adds old values to CHANGED rows so the report can list the fields that changed.
+// checksumVerified is the number of rows excluded from the diff because their checksums match.
+func setDiffReportReplacements(m map[string]string, cfg *DiffConfig, pkTokens string, otherTokens string, checksumVerified int64) error {
+	m["${diffOutputIdenticalRows}"] = "false"
+	m["${diffOldFieldPrefix}"] = ""
+	m["${reportStep}"] = ""
+	m["${reportSequence}"] = ""
+	m["${fieldMapperInputStep}"] = "diff"
+	if cfg.ReportFormat == "" { // if there is no report required...
+		return nil
+	}
+	data := map[string]string{
+		"readDataFromStep": "diff",
+		"joinKeys":         pkTokens,
+		"compareKeys":      otherTokens,
+		"flagFieldName":    constants.DiffStatusFieldName,
+		"checksumVerified": strconv.FormatInt(checksumVerified, 10),
+		"format":           cfg.ReportFormat,
+		"fileName":         cfg.ReportFile,
+	}
+	if m["${diffStepType}"] == "MergeDiff" { // if the diff step can output old values...
+		data["oldFieldPrefix"] = diffOldFieldPrefix
+		m["${diffOldFieldPrefix}"] = fmt.Sprintf(`, "oldFieldPrefix": "%v"`, diffOldFieldPrefix)
+	}
+	step, err := json.Marshal(map[string]interface{}{"type": "DiffReport", "data": data})
+	if err != nil {
+		return errors.Wrap(err, "unable to convert the diff report step to JSON")
+	}
+	m["${diffOutputIdenticalRows}"] = "true"
+	m["${reportStep}"] = fmt.Sprintf("\n        \"report\": %v,", string(step))
+	m["${reportSequence}"] = "\n        \"report\","
+	m["${fieldMapperInputStep}"] = "report"
+	return nil
+}
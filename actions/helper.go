@@ -10,10 +10,12 @@ import (
 	"strings"
 
 	"github.com/ghodss/yaml"
+	"github.com/relloyd/halfpipe/components"
 	"github.com/relloyd/halfpipe/helper"
 	"github.com/relloyd/halfpipe/logger"
 	"github.com/relloyd/halfpipe/rdbms"
 	"github.com/relloyd/halfpipe/rdbms/shared"
+	tabledefinition "github.com/relloyd/halfpipe/table-definition"
 	"github.com/relloyd/halfpipe/transform"
 )
 
@@ -45,6 +47,66 @@ func debugLogSnowflakeDBDetails(log logger.Logger, snowConnectionDetails *rdbms.
 	}
 }
 
+// setCheckpointVariables adds the values used by CheckpointRead and CheckpointWrite steps to template map m.
+// The checkpoint key is made by joining keyParts with slashes. Table stores use the source database connection.
+func setCheckpointVariables(m map[string]string, checkpointStore string, keyParts ...string) {
+	m["${checkpointStore}"] = checkpointStore
+	m["${checkpointKey}"] = strings.Join(keyParts, "/")
+	if strings.HasPrefix(checkpointStore, "table://") { // if checkpoints are saved in a database table...
+		m["${checkpointConnection}"] = "source"
+	} else {
+		m["${checkpointConnection}"] = ""
+	}
+}
+
+// setFileFormatVariables adds the values used to write and load files of the given fileFormat to template map m.
+// Parquet files are typed using the definition of the source table schemaTable found via conn.
+func setFileFormatVariables(log logger.Logger, m map[string]string, fileFormat string, conn *shared.ConnectionDetails, schemaTable *rdbms.SchemaTable) error {
+	switch fileFormat {
+	case "", components.FileFormatCsv:
+		m["${fileWriterType}"] = "CSVFileWriter"
+		m["${fileNameExtension}"] = "csv"
+		m["${useGzip}"] = "true"
+		m["${parquetFieldTypes}"] = ""
+		m["${fileFormat}"] = components.FileFormatCsv
+	case components.FileFormatParquet:
+		fieldTypes, err := tabledefinition.GetTableParquetFieldTypes(log, tabledefinition.GetColumnsFunc(conn), tabledefinition.MustGetMapper(conn), schemaTable)
+		if err != nil {
+			return err
+		}
+		m["${fileWriterType}"] = "ParquetFileWriter"
+		m["${fileNameExtension}"] = "parquet"
+		m["${useGzip}"] = "false" // use SNAPPY compression.
+		m["${parquetFieldTypes}"] = fmt.Sprintf(` "fieldTypes": "%v",`, helper.EscapeQuotesInString(fieldTypes))
+		m["${fileFormat}"] = components.FileFormatParquet
+	default:
+		return fmt.Errorf("unsupported file format %q: use %q or %q", fileFormat, components.FileFormatCsv, components.FileFormatParquet)
+	}
+	return nil
+}
+
+// Values for the diff mode of the diff and sync batch actions, which choose the component used to compare records.
+const (
+	DiffModeMerge = "merge" // sorted merge-diff, where the databases order records by primary key (default).
+	DiffModeHash  = "hash"  // hash-diff, which doesn't need records to be sorted.
+)
+
+// setDiffModeReplacements adds the diff step type and ORDER BY clause required by diffMode to the map of
+// replacements, m, used to render the action's pipe.
+func setDiffModeReplacements(m map[string]string, diffMode string, primaryKeyFieldsCsv string) error {
+	switch diffMode {
+	case "", DiffModeMerge:
+		m["${diffStepType}"] = "MergeDiff"
+		m["${orderByPrimaryKeys}"] = " order by " + primaryKeyFieldsCsv
+	case DiffModeHash:
+		m["${diffStepType}"] = "HashDiff"
+		m["${orderByPrimaryKeys}"] = ""
+	default:
+		return fmt.Errorf("unsupported diff mode %q: use %v or %v", diffMode, DiffModeMerge, DiffModeHash)
+	}
+	return nil
+}
+
 // mustReplaceInStringUsingMapKeyVals will replace in string s (by reference)
 // the old and new values found in the map, where:
 // the map key is the old value; and
@@ -72,6 +134,8 @@ func outputPipeDefinition(log logger.Logger, transformString string, yamlOrJson
 		writeTransformConfigToFile(log, &t, os.Stdout, true)
 	} else if yamlOrJson == "json" {
 		writeTransformConfigToFile(log, &t, os.Stdout, false)
+	} else if yamlOrJson == transform.GraphFormatDot || yamlOrJson == transform.GraphFormatMermaid {
+		return transform.WriteGraph(os.Stdout, &t, yamlOrJson)
 	} else {
 		return fmt.Errorf("unsupported output format %q", yamlOrJson)
 	}
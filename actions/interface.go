@@ -13,6 +13,11 @@ type ConnectionLoader interface {
 	LoadConnection(connectionName string) (shared.ConnectionDetails, error)
 }
 
+// ConnectionLister is implemented by ConnectionLoaders that can list the names of all their connections.
+type ConnectionLister interface {
+	GetAllKeys() ([]string, error)
+}
+
 type ConnectionGetterSetter interface {
 	Get(key string, out interface{}) error
 	Set(key string, val interface{}) error
@@ -0,0 +1,28 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/relloyd/halfpipe/transform"
+)
+
+type PipeGraphConfig struct {
+	TransformFile string `errorTxt:"pipe file" mandatory:"yes"`
+	Format        string `errorTxt:"format" mandatory:"yes"`
+}
+
+// RunPipeGraph prints a diagram of the steps in the transform found in the YAML or JSON file supplied in cfg.
+func RunPipeGraph(cfg *PipeGraphConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("nil pointer for pipe graph config supplied")
+	}
+	if cfg.TransformFile == "" {
+		return fmt.Errorf("supply a YAML or JSON config file name to graph")
+	}
+	t, err := loadTransformFromFile(cfg.TransformFile)
+	if err != nil {
+		return err
+	}
+	return transform.WriteGraph(os.Stdout, t, cfg.Format)
+}
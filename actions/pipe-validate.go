@@ -0,0 +1,39 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/transform"
+)
+
+type PipeValidateConfig struct {
+	TransformFile    string `errorTxt:"pipe file" mandatory:"yes"`
+	LogLevel         string
+	StackDumpOnPanic bool
+}
+
+// RunPipeValidate checks the transform found in the YAML or JSON file supplied in cfg without opening any
+// connections. Each problem is printed to stdout and an error is returned if any are found.
+func RunPipeValidate(cfg *PipeValidateConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("nil pointer for pipe validate config supplied")
+	}
+	if cfg.TransformFile == "" {
+		return fmt.Errorf("supply a YAML or JSON config file name to validate")
+	}
+	log := logger.NewLogger("halfpipe", cfg.LogLevel, cfg.StackDumpOnPanic)
+	t, err := loadTransformFromFile(cfg.TransformFile)
+	if err != nil {
+		return err
+	}
+	errs := transform.ValidateTransformDefinition(log, t)
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("pipe %q is invalid: %v error(s) found", cfg.TransformFile, len(errs))
+	}
+	fmt.Printf("Pipe %q is valid\n", cfg.TransformFile)
+	return nil
+}
@@ -0,0 +1,166 @@
+package actions
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/transform"
+)
+
+type PipeWatchConfig struct {
+	PipeId     string `errorTxt:"pipe ID" mandatory:"yes"`
+	Url        string `errorTxt:"web service URL" mandatory:"yes"`
+	ApiKey     string
+	CaCertFile string
+}
+
+const watchBarWidth = 20
+
+// RunPipeWatch connects to the events of a pipe running in "hp serve" and prints its status and step stats as they
+// change, until the pipe ends. An error is returned if the pipe completes with an error.
+func RunPipeWatch(cfg *PipeWatchConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("nil pointer for pipe watch config supplied")
+	}
+	if err := helper.ValidateStructIsPopulated(cfg); err != nil {
+		return err
+	}
+	client := &http.Client{}
+	if cfg.CaCertFile != "" { // if the server uses a certificate that is not trusted by the system...
+		b, err := ioutil.ReadFile(cfg.CaCertFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return fmt.Errorf("no certificates found in %q", cfg.CaCertFile)
+		}
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(cfg.Url, "/")+"/pipes/"+cfg.PipeId+"/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if cfg.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.ApiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK { // if the request failed...
+		msg := struct {
+			Message string `json:"message"`
+		}{}
+		b, _ := ioutil.ReadAll(resp.Body)
+		if err := json.Unmarshal(b, &msg); err != nil || msg.Message == "" {
+			msg.Message = strings.TrimSpace(string(b))
+		}
+		return fmt.Errorf("error watching pipe %v: HTTP status %v: %v", cfg.PipeId, resp.StatusCode, msg.Message)
+	}
+	return watchPipeEvents(cfg.PipeId, resp.Body, os.Stdout, isatty.IsTerminal(os.Stdout.Fd()))
+}
+
+// watchPipeEvents reads server-sent events from r and writes the pipe status and step stats to w.
+// If interactive is true then the output is redrawn in place, else it is appended on each change.
+func watchPipeEvents(pipeId string, r io.Reader, w io.Writer, interactive bool) error {
+	status := transform.TransformStatus{}
+	var stepStats []stats.Stats
+	linesDrawn := 0
+	draw := func() {
+		lines := renderPipeWatch(pipeId, &status, stepStats)
+		if interactive && linesDrawn > 0 { // if we should overwrite the previous output...
+			fmt.Fprintf(w, "\033[%dA", linesDrawn)
+		}
+		for _, l := range lines {
+			if interactive {
+				fmt.Fprint(w, "\033[K")
+			}
+			fmt.Fprintln(w, l)
+		}
+		linesDrawn = len(lines)
+	}
+	eventType, data := "", ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() { // for each line of the stream...
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "" && eventType != "": // if the event is complete...
+			var err error
+			switch eventType {
+			case transform.EventTypeStatus:
+				err = json.Unmarshal([]byte(data), &status)
+			case transform.EventTypeStats:
+				err = json.Unmarshal([]byte(data), &stepStats)
+			}
+			if err != nil {
+				return fmt.Errorf("error reading %v event: %v", eventType, err)
+			}
+			draw()
+			eventType, data = "", ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if status.Status == transform.StatusCompleteWithError {
+		return fmt.Errorf("pipe %v completed with error: %v", pipeId, status.Error)
+	}
+	return nil
+}
+
+// renderPipeWatch returns lines describing the status of a pipe and a progress bar per step, where the length of
+// each bar is relative to the step with the most rows.
+func renderPipeWatch(pipeId string, status *transform.TransformStatus, stepStats []stats.Stats) []string {
+	statusText, _ := status.Status.MarshalJSON()
+	elapsed := time.Duration(0)
+	if !status.StartTime.IsZero() {
+		end := time.Now()
+		if status.TransformIsFinished() && !status.EndTime.IsZero() {
+			end = status.EndTime
+		}
+		elapsed = end.Sub(status.StartTime).Truncate(time.Second)
+	}
+	lines := []string{fmt.Sprintf("Pipe %v: %v, elapsed %v", pipeId, strings.Trim(string(statusText), `"`), elapsed)}
+	if status.Error != "" {
+		lines = append(lines, "Error: "+status.Error)
+	}
+	maxRows, nameWidth := 0, 0
+	for _, s := range stepStats {
+		if s.TotalRowsProcessed > maxRows {
+			maxRows = s.TotalRowsProcessed
+		}
+		if len(s.StepName) > nameWidth {
+			nameWidth = len(s.StepName)
+		}
+	}
+	for _, s := range stepStats { // for each step...
+		filled := 0
+		if maxRows > 0 {
+			filled = s.TotalRowsProcessed * watchBarWidth / maxRows
+		}
+		bar := strings.Repeat("#", filled) + strings.Repeat(".", watchBarWidth-filled)
+		lines = append(lines, fmt.Sprintf("  %-*v [%v] %10d rows %8d rows/s  buffer %-6d errors %d",
+			nameWidth, s.StepName, bar, s.TotalRowsProcessed, s.RowsPerSecondDelta, s.OutputBufferLen, s.ErrorCount))
+	}
+	return lines
+}
@@ -54,7 +54,7 @@ func launchPipeFromFile(log logger.Logger, transformFileName string, c Connectio
 	log.Debug("TransformDefinition data: ", string(b)) // Dump the transformation details JSON.
 	// Launch the transform.
 	ti := transform.NewSafeMapTransformInfo()
-	_, err = transform.LaunchTransformDefinition(log, ti, t, true, statsDumpFrequencySeconds)
+	_, err = transform.LaunchTransformDefinition(log, ti, t, "", true, statsDumpFrequencySeconds)
 	if err != nil {
 		return errors.Wrap(err, "unable to unmarshal reference JSON to build the pipe")
 	}
@@ -76,7 +76,7 @@ func launchPipeFromFileWithServer(log logger.Logger, transformFileName string, c
 	b, _ := json.MarshalIndent(t, "", "  ")
 	log.Debug("TransformDefinition data: ", string(b)) // Dump the transformation details JSON.
 	// Start the web server.
-	srv, chanStopServer, allTransformInfo := runServer(log, web)
+	srv, chanStopServer, allTransformInfo, scheduler := runServer(log, web, nil, nil)
 	// Launch the transform file by POSTing the JSON.
 	url := "http://localhost:" + strconv.Itoa(web.Port) + urlContext4Launch
 	log.Debug("posting to url = ", url)
@@ -88,7 +88,7 @@ func launchPipeFromFileWithServer(log logger.Logger, transformFileName string, c
 	if resp.StatusCode == http.StatusOK { // if the POST succeeded...
 		// Wait for the web server to end.
 		log.Info("Launched transform file ", transformFileName)
-		return waitForServer(log, srv, chanStopServer, allTransformInfo)
+		return waitForServer(log, srv, chanStopServer, allTransformInfo, scheduler)
 	} else { // else the POST failed...
 		// Shutdown and quit (kills the web server).
 		chanStopServer <- ""
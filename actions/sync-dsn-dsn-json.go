@@ -0,0 +1,82 @@
+package actions
+
+var jsonSyncDsnDsn = `{
+  "schemaVersion": 3,
+  "description": "sync batch from DSN to DSN",
+  "connections": {
+    "source": {
+      "type": "${sourceType}",
+      "logicalName": "${sourceEnv}",
+      "data": {
+        "dsn": "${sourceDsn}"
+      }
+    },
+    "target": {
+      "type": "${targetType}",
+      "logicalName": "${targetEnv}",
+      "data": {
+        "dsn": "${targetDsn}"
+      }
+    }
+  },
+  "type": "${repeatTransform}",
+  "repeatMetadata": {
+	"sleepSeconds": ${sleepSeconds}
+  },
+  "transformGroups": {
+    "syncTransformName": {
+	  "type": "sequential",
+      "steps": {
+        "getFromSource": {
+          "type": "TableInput",
+          "data": {
+            "databaseConnectionName": "source",
+            "sqlText": "select ${columnListCsv} from ${sourceTable}${orderByPrimaryKeys}"
+          }
+        },
+        "getFromCopy": {
+          "type": "TableInput",
+          "data": {
+            "databaseConnectionName": "target",
+            "sqlText": "select ${columnListCsv} from ${targetTable}${orderByPrimaryKeys}"
+          }
+        },
+        "diff": {
+          "type": "${diffStepType}",
+          "data": {
+            "readOldDataFromStep": "getFromCopy",
+            "readNewDataFromStep": "getFromSource",
+            "joinKeys": "${keyTokens}",
+            "compareKeys": "${otherTokens}",
+            "flagFieldName": "#flagField",
+            "outputIdenticalRows": "false"
+          }
+        },
+        "sync": {
+          "type": "TableSync",
+          "data": {
+            "readDataFromStep": "diff",
+            "databaseConnectionName": "target",
+            "outputSchemaName": "${syncTargetSchema}",
+            "outputTable": "${syncTargetTable}",
+            "flagFieldName": "#flagField",
+            "commitBatchSize": "${targetCommitBatchSize}",
+			"txtBatchNumRows": "${targetTxtBatchNumRows}",
+            "commitSequenceKeyName": "#syncCommitSequence",
+            "keyCols": "${keyTokens}",
+            "otherCols": "${otherTokens}"
+          }
+        }
+      },
+      "sequence": [
+        "getFromSource",
+        "getFromCopy",
+        "diff",
+        "sync"
+      ]
+    }
+  },
+  "sequence": [
+    "syncTransformName"
+  ]
+}`
@@ -31,18 +31,18 @@ var jsonSyncOracleOracle = `{
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "source",
-            "sqlText": "select ${columnListCsv} from ${sourceTable} order by ${SQLPrimaryKeyFieldsCsv}"
+            "sqlText": "select ${columnListCsv} from ${sourceTable}${orderByPrimaryKeys}"
           }
         },
         "getFromCopy": {
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "target",
-            "sqlText": "select ${columnListCsv} from ${targetTable} order by ${SQLPrimaryKeyFieldsCsv}"
+            "sqlText": "select ${columnListCsv} from ${targetTable}${orderByPrimaryKeys}"
           }
         },
         "diff": {
-          "type": "MergeDiff",
+          "type": "${diffStepType}",
           "data": {
             "readOldDataFromStep": "getFromCopy",
             "readNewDataFromStep": "getFromSource",
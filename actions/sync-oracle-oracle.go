@@ -20,6 +20,7 @@ type SyncOracleOracleConfig struct {
 	TgtSchemaTable            rdbms.SchemaTable
 	SQLPrimaryKeyFieldsCsv    string `errorTxt:"primary key fields CSV" mandatory:"yes"`
 	CommitBatchSize           int
+	DiffMode                  string
 	RepeatInterval            int `errorTxt:"repeat interval"`
 	ExportConfigType          string
 	ExportIncludeConnections  bool
@@ -53,6 +54,7 @@ func SetupOracleToOracleSync(genericCfg interface{}, actionCfg interface{}) erro
 	// Oracle specific.
 	tgt.SQLPrimaryKeyFieldsCsv = src.SQLPrimaryKeyFieldsCsv
 	tgt.CommitBatchSize = src.CommitBatchSize
+	tgt.DiffMode = src.DiffMode
 	return nil
 }
 
@@ -89,6 +91,9 @@ func RunOracleOracleSync(cfg interface{}) error {
 	m["${SQLPrimaryKeyFieldsCsv}"] = cfgSync.SQLPrimaryKeyFieldsCsv
 	m["${keyTokens}"] = pkTokens
 	m["${otherTokens}"] = otherTokens
+	if err := setDiffModeReplacements(m, cfgSync.DiffMode, cfgSync.SQLPrimaryKeyFieldsCsv); err != nil {
+		return err
+	}
 	// Other Target Stuff.
 	m["${targetEnv}"] = cfgSync.TgtConnDetails.LogicalName
 	m["${targetDsn}"] = connTgt.Dsn
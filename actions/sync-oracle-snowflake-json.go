@@ -31,18 +31,18 @@ var jsonSyncOracleSnowflake = `{
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "source",
-            "sqlText": "select ${columnListCsv} from ${sourceTable} order by ${SQLPrimaryKeyFieldsCsv}"
+            "sqlText": "select ${columnListCsv} from ${sourceTable}${orderByPrimaryKeys}"
           }
         },
         "getFromTarget": {
           "type": "TableInput",
           "data": {
             "databaseConnectionName": "target",
-            "sqlText": "select ${columnListCsv} from ${snowflakeSchemaTable} order by ${SQLPrimaryKeyFieldsCsv}"
+            "sqlText": "select ${columnListCsv} from ${snowflakeSchemaTable}${orderByPrimaryKeys}"
           }
         },
         "diff": {
-          "type": "MergeDiff",
+          "type": "${diffStepType}",
           "data": {
             "readOldDataFromStep": "getFromTarget",
             "readNewDataFromStep": "getFromSource",
@@ -122,7 +122,6 @@ var jsonSyncOracleSnowflake = `{
             "readDataFromStep": "manifestReader",
             "logicalConnectionName": "target",
             "fieldName4FileName": "#dataFile",
-            "use1Transaction": "true",
             "stageName": "${snowflakeStage}",
             "schemaTableName": "${snowflakeSchemaTable}",
             "keyCols": "${keyTokens}",
@@ -25,6 +25,7 @@ type SyncOracleSnowflakeConfig struct {
 	TgtSnowSchemaTable rdbms.SchemaTable
 	// Sync specific.
 	SQLPrimaryKeyFieldsCsv string `errorTxt:"primary key fields CSV" mandatory:"yes"`
+	DiffMode               string
 	// Snowflake specific.
 	SnowStageName     string `errorTxt:"Snowflake stage" mandatory:"yes"`
 	BucketName        string `errorTxt:"s3 bucket" mandatory:"yes"`
@@ -70,6 +71,7 @@ func SetupOracleToSnowflakeSync(genericCfg interface{}, actionCfg interface{}) e
 	tgt.TgtSnowSchemaTable.SchemaTable = src.TargetString.GetObject()
 	// Sync specific
 	tgt.SQLPrimaryKeyFieldsCsv = src.SQLPrimaryKeyFieldsCsv
+	tgt.DiffMode = src.DiffMode
 	// Snowflake specific
 	tgt.SnowStageName = src.SnowStageName
 	// S3
@@ -155,6 +157,9 @@ func RunOracleSnowflakeSync(cfg interface{}) error {
 	m["${SQLPrimaryKeyFieldsCsv}"] = cfgSync.SQLPrimaryKeyFieldsCsv
 	m["${keyTokens}"] = pkTokens
 	m["${otherTokens}"] = otherTokens
+	if err := setDiffModeReplacements(m, cfgSync.DiffMode, cfgSync.SQLPrimaryKeyFieldsCsv); err != nil {
+		return err
+	}
 	// CSV
 	m["${fileNamePrefix}"] = cfgSync.CsvFileNamePrefix // multiple uses of fileNamePrefix exist in different steps not just CSV file writer.
 	m["${csvHeaderFields}"] = cols                     // not used: cfgSync.CsvHeaderFields
@@ -15,6 +15,7 @@ type SyncConfig struct {
 	SQLTargetTableOriginRowIdFieldName string
 	CommitBatchSize                    int
 	TxtBatchNumRows                    int
+	DiffMode                           string
 	// Snowflake Specific
 	SnowTableName     string `errorTxt:"Snowflake [schema.]table" mandatory:"yes"`
 	SnowStageName     string `errorTxt:"Snowflake stage" mandatory:"yes"`
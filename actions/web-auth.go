@@ -0,0 +1,197 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/relloyd/halfpipe/logger"
+)
+
+// ApiRole is the level of access granted to an API key.
+// Each role includes the access of those before it.
+type ApiRole int
+
+const (
+	RoleRead   ApiRole = iota + 1 // fetch status, stats, metrics and pipe definitions
+	RoleLaunch                    // launch, run and stop pipes and save pipe definitions
+	RoleAdmin                     // stop the server
+)
+
+var apiRoles = map[string]ApiRole{
+	"read":   RoleRead,
+	"launch": RoleLaunch,
+	"admin":  RoleAdmin,
+}
+
+const (
+	headerHmacTimestamp = "X-Halfpipe-Timestamp"
+	hmacScheme          = "HMAC-SHA256"
+	hmacMaxClockSkew    = 5 * time.Minute
+)
+
+// ApiKey is a credential that may be used to call the web service.
+type ApiKey struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+	Role   string `json:"role"` // read | launch | admin
+}
+
+// ApiKeys is the format of the file supplied to "hp serve --api-keys" in YAML or JSON.
+type ApiKeys struct {
+	Keys []ApiKey `json:"keys"`
+}
+
+// apiAuthenticator checks the credentials supplied with each HTTP request.
+// Clients send either "Authorization: Bearer <secret>" or a signed request with headers
+// "Authorization: HMAC-SHA256 <name>:<signature>" and "X-Halfpipe-Timestamp: <unix seconds>", where the signature
+// is the hex encoded HMAC-SHA256, keyed by the secret, of the following lines joined by "\n":
+// method, request URI, timestamp and the hex encoded SHA-256 of the request body.
+type apiAuthenticator struct {
+	keys  []ApiKey
+	roles map[string]ApiRole // map[key name]role
+}
+
+type ctxKeyCredential struct{}
+
+// loadApiKeys reads the API keys in fileName and returns an authenticator that accepts them.
+func loadApiKeys(fileName string) (*apiAuthenticator, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	k := ApiKeys{}
+	if err = yaml.Unmarshal(b, &k); err != nil {
+		return nil, fmt.Errorf("error reading API keys file %q: %v", fileName, err)
+	}
+	a := &apiAuthenticator{keys: k.Keys, roles: make(map[string]ApiRole)}
+	for idx, key := range k.Keys { // for each key...
+		if key.Name == "" || key.Secret == "" {
+			return nil, fmt.Errorf("API key %v in %q must have a name and secret", idx+1, fileName)
+		}
+		if _, ok := a.roles[key.Name]; ok {
+			return nil, fmt.Errorf("duplicate API key name %q in %q", key.Name, fileName)
+		}
+		role, ok := apiRoles[strings.ToLower(key.Role)]
+		if !ok {
+			return nil, fmt.Errorf("bad role %q for API key %q in %q: use read, launch or admin", key.Role, key.Name, fileName)
+		}
+		a.roles[key.Name] = role
+	}
+	if len(a.keys) == 0 {
+		return nil, fmt.Errorf("no API keys found in %q", fileName)
+	}
+	return a, nil
+}
+
+// authenticate returns the name of the API key used to sign or authorise request r.
+func (a *apiAuthenticator) authenticate(r *http.Request) (name string, err error) {
+	auth := r.Header.Get("Authorization")
+	scheme, credential := auth, ""
+	if idx := strings.Index(auth, " "); idx >= 0 {
+		scheme, credential = auth[:idx], strings.TrimSpace(auth[idx+1:])
+	}
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		for _, key := range a.keys { // for each key, compare them all in constant time...
+			if subtle.ConstantTimeCompare([]byte(credential), []byte(key.Secret)) == 1 {
+				name = key.Name
+			}
+		}
+		if name == "" {
+			return "", fmt.Errorf("bad bearer token")
+		}
+		return name, nil
+	case strings.EqualFold(scheme, hmacScheme):
+		return a.authenticateHmac(r, credential)
+	default:
+		return "", fmt.Errorf("missing Authorization header")
+	}
+}
+
+// authenticateHmac checks the signature in credential, which is of the form <name>:<signature>.
+func (a *apiAuthenticator) authenticateHmac(r *http.Request, credential string) (string, error) {
+	parts := strings.SplitN(credential, ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("bad %v credential", hmacScheme)
+	}
+	var key *ApiKey
+	for idx := range a.keys {
+		if a.keys[idx].Name == parts[0] {
+			key = &a.keys[idx]
+		}
+	}
+	if key == nil {
+		return "", fmt.Errorf("unknown API key %q", parts[0])
+	}
+	ts := r.Header.Get(headerHmacTimestamp)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("missing or bad %v header", headerHmacTimestamp)
+	}
+	if skew := time.Since(time.Unix(sec, 0)); skew > hmacMaxClockSkew || skew < -hmacMaxClockSkew {
+		return "", fmt.Errorf("%v is outside the allowed clock skew of %v", headerHmacTimestamp, hmacMaxClockSkew)
+	}
+	// Read the body and put it back for the handler.
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	expected := getHmacSignature(key.Secret, r.Method, r.URL.RequestURI(), ts, body)
+	if !hmac.Equal([]byte(strings.ToLower(parts[1])), []byte(expected)) {
+		return "", fmt.Errorf("bad %v signature", hmacScheme)
+	}
+	return key.Name, nil
+}
+
+// getHmacSignature returns the hex encoded signature of a request, see apiAuthenticator.
+func getHmacSignature(secret string, method string, requestURI string, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{method, requestURI, timestamp, hex.EncodeToString(bodyHash[:])}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requireRole returns a handler that calls h if the request is authenticated by auth with a key that has at least
+// the given role. The key name is saved in the request context, see getCredentialName.
+// If auth is nil then authentication is disabled and h is returned.
+func requireRole(log logger.Logger, auth *apiAuthenticator, role ApiRole, h http.HandlerFunc) http.HandlerFunc {
+	if auth == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, err := auth.authenticate(r)
+		if err != nil {
+			log.Info("HTTP request to ", r.URL.Path, " rejected from ", r.RemoteAddr, ": ", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="halfpipe"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			respond(log, w, ResponseMessage{Status: Error, Message: "unauthorised"})
+			return
+		}
+		if auth.roles[name] < role {
+			log.Info("HTTP request to ", r.URL.Path, " forbidden for API key ", name)
+			w.WriteHeader(http.StatusForbidden)
+			respond(log, w, ResponseMessage{Status: Error, Message: "forbidden"})
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), ctxKeyCredential{}, name)))
+	}
+}
+
+// getCredentialName returns the name of the API key that authenticated request r or an empty string if
+// authentication is disabled.
+func getCredentialName(r *http.Request) string {
+	name, _ := r.Context().Value(ctxKeyCredential{}).(string)
+	return name
+}
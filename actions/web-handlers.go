@@ -3,11 +3,19 @@ package actions
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/ghodss/yaml"
 	"github.com/gorilla/mux"
+	"github.com/relloyd/halfpipe/components"
 	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/registry"
 	"github.com/relloyd/halfpipe/transform"
 )
 
@@ -36,6 +44,11 @@ type ResponseSimple struct {
 	ServerStatus WebServerResponse `json:"status"`
 }
 
+type ResponseMessage struct {
+	Status  WebServerResponse `json:"status"`
+	Message string            `json:"message"`
+}
+
 type ResponseTransformList struct {
 	Status        WebServerResponse   `json:"status"`
 	TransformList []TransformListItem `json:"pipes"`
@@ -45,6 +58,7 @@ type TransformListItem struct {
 	TransformId          string           `json:"pipeId"`
 	TransformDescription string           `json:"pipeDescription"`
 	TransformStatus      transform.Status `json:"pipeStatus"`
+	SubmittedBy          string           `json:"submittedBy,omitempty"`
 }
 
 type ResponseTransformStats struct {
@@ -57,6 +71,7 @@ type ResponseTransformStatus struct {
 	Status          WebServerResponse         `json:"status"`
 	Message         string                    `json:"message"`
 	TransformStatus transform.TransformStatus `json:"pipeStatus"`
+	SubmittedBy     string                    `json:"submittedBy,omitempty"`
 }
 
 type ResponseTransformStop struct {
@@ -65,12 +80,53 @@ type ResponseTransformStop struct {
 	TransformId string            `json:"pipeId"`
 }
 
+type ResponseTransformControl struct {
+	Status      WebServerResponse `json:"status"`
+	Message     string            `json:"message"`
+	TransformId string            `json:"pipeId"`
+}
+
 type ResponseTransformLaunch struct {
 	Status      WebServerResponse `json:"status"`
 	Message     string            `json:"message"`
 	TransformId string            `json:"pipeId"`
 }
 
+type ResponseConnectionList struct {
+	Status      WebServerResponse    `json:"status"`
+	Message     string               `json:"message"`
+	Connections []ConnectionListItem `json:"connections"`
+}
+
+type ConnectionListItem struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Details string `json:"details"` // see shared.ConnectionDetails.String() which redacts passwords.
+}
+
+type ResponsePipeDefinitionList struct {
+	Status WebServerResponse `json:"status"`
+	Pipes  []registry.Pipe   `json:"pipeDefinitions"`
+}
+
+type ResponsePipeDefinition struct {
+	Status  WebServerResponse `json:"status"`
+	Message string            `json:"message"`
+	Pipe    *registry.Pipe    `json:"pipeDefinition,omitempty"`
+}
+
+type ResponsePipeRun struct {
+	Status  WebServerResponse `json:"status"`
+	Message string            `json:"message"`
+	Run     *registry.Run     `json:"run,omitempty"`
+}
+
+type ResponsePipeRunList struct {
+	Status  WebServerResponse `json:"status"`
+	Message string            `json:"message"`
+	Runs    []registry.Run    `json:"runs"`
+}
+
 func GetHandlerHealth(log logger.Logger) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -92,6 +148,14 @@ func GetHandlerTransformLaunch(log logger.Logger, allTransformInfo *transform.Sa
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Ingest the transform from the request body JSON.
 		b, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(r.Header.Get("Content-Type"), "yaml") { // if the transform is YAML...
+			var err error
+			if b, err = yaml.YAMLToJSON(b); err != nil {
+				logAndRespond(log, err, w,
+					ResponseTransformLaunch{Status: Error, Message: fmt.Sprintf("error converting YAML to JSON: %v", err)})
+				return
+			}
+		}
 		// Unmarshal the supplied JSON.
 		t := transform.TransformDefinition{}
 		err := json.Unmarshal(b, &t)
@@ -107,7 +171,7 @@ func GetHandlerTransformLaunch(log logger.Logger, allTransformInfo *transform.Sa
 			return
 		}
 		// Launch.
-		guid, err := transform.LaunchTransformDefinition(log, allTransformInfo, &t, false, statsDumpFrequencySeconds)
+		guid, err := transform.LaunchTransformDefinition(log, allTransformInfo, &t, getCredentialName(r), false, statsDumpFrequencySeconds)
 		if err != nil {
 			logAndRespond(log, err, w,
 				ResponseTransformLaunch{Status: Error, Message: fmt.Sprintf("invalid JSON transform definition supplied: %v", err)})
@@ -144,6 +208,66 @@ func GetHandlerTransformStop(log logger.Logger, allTransformInfo *transform.Safe
 	}
 }
 
+// transformControlTimeout is how long to wait for a transform to accept a pause or resume request.
+const transformControlTimeout = 5 * time.Second
+
+func GetHandlerTransformPause(log logger.Logger, allTransformInfo *transform.SafeMapTransformInfo) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sendTransformControlAction(log, w, r, allTransformInfo, components.Pause, transform.StatusRunning)
+	}
+}
+
+func GetHandlerTransformResume(log logger.Logger, allTransformInfo *transform.SafeMapTransformInfo) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sendTransformControlAction(log, w, r, allTransformInfo, components.Resume, transform.StatusPaused)
+	}
+}
+
+// sendTransformControlAction sends action to the transform with the pipeId found in r, if it has status
+// requiredStatus, and responds with the outcome once all of its steps have responded.
+func sendTransformControlAction(log logger.Logger, w http.ResponseWriter, r *http.Request, allTransformInfo *transform.SafeMapTransformInfo, action components.Action, requiredStatus transform.Status) {
+	id := mux.Vars(r)["pipeId"]
+	t, ok := allTransformInfo.Load(id)
+	if !ok { // if the transform doesn't exist...
+		w.WriteHeader(http.StatusBadRequest)
+		log.Info("HTTP request to ", action, " transform ", id, " that doesn't exist.")
+		respond(log, w, ResponseTransformControl{Status: Error, Message: "transform does not exist", TransformId: id})
+		return
+	}
+	if t.Status.Status != requiredStatus || t.ChanControl == nil { // if the transform can't accept the action...
+		statusText, _ := t.Status.Status.MarshalJSON()
+		w.WriteHeader(http.StatusConflict)
+		log.Info("HTTP request to ", action, " transform ", id, " ignored since its status is ", string(statusText))
+		respond(log, w, ResponseTransformControl{Status: Error, Message: fmt.Sprintf("unable to %v transform with status %s", action, statusText), TransformId: id})
+		return
+	}
+	log.Info("Sending ", action, " request to transform ", id)
+	a := components.ControlAction{Action: action, ResponseChan: make(chan error, 1)}
+	var err error
+	select {
+	case t.ChanControl <- a:
+		select {
+		case err = <-a.ResponseChan:
+		case <-r.Context().Done(): // if the client went away...
+			return
+		}
+	case <-time.After(transformControlTimeout): // if the transform ended or is busy...
+		err = fmt.Errorf("transform did not accept the %v request in a timely manner", action)
+	}
+	if err != nil {
+		log.Error("Error sending ", action, " request to transform ", id, ": ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		respond(log, w, ResponseTransformControl{Status: Error, Message: err.Error(), TransformId: id})
+		return
+	}
+	msg := "paused"
+	if action == components.Resume {
+		msg = "resumed"
+	}
+	w.WriteHeader(http.StatusOK)
+	respond(log, w, ResponseTransformControl{Status: Okay, Message: msg, TransformId: id})
+}
+
 func GetHandlerTransformList(log logger.Logger, allTransformInfo *transform.SafeMapTransformInfo) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// vars := mux.Vars(r)
@@ -155,6 +279,7 @@ func GetHandlerTransformList(log logger.Logger, allTransformInfo *transform.Safe
 				TransformId:          jobId,
 				TransformDescription: v.Transform.Description,
 				TransformStatus:      v.Status.Status,
+				SubmittedBy:          v.SubmittedBy,
 			})
 		}
 		allTransformInfo.Unlock()
@@ -188,7 +313,7 @@ func GetHandlerTransformStatus(log logger.Logger, allTransformInfo *transform.Sa
 		ti, ok := allTransformInfo.Load(id)
 		if ok { // if the transform exists...
 			w.WriteHeader(http.StatusOK)
-			respond(log, w, ResponseTransformStatus{Status: Okay, Message: "", TransformStatus: ti.Status})
+			respond(log, w, ResponseTransformStatus{Status: Okay, Message: "", TransformStatus: ti.Status, SubmittedBy: ti.SubmittedBy})
 		} else { // else the transform doesn't exist...
 			w.WriteHeader(http.StatusBadRequest)
 			log.Info("HTTP request status of transform ", id, " that doesn't exist.")
@@ -197,6 +322,275 @@ func GetHandlerTransformStatus(log logger.Logger, allTransformInfo *transform.Sa
 	}
 }
 
+// GetHandlerTransformEvents streams the events of a transform using server-sent events.
+// The current status and stats are sent first, followed by each status change and stats snapshot until the
+// transform ends or the client disconnects. Stats snapshots are sent as often as they are dumped to the log.
+func GetHandlerTransformEvents(log logger.Logger, allTransformInfo *transform.SafeMapTransformInfo) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["pipeId"]
+		ti, ok := allTransformInfo.Load(id)
+		if !ok { // if the transform doesn't exist...
+			w.WriteHeader(http.StatusBadRequest)
+			log.Info("HTTP request for events of transform ", id, " that doesn't exist.")
+			respond(log, w, ResponseMessage{Status: Error, Message: fmt.Sprintf("transform %v does not exist", id)})
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok || ti.Events == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			respond(log, w, ResponseMessage{Status: Error, Message: "event streaming is not supported"})
+			return
+		}
+		// Subscribe before sending the current state so no events are missed in between.
+		events, cancel := ti.Events.Subscribe()
+		defer cancel()
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		if err := extendWriteDeadline(r); err != nil {
+			log.Error("unable to extend the write deadline for events of transform ", id, ": ", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		ti, _ = allTransformInfo.Load(id)
+		initial := []transform.Event{{Type: transform.EventTypeStatus, Data: ti.Status}}
+		if ti.Stats != nil {
+			initial = append(initial, transform.Event{Type: transform.EventTypeStats, Data: ti.Stats.GetStats()})
+		}
+		for _, e := range initial {
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+		keepAlive := time.NewTicker(webWriteTimeout / 3)
+		defer keepAlive.Stop()
+		for {
+			if err := extendWriteDeadline(r); err != nil {
+				return
+			}
+			select {
+			case e, ok := <-events:
+				if !ok { // if the transform has ended...
+					return
+				}
+				if err := writeEvent(w, e); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case <-r.Context().Done(): // if the client went away...
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes e to w in server-sent event format with the data marshalled to JSON.
+func writeEvent(w io.Writer, e transform.Event) error {
+	b, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %v\ndata: %v\n\n", e.Type, string(b))
+	return err
+}
+
+// GetHandlerTransformGraph writes the steps of a transform as a graph in transform.GraphFormatJSON.
+func GetHandlerTransformGraph(log logger.Logger, allTransformInfo *transform.SafeMapTransformInfo) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["pipeId"]
+		ti, ok := allTransformInfo.Load(id)
+		if !ok { // if the transform doesn't exist...
+			w.WriteHeader(http.StatusBadRequest)
+			log.Info("HTTP request for graph of transform ", id, " that doesn't exist.")
+			respond(log, w, ResponseMessage{Status: Error, Message: fmt.Sprintf("transform %v does not exist", id)})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := transform.WriteGraph(w, &ti.Transform, transform.GraphFormatJSON); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+// GetHandlerConnectionList lists the connections that can be used by pipes, with their passwords redacted.
+// Connections cannot be listed if c does not implement ConnectionLister, e.g. in 12 factor mode.
+func GetHandlerConnectionList(log logger.Logger, c ConnectionLoader) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, ok := c.(ConnectionLister)
+		if !ok { // if the connections can't be listed...
+			w.WriteHeader(http.StatusNotImplemented)
+			respond(log, w, ResponseConnectionList{Status: Error, Message: "connections cannot be listed in this mode", Connections: []ConnectionListItem{}})
+			return
+		}
+		names, err := l.GetAllKeys()
+		if err != nil {
+			log.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			respond(log, w, ResponseConnectionList{Status: Error, Message: err.Error(), Connections: []ConnectionListItem{}})
+			return
+		}
+		sort.Strings(names)
+		conns := make([]ConnectionListItem, 0, len(names))
+		for _, name := range names { // for each connection...
+			d, err := c.LoadConnection(name)
+			if err != nil {
+				log.Error(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				respond(log, w, ResponseConnectionList{Status: Error, Message: err.Error(), Connections: []ConnectionListItem{}})
+				return
+			}
+			conns = append(conns, ConnectionListItem{Name: name, Type: d.Type, Details: redactConnectionDetails(d)})
+		}
+		w.WriteHeader(http.StatusOK)
+		respond(log, w, ResponseConnectionList{Status: Okay, Connections: conns})
+	}
+}
+
+// redactConnectionDetails returns d.String(), which hides passwords, or a message if d has a DSN that can't be
+// parsed, since String() panics in that case.
+func redactConnectionDetails(d shared.ConnectionDetails) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = "  (unable to parse connection details)"
+		}
+	}()
+	return d.String()
+}
+
+// GetHandlerMetrics returns the status and step stats of all transforms in Prometheus text format.
+func GetHandlerMetrics(log logger.Logger, allTransformInfo *transform.SafeMapTransformInfo) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err := transform.WritePrometheusMetrics(w, allTransformInfo); err != nil {
+			log.Error("error writing metrics: ", err)
+		}
+	}
+}
+
+// GetHandlerPipeDefinitionList returns all pipe definitions saved in the registry.
+func GetHandlerPipeDefinitionList(log logger.Logger, reg *registry.Registry) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pipes, err := reg.ListPipes()
+		if err != nil {
+			log.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			respond(log, w, ResponseSimple{ServerStatus: Error})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		respond(log, w, ResponsePipeDefinitionList{Status: Okay, Pipes: pipes})
+	}
+}
+
+// GetHandlerPipeDefinitionGet returns the pipe definition saved in the registry with the name in the URL.
+func GetHandlerPipeDefinitionGet(log logger.Logger, reg *registry.Registry) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		p, found, err := reg.GetPipe(name)
+		if err != nil {
+			log.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: err.Error()})
+		} else if !found {
+			w.WriteHeader(http.StatusNotFound)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: fmt.Sprintf("pipe definition %v does not exist", name)})
+		} else {
+			w.WriteHeader(http.StatusOK)
+			respond(log, w, ResponsePipeDefinition{Status: Okay, Pipe: &p})
+		}
+	}
+}
+
+// GetHandlerPipeDefinitionSave validates the pipe definition in the request body JSON and saves it in the registry
+// with the name in the URL, replacing any existing definition.
+func GetHandlerPipeDefinitionSave(log logger.Logger, reg *registry.Registry) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		p := registry.Pipe{}
+		if err := json.Unmarshal(b, &p); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: fmt.Sprintf("error unmarshalling JSON: %v", err)})
+			return
+		}
+		p.Name = mux.Vars(r)["name"]
+		if err := registry.ValidatePipe(&p); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: err.Error()})
+			return
+		}
+		if errs := transform.ValidateTransformDefinition(log, &p.Transform); len(errs) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: fmt.Sprintf("invalid pipe: %v", errs[0])})
+			return
+		}
+		if err := reg.SavePipe(p); err != nil {
+			log.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: err.Error()})
+			return
+		}
+		log.Info("Saved pipe definition ", p.Name)
+		w.WriteHeader(http.StatusOK)
+		respond(log, w, ResponsePipeDefinition{Status: Okay, Message: "pipe definition saved"})
+	}
+}
+
+// GetHandlerPipeDefinitionDelete removes the pipe definition with the name in the URL and its run history.
+func GetHandlerPipeDefinitionDelete(log logger.Logger, reg *registry.Registry) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		found, err := reg.DeletePipe(name)
+		if err != nil {
+			log.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: err.Error()})
+		} else if !found {
+			w.WriteHeader(http.StatusNotFound)
+			respond(log, w, ResponsePipeDefinition{Status: Error, Message: fmt.Sprintf("pipe definition %v does not exist", name)})
+		} else {
+			log.Info("Deleted pipe definition ", name)
+			w.WriteHeader(http.StatusOK)
+			respond(log, w, ResponsePipeDefinition{Status: Okay, Message: "pipe definition deleted"})
+		}
+	}
+}
+
+// GetHandlerPipeDefinitionRun launches the pipe definition with the name in the URL now.
+func GetHandlerPipeDefinitionRun(log logger.Logger, scheduler *registry.Scheduler) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		run, err := scheduler.Launch(mux.Vars(r)["name"], registry.TriggerManual, getCredentialName(r))
+		if err != nil {
+			log.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			respond(log, w, ResponsePipeRun{Status: Error, Message: err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		respond(log, w, ResponsePipeRun{Status: Okay, Message: "transform launched", Run: &run})
+	}
+}
+
+// GetHandlerPipeDefinitionRuns returns the run history of the pipe definition with the name in the URL.
+func GetHandlerPipeDefinitionRuns(log logger.Logger, reg *registry.Registry) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runs, err := reg.ListRuns(mux.Vars(r)["name"])
+		if err != nil {
+			log.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			respond(log, w, ResponsePipeRunList{Status: Error, Message: err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		respond(log, w, ResponsePipeRunList{Status: Okay, Runs: runs})
+	}
+}
+
 // logAndRespond will log the error, write a http.StatusBadRequest and r to w.
 func logAndRespond(log logger.Logger, err error, w http.ResponseWriter, r ResponseTransformLaunch) {
 	log.Error(err)
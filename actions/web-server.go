@@ -2,6 +2,7 @@ package actions
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -14,13 +15,36 @@ import (
 	"github.com/pkg/errors"
 	"github.com/relloyd/halfpipe/helper"
 	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/registry"
 	"github.com/relloyd/halfpipe/transform"
 )
 
 const (
 	urlContext4Launch = "/launch"
+	webWriteTimeout   = time.Second * 15
 )
 
+// connContextKey is the request context key for the connection serving the request.
+type connContextKey struct{}
+
+// saveConnInContext is used as the http.Server ConnContext so handlers can find their connection.
+func saveConnInContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// extendWriteDeadline moves the write deadline of the connection serving r to webWriteTimeout from now.
+// Long-lived responses call this before each write so they are exempt from the server's WriteTimeout.
+// This is a stand-in for http.ResponseController.SetWriteDeadline, which needs Go 1.20.
+func extendWriteDeadline(r *http.Request) error {
+	c, ok := r.Context().Value(connContextKey{}).(net.Conn)
+	if !ok { // if there is no connection to extend...
+		return nil
+	}
+	return c.SetWriteDeadline(time.Now().Add(webWriteTimeout))
+}
+
 type WebServerConfig struct {
 	LogLevel                  string `errorTxt:"log level" mandatory:"yes"`
 	Scheme                    string `errorTxt:"scheme" mandatory:"no"`
@@ -29,6 +53,11 @@ type WebServerConfig struct {
 	Connections               ConnectionLoader
 	StatsDumpFrequencySeconds int
 	StackDumpOnPanic          bool
+	PipeRegistry              string // see registry.NewStore(); leave empty to disable the pipe registry and scheduler.
+	PipeRegistryConnection    string // connection name required when the registry is a database table.
+	TLSCertFile               string // serve HTTPS using this certificate file and TLSKeyFile.
+	TLSKeyFile                string
+	ApiKeysFile               string // see ApiKeys; leave empty to disable authentication.
 }
 
 func RunWebServer(web *WebServerConfig) error {
@@ -42,42 +71,137 @@ func RunWebServer(web *WebServerConfig) error {
 	if err != nil {
 		return err
 	}
+	if (web.TLSCertFile == "") != (web.TLSKeyFile == "") {
+		return errors.New("supply both a TLS certificate and key file to use HTTPS")
+	} else if web.TLSCertFile != "" { // else if we should use HTTPS, check the files before the server starts...
+		if _, err = tls.LoadX509KeyPair(web.TLSCertFile, web.TLSKeyFile); err != nil {
+			return errors.Wrap(err, "error loading TLS certificate")
+		}
+	}
+	// Load the API keys.
+	var auth *apiAuthenticator
+	if web.ApiKeysFile != "" {
+		if auth, err = loadApiKeys(web.ApiKeysFile); err != nil {
+			return err
+		}
+	} else {
+		log.Warn("No API keys supplied: the web service will accept requests without authentication")
+	}
+	// Open the pipe registry.
+	reg, err := openPipeRegistry(log, web)
+	if err != nil {
+		return err
+	}
 	// Start the web server.
-	srv, chanStopServer, allTransformInfo := runServer(log, web)
+	srv, chanStopServer, allTransformInfo, scheduler := runServer(log, web, reg, auth)
 	// Block & wait for completion.
-	return waitForServer(log, srv, chanStopServer, allTransformInfo)
+	return waitForServer(log, srv, chanStopServer, allTransformInfo, scheduler)
+}
+
+// openPipeRegistry returns the registry of pipe definitions described by web.PipeRegistry or nil if it is not set.
+func openPipeRegistry(log logger.Logger, web *WebServerConfig) (*registry.Registry, error) {
+	if web.PipeRegistry == "" {
+		return nil, nil
+	}
+	var db shared.Connector
+	if web.PipeRegistryConnection != "" { // if the registry is in a database...
+		conn, err := web.Connections.LoadConnection(web.PipeRegistryConnection)
+		if err != nil {
+			return nil, err
+		}
+		if db, err = rdbms.OpenDbConnection(log, conn); err != nil {
+			return nil, err
+		}
+	}
+	store, err := registry.NewStore(web.PipeRegistry, db)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Using pipe registry ", web.PipeRegistry)
+	return registry.NewRegistry(store), nil
 }
 
 // runServer starts a web server and returns:
 // 1) the server; and
 // 2) a channel that can be used to stop the web server
 // 3) a pointer to info on the the running transforms
-func runServer(log logger.Logger, web *WebServerConfig) (*http.Server, chan string, *transform.SafeMapTransformInfo) {
+// 4) the scheduler of pipes saved in reg, which is nil if reg is nil
+// Requests are authenticated by auth unless it is nil.
+func runServer(log logger.Logger, web *WebServerConfig, reg *registry.Registry, auth *apiAuthenticator) (*http.Server, chan string, *transform.SafeMapTransformInfo, *registry.Scheduler) {
 	chanStopServer := make(chan string, 1)
 	allTransformInfo := transform.NewSafeMapTransformInfo()
 	// Create routes.
 	r := mux.NewRouter()
+	var scheduler *registry.Scheduler
+	if reg != nil { // if there is a pipe registry...
+		// Start the scheduler and add routes for the pipe definitions before /pipes/{pipeId} routes.
+		scheduler = registry.NewScheduler(log, reg, allTransformInfo, func(t *transform.TransformDefinition, submittedBy string) (string, error) {
+			if err := loadConnectionDataIfMissing(web.Connections, t); err != nil {
+				return "", err
+			}
+			return transform.LaunchTransformDefinition(log, allTransformInfo, t, submittedBy, false, web.StatsDumpFrequencySeconds)
+		})
+		if err := scheduler.Start(); err != nil {
+			log.Panic("error starting pipe scheduler: ", err)
+		}
+		r.Path("/pipes/definitions").Methods(http.MethodGet).HandlerFunc(
+			requireRole(log, auth, RoleRead, GetHandlerPipeDefinitionList(log, reg)))
+		r.Path("/pipes/definitions/{name}").Methods(http.MethodGet).HandlerFunc(
+			requireRole(log, auth, RoleRead, GetHandlerPipeDefinitionGet(log, reg)))
+		r.Path("/pipes/definitions/{name}").Methods(http.MethodPut).HandlerFunc(
+			requireRole(log, auth, RoleLaunch, GetHandlerPipeDefinitionSave(log, reg)))
+		r.Path("/pipes/definitions/{name}").Methods(http.MethodDelete).HandlerFunc(
+			requireRole(log, auth, RoleLaunch, GetHandlerPipeDefinitionDelete(log, reg)))
+		r.Path("/pipes/definitions/{name}/run").Methods(http.MethodPost).HandlerFunc(
+			requireRole(log, auth, RoleLaunch, GetHandlerPipeDefinitionRun(log, scheduler)))
+		r.Path("/pipes/definitions/{name}/runs").Methods(http.MethodGet).HandlerFunc(
+			requireRole(log, auth, RoleRead, GetHandlerPipeDefinitionRuns(log, reg)))
+	}
 	// r.Headers("Content-Type", "application/json").Path("/launch").HandlerFunc(GetHandlerTransformLaunch(log))
 	// r.HandleFunc("/stats", StatsHandler).Headers("Content-Type", "application/json")
-	r.HandleFunc("/stop", GetHandlerStopServer(log, chanStopServer))
-	r.Path("/health").HandlerFunc(GetHandlerHealth(log))
-	r.Path("/pipes").HandlerFunc(GetHandlerTransformList(log, allTransformInfo))
-	r.Path("/pipes/{pipeId}/stats").HandlerFunc(GetHandlerTransformStats(log, allTransformInfo))
-	r.Path("/pipes/{pipeId}/status").HandlerFunc(GetHandlerTransformStatus(log, allTransformInfo))
-	r.Path("/pipes/{pipeId}/stop").HandlerFunc(GetHandlerTransformStop(log, allTransformInfo))
-	r.Path(urlContext4Launch).Headers("Content-Type", "application/json").HandlerFunc(
-		GetHandlerTransformLaunch(log, allTransformInfo, web.Connections, web.StatsDumpFrequencySeconds))
+	r.HandleFunc("/stop", requireRole(log, auth, RoleAdmin, GetHandlerStopServer(log, chanStopServer)))
+	r.Path("/health").HandlerFunc(GetHandlerHealth(log)) // health is not authenticated for use by load balancers.
+	r.Path("/metrics").HandlerFunc(requireRole(log, auth, RoleRead, GetHandlerMetrics(log, allTransformInfo)))
+	r.Path("/pipes").HandlerFunc(requireRole(log, auth, RoleRead, GetHandlerTransformList(log, allTransformInfo)))
+	r.Path("/pipes/{pipeId}/stats").HandlerFunc(requireRole(log, auth, RoleRead, GetHandlerTransformStats(log, allTransformInfo)))
+	r.Path("/pipes/{pipeId}/status").HandlerFunc(requireRole(log, auth, RoleRead, GetHandlerTransformStatus(log, allTransformInfo)))
+	r.Path("/pipes/{pipeId}/events").HandlerFunc(requireRole(log, auth, RoleRead, GetHandlerTransformEvents(log, allTransformInfo)))
+	r.Path("/pipes/{pipeId}/graph").HandlerFunc(requireRole(log, auth, RoleRead, GetHandlerTransformGraph(log, allTransformInfo)))
+	r.Path("/pipes/{pipeId}/stop").HandlerFunc(requireRole(log, auth, RoleLaunch, GetHandlerTransformStop(log, allTransformInfo)))
+	r.Path("/pipes/{pipeId}/pause").HandlerFunc(requireRole(log, auth, RoleLaunch, GetHandlerTransformPause(log, allTransformInfo)))
+	r.Path("/pipes/{pipeId}/resume").HandlerFunc(requireRole(log, auth, RoleLaunch, GetHandlerTransformResume(log, allTransformInfo)))
+	r.Path(urlContext4Launch).HeadersRegexp("Content-Type", "^application/(json|yaml|x-yaml)").HandlerFunc(
+		requireRole(log, auth, RoleLaunch, GetHandlerTransformLaunch(log, allTransformInfo, web.Connections, web.StatsDumpFrequencySeconds)))
+	r.Path("/connections").HandlerFunc(requireRole(log, auth, RoleAdmin, GetHandlerConnectionList(log, web.Connections)))
+	// Serve the web UI without authentication since it only contains static files.
+	r.PathPrefix(urlContext4UI).HandlerFunc(GetHandlerWebUI(log))
+	r.Path("/").Handler(http.RedirectHandler(urlContext4UI, http.StatusFound))
+	r.Path("/ui").Handler(http.RedirectHandler(urlContext4UI, http.StatusFound))
 	// Configure HTTP server.
 	srv := &http.Server{ // Good practice to set timeouts to avoid Slowloris attacks.
 		Addr:         fmt.Sprintf("%v:%v", web.Addr, web.Port),
-		WriteTimeout: time.Second * 15,
+		WriteTimeout: webWriteTimeout, // /pipes/{pipeId}/events extends this using extendWriteDeadline().
 		ReadTimeout:  time.Second * 15,
 		IdleTimeout:  time.Second * 60,
 		Handler:      r, // supply our instance of gorilla/mux.
+		ConnContext:  saveConnInContext,
+	}
+	useTLS := web.TLSCertFile != ""
+	if useTLS {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		// Disable HTTP/2 since it applies WriteTimeout to each stream, which extendWriteDeadline() can't extend.
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		web.Scheme = "https"
 	}
 	// Run HTTP server non-blocking.
 	go func() {
-		if err := srv.ListenAndServe(); err != nil {
+		var err error
+		if useTLS {
+			err = srv.ListenAndServeTLS(web.TLSCertFile, web.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil {
 			if err == http.ErrServerClosed {
 				log.Info(err)
 			} else {
@@ -86,10 +210,10 @@ func runServer(log logger.Logger, web *WebServerConfig) (*http.Server, chan stri
 		}
 	}()
 	log.Info(fmt.Sprintf("Listening on %v://%v:%v", strings.ToLower(web.Scheme), web.Addr, web.Port))
-	return srv, chanStopServer, allTransformInfo
+	return srv, chanStopServer, allTransformInfo, scheduler
 }
 
-func waitForServer(log logger.Logger, srv *http.Server, chanStopServer chan string, allTransformInfo *transform.SafeMapTransformInfo) error {
+func waitForServer(log logger.Logger, srv *http.Server, chanStopServer chan string, allTransformInfo *transform.SafeMapTransformInfo, scheduler *registry.Scheduler) error {
 	// Block & wait for shutdown signals.
 	// Accept graceful shutdowns when quit via SIGINT (Ctrl+C)
 	// SIGKILL, SIGQUIT or SIGTERM (Ctrl+\) will not be caught.
@@ -101,6 +225,10 @@ func waitForServer(log logger.Logger, srv *http.Server, chanStopServer chan stri
 	}
 	fmt.Println() // print new line char for clean looking CLI.
 	log.Info("Shutting down web server...")
+	// Stop the scheduler first so the runs it launched are recovered on restart.
+	if scheduler != nil {
+		scheduler.Stop()
+	}
 	// Send shutdown to all running transforms first.
 	// TODO: cleanup the way shutdown works since there is no single mutex wrapping t.ChanStop below
 	// TODO: the channel could be closed by the time we get there!
@@ -0,0 +1,33 @@
+package actions
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/relloyd/halfpipe/logger"
+)
+
+const urlContext4UI = "/ui/"
+
+// webUIFiles holds the single-page UI served by hp serve.
+// It uses no external assets so that it works offline.
+//
+//go:embed web-ui
+var webUIFiles embed.FS
+
+// GetHandlerWebUI serves the embedded web UI below urlContext4UI.
+// The UI calls the other handlers using an API key supplied by the user, so the static files are not authenticated.
+func GetHandlerWebUI(log logger.Logger) func(w http.ResponseWriter, r *http.Request) {
+	files, err := fs.Sub(webUIFiles, "web-ui")
+	if err != nil {
+		log.Panic(err)
+	}
+	h := http.StripPrefix(urlContext4UI, http.FileServer(http.FS(files)))
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only allow scripts, styles and requests from this server.
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		h.ServeHTTP(w, r)
+	}
+}
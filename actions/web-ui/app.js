@@ -0,0 +1,467 @@
+// Halfpipe web UI.
+// Uses the hp serve REST API with the API key saved in session storage, if any.
+(function () {
+  'use strict';
+
+  var refreshIntervalMs = 2000;
+  var apiKeyStorageKey = 'halfpipeApiKey';
+  var svgNS = 'http://www.w3.org/2000/svg';
+
+  var state = {
+    view: '',
+    selectedPipeId: '',
+    graphPipeId: '',
+    graphRows: {}, // map[statsName]svg text element showing the rows processed.
+    timer: null
+  };
+
+  function $(id) {
+    return document.getElementById(id);
+  }
+
+  function el(tag, text, className) {
+    var e = document.createElement(tag);
+    if (text !== undefined && text !== null) {
+      e.textContent = String(text);
+    }
+    if (className) {
+      e.className = className;
+    }
+    return e;
+  }
+
+  function svg(tag, attrs, text) {
+    var e = document.createElementNS(svgNS, tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      e.setAttribute(k, attrs[k]);
+    });
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    return e;
+  }
+
+  function clear(e) {
+    while (e.firstChild) {
+      e.removeChild(e.firstChild);
+    }
+  }
+
+  function showMessage(text, isInfo) {
+    var m = $('message');
+    m.textContent = text || '';
+    m.className = isInfo ? 'info' : '';
+    m.hidden = !text;
+  }
+
+  // api calls the hp serve endpoint at path and returns a promise of the parsed JSON response.
+  // The promise is rejected with the message in the response if the request fails.
+  function api(path, options) {
+    options = options || {};
+    options.headers = options.headers || {};
+    var key = sessionStorage.getItem(apiKeyStorageKey);
+    if (key) {
+      options.headers['Authorization'] = 'Bearer ' + key;
+    }
+    return fetch(path, options).then(function (resp) {
+      return resp.text().then(function (body) {
+        var data = {};
+        try {
+          data = JSON.parse(body);
+        } catch (e) {
+          data = {message: body};
+        }
+        if (!resp.ok) {
+          throw new Error((data && data.message) || ('HTTP status ' + resp.status));
+        }
+        return data;
+      });
+    });
+  }
+
+  function statusClass(status) {
+    return 'status-' + String(status || '').replace(/ /g, '-');
+  }
+
+  function isFinished(status) {
+    return status !== 'starting' && status !== 'running' && status !== 'paused';
+  }
+
+  function formatTime(t) {
+    if (!t || t.indexOf('0001-01-01') === 0) {
+      return '';
+    }
+    return new Date(t).toLocaleString();
+  }
+
+  // Views.
+
+  function showView(view) {
+    state.view = view;
+    ['pipes', 'launch', 'connections'].forEach(function (v) {
+      $('view-' + v).hidden = v !== view;
+    });
+    Array.prototype.forEach.call(document.querySelectorAll('nav a'), function (a) {
+      a.className = a.getAttribute('data-view') === view ? 'active' : '';
+    });
+    showMessage('');
+    clearInterval(state.timer);
+    state.timer = null;
+    if (view === 'pipes') {
+      refreshPipes();
+      state.timer = setInterval(refreshPipes, refreshIntervalMs);
+    } else if (view === 'connections') {
+      refreshConnections();
+    }
+  }
+
+  function onHashChange() {
+    var view = window.location.hash.replace('#', '');
+    if (['pipes', 'launch', 'connections'].indexOf(view) < 0) {
+      view = 'pipes';
+    }
+    showView(view);
+  }
+
+  // Pipes.
+
+  function refreshPipes() {
+    api('../pipes').then(function (data) {
+      renderPipeList(data.pipes || []);
+      if (state.selectedPipeId) {
+        return refreshPipeDetail();
+      }
+    }).catch(function (err) {
+      showMessage('Error fetching pipes: ' + err.message);
+    });
+  }
+
+  function renderPipeList(pipes) {
+    var tbody = $('pipe-list');
+    clear(tbody);
+    pipes.sort(function (a, b) {
+      return a.pipeId < b.pipeId ? -1 : 1;
+    });
+    $('pipe-list-empty').hidden = pipes.length > 0;
+    pipes.forEach(function (p) {
+      var tr = el('tr');
+      if (p.pipeId === state.selectedPipeId) {
+        tr.className = 'selected';
+      }
+      tr.appendChild(el('td', p.pipeId));
+      tr.appendChild(el('td', p.pipeDescription));
+      tr.appendChild(el('td', p.pipeStatus, statusClass(p.pipeStatus)));
+      tr.appendChild(el('td', p.submittedBy || ''));
+      var td = el('td');
+      td.appendChild(button('View', function () {
+        selectPipe(p.pipeId);
+      }));
+      if (p.pipeStatus === 'running') {
+        td.appendChild(button('Pause', function () {
+          controlPipe(p.pipeId, 'pause');
+        }));
+      } else if (p.pipeStatus === 'paused') {
+        td.appendChild(button('Resume', function () {
+          controlPipe(p.pipeId, 'resume');
+        }));
+      }
+      if (!isFinished(p.pipeStatus)) {
+        td.appendChild(button('Stop', function () {
+          if (window.confirm('Stop pipe ' + p.pipeId + '?')) {
+            controlPipe(p.pipeId, 'stop');
+          }
+        }));
+      }
+      tr.appendChild(td);
+      tbody.appendChild(tr);
+    });
+  }
+
+  function button(text, onClick) {
+    var b = el('button', text);
+    b.type = 'button';
+    b.addEventListener('click', onClick);
+    return b;
+  }
+
+  function controlPipe(pipeId, action) {
+    api('../pipes/' + encodeURIComponent(pipeId) + '/' + action).then(function (data) {
+      showMessage('Pipe ' + pipeId + ': ' + data.message, true);
+      refreshPipes();
+    }).catch(function (err) {
+      showMessage('Error sending ' + action + ' to pipe ' + pipeId + ': ' + err.message);
+    });
+  }
+
+  function selectPipe(pipeId) {
+    state.selectedPipeId = pipeId;
+    $('pipe-id').textContent = pipeId;
+    $('pipe-detail').hidden = false;
+    refreshPipes();
+  }
+
+  function refreshPipeDetail() {
+    var pipeId = state.selectedPipeId;
+    var path = '../pipes/' + encodeURIComponent(pipeId);
+    var graph = Promise.resolve();
+    if (state.graphPipeId !== pipeId) { // if the graph of this pipe has not been drawn yet...
+      graph = api(path + '/graph').then(function (g) {
+        state.graphPipeId = pipeId;
+        state.graphRows = drawGraph($('pipe-graph'), g);
+      });
+    }
+    return Promise.all([api(path + '/status'), api(path + '/stats'), graph]).then(function (results) {
+      if (pipeId !== state.selectedPipeId) { // if another pipe was selected while waiting...
+        return;
+      }
+      renderPipeStatus(results[0].pipeStatus || {}, results[0].submittedBy);
+      renderPipeStats(results[1].pipeStats || []);
+    }).catch(function (err) {
+      showMessage('Error fetching pipe ' + pipeId + ': ' + err.message);
+    });
+  }
+
+  function renderPipeStatus(s, submittedBy) {
+    var dl = $('pipe-status');
+    clear(dl);
+    var add = function (name, value, className) {
+      dl.appendChild(el('dt', name));
+      dl.appendChild(el('dd', value, className));
+    };
+    add('Status', s.pipeStatus, statusClass(s.pipeStatus));
+    add('Started', formatTime(s.startTime));
+    add('Ended', formatTime(s.endTime));
+    if (submittedBy) {
+      add('Submitted by', submittedBy);
+    }
+    if (s.error) {
+      add('Error', s.error, statusClass('complete with error'));
+    }
+  }
+
+  function renderPipeStats(stats) {
+    var tbody = $('pipe-stats');
+    clear(tbody);
+    stats.sort(function (a, b) {
+      return a.stepName < b.stepName ? -1 : 1;
+    });
+    stats.forEach(function (s) {
+      var tr = el('tr');
+      tr.appendChild(el('td', s.stepName));
+      tr.appendChild(el('td', (s.statusEmoji ? s.statusEmoji + ' ' : '') + s.statusText));
+      [s.elapsedTimeSec, s.totalRowsProcessed, s.rowsPerSecondDelta, s.rowsPerSecondAvg, s.outputBufferLen, s.errorCount]
+        .forEach(function (v) {
+          tr.appendChild(el('td', v, 'number'));
+        });
+      tbody.appendChild(tr);
+      var rows = state.graphRows[s.stepName];
+      if (rows) {
+        rows.textContent = s.totalRowsProcessed + ' rows';
+      }
+    });
+  }
+
+  // drawGraph draws graph g, as returned by /pipes/{pipeId}/graph, as SVG in container.
+  // Step groups are drawn left to right as boxes of their steps, where the steps are placed in columns by the
+  // length of the longest chain of steps that they read data from.
+  // Returns a map of step stats name to the SVG text element that shows the rows processed by the step.
+  function drawGraph(container, g) {
+    var nodeW = 190, nodeH = 52, gapX = 48, gapY = 18, pad = 16, titleH = 24, clusterGap = 72, margin = 24;
+    var boxes = {}; // map[node or cluster id]{x, y, w, h}
+    var nodes = [];
+    var x = margin, height = 0;
+    (g.clusters || []).forEach(function (c) {
+      var ids = {};
+      c.nodes.forEach(function (n) {
+        ids[n.id] = 0;
+      });
+      var internal = (g.edges || []).filter(function (e) {
+        return (e.type === 'data' || e.type === 'rejected') && e.from in ids && e.to in ids;
+      });
+      // Find the column of each step, with a limit on iterations in case of cycles.
+      for (var i = 0; i < c.nodes.length; i++) {
+        var changed = false;
+        internal.forEach(function (e) {
+          if (ids[e.to] < ids[e.from] + 1) {
+            ids[e.to] = ids[e.from] + 1;
+            changed = true;
+          }
+        });
+        if (!changed) {
+          break;
+        }
+      }
+      var rowsInColumn = [];
+      c.nodes.forEach(function (n) {
+        var col = ids[n.id];
+        var row = rowsInColumn[col] || 0;
+        rowsInColumn[col] = row + 1;
+        boxes[n.id] = {
+          x: x + pad + col * (nodeW + gapX),
+          y: margin + titleH + pad + row * (nodeH + gapY),
+          w: nodeW,
+          h: nodeH
+        };
+        nodes.push(n);
+      });
+      var cols = Math.max(rowsInColumn.length, 1);
+      var rows = Math.max.apply(null, rowsInColumn.concat([1]));
+      var box = {
+        x: x,
+        y: margin,
+        w: pad * 2 + cols * nodeW + (cols - 1) * gapX,
+        h: titleH + pad * 2 + rows * nodeH + (rows - 1) * gapY,
+        label: c.label
+      };
+      boxes[c.id] = box;
+      x += box.w + clusterGap;
+      height = Math.max(height, box.y + box.h + margin);
+    });
+
+    var root = svg('svg', {width: Math.max(x - clusterGap + margin, 0), height: height});
+    var colours = {data: '#555', sequence: '#2d3e50', inject: '#6a1b9a', rejected: '#c62828'};
+    var defs = svg('defs');
+    Object.keys(colours).forEach(function (t) {
+      var m = svg('marker', {
+        id: 'arrow-' + t, viewBox: '0 0 10 10', refX: 10, refY: 5,
+        markerWidth: 8, markerHeight: 8, orient: 'auto-start-reverse'
+      });
+      m.appendChild(svg('path', {d: 'M 0 0 L 10 5 L 0 10 z', fill: colours[t]}));
+      defs.appendChild(m);
+    });
+    root.appendChild(defs);
+
+    (g.clusters || []).forEach(function (c) {
+      var b = boxes[c.id];
+      root.appendChild(svg('rect', {x: b.x, y: b.y, width: b.w, height: b.h, rx: 6, fill: '#eef2f6', stroke: '#9aa8b6'}));
+      root.appendChild(svg('text', {x: b.x + pad, y: b.y + 18, 'font-weight': 'bold'}, b.label));
+    });
+
+    (g.edges || []).forEach(function (e) {
+      var from = boxes[e.from], to = boxes[e.to];
+      if (!from || !to) {
+        return;
+      }
+      var x1 = from.x + from.w, y1 = from.y + from.h / 2;
+      var x2 = to.x, y2 = to.y + to.h / 2;
+      var bend = Math.max(Math.abs(x2 - x1) / 2, 40);
+      var attrs = {
+        d: 'M ' + x1 + ' ' + y1 + ' C ' + (x1 + bend) + ' ' + y1 + ', ' + (x2 - bend) + ' ' + y2 + ', ' + x2 + ' ' + y2,
+        fill: 'none',
+        stroke: colours[e.type] || colours.data,
+        'stroke-width': e.type === 'sequence' ? 3 : 1.5,
+        'marker-end': 'url(#arrow-' + (colours[e.type] ? e.type : 'data') + ')'
+      };
+      if (e.type === 'inject') {
+        attrs['stroke-dasharray'] = '6 4';
+      } else if (e.type === 'rejected') {
+        attrs['stroke-dasharray'] = '2 3';
+      }
+      root.appendChild(svg('path', attrs));
+      if (e.type !== 'data') {
+        var label = e.type === 'sequence' ? 'then' : e.type;
+        root.appendChild(svg('text', {x: (x1 + x2) / 2, y: (y1 + y2) / 2 - 6, 'text-anchor': 'middle', fill: attrs.stroke}, label));
+      }
+    });
+
+    var rowText = {};
+    nodes.forEach(function (n) {
+      var b = boxes[n.id];
+      var group = svg('g');
+      group.appendChild(svg('title', {}, n.statsName));
+      group.appendChild(svg('rect', {x: b.x, y: b.y, width: b.w, height: b.h, rx: 4, fill: '#fff', stroke: '#2d3e50'}));
+      group.appendChild(svg('text', {x: b.x + 8, y: b.y + 16, 'font-weight': 'bold'}, truncate(n.step, 26)));
+      group.appendChild(svg('text', {x: b.x + 8, y: b.y + 31, fill: '#555'}, truncate(n.stepType, 28)));
+      var rows = svg('text', {x: b.x + 8, y: b.y + 46, fill: '#1565c0'}, '');
+      group.appendChild(rows);
+      rowText[n.statsName] = rows;
+      root.appendChild(group);
+    });
+
+    clear(container);
+    container.appendChild(root);
+    return rowText;
+  }
+
+  function truncate(s, n) {
+    s = String(s || '');
+    return s.length > n ? s.substring(0, n - 1) + '…' : s;
+  }
+
+  // Launch.
+
+  function onLaunchFileChange() {
+    var f = $('launch-file').files[0];
+    if (!f) {
+      return;
+    }
+    var reader = new FileReader();
+    reader.onload = function () {
+      $('launch-text').value = reader.result;
+    };
+    reader.onerror = function () {
+      showMessage('Error reading file ' + f.name);
+    };
+    reader.readAsText(f);
+  }
+
+  function onLaunchSubmit(ev) {
+    ev.preventDefault();
+    var text = $('launch-text').value;
+    if (!text.trim()) {
+      showMessage('Choose or paste a pipe definition to launch.');
+      return;
+    }
+    // JSON pipes start with an object, anything else is sent as YAML.
+    var contentType = text.trim().charAt(0) === '{' ? 'application/json' : 'application/yaml';
+    api('../launch', {method: 'POST', headers: {'Content-Type': contentType}, body: text}).then(function (data) {
+      showMessage('Launched pipe ' + data.pipeId, true);
+      state.selectedPipeId = data.pipeId;
+      $('pipe-id').textContent = data.pipeId;
+      $('pipe-detail').hidden = false;
+      window.location.hash = '#pipes';
+    }).catch(function (err) {
+      showMessage('Error launching pipe: ' + err.message);
+    });
+  }
+
+  // Connections.
+
+  function refreshConnections() {
+    api('../connections').then(function (data) {
+      var tbody = $('connection-list');
+      clear(tbody);
+      (data.connections || []).forEach(function (c) {
+        var tr = el('tr');
+        tr.appendChild(el('td', c.name));
+        tr.appendChild(el('td', c.type));
+        var td = el('td');
+        td.appendChild(el('pre', c.details));
+        tr.appendChild(td);
+        tbody.appendChild(tr);
+      });
+    }).catch(function (err) {
+      showMessage('Error fetching connections: ' + err.message);
+    });
+  }
+
+  // Setup.
+
+  document.addEventListener('DOMContentLoaded', function () {
+    $('api-key').value = sessionStorage.getItem(apiKeyStorageKey) || '';
+    $('api-key-form').addEventListener('submit', function (ev) {
+      ev.preventDefault();
+      var key = $('api-key').value.trim();
+      if (key) {
+        sessionStorage.setItem(apiKeyStorageKey, key);
+      } else {
+        sessionStorage.removeItem(apiKeyStorageKey);
+      }
+      showView(state.view);
+    });
+    $('launch-file').addEventListener('change', onLaunchFileChange);
+    $('launch-form').addEventListener('submit', onLaunchSubmit);
+    window.addEventListener('hashchange', onHashChange);
+    onHashChange();
+  });
+})();
@@ -0,0 +1,58 @@
+package checkpoint
+
+import (
+	"fmt"
+
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+// DatabaseStore saves checkpoints in a database table with one row per key.
+// Values are supplied as bind variables using the syntax of the connection's database type.
+type DatabaseStore struct {
+	db    shared.Connector
+	table string
+}
+
+func NewDatabaseStore(db shared.Connector, table string) *DatabaseStore {
+	return &DatabaseStore{db: db, table: table}
+}
+
+func (s *DatabaseStore) Read(key string) (value string, found bool, err error) {
+	rows, err := s.db.Query(fmt.Sprintf("select checkpoint_value from %v where checkpoint_key = %v", s.table, s.bind(1)), key)
+	if err != nil {
+		return "", false, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err = rows.Scan(&value); err != nil {
+			return "", false, err
+		}
+		found = true
+	}
+	return value, found, rows.Err()
+}
+
+// Write deletes any existing row for key and inserts the new value in a single transaction.
+func (s *DatabaseStore) Write(key string, value string) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tx.Exec(fmt.Sprintf("delete from %v where checkpoint_key = %v", s.table, s.bind(1)), key); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(fmt.Sprintf("insert into %v (checkpoint_key, checkpoint_value) values (%v, %v)",
+		s.table, s.bind(1), s.bind(2)), key, value); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *DatabaseStore) bind(position int) string {
+	return shared.GetBindPlaceholder(s.db.GetType(), position)
+}
@@ -0,0 +1,53 @@
+package checkpoint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore saves checkpoints in files in a local directory.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) Read(key string) (value string, found bool, err error) {
+	b, err := ioutil.ReadFile(s.fileName(key))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(b)), true, nil
+}
+
+// Write saves value to a temporary file which is renamed over the existing one, so a failure part way through
+// leaves the previous checkpoint in place.
+func (s *FileStore) Write(key string, value string) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(s.dir, safeKey(key)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(value); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.fileName(key))
+}
+
+func (s *FileStore) fileName(key string) string {
+	return filepath.Join(s.dir, safeKey(key)+".checkpoint")
+}
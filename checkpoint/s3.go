@@ -0,0 +1,39 @@
+package checkpoint
+
+import (
+	"strings"
+
+	"github.com/relloyd/halfpipe/aws/s3"
+)
+
+type s3GetterPutter interface {
+	s3.Getter
+	s3.Putter
+}
+
+// S3Store saves checkpoints in objects in an S3 bucket.
+type S3Store struct {
+	client s3GetterPutter
+}
+
+func NewS3Store(client s3GetterPutter) *S3Store {
+	return &S3Store{client: client}
+}
+
+func (s *S3Store) Read(key string) (value string, found bool, err error) {
+	b, err := s.client.Get(s.objectKey(key))
+	if err == s3.ErrKeyNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(b)), true, nil
+}
+
+func (s *S3Store) Write(key string, value string) error {
+	return s.client.Put(s.objectKey(key), []byte(value))
+}
+
+func (s *S3Store) objectKey(key string) string {
+	return safeKey(key) + ".checkpoint"
+}
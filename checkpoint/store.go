@@ -0,0 +1,67 @@
+package checkpoint
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/relloyd/halfpipe/aws/s3"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+// Store saves the latest value of a checkpoint, such as the high-water mark of a delta extract, so that it can be
+// read back when a transform restarts.
+type Store interface {
+	// Read returns the value saved for key and found=false if there is none.
+	Read(key string) (value string, found bool, err error)
+	// Write saves value for key, replacing any existing value.
+	Write(key string, value string) error
+}
+
+// NewStore returns the Store described by spec, which takes one of the following forms:
+// file://<directory> saves each key in a file of the same name in the directory.
+// s3://<bucket>/<prefix>?region=<region> saves each key in an object of the same name under the bucket prefix.
+// table://[<schema>.]<table> saves each key in a row of the table using database connection db.
+// The table must have VARCHAR columns CHECKPOINT_KEY and CHECKPOINT_VALUE.
+func NewStore(spec string, db shared.Connector) (Store, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint store %q: %v", spec, err)
+	}
+	switch u.Scheme {
+	case "file":
+		dir := u.Host + u.Path // support relative paths like file://dir as well as absolute ones like file:///dir
+		if dir == "" {
+			return nil, fmt.Errorf("missing directory in checkpoint store %q", spec)
+		}
+		return NewFileStore(dir), nil
+	case "s3":
+		b, err := s3.ParseDSN(fmt.Sprintf("s3://%v%v", u.Host, u.Path), u.Query().Get("region"))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing checkpoint store %q: %v", spec, err)
+		}
+		return NewS3Store(s3.NewBasicClient(b.Name, b.Region, b.Prefix)), nil
+	case "table":
+		table := u.Host + u.Path
+		if !rexpTableName.MatchString(table) {
+			return nil, fmt.Errorf("missing or bad table name in checkpoint store %q", spec)
+		}
+		if db == nil {
+			return nil, fmt.Errorf("checkpoint store %q requires a database connection", spec)
+		}
+		return NewDatabaseStore(db, table), nil
+	default:
+		return nil, fmt.Errorf("unsupported checkpoint store %q: use file://, s3:// or table://", spec)
+	}
+}
+
+var (
+	rexpUnsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+	rexpTableName      = regexp.MustCompile(`^[A-Za-z0-9_$#]+(\.[A-Za-z0-9_$#]+)?$`)
+)
+
+// safeKey returns key with any characters that are not safe to use in file or object names replaced by underscores.
+func safeKey(key string) string {
+	return rexpUnsafeKeyChars.ReplaceAllString(strings.TrimSpace(key), "_")
+}
@@ -0,0 +1,139 @@
+package checkpoint
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/relloyd/halfpipe/aws/s3"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+func TestNewStore(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	db, _ := shared.NewMockConnectionWithMockTx(log, "mock")
+	// Test 1 - good specs.
+	for spec, expected := range map[string]interface{}{
+		"file:///tmp/checkpoints":             &FileStore{},
+		"s3://bucket/prefix?region=eu-west-2": &S3Store{},
+		"table://schema.table":                &DatabaseStore{},
+	} {
+		s, err := NewStore(spec, db)
+		if err != nil {
+			t.Fatalf("unexpected error for spec %v: %v", spec, err)
+		}
+		if reflect.TypeOf(s) != reflect.TypeOf(expected) {
+			t.Fatalf("expected %T for spec %v; got %T", expected, spec, s)
+		}
+	}
+	// Test 2 - bad specs.
+	for _, spec := range []string{
+		"/tmp/no-scheme",
+		"file://",
+		"s3://bucket/prefix",
+		"table://",
+		"table://bad;table",
+		"http://host/path",
+	} {
+		if _, err := NewStore(spec, db); err == nil {
+			t.Fatalf("expected error for spec %v", spec)
+		}
+	}
+	// Test 3 - database store requires a connection.
+	if _, err := NewStore("table://t", nil); err == nil {
+		t.Fatal("expected error for missing database connection")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "halfpipe-checkpoint-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := NewFileStore(dir + "/sub")
+	// Test 1 - missing key.
+	if _, found, err := s.Read("a/b"); err != nil || found {
+		t.Fatalf("expected no checkpoint; got found = %v, err = %v", found, err)
+	}
+	// Test 2 - write and overwrite.
+	for _, v := range []string{"20200101T000000", "20200102T000000"} {
+		if err := s.Write("a/b", v); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		got, found, err := s.Read("a/b")
+		if err != nil || !found || got != v {
+			t.Fatalf("expected %v; got %v, found = %v, err = %v", v, got, found, err)
+		}
+	}
+	// Test 3 - only the checkpoint file remains.
+	files, _ := ioutil.ReadDir(dir + "/sub")
+	if len(files) != 1 || files[0].Name() != "a_b.checkpoint" {
+		t.Fatal("expected a single checkpoint file; got: ", files)
+	}
+}
+
+type mockS3Client struct {
+	objects map[string][]byte
+}
+
+func (c *mockS3Client) Get(key string) ([]byte, error) {
+	b, ok := c.objects[key]
+	if !ok {
+		return nil, s3.ErrKeyNotFound
+	}
+	return b, nil
+}
+
+func (c *mockS3Client) Put(key string, data []byte) error {
+	c.objects[key] = data
+	return nil
+}
+
+func TestS3Store(t *testing.T) {
+	c := &mockS3Client{objects: make(map[string][]byte)}
+	s := NewS3Store(c)
+	if _, found, err := s.Read("k"); err != nil || found {
+		t.Fatalf("expected no checkpoint; got found = %v, err = %v", found, err)
+	}
+	if err := s.Write("k", "000000000123"); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if string(c.objects["k.checkpoint"]) != "000000000123" {
+		t.Fatal("unexpected objects: ", c.objects)
+	}
+	if got, found, err := s.Read("k"); err != nil || !found || got != "000000000123" {
+		t.Fatalf("unexpected checkpoint %v, found = %v, err = %v", got, found, err)
+	}
+}
+
+func TestDatabaseStoreWrite(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	for dbType, expected := range map[string][]string{ // for each bind syntax...
+		"postgres": {
+			"delete from s.checkpoints where checkpoint_key = $1",
+			`it's\`,
+			"insert into s.checkpoints (checkpoint_key, checkpoint_value) values ($1, $2)",
+			`it's\ v`,
+		},
+		"oracle": {
+			"delete from s.checkpoints where checkpoint_key = :1",
+			`it's\`,
+			"insert into s.checkpoints (checkpoint_key, checkpoint_value) values (:1, :2)",
+			`it's\ v`,
+		},
+	} {
+		db, out := shared.NewMockConnectionWithMockTx(log, dbType)
+		s := NewDatabaseStore(db, "s.checkpoints")
+		if err := s.Write(`it's\`, "v"); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		for _, e := range expected {
+			if got := <-out; got != e {
+				t.Fatalf("%v: expected %q; got %q", dbType, e, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/relloyd/halfpipe/actions"
+	"github.com/relloyd/halfpipe/config"
+	"github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/spf13/cobra"
+)
+
+var configConnAddPostgresCfg = &actions.ConnectionConfig{}
+var postgresConn = shared.PostgresConnectionDetails{}
+
+var configConnAddPostgresCmd = &cobra.Command{
+	Use:   "postgres",
+	Short: "Add a Postgres connection",
+	Long: fmt.Sprintf(`Add Postgres database connection to the config store %q
+by providing a DSN of the form: 
+
+postgres://<user>:<pass>@<host>[:<port>]/<dbname>[?<param1>=<value1>&<param2>=<value2>&...]
+
+where the following parameter keys can be used:
+
+* sslmode - Whether or not to use SSL (default is require)
+* sslcert - PEM cert file location
+* sslkey - PEM key file location
+* sslrootcert - The location of the root certificate in PEM format
+* connect_timeout - Maximum wait for connection, in seconds
+
+where sslmode can take these values:
+
+* disable - No SSL
+* require - Always SSL (skip verification)
+* verify-ca - Always SSL (verify that the certificate presented by the
+  server was signed by a trusted CA)
+* verify-full - Always SSL (verify that the certification presented by
+  the server was signed by a trusted CA and the server host name
+  matches the one in the certificate)
+
+Please refer to this documentation for reference:
+
+https://pkg.go.dev/github.com/lib/pq
+
+`,
+		config.Connections.FullPath),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configConnAddPostgresCfg.Type = constants.ConnectionTypePostgres
+		configConnAddPostgresCfg.ConfigFile = getConnectionGetterSetter()
+		configConnAddPostgresCfg.ConnDetails = postgresConn
+		cmd.SilenceUsage = true
+		return actions.RunConnectionAdd(configConnAddPostgresCfg)
+	},
+}
+
+func init() {
+	configConnAddCmd.AddCommand(configConnAddPostgresCmd)
+	configConnAddPostgresCmd.Flags().SortFlags = false
+	switches.addFlag(configConnAddPostgresCmd, &configConnAddPostgresCfg.LogicalName, "connection-name", "", true, "")
+	switches.addFlag(configConnAddPostgresCmd, &configConnAddPostgresCfg.Force, "force-connection", "", false, "")
+	switches.addFlag(configConnAddPostgresCmd, &postgresConn.Dsn, "dsn", "", true, "")
+}
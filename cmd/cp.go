@@ -85,6 +85,9 @@ func runCpSnap() error {
 	if targetType == constants.ConnectionTypeStdout { // if there will be output to STDOUT...
 		silenceUsage = true // disable usage via global variable so 12Factor mode can continue to work.
 	}
+	if err := validateCpAutoEvolve(&cpSnapCfg, targetType); err != nil {
+		return err
+	}
 	return actions.ActionLauncher(&cpSnapCfg, actions.GetCpSnapAction, sourceType, targetType)
 }
 
@@ -121,9 +124,21 @@ func runCpDelta() error {
 	if err != nil {
 		return err
 	}
+	if err := validateCpAutoEvolve(&cpDeltaCfg, targetType); err != nil {
+		return err
+	}
 	return actions.ActionLauncher(&cpDeltaCfg, actions.GetCpDeltaAction, sourceType, targetType)
 }
 
+// validateCpAutoEvolve returns an error if the auto-evolve flag is set for a target type that doesn't support it,
+// so it is not silently ignored.
+func validateCpAutoEvolve(cfg *actions.CpConfig, targetType string) error {
+	if cfg.AutoEvolve && targetType != constants.ConnectionTypeSnowflake {
+		return fmt.Errorf("flag --auto-evolve is only supported for Snowflake targets, not target type %q", targetType)
+	}
+	return nil
+}
+
 // ALL CP FLAGS
 
 func addFlagsCpCoreRequired(c *cobra.Command, cfg *actions.CpConfig) {
@@ -138,9 +153,11 @@ func addFlagsCpCoreOther(c *cobra.Command, cfg *actions.CpConfig) {
 	switches.addFlag(c, &cfg.CsvMaxFileBytes, "csv-bytes", "104857600", false, "")
 	switches.addFlag(c, &cfg.CsvMaxFileRows, "csv-rows", "0", false, "")
 	switches.addFlag(c, &cfg.CsvHeaderFields, "csv-header", "", false, "")
+	switches.addFlag(c, &cfg.FileFormat, "file-format", "csv", false, "")
 	switches.addFlag(c, &cfg.CsvRegexp, "csv-regexp", `.+\.csv.*`, false, "")
 	switches.addFlag(c, &cfg.CommitBatchSize, "commit-batch-size", "10000", false, "")
 	switches.addFlag(c, &cfg.AppendTarget, "append", "", false, "")
+	switches.addFlag(c, &cfg.AutoEvolve, "auto-evolve", "", false, "")
 	// General
 	switches.addFlag(c, &cfg.RepeatInterval, "repeat", "0", false, "")
 	switches.addFlag(c, &cfg.LogLevel, "log-level", "warn", false, "")
@@ -162,6 +179,7 @@ func addFlagsCpDeltaOther(c *cobra.Command, cfg *actions.CpConfig) {
 	switches.addFlag(c, &cfg.SQLBatchStartDateTime, "start-date", "19000101T000000", false, "")
 	switches.addFlag(c, &cfg.SQLBatchStartSequence, "start-sequence", "0", false, "")
 	switches.addFlag(c, &cfg.ExecBatchSize, "exec-batch-size", "1000", false, "")
+	switches.addFlag(c, &cfg.CheckpointStore, "checkpoint-store", "", false, "")
 }
 
 // META CONFIG
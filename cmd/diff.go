@@ -26,6 +26,16 @@ If no differences are found the return code will be 0, else 1.
 
 - Supply the primary key fields to sort and join the two datasets for comparison
 - Optionally choose the number of differences allowed before exiting
+- Use "--diff-mode hash" if the databases can't sort records identically, e.g. due to
+  different collations; DELETED records will then contain the primary key fields only
+- Use "--checksum-chunks" on large tables to compare hashes of buckets of records in
+  the databases first, so only records in mismatched buckets are fetched; this needs
+  an integer primary key and source and target databases of the same type
+  (Oracle, Snowflake or SQL Server)
+- Use "--report" and "--report-file" to write a summary of the number of NEW, CHANGED,
+  DELETED and IDENTICAL records plus the first differences found, including the old
+  and new values of fields that changed (in the default merge diff mode); records in
+  buckets with matching checksums are counted as CHECKSUM VERIFIED
 `, constants.DiffStatusFieldName),
 	Args: getConnectionsArgsFunc(&diffCfg.SourceString, &diffCfg.TargetString, ""),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -43,6 +53,10 @@ func init() {
 	switches.addFlag(diffCmd, &diffCfg.SQLPrimaryKeyFieldsCsv, "primary-keys", "", true, "")
 	switches.addFlag(diffCmd, &diffCfg.AbortAfterNumRecords, "abort-after", "0", false, "")
 	switches.addFlag(diffCmd, &diffCfg.OutputAllDiffFields, "output-all-fields", "", false, "")
+	switches.addFlag(diffCmd, &diffCfg.DiffMode, "diff-mode", actions.DiffModeMerge, false, "")
+	switches.addFlag(diffCmd, &diffCfg.ChecksumChunks, "checksum-chunks", "0", false, "")
+	switches.addFlag(diffCmd, &diffCfg.ReportFormat, "report", "", false, "")
+	switches.addFlag(diffCmd, &diffCfg.ReportFile, "report-file", "", false, "")
 	switches.addFlag(diffCmd, &diffCfg.LogLevel, "log-level", "error", false, "")
 	switches.addFlag(diffCmd, &diffCfg.ExportConfigType, "output", "", false, "")
 	switches.addFlag(diffCmd, &diffCfg.ExportIncludeConnections, "include-connections", "", false, "")
@@ -53,6 +53,10 @@ var switches = cliFlags{
 		desc: "The <CSV of fields> (case sensitive) to be written to CSV files\n" +
 			"and the target Snowflake table. Leave blank to use all source table\n" +
 			"fields, or choose to match the order of target Snowflake table column names if different"},
+	"file-format": cliFlag{name: "file-format",
+		desc: "The format of files written to S3 and loaded into Snowflake: csv or parquet.\n" +
+			"Parquet columns are typed using the source table definition and loaded\n" +
+			"into Snowflake table columns of the same name"},
 	"csv-prefix": cliFlag{name: "csv-prefix", shortHand: "c",
 		desc: "The name prefix for CSV files generated and staged in the S3 bucket\n" +
 			"when the target is S3 or Snowflake. Alternatively, when the\n" +
@@ -71,7 +75,8 @@ var switches = cliFlags{
 			"Use 0 to disable repeating. Use this to keep data up-to-date in near real-time"},
 	"output": cliFlag{name: "output", shortHand: "o",
 		desc: "Specify \"yaml\" or \"json\" to print the pipe definition. Optionally redirect this output \n" +
-			"to a file for use with \"pipe\" action or supply as input k8s yaml generation"},
+			"to a file for use with \"pipe\" action or supply as input k8s yaml generation.\n" +
+			"Specify \"dot\" or \"mermaid\" to print a diagram of the pipe's steps instead"},
 	"log-level": cliFlag{name: "log-level", shortHand: "l",
 		desc: "Log level: \"error | warn | info | debug\" where only step stats are \n" +
 			"output at using \"warn\""},
@@ -157,10 +162,42 @@ var switches = cliFlags{
 			"when append is false, COPY INTO statements include FORCE=TRUE to reload data files;\n" +
 			"when append is true, Snowflake COPY INTO statements do not include FORCE=TRUE so\n" +
 			"their affect will depend on the load history"},
+	"auto-evolve": cliFlag{name: "auto-evolve",
+		desc: "For Snowflake targets: before loading, compare the source table definition with the target\n" +
+			"as per 'cp meta' and ALTER the target table to add new columns or widen VARCHAR/NUMBER columns.\n" +
+			"The target table is created if it does not exist. Incompatible type changes abort the load"},
 	"print-header": cliFlag{name: "print-header", shortHand: "x",
 		desc: "Print a header for SQL query results"},
 	"file": cliFlag{name: "file", shortHand: "f",
 		desc: "File containing the pipe definition (.yaml or .json)"},
+	"checkpoint-store": cliFlag{name: "checkpoint-store",
+		desc: "Location to save the last extracted date or sequence after each delta batch is copied to S3,\n" +
+			"which is read back on restart. Use file://<dir>, s3://<bucket>/<prefix>?region=<region> or\n" +
+			"table://[<schema>.]<table> in the source database with columns CHECKPOINT_KEY and CHECKPOINT_VALUE"},
+	"registry": cliFlag{name: "registry",
+		desc: "Location to save named pipe definitions, their cron schedules and run history, which enables\n" +
+			"the /pipes/definitions routes. Use file://<dir> or table://[<schema>.]<table> with columns\n" +
+			"REGISTRY_COLLECTION, REGISTRY_NAME and REGISTRY_VALUE in the database of --registry-connection"},
+	"registry-connection": cliFlag{name: "registry-connection",
+		desc: "Connection name of the database that holds the pipe registry table"},
+	"tls-cert": cliFlag{name: "tls-cert",
+		desc: "Certificate file (PEM) used to serve HTTPS, requires --tls-key"},
+	"tls-key": cliFlag{name: "tls-key",
+		desc: "Private key file (PEM) used to serve HTTPS, requires --tls-cert"},
+	"api-keys": cliFlag{name: "api-keys",
+		desc: "YAML or JSON file of API keys required to call the web service, in the form:\n" +
+			"keys: [{name: <name>, secret: <secret>, role: read | launch | admin}]\n" +
+			"Clients send \"Authorization: Bearer <secret>\" or an HMAC-SHA256 signed request.\n" +
+			"Role read fetches status, stats and metrics; launch also starts and stops pipes;\n" +
+			"admin can also stop the server. Leave empty to disable authentication"},
+	"url": cliFlag{name: "url", shortHand: "u",
+		desc: "URL of the web service started by 'hp serve'"},
+	"api-key": cliFlag{name: "api-key", shortHand: "k",
+		desc: "Secret of an API key with the read role, if the web service requires authentication"},
+	"ca-cert": cliFlag{name: "ca-cert",
+		desc: "Certificate file (PEM) used to verify the web service, if it is not trusted by the system"},
+	"format": cliFlag{name: "format", shortHand: "F",
+		desc: "Diagram format: \"dot | mermaid | json\""},
 	"web-service": cliFlag{name: "web-service", shortHand: "w",
 		desc: "Launch a web service to monitor the pipe"},
 	"port": cliFlag{name: "port", shortHand: "p",
@@ -176,6 +213,18 @@ var switches = cliFlags{
 			"before aborting (use 0 to process all records)"},
 	"output-all-fields": cliFlag{name: "output-all-fields", shortHand: "a",
 		desc: "Include all fields in the diff output, else output only the primary key fields"},
+	"diff-mode": cliFlag{name: "diff-mode", shortHand: "M",
+		desc: "How to compare records: \"merge | hash\", where merge requires the databases to \n" +
+			"sort records identically by primary key and hash holds target keys in memory, \n" +
+			"spilling to disk if required"},
+	"checksum-chunks": cliFlag{name: "checksum-chunks", shortHand: "C",
+		desc: "Split the integer range of the first primary key field into this many buckets and \n" +
+			"compare row counts and hashes per bucket in the database, drilling down into \n" +
+			"mismatched buckets and diffing records in those ranges only (use 0 to diff all records)"},
+	"report": cliFlag{name: "report", shortHand: "O",
+		desc: "Write a summary report of the differences: \"json | html | csv\""},
+	"report-file": cliFlag{name: "report-file", shortHand: "W",
+		desc: "The file to write the report to"},
 }
 
 // addFlag add a flag to combra.Command c, based on the type of targetVar (which must be a pointer).
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/relloyd/halfpipe/actions"
+	"github.com/spf13/cobra"
+)
+
+var pipeGraphConfig = actions.PipeGraphConfig{}
+
+var pipeGraphCmd = &cobra.Command{
+	Use:   "graph <file>",
+	Short: "Print a diagram of the steps in a transform described in a YAML or JSON file",
+	Long: `Print a diagram of the steps in a transform described in a YAML or JSON file.
+
+Step groups are drawn as clusters of their steps. Edges show:
+- steps that read data from other steps
+- the order of step groups in the top-level sequence
+- MetadataInjection steps and the step group they execute
+
+Use "dot" format for Graphviz or "mermaid" format for Markdown viewers that render Mermaid diagrams.
+Use "json" format to draw the graph with your own tools.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeGraphConfig.TransformFile = args[0]
+		return actions.RunPipeGraph(&pipeGraphConfig)
+	},
+}
+
+func init() {
+	pipeCmd.AddCommand(pipeGraphCmd)
+	pipeGraphCmd.SilenceUsage = true
+	switches.addFlag(pipeGraphCmd, &pipeGraphConfig.Format, "format", "dot", false, "")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/relloyd/halfpipe/actions"
+	"github.com/spf13/cobra"
+)
+
+var pipeValidateConfig = actions.PipeValidateConfig{}
+
+var pipeValidateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Check a transform described in a YAML or JSON file without running it",
+	Long: `Check a transform described in a YAML or JSON file without opening any connections.
+
+The following are reported together with the JSON path of each value in error:
+- unknown step types and bad step data
+- steps that read from a step that is not earlier in the step group sequence
+- connection names that are not found in the connections section
+- MetadataInjection steps that execute an unknown step group
+- sequences that name undefined step groups or steps
+
+If no errors are found the return code will be 0, else 1.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeValidateConfig.TransformFile = args[0]
+		pipeValidateConfig.StackDumpOnPanic = stackDumpOnPanic
+		return actions.RunPipeValidate(&pipeValidateConfig)
+	},
+}
+
+func init() {
+	pipeCmd.AddCommand(pipeValidateCmd)
+	pipeValidateCmd.SilenceUsage = true
+	switches.addFlag(pipeValidateCmd, &pipeValidateConfig.LogLevel, "log-level", "error", false, "")
+}
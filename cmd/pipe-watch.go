@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/relloyd/halfpipe/actions"
+	"github.com/spf13/cobra"
+)
+
+var pipeWatchConfig = actions.PipeWatchConfig{}
+
+var pipeWatchCmd = &cobra.Command{
+	Use:   "watch <pipe ID>",
+	Short: "Show live progress of a pipe running in the web service",
+	Long: `Show live progress of a pipe running in the web service started by "hp serve".
+
+The pipe status and a progress bar per step are redrawn each time the web service dumps step stats
+(see "hp serve --stats"), until the pipe ends. The bar lengths are relative to the step with the most rows.
+Use GET /pipes on the web service to find the pipe ID.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeWatchConfig.PipeId = args[0]
+		return actions.RunPipeWatch(&pipeWatchConfig)
+	},
+}
+
+func init() {
+	pipeCmd.AddCommand(pipeWatchCmd)
+	pipeWatchCmd.SilenceUsage = true
+	switches.addFlag(pipeWatchCmd, &pipeWatchConfig.Url, "url", "http://localhost:8080", false, "")
+	switches.addFlag(pipeWatchCmd, &pipeWatchConfig.ApiKey, "api-key", "", false, "")
+	switches.addFlag(pipeWatchCmd, &pipeWatchConfig.CaCertFile, "ca-cert", "", false, "")
+}
@@ -8,8 +8,9 @@ import (
 )
 
 var pipeCmd = &cobra.Command{
-	Use:   "pipe",
-	Short: "Execute a transform described in a YAML or JSON file",
+	Use:     "pipe",
+	Aliases: []string{"pipes"},
+	Short:   "Execute a transform described in a YAML or JSON file",
 	Long: `Execute a transform described in a YAML or JSON file.
 Optionally run a web server to monitor progress and health remotely.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -12,7 +12,9 @@ import (
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start a web service and listen for pipe commands described in JSON",
-	Long:  `Start a web service and listen for pipe commands described in JSON`,
+	Long: `Start a web service and listen for pipe commands described in JSON or YAML.
+
+Browse to http://<address>:<port>/ui/ to manage pipes in the web UI.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		serveConfig.Connections = getConnectionLoader()
 		serveConfig.StackDumpOnPanic = stackDumpOnPanic
@@ -36,4 +38,9 @@ func init() {
 	switches.addFlag(serveCmd, &serveConfig.Port, "port", "8080", false, "")
 	switches.addFlag(serveCmd, &serveConfig.LogLevel, "log-level", "info", false, "")
 	switches.addFlag(serveCmd, &serveConfig.StatsDumpFrequencySeconds, "stats", "5", false, "")
+	switches.addFlag(serveCmd, &serveConfig.PipeRegistry, "registry", "", false, "")
+	switches.addFlag(serveCmd, &serveConfig.PipeRegistryConnection, "registry-connection", "", false, "")
+	switches.addFlag(serveCmd, &serveConfig.TLSCertFile, "tls-cert", "", false, "")
+	switches.addFlag(serveCmd, &serveConfig.TLSKeyFile, "tls-key", "", false, "")
+	switches.addFlag(serveCmd, &serveConfig.ApiKeysFile, "api-keys", "", false, "")
 }
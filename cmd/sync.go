@@ -95,6 +95,8 @@ var syncBatchCmd = &cobra.Command{
 - Optionally loop to keep target data up-to-date in near real-time
 - If you need more performance and your source contains date/time-stamps and is not deleted
   use the "cp delta" action
+- Use "--diff-mode hash" if the databases can't sort records identically, e.g. due to
+  different collations
 `,
 	Args: getConnectionsArgsFunc(&syncBatchCfg.SourceString, &syncBatchCfg.TargetString, ""),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -123,6 +125,7 @@ func initSyncBatch() {
 	switches.addFlag(syncBatchCmd, &syncBatchCfg.SQLPrimaryKeyFieldsCsv, "primary-keys", "", true, "")
 	switches.addFlag(syncBatchCmd, &syncBatchCfg.CommitBatchSize, "commit-batch-size", "10000", false, "")
 	switches.addFlag(syncBatchCmd, &syncBatchCfg.TxtBatchNumRows, "sql-txt-batch-num-rows", "1000", false, "")
+	switches.addFlag(syncBatchCmd, &syncBatchCfg.DiffMode, "diff-mode", actions.DiffModeMerge, false, "")
 	// Snowflake specific.
 	switches.addFlag(syncBatchCmd, &syncBatchCfg.SnowStageName, "stage", "", false, "")
 	switches.addFlag(syncBatchCmd, &syncBatchCfg.BucketName, "s3-bucket", "", false, "")
@@ -51,6 +51,7 @@ func NewMergeNChannels(i interface{}) (outputChan chan stream.Record, controlCha
 		records := make([][]stream.Record, len(cfg.InputChannels), len(cfg.InputChannels))
 		shutdownChans := make([]chan ControlAction, len(cfg.InputChannels), len(cfg.InputChannels))
 		wg := sync.WaitGroup{}
+		pauseGate := sync.RWMutex{} // locked while we are paused to stop the goroutines below from collecting input.
 		// Read all input records from all input channels using goroutines.
 		for idx, ic := range cfg.InputChannels { // for each input channel...
 			// for idx := len(cfg.InputChannels) - 1; idx >= 0; idx-- { // for each input channel in reverse...
@@ -65,7 +66,9 @@ func NewMergeNChannels(i interface{}) (outputChan chan stream.Record, controlCha
 						if !ok { // if the input channel closed...
 							return // quit
 						}
+						pauseGate.RLock()                        // wait while paused.
 						records[idx] = append(records[idx], rec) // else save the record.
+						pauseGate.RUnlock()
 					case action := <-shutdownChans[idx]: // or if we have been shutdown...
 						sendNilControlResponse(action)
 						return
@@ -79,14 +82,28 @@ func NewMergeNChannels(i interface{}) (outputChan chan stream.Record, controlCha
 			waitChan <- struct{}{}
 		}()
 		// Wait for all records to be collected from input channels - completion of goroutines above.
-		select {
-		case <-waitChan: // if the goroutines above collected all input records...
-		case controlAction := <-controlChan: // if we were asked to shutdown...
-			for _, shutdownChan := range shutdownChans {
-				shutdownChan <- ControlAction{Action: Shutdown, ResponseChan: make(chan error, 1)}
+		for collecting := true; collecting; {
+			select {
+			case <-waitChan: // if the goroutines above collected all input records...
+				collecting = false
+			case controlAction := <-controlChan: // if we were asked to shutdown...
+				paused := controlAction.Action == Pause
+				if paused {
+					pauseGate.Lock()
+				}
+				controlAction = HandlePauseAction(controlAction, controlChan, nil)
+				if paused {
+					pauseGate.Unlock()
+				}
+				if controlAction.Action == Resume { // if we should carry on collecting input...
+					continue
+				}
+				for _, shutdownChan := range shutdownChans {
+					shutdownChan <- ControlAction{Action: Shutdown, ResponseChan: make(chan error, 1)}
+				}
+				sendNilControlResponse(controlAction)
+				return
 			}
-			sendNilControlResponse(controlAction)
-			return
 		}
 		// Produce cartesian now we have all input records from all input channels.
 		sizes := make([]int, len(records), len(records))
@@ -12,8 +12,8 @@ import (
 type ChannelCombinerConfig struct {
 	Log            logger.Logger
 	Name           string
-	Chan1          chan stream.Record
-	Chan2          chan stream.Record
+	Chan1          chan stream.Record `data:"readDataFromStep1" mandatory:"yes"`
+	Chan2          chan stream.Record `data:"readDataFromStep2" mandatory:"yes"`
 	StepWatcher    *s.StepWatcher
 	WaitCounter    ComponentWaiter
 	PanicHandlerFn PanicHandlerFunc
@@ -60,6 +60,9 @@ func NewChannelCombiner(i interface{}) (outputChan chan stream.Record, controlCh
 					}
 				}
 			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				cfg.Log.Info(cfg.Name, " shutdown")
 				return
@@ -0,0 +1,168 @@
+package components
+
+import (
+	"sync/atomic"
+
+	"github.com/relloyd/halfpipe/checkpoint"
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+type CheckpointReadConfig struct {
+	Log                  logger.Logger
+	Name                 string
+	StoreSpec            string           `data:"checkpointStore"`        // see checkpoint.NewStore(); leave empty to disable checkpoints.
+	Db                   shared.Connector `data:"databaseConnectionName"` // required when the store is a database table.
+	Key                  string           `data:"checkpointKey" mandatory:"yes"`
+	OutputField4Value    string           `data:"outputFieldName4Value" mandatory:"yes"`
+	StepWatcher          *stats.StepWatcher
+	WaitCounter          ComponentWaiter
+	PanicHandlerFn       PanicHandlerFunc
+	fnNewCheckpointStore func(spec string, db shared.Connector) (checkpoint.Store, error)
+}
+
+// NewCheckpointRead reads the value saved for Key in the checkpoint store described by StoreSpec.
+// If a value is found, a single record is produced on outputChan with the value in field OutputField4Value.
+// If there is no value or StoreSpec is empty then no records are produced, so use this alongside a default row.
+func NewCheckpointRead(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*CheckpointReadConfig)
+	if cfg.fnNewCheckpointStore == nil {
+		cfg.fnNewCheckpointStore = checkpoint.NewStore
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a StepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		if cfg.StoreSpec != "" { // if checkpoints are enabled...
+			store, err := cfg.fnNewCheckpointStore(cfg.StoreSpec, cfg.Db)
+			if err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to open checkpoint store: ", err)
+			}
+			val, found, err := store.Read(cfg.Key)
+			if err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to read checkpoint ", cfg.Key, ": ", err)
+			}
+			if found { // if there is a checkpoint...
+				cfg.Log.Info(cfg.Name, " found checkpoint ", cfg.Key, " = ", val)
+				rec := stream.NewRecord()
+				rec.SetData(cfg.OutputField4Value, val)
+				if recSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSentOK {
+					cfg.Log.Info(cfg.Name, " shutdown")
+					return
+				}
+				atomic.AddInt64(&rowCount, 1)
+			} else {
+				cfg.Log.Info(cfg.Name, " found no checkpoint ", cfg.Key)
+			}
+		}
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return
+}
+
+type CheckpointWriteConfig struct {
+	Log                  logger.Logger
+	Name                 string
+	InputChan            chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	StoreSpec            string             `data:"checkpointStore"`        // see checkpoint.NewStore(); leave empty to disable checkpoints.
+	Db                   shared.Connector   `data:"databaseConnectionName"` // required when the store is a database table.
+	Key                  string             `data:"checkpointKey" mandatory:"yes"`
+	Value                string             `data:"checkpointValue"`      // a fixed value to save per input record.
+	InputField4Value     string             `data:"inputFieldName4Value"` // the field to save per input record, used instead of Value.
+	StepWatcher          *stats.StepWatcher
+	WaitCounter          ComponentWaiter
+	PanicHandlerFn       PanicHandlerFunc
+	fnNewCheckpointStore func(spec string, db shared.Connector) (checkpoint.Store, error)
+}
+
+// NewCheckpointWrite saves a checkpoint for each record found on InputChan before passing the record to outputChan.
+// Use it after steps that only produce records once their work is complete, e.g. after copying a manifest to S3,
+// so the checkpoint is only saved once the data is safely written.
+// The value saved is taken from input field InputField4Value if supplied, else Value is used.
+// If StoreSpec is empty then records are passed through without saving checkpoints.
+func NewCheckpointWrite(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*CheckpointWriteConfig)
+	if cfg.fnNewCheckpointStore == nil {
+		cfg.fnNewCheckpointStore = checkpoint.NewStore
+	}
+	var store checkpoint.Store
+	if cfg.StoreSpec != "" { // if checkpoints are enabled...
+		if cfg.InputField4Value == "" && cfg.Value == "" {
+			cfg.Log.Panic(cfg.Name, " missing checkpoint value or input field name for the value")
+		}
+		var err error
+		if store, err = cfg.fnNewCheckpointStore(cfg.StoreSpec, cfg.Db); err != nil {
+			cfg.Log.Panic(cfg.Name, " unable to open checkpoint store: ", err)
+		}
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a StepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		for { // loop until the input channel is closed...
+			select {
+			case rec, ok := <-cfg.InputChan:
+				if !ok { // if there is no more input data...
+					cfg.InputChan = nil
+					break
+				}
+				if store != nil { // if we should save a checkpoint...
+					val := cfg.Value
+					if cfg.InputField4Value != "" {
+						val = rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.InputField4Value)
+					}
+					if err := store.Write(cfg.Key, val); err != nil {
+						cfg.Log.Panic(cfg.Name, " unable to write checkpoint ", cfg.Key, ": ", err)
+					}
+					cfg.Log.Info(cfg.Name, " saved checkpoint ", cfg.Key, " = ", val)
+				}
+				if recSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSentOK {
+					cfg.Log.Info(cfg.Name, " shutdown")
+					return
+				}
+				atomic.AddInt64(&rowCount, 1)
+			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+			if cfg.InputChan == nil {
+				break
+			}
+		}
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return
+}
@@ -0,0 +1,111 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/relloyd/halfpipe/checkpoint"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+type mockCheckpointStore struct {
+	values map[string]string
+	writes []string
+}
+
+func (s *mockCheckpointStore) Read(key string) (string, bool, error) {
+	v, ok := s.values[key]
+	return v, ok, nil
+}
+
+func (s *mockCheckpointStore) Write(key string, value string) error {
+	s.values[key] = value
+	s.writes = append(s.writes, value)
+	return nil
+}
+
+func newMockCheckpointStoreFn(s *mockCheckpointStore) func(spec string, db shared.Connector) (checkpoint.Store, error) {
+	return func(spec string, db shared.Connector) (checkpoint.Store, error) {
+		return s, nil
+	}
+}
+
+func TestNewCheckpointRead(t *testing.T) {
+	log := logger.NewLogger("checkpoint read test", "info", true)
+	store := &mockCheckpointStore{values: map[string]string{"k": "20200101T000000"}}
+	// Test 1 - a saved checkpoint produces one record.
+	cfg := &CheckpointReadConfig{Log: log, Name: "Test CheckpointRead", StoreSpec: "mock", Key: "k", OutputField4Value: "#max",
+		fnNewCheckpointStore: newMockCheckpointStoreFn(store)}
+	o, _ := NewCheckpointRead(cfg)
+	result := make([]stream.Record, 0)
+	for rec := range o {
+		result = append(result, rec)
+	}
+	if len(result) != 1 || result[0].GetDataAsStringPreserveTimeZone(log, "#max") != "20200101T000000" {
+		t.Fatal("expected one record with the checkpoint value; got: ", result)
+	}
+	// Test 2 - a missing checkpoint produces no records.
+	cfg.Key = "missing"
+	o, _ = NewCheckpointRead(cfg)
+	for rec := range o {
+		t.Fatal("expected no records; got: ", rec)
+	}
+	// Test 3 - checkpoints are disabled.
+	cfg = &CheckpointReadConfig{Log: log, Name: "Test CheckpointRead", Key: "k", OutputField4Value: "#max"}
+	o, _ = NewCheckpointRead(cfg)
+	for rec := range o {
+		t.Fatal("expected no records; got: ", rec)
+	}
+}
+
+func TestNewCheckpointWrite(t *testing.T) {
+	log := logger.NewLogger("checkpoint write test", "info", true)
+	store := &mockCheckpointStore{values: make(map[string]string)}
+	// Test 1 - values are saved from an input field and records are passed on.
+	input := make(chan stream.Record, 2)
+	for _, v := range []string{"a", "b"} {
+		rec := stream.NewRecord()
+		rec.SetData("f", v)
+		input <- rec
+	}
+	close(input)
+	cfg := &CheckpointWriteConfig{Log: log, Name: "Test CheckpointWrite", InputChan: input, StoreSpec: "mock", Key: "k",
+		InputField4Value: "f", fnNewCheckpointStore: newMockCheckpointStoreFn(store)}
+	o, _ := NewCheckpointWrite(cfg)
+	count := 0
+	for range o {
+		count++
+	}
+	if count != 2 {
+		t.Fatal("expected 2 records to be passed on; got: ", count)
+	}
+	if len(store.writes) != 2 || store.values["k"] != "b" {
+		t.Fatal("expected 2 checkpoints to be saved with the last value b; got: ", store.writes)
+	}
+	// Test 2 - a fixed value is saved.
+	input = make(chan stream.Record, 1)
+	input <- stream.NewRecord()
+	close(input)
+	cfg.InputChan = input
+	cfg.InputField4Value = ""
+	cfg.Value = "fixed"
+	o, _ = NewCheckpointWrite(cfg)
+	for range o {
+	}
+	if store.values["k"] != "fixed" {
+		t.Fatal("expected fixed checkpoint value; got: ", store.values["k"])
+	}
+	// Test 3 - checkpoints are disabled so records pass through.
+	input = make(chan stream.Record, 1)
+	input <- stream.NewRecord()
+	close(input)
+	o, _ = NewCheckpointWrite(&CheckpointWriteConfig{Log: log, Name: "Test CheckpointWrite", InputChan: input, Key: "k"})
+	count = 0
+	for range o {
+		count++
+	}
+	if count != 1 {
+		t.Fatal("expected 1 record to be passed on; got: ", count)
+	}
+}
@@ -17,12 +17,12 @@ import (
 type CopyFilesToS3Config struct {
 	Log               logger.Logger
 	Name              string
-	InputChan         chan stream.Record // the input channel of rows containing files (with full paths) to copy/move to S3.
-	FileNameChanField string             // name of the field in InputChan that contains the files to move.
-	BucketName        string             // target bucket
-	BucketPrefix      string
-	Region            string
-	RemoveInputFiles  bool // true to delete the input files after successful copy to s3.
+	InputChan         chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // the input channel of rows containing files (with full paths) to copy/move to S3.
+	FileNameChanField string             `data:"inputFieldName4FilePath"`          // name of the field in InputChan that contains the files to move.
+	BucketName        string             `data:"bucketName" mandatory:"yes"`       // target bucket
+	BucketPrefix      string             `data:"bucketPrefix"`
+	Region            string             `data:"bucketRegion"`
+	RemoveInputFiles  bool               `data:"removeInputFiles"` // true to delete the input files after successful copy to s3.
 	StepWatcher       *stats.StepWatcher
 	WaitCounter       ComponentWaiter
 	PanicHandlerFn    PanicHandlerFunc
@@ -120,6 +120,9 @@ func NewCopyFilesToS3(i interface{}) (outputChan chan stream.Record, controlChan
 					}
 				}
 			case controlAction := <-controlChan: // if we received a shutdown request...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				cfg.Log.Info(cfg.Name, " shutdown")
 				return
@@ -15,17 +15,17 @@ import (
 type CsvFileWriterConfig struct {
 	Log                               logger.Logger
 	Name                              string
-	InputChan                         chan stream.Record // the input channel of rows to write to an output CSV file.
-	OutputDir                         string             // set to empty string to use a system generated sub directory in OS temp space.
-	FileNamePrefix                    string
-	FileNameSuffixAppendCreationStamp bool
-	FileNameSuffixDateFormat          string
-	FileNameExtension                 string
-	UseGzip                           bool
-	MaxFileRows                       int
-	MaxFileBytes                      int
-	HeaderFields                      []string // the slice of key names to be found in InputChan that will be used as the CSV header.
-	OutputChanField4FilePath          string   // the field on outputChan that will contain the file name.
+	InputChan                         chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // the input channel of rows to write to an output CSV file.
+	OutputDir                         string             `data:"outputDir"`                        // set to empty string to use a system generated sub directory in OS temp space.
+	FileNamePrefix                    string             `data:"fileNamePrefix"`
+	FileNameSuffixAppendCreationStamp bool               `data:"fileNameSuffixAppendCreationStamp"`
+	FileNameSuffixDateFormat          string             `data:"fileNameSuffixDateTimeFormat"`
+	FileNameExtension                 string             `data:"fileNameExtension"`
+	UseGzip                           bool               `data:"useGzip"`
+	MaxFileRows                       int                `data:"maxFileRows" mandatory:"yes"`
+	MaxFileBytes                      int                `data:"maxFileBytes" mandatory:"yes"`
+	HeaderFields                      []string           `data:"headerFieldsCSV"`          // the slice of key names to be found in InputChan that will be used as the CSV header.
+	OutputChanField4FilePath          string             `data:"outputFieldName4FilePath"` // the field on outputChan that will contain the file name.
 	StepWatcher                       *s.StepWatcher
 	WaitCounter                       ComponentWaiter
 	PanicHandlerFn                    PanicHandlerFunc
@@ -123,6 +123,9 @@ func NewCsvFileWriter(i interface{}) (outputChan chan stream.Record, controlChan
 					}
 				}
 			case controlAction = <-controlChan:
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				controlChan = nil
 			}
 			if controlChan == nil || cfg.InputChan == nil { // if we should quit due to a shutdown request or the end or input...
@@ -10,6 +10,9 @@ var Defaults = struct {
 	ChanField4BucketRegion          string // the default map key that contains the bucket region, used by input and output Channels.
 	ChanField4StageName             string // the default map key that contains the Snowflake stage name, used by input and output Channels.
 	ChanField4TableName             string // the default map key that contains the Snowflake table name, used by input and output Channels.
+	ChanField4KafkaAck              string // the default map key that contains the Kafka message handle used to commit offsets, used by input and output Channels.
+	ChanField4ErrorStep             string // the map key added to rejected records that contains the name of the step that rejected them.
+	ChanField4ErrorText             string // the map key added to rejected records that contains the error text.
 }{
 	ChanField4CSVFileName:           "#CSVFileName",
 	ChanField4FileName:              "#DataFileName",
@@ -19,4 +22,7 @@ var Defaults = struct {
 	ChanField4BucketRegion:          "#BucketRegion",
 	ChanField4StageName:             "#SnowflakeStageName",
 	ChanField4TableName:             "#SnowflakeTargetTableName",
+	ChanField4KafkaAck:              "#KafkaAck",
+	ChanField4ErrorStep:             "#ErrorStepName",
+	ChanField4ErrorText:             "#ErrorText",
 }
@@ -0,0 +1,333 @@
+package components
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	om "github.com/cevaris/ordered_map"
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	s "github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// Formats supported by DiffReport.
+const (
+	DiffReportFormatJson = "json"
+	DiffReportFormatHtml = "html"
+	DiffReportFormatCsv  = "csv"
+)
+
+// diffReportDefaultTopN is the number of differences listed when a DiffReport does not specify topN.
+const diffReportDefaultTopN = 100
+
+// diffReportStatus maps MergeDiff flag values to the names used in reports.
+var diffReportStatus = map[string]string{
+	c.MergeDiffValueNew:       "NEW",
+	c.MergeDiffValueChanged:   "CHANGED",
+	c.MergeDiffValueDeleted:   "DELETED",
+	c.MergeDiffValueIdentical: "IDENTICAL",
+}
+
+type DiffReportConfig struct {
+	Log                 logger.Logger
+	Name                string
+	InputChan           chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	JoinKeys            *om.OrderedMap     `data:"joinKeys" mandatory:"yes"`
+	CompareKeys         *om.OrderedMap     `data:"compareKeys"`
+	ResultFlagKeyName   string             `data:"flagFieldName"`
+	OldFieldPrefix      string             `data:"oldFieldPrefix"` // the prefix of fields holding the old values of CHANGED records, if any.
+	OutputIdenticalRows bool               `data:"outputIdenticalRows"`
+	TopN                int                `data:"topN"`             // the number of differences to list in the report.
+	ChecksumVerified    int64              `data:"checksumVerified"` // the number of rows found to be identical by comparing checksums instead of records.
+	Format              string             `data:"format" mandatory:"yes"`
+	FileName            string             `data:"fileName" mandatory:"yes"`
+	StepWatcher         *s.StepWatcher
+	WaitCounter         ComponentWaiter
+	PanicHandlerFn      PanicHandlerFunc
+}
+
+// DiffReport summarises the output of a MergeDiff or HashDiff step.
+type DiffReport struct {
+	New              int64              `json:"new"`
+	Changed          int64              `json:"changed"`
+	Deleted          int64              `json:"deleted"`
+	Identical        int64              `json:"identical"`
+	ChecksumVerified int64              `json:"checksumVerified"` // identical rows that were not diffed because their checksums match.
+	DifferencesFound bool               `json:"differencesFound"`
+	Differences      []DiffReportRecord `json:"differences"` // the first TopN differences in the order they were found.
+}
+
+// DiffReportRecord is a record that is NEW, CHANGED or DELETED.
+type DiffReportRecord struct {
+	Status        string             `json:"status"`
+	Keys          []DiffReportValue  `json:"keys"`
+	ChangedFields []DiffReportChange `json:"changedFields,omitempty"`
+}
+
+// DiffReportValue is the value of a key field.
+type DiffReportValue struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// DiffReportChange is the old and new value of a field that differs on a CHANGED record.
+type DiffReportChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+// NewDiffReport reads the flagged records output by MergeDiff or HashDiff on InputChan and writes a summary to
+// FileName in the given Format once all input has been read.
+// The summary contains the number of NEW, CHANGED, DELETED and IDENTICAL records plus the join keys of the first
+// TopN differences. Rows that were skipped because their checksums match can be counted by setting ChecksumVerified.
+// If OldFieldPrefix is set and the input records hold old values in fields named using the prefix
+// then CHANGED records also list the CompareKeys fields that differ, with their old and new values.
+// Records are passed on to the output channel, except for IDENTICAL records unless OutputIdenticalRows is true, so
+// the diff step can output identical records for counting without them reaching downstream steps.
+func NewDiffReport(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*DiffReportConfig)
+	if err := ValidateDiffReportFormat(cfg.Format); err != nil {
+		cfg.Log.Panic(cfg.Name, " ", err)
+	}
+	if cfg.ResultFlagKeyName == "" {
+		cfg.ResultFlagKeyName = "mergeDiffResult"
+	}
+	if cfg.CompareKeys == nil {
+		cfg.CompareKeys = om.NewOrderedMap()
+	}
+	if cfg.TopN <= 0 {
+		cfg.TopN = diffReportDefaultTopN
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a stepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		report := &DiffReport{ChecksumVerified: cfg.ChecksumVerified, Differences: make([]DiffReportRecord, 0)}
+		var controlAction ControlAction
+		for {
+			select {
+			case rec, ok := <-cfg.InputChan:
+				if !ok { // if we have run out of rows...
+					cfg.InputChan = nil // disable this case
+				} else { // else add the row to the report...
+					flag := rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.ResultFlagKeyName)
+					if err := report.add(cfg, rec, flag); err != nil {
+						cfg.Log.Panic(cfg.Name, " ", err)
+					}
+					if flag != c.MergeDiffValueIdentical || cfg.OutputIdenticalRows { // if we should pass on the row...
+						if rowSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !rowSentOK {
+							cfg.Log.Info(cfg.Name, " shutdown")
+							return
+						}
+					}
+					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+				}
+			case controlAction = <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+			if cfg.InputChan == nil { // if we should exit gracefully...
+				break
+			}
+		}
+		if err := report.WriteFile(cfg.FileName, cfg.Format); err != nil {
+			cfg.Log.Panic(cfg.Name, " unable to write report: ", err)
+		}
+		cfg.Log.Info(cfg.Name, " wrote report to ", cfg.FileName)
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return outputChan, controlChan
+}
+
+// ValidateDiffReportFormat returns an error if format is not supported by DiffReport.
+func ValidateDiffReportFormat(format string) error {
+	switch format {
+	case DiffReportFormatJson, DiffReportFormatHtml, DiffReportFormatCsv:
+		return nil
+	default:
+		return fmt.Errorf("unsupported report format %q: use %v, %v or %v", format, DiffReportFormatJson, DiffReportFormatHtml, DiffReportFormatCsv)
+	}
+}
+
+// add counts the record with the given MergeDiff flag and saves its details if it is one of the first TopN
+// differences.
+func (r *DiffReport) add(cfg *DiffReportConfig, rec stream.Record, flag string) error {
+	switch flag {
+	case c.MergeDiffValueNew:
+		r.New++
+	case c.MergeDiffValueChanged:
+		r.Changed++
+	case c.MergeDiffValueDeleted:
+		r.Deleted++
+	case c.MergeDiffValueIdentical:
+		r.Identical++
+		return nil
+	default:
+		return fmt.Errorf("unexpected value %q in field %v", flag, cfg.ResultFlagKeyName)
+	}
+	r.DifferencesFound = true
+	if len(r.Differences) >= cfg.TopN { // if we have listed enough differences...
+		return nil
+	}
+	d := DiffReportRecord{Status: diffReportStatus[flag]}
+	iter := cfg.JoinKeys.IterFunc()
+	for kv, ok := iter(); ok; kv, ok = iter() { // for each key field...
+		field := kv.Value.(string)
+		if flag == c.MergeDiffValueDeleted { // if the record came from the old data...
+			field = kv.Key.(string)
+		}
+		d.Keys = append(d.Keys, DiffReportValue{Field: field, Value: rec.GetDataAsStringUseUtcTime(cfg.Log, field)})
+	}
+	if flag == c.MergeDiffValueChanged && cfg.OldFieldPrefix != "" { // if we can list the changed fields...
+		iter = cfg.CompareKeys.IterFunc()
+		for kv, ok := iter(); ok; kv, ok = iter() { // for each field compared...
+			field := kv.Value.(string)
+			oldValue := rec.GetDataAsStringUseUtcTime(cfg.Log, cfg.OldFieldPrefix+field)
+			newValue := rec.GetDataAsStringUseUtcTime(cfg.Log, field)
+			if oldValue != newValue {
+				d.ChangedFields = append(d.ChangedFields, DiffReportChange{Field: field, OldValue: oldValue, NewValue: newValue})
+			}
+		}
+	}
+	r.Differences = append(r.Differences, d)
+	return nil
+}
+
+// WriteFile writes the report to fileName in the given format.
+func (r *DiffReport) WriteFile(fileName string, format string) error {
+	if err := ValidateDiffReportFormat(format); err != nil {
+		return err
+	}
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err = r.Write(f, format); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the report to w in the given format.
+func (r *DiffReport) Write(w io.Writer, format string) error {
+	switch format {
+	case DiffReportFormatJson:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case DiffReportFormatHtml:
+		return diffReportHtml.Execute(w, r)
+	case DiffReportFormatCsv:
+		return r.writeCsv(w)
+	default:
+		return ValidateDiffReportFormat(format)
+	}
+}
+
+// writeCsv writes the counts per status followed by a blank line and one row per difference, or per changed field
+// of CHANGED records.
+func (r *DiffReport) writeCsv(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"status", "count"},
+		{"NEW", strconv.FormatInt(r.New, 10)},
+		{"CHANGED", strconv.FormatInt(r.Changed, 10)},
+		{"DELETED", strconv.FormatInt(r.Deleted, 10)},
+		{"IDENTICAL", strconv.FormatInt(r.Identical, 10)},
+		{"CHECKSUM VERIFIED", strconv.FormatInt(r.ChecksumVerified, 10)},
+		{},
+		{"status", "keys", "field", "oldValue", "newValue"},
+	}
+	for _, d := range r.Differences {
+		if len(d.ChangedFields) == 0 {
+			rows = append(rows, []string{d.Status, d.KeyString(), "", "", ""})
+		}
+		for _, f := range d.ChangedFields {
+			rows = append(rows, []string{d.Status, d.KeyString(), f.Field, f.OldValue, f.NewValue})
+		}
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// KeyString returns the key fields and values of the record as a string like "A=1, B=2".
+func (d DiffReportRecord) KeyString() string {
+	s := make([]string, len(d.Keys))
+	for idx, k := range d.Keys {
+		s[idx] = k.Field + "=" + k.Value
+	}
+	return strings.Join(s, ", ")
+}
+
+var diffReportHtml = template.Must(template.New("diffReport").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Diff Report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Diff Report</h1>
+<h2>Summary</h2>
+<table>
+<tr><th>Status</th><th>Count</th></tr>
+<tr><td>NEW</td><td>{{.New}}</td></tr>
+<tr><td>CHANGED</td><td>{{.Changed}}</td></tr>
+<tr><td>DELETED</td><td>{{.Deleted}}</td></tr>
+<tr><td>IDENTICAL</td><td>{{.Identical}}</td></tr>
+<tr><td>CHECKSUM VERIFIED</td><td>{{.ChecksumVerified}}</td></tr>
+</table>
+<h2>Differences</h2>
+{{- if .Differences}}
+<table>
+<tr><th>Status</th><th>Keys</th><th>Field</th><th>Old Value</th><th>New Value</th></tr>
+{{- range .Differences}}
+{{- $d := .}}
+{{- if .ChangedFields}}
+{{- range .ChangedFields}}
+<tr><td>{{$d.Status}}</td><td>{{$d.KeyString}}</td><td>{{.Field}}</td><td>{{.OldValue}}</td><td>{{.NewValue}}</td></tr>
+{{- end}}
+{{- else}}
+<tr><td>{{.Status}}</td><td>{{.KeyString}}</td><td></td><td></td><td></td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- else}}
+<p>No differences found.</p>
+{{- end}}
+</body>
+</html>
+`))
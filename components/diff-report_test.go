@@ -0,0 +1,111 @@
+package components
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestNewDiffReport(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	rows := [][]interface{}{
+		{1, "a", nil, c.MergeDiffValueIdentical},
+		{2, "x", "b", c.MergeDiffValueChanged},
+		{3, "c", nil, c.MergeDiffValueDeleted},
+		{4, "d", nil, c.MergeDiffValueNew},
+		{5, "e", nil, c.MergeDiffValueNew},
+	}
+	newInput := func() chan stream.Record {
+		ch := make(chan stream.Record, len(rows))
+		for _, r := range rows {
+			rec := stream.NewRecord()
+			rec.SetData("ID", r[0])
+			rec.SetData("NAME", r[1])
+			if r[3] == c.MergeDiffValueChanged {
+				rec.SetData("#old.NAME", r[2])
+			}
+			rec.SetData("#flag", r[3])
+			ch <- rec
+		}
+		close(ch)
+		return ch
+	}
+	fileName := filepath.Join(t.TempDir(), "report.json")
+	cfg := &DiffReportConfig{
+		Log:               log,
+		Name:              "Test DiffReport",
+		InputChan:         newInput(),
+		JoinKeys:          helper.TokensToOrderedMap("ID:ID"),
+		CompareKeys:       helper.TokensToOrderedMap("NAME:NAME"),
+		ResultFlagKeyName: "#flag",
+		OldFieldPrefix:    "#old.",
+		TopN:              3,
+		ChecksumVerified:  10,
+		Format:            DiffReportFormatJson,
+		FileName:          fileName,
+	}
+	outputChan, _ := NewDiffReport(cfg)
+	// Confirm identical rows are not passed on.
+	gotIds := make([]interface{}, 0)
+	for rec := range outputChan {
+		gotIds = append(gotIds, rec.GetData("ID"))
+	}
+	if expected := []interface{}{2, 3, 4, 5}; !reflect.DeepEqual(gotIds, expected) {
+		t.Fatalf("expected output IDs %v; got %v", expected, gotIds)
+	}
+	// Confirm the report contents.
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := DiffReport{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := DiffReport{
+		New:              2,
+		Changed:          1,
+		Deleted:          1,
+		Identical:        1,
+		ChecksumVerified: 10,
+		DifferencesFound: true,
+		Differences: []DiffReportRecord{
+			{Status: "CHANGED", Keys: []DiffReportValue{{"ID", "2"}}, ChangedFields: []DiffReportChange{{"NAME", "b", "x"}}},
+			{Status: "DELETED", Keys: []DiffReportValue{{"ID", "3"}}},
+			{Status: "NEW", Keys: []DiffReportValue{{"ID", "4"}}},
+		},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected report %+v; got %+v", expected, got)
+	}
+	// Confirm CSV and HTML output.
+	buf := &bytes.Buffer{}
+	if err := got.Write(buf, DiffReportFormatCsv); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "NEW,2\n") || !strings.Contains(buf.String(), "CHECKSUM VERIFIED,10\n") ||
+		!strings.Contains(buf.String(), "CHANGED,ID=2,NAME,b,x\n") {
+		t.Fatalf("unexpected CSV report: %v", buf.String())
+	}
+	buf.Reset()
+	if err := got.Write(buf, DiffReportFormatHtml); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<td>CHANGED</td><td>ID=2</td><td>NAME</td><td>b</td><td>x</td>") ||
+		!strings.Contains(buf.String(), "<td>CHECKSUM VERIFIED</td><td>10</td>") {
+		t.Fatalf("unexpected HTML report: %v", buf.String())
+	}
+	// Confirm unsupported formats are rejected.
+	if err := got.Write(buf, "xml"); err == nil {
+		t.Fatal("expected an error for unsupported format")
+	}
+}
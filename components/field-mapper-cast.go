@@ -0,0 +1,254 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// Types supported by CastFields.
+const (
+	castTypeString  = "string"
+	castTypeInt64   = "int64"
+	castTypeFloat64 = "float64"
+	castTypeDecimal = "decimal"
+	castTypeTime    = "time"
+)
+
+// castTimeLayouts are names that can be used in place of Go time layouts.
+var castTimeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"ISO8601":     time.RFC3339,
+}
+
+type castFunc func(v interface{}) (interface{}, error)
+
+// setupCastFields returns a fieldMapperFunc that converts the values of fieldNames, a comma separated list, to
+// toType. Null values are left as null. Supply toType as one of:
+// string - values are rendered using helper.GetStringFromInterface; optionally supply trueValue and falseValue to
+// render booleans.
+// int64 or float64 - numbers, numeric strings and booleans (1 or 0) are converted; int64 requires whole numbers.
+// decimal - numbers and numeric strings are rounded to scale decimal places, with halves rounded away from zero,
+// and rendered as strings with exactly scale decimal places.
+// time - strings are parsed using inputLayout (default RFC3339). Strings without a time zone are read in
+// inputTimeZone, if supplied, which also replaces the time zone of time values without converting the clock time
+// (e.g. for Oracle DATEs). The result is converted to outputTimeZone, if supplied. If outputLayout is supplied then
+// the time is rendered as a string using it.
+// Layouts are Go time layouts or one of the names in castTimeLayouts. Time zones are IANA names, UTC or Local.
+func setupCastFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	errBuilder := strings.Builder{}
+	fieldNames := getFieldNames(cfg["fieldNames"])
+	if len(fieldNames) == 0 {
+		errBuilder.WriteString("fieldNames, ")
+	}
+	toType, ok := cfg["toType"]
+	if !ok {
+		errBuilder.WriteString("toType, ")
+	}
+	if errBuilder.Len() > 0 {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: %v", fieldMapperCastFields, strings.TrimRight(errBuilder.String(), ", "))
+	}
+	var fn castFunc
+	var err error
+	switch toType {
+	case castTypeString:
+		fn = setupCastToString(log, cfg)
+	case castTypeInt64:
+		fn = castToInt64
+	case castTypeFloat64:
+		fn = castToFloat64
+	case castTypeDecimal:
+		fn, err = setupCastToDecimal(cfg)
+	case castTypeTime:
+		fn, err = setupCastToTime(cfg)
+	default:
+		err = fmt.Errorf("unsupported toType supplied to %v, %q. supported types are '%v', '%v', '%v', '%v', '%v'",
+			fieldMapperCastFields, toType, castTypeString, castTypeInt64, castTypeFloat64, castTypeDecimal, castTypeTime)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func(data stream.Record) stream.Record {
+		for _, f := range fieldNames { // for each field to convert...
+			v := data.GetData(f)
+			if v == nil {
+				continue
+			}
+			newVal, err := fn(v)
+			if err != nil {
+				log.Panic(fmt.Sprintf("%v unable to convert field %q to %v: %v", fieldMapperCastFields, f, toType, err))
+			}
+			data.SetData(f, newVal)
+		}
+		return data
+	}, nil
+}
+
+func setupCastToString(log logger.Logger, cfg map[string]string) castFunc {
+	trueValue, falseValue := "true", "false"
+	if v, ok := cfg["trueValue"]; ok {
+		trueValue = v
+	}
+	if v, ok := cfg["falseValue"]; ok {
+		falseValue = v
+	}
+	return func(v interface{}) (interface{}, error) {
+		if b, ok := v.(bool); ok {
+			if b {
+				return trueValue, nil
+			}
+			return falseValue, nil
+		}
+		return helper.GetStringFromInterfacePreserveTimeZone(log, v), nil
+	}
+}
+
+func castToInt64(v interface{}) (interface{}, error) {
+	r, err := getRatFromInterface(v)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return nil, fmt.Errorf("%v is not a whole number in the range of int64", r.RatString())
+	}
+	return r.Num().Int64(), nil
+}
+
+func castToFloat64(v interface{}) (interface{}, error) {
+	if f, ok := v.(float64); ok {
+		return f, nil
+	}
+	r, err := getRatFromInterface(v)
+	if err != nil {
+		return nil, err
+	}
+	f, _ := r.Float64()
+	return f, nil
+}
+
+func setupCastToDecimal(cfg map[string]string) (castFunc, error) {
+	s, ok := cfg["scale"]
+	if !ok {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: scale", fieldMapperCastFields)
+	}
+	scale, err := strconv.Atoi(s)
+	if err != nil || scale < 0 {
+		return nil, fmt.Errorf("scale must be an integer >= 0, got %q", s)
+	}
+	return func(v interface{}) (interface{}, error) {
+		r, err := getRatFromInterface(v)
+		if err != nil {
+			return nil, err
+		}
+		return r.FloatString(scale), nil // rounds half away from zero.
+	}, nil
+}
+
+// getRatFromInterface returns the exact value of the number in v, which may be an integer, float, boolean or string.
+// Floats are read from their shortest decimal representation so that e.g. 2.675 is not read as 2.67499999...
+func getRatFromInterface(v interface{}) (*big.Rat, error) {
+	var s string
+	switch x := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s = fmt.Sprintf("%d", x)
+	case float32:
+		s = strconv.FormatFloat(float64(x), 'g', -1, 32)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, fmt.Errorf("%v is not a number", x)
+		}
+		s = strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		if x {
+			return big.NewRat(1, 1), nil
+		}
+		return new(big.Rat), nil
+	case string:
+		s = x
+	case []byte:
+		s = string(x)
+	default:
+		return nil, fmt.Errorf("unsupported input type %v", reflect.TypeOf(v))
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil, fmt.Errorf("%q is not a number", s)
+	}
+	return r, nil
+}
+
+func setupCastToTime(cfg map[string]string) (castFunc, error) {
+	getLayout := func(key string, defaultLayout string) string {
+		l, ok := cfg[key]
+		if !ok {
+			return defaultLayout
+		}
+		if named, ok := castTimeLayouts[l]; ok {
+			return named
+		}
+		return l
+	}
+	getLocation := func(key string) (*time.Location, error) {
+		z, ok := cfg[key]
+		if !ok {
+			return nil, nil
+		}
+		loc, err := time.LoadLocation(z)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v %q: %v", key, z, err)
+		}
+		return loc, nil
+	}
+	inputLayout := getLayout("inputLayout", time.RFC3339)
+	outputLayout := getLayout("outputLayout", "")
+	inputLoc, err := getLocation("inputTimeZone")
+	if err != nil {
+		return nil, err
+	}
+	outputLoc, err := getLocation("outputTimeZone")
+	if err != nil {
+		return nil, err
+	}
+	parse := func(s string) (time.Time, error) {
+		if inputLoc != nil {
+			return time.ParseInLocation(inputLayout, strings.TrimSpace(s), inputLoc)
+		}
+		return time.Parse(inputLayout, strings.TrimSpace(s))
+	}
+	return func(v interface{}) (interface{}, error) {
+		var t time.Time
+		var err error
+		switch x := v.(type) {
+		case time.Time:
+			t = x
+			if inputLoc != nil { // if the clock time is in a known time zone...
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), inputLoc)
+			}
+		case string:
+			t, err = parse(x)
+		case []byte:
+			t, err = parse(string(x))
+		default:
+			err = fmt.Errorf("unsupported input type %v", reflect.TypeOf(v))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if outputLoc != nil {
+			t = t.In(outputLoc)
+		}
+		if outputLayout != "" {
+			return t.Format(outputLayout), nil
+		}
+		return t, nil
+	}, nil
+}
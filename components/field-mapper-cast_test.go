@@ -0,0 +1,110 @@
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestCastFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	cast := func(cfg map[string]string, v interface{}) interface{} {
+		cfg["fieldNames"] = "a"
+		fn, err := setupCastFields(log, cfg)
+		if err != nil {
+			t.Fatalf("unexpected error for config %v: %v", cfg, err)
+		}
+		rec := stream.NewRecord()
+		rec.SetData("a", v)
+		return fn(rec).GetData("a")
+	}
+
+	// Test 1 - bad config.
+	for _, cfg := range []map[string]string{
+		{"toType": "string"},
+		{"fieldNames": "a"},
+		{"fieldNames": "a", "toType": "blob"},
+		{"fieldNames": "a", "toType": "decimal"},
+		{"fieldNames": "a", "toType": "decimal", "scale": "-1"},
+		{"fieldNames": "a", "toType": "time", "outputTimeZone": "Nowhere/Special"},
+	} {
+		if _, err := setupCastFields(log, cfg); err == nil {
+			t.Fatalf("Test 1, expected error for config %v", cfg)
+		}
+	}
+
+	// Test 2 - strings and booleans.
+	if got := cast(map[string]string{"toType": "string"}, 12.5); got != "12.5" {
+		t.Fatal("Test 2, unexpected string: ", got)
+	}
+	if got := cast(map[string]string{"toType": "string", "trueValue": "Y", "falseValue": "N"}, false); got != "N" {
+		t.Fatal("Test 2, unexpected boolean string: ", got)
+	}
+	if got := cast(map[string]string{"toType": "string"}, nil); got != nil {
+		t.Fatal("Test 2, expected null to be kept; got ", got)
+	}
+
+	// Test 3 - integers and floats.
+	for _, tc := range []struct {
+		toType   string
+		input    interface{}
+		expected interface{}
+	}{
+		{castTypeInt64, "42", int64(42)},
+		{castTypeInt64, []byte("42.000"), int64(42)},
+		{castTypeInt64, 7.0, int64(7)},
+		{castTypeInt64, true, int64(1)},
+		{castTypeFloat64, "1.25", 1.25},
+		{castTypeFloat64, int32(3), 3.0},
+	} {
+		if got := cast(map[string]string{"toType": tc.toType}, tc.input); got != tc.expected {
+			t.Fatalf("Test 3, casting %v to %v expected %v (%T); got %v (%T)", tc.input, tc.toType, tc.expected, tc.expected, got, got)
+		}
+	}
+
+	// Test 4 - decimals are rounded to scale half away from zero, including floats.
+	for _, tc := range []struct {
+		input    interface{}
+		expected string
+	}{
+		{2.675, "2.68"},
+		{"-2.675", "-2.68"},
+		{[]byte("10"), "10.00"},
+		{"1.004", "1.00"},
+		{int64(-3), "-3.00"},
+	} {
+		if got := cast(map[string]string{"toType": "decimal", "scale": "2"}, tc.input); got != tc.expected {
+			t.Fatalf("Test 4, casting %v to decimal expected %v; got %v", tc.input, tc.expected, got)
+		}
+	}
+
+	// Test 5 - times.
+	// Convert a DATE without a time zone from London to UTC ISO-8601.
+	date := time.Date(2021, 7, 1, 9, 30, 0, 0, time.UTC)
+	cfg := map[string]string{"toType": "time", "inputTimeZone": "Europe/London", "outputTimeZone": "UTC", "outputLayout": "ISO8601"}
+	if got := cast(cfg, date); got != "2021-07-01T08:30:00Z" {
+		t.Fatal("Test 5, unexpected time: ", got)
+	}
+	// Parse a string using a Go layout and keep it as a time.
+	cfg = map[string]string{"toType": "time", "inputLayout": "02/01/2006 15:04", "inputTimeZone": "UTC"}
+	if got := cast(cfg, "25/12/2020 18:00"); got != time.Date(2020, 12, 25, 18, 0, 0, 0, time.UTC) {
+		t.Fatal("Test 5, unexpected time: ", got)
+	}
+	// Parse RFC3339 by default.
+	cfg = map[string]string{"toType": "time", "outputTimeZone": "UTC", "outputLayout": "2006-01-02 15:04:05"}
+	if got := cast(cfg, "2020-01-01T12:00:00+02:00"); got != "2020-01-01 10:00:00" {
+		t.Fatal("Test 5, unexpected time: ", got)
+	}
+
+	// Test 6 - values that cannot be converted cause a panic for the row error handler.
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("Test 6, expected panic for a bad value")
+			}
+		}()
+		cast(map[string]string{"toType": "int64"}, "1.5")
+	}()
+}
@@ -0,0 +1,293 @@
+package components
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// The field mappers in this file protect sensitive data in flight.
+// Each one replaces the values of the fields listed in fieldNames, which is a comma separated list.
+// Null values are left as null. Add one step per field to configure fields differently.
+
+// setupHashFields returns a fieldMapperFunc that replaces the values of fieldNames with the hex encoded
+// SHA-256 hash of salt followed by the value.
+func setupHashFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	errBuilder := strings.Builder{}
+	fieldNames := getFieldNames(cfg["fieldNames"])
+	if len(fieldNames) == 0 {
+		errBuilder.WriteString("fieldNames, ")
+	}
+	salt, ok := cfg["salt"]
+	if !ok || salt == "" {
+		errBuilder.WriteString("salt, ")
+	}
+	if errBuilder.Len() > 0 {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: %v", fieldMapperHashFields, strings.TrimRight(errBuilder.String(), ", "))
+	}
+	return func(data stream.Record) stream.Record {
+		for _, f := range fieldNames { // for each field to hash...
+			if data.GetData(f) == nil {
+				continue
+			}
+			h := sha256.Sum256([]byte(salt + data.GetDataAsStringPreserveTimeZone(log, f)))
+			data.SetData(f, hex.EncodeToString(h[:]))
+		}
+		return data
+	}, nil
+}
+
+// setupMaskFields returns a fieldMapperFunc that replaces the characters of fieldNames with maskChar (default "*"),
+// except for the first keepFirst and last keepLast characters. Values that are not longer than the characters to
+// keep are masked completely.
+func setupMaskFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	errBuilder := strings.Builder{}
+	errMsg := ""
+	fieldNames := getFieldNames(cfg["fieldNames"])
+	if len(fieldNames) == 0 {
+		errBuilder.WriteString("fieldNames, ")
+	}
+	if errBuilder.Len() > 0 {
+		errMsg = fmt.Sprintf("missing field mapper configuration; please supply %v with: %v", fieldMapperMaskFields, strings.TrimRight(errBuilder.String(), ", "))
+	}
+	// Optional config.
+	keep := make(map[string]int)
+	for _, k := range []string{"keepFirst", "keepLast"} {
+		v, ok := cfg[k]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errMsg = fmt.Sprintf("%v must be an integer >= 0, got %q. %v", k, v, errMsg)
+			continue
+		}
+		keep[k] = n
+	}
+	maskChar := '*'
+	if v, ok := cfg["maskChar"]; ok {
+		if utf8.RuneCountInString(v) != 1 {
+			errMsg = fmt.Sprintf("maskChar must be a single character, got %q. %v", v, errMsg)
+		} else {
+			maskChar, _ = utf8.DecodeRuneInString(v)
+		}
+	}
+	if errMsg != "" { // if there was any error above...
+		return nil, errors.New(strings.TrimSpace(errMsg))
+	}
+	keepFirst, keepLast := keep["keepFirst"], keep["keepLast"]
+	return func(data stream.Record) stream.Record {
+		for _, f := range fieldNames { // for each field to mask...
+			if data.GetData(f) == nil {
+				continue
+			}
+			r := []rune(data.GetDataAsStringPreserveTimeZone(log, f))
+			for idx := range r { // for each character...
+				if len(r) <= keepFirst+keepLast || (idx >= keepFirst && idx < len(r)-keepLast) {
+					r[idx] = maskChar
+				}
+			}
+			data.SetData(f, string(r))
+		}
+		return data
+	}, nil
+}
+
+// setupNullifyFields returns a fieldMapperFunc that sets the values of fieldNames to null.
+func setupNullifyFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	fieldNames := getFieldNames(cfg["fieldNames"])
+	if len(fieldNames) == 0 {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: fieldNames", fieldMapperNullifyFields)
+	}
+	return func(data stream.Record) stream.Record {
+		for _, f := range fieldNames { // for each field to nullify...
+			if data.GetData(f) != nil { // if the field exists and has a value...
+				data.SetData(f, nil)
+			}
+		}
+		return data
+	}, nil
+}
+
+// setupTokenizeFields returns a fieldMapperFunc that replaces the values of fieldNames with tokens of the same
+// format: digits 0-9 are replaced by digits, letters A-Z by A-Z and letters a-z by a-z, while all other characters
+// are kept. Tokens are produced by format preserving encryption using FF1 (NIST SP 800-38G) with an AES-256 key
+// derived from secret. The same value always produces the same token, which keeps joins between tokenized tables
+// intact, and different values always produce different tokens, so tokenized keys stay unique.
+// Values with fewer than 6 digits or 5 letters of one case have few possible tokens, which are easier to guess.
+func setupTokenizeFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	errBuilder := strings.Builder{}
+	fieldNames := getFieldNames(cfg["fieldNames"])
+	if len(fieldNames) == 0 {
+		errBuilder.WriteString("fieldNames, ")
+	}
+	secret, ok := cfg["secret"]
+	if !ok || secret == "" {
+		errBuilder.WriteString("secret, ")
+	}
+	if errBuilder.Len() > 0 {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: %v", fieldMapperTokenize, strings.TrimRight(errBuilder.String(), ", "))
+	}
+	key := sha256.Sum256([]byte(secret))
+	f, err := newFF1(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return func(data stream.Record) stream.Record {
+		for _, fld := range fieldNames { // for each field to tokenize...
+			if data.GetData(fld) == nil {
+				continue
+			}
+			data.SetData(fld, tokenize(f, data.GetDataAsStringPreserveTimeZone(log, fld)))
+		}
+		return data
+	}, nil
+}
+
+// tokenClasses are the sets of characters that tokenize replaces, where each one is encrypted separately.
+var tokenClasses = []struct {
+	first rune
+	radix int
+}{
+	{'0', 10},
+	{'A', 26},
+	{'a', 26},
+}
+
+// tokenize returns the format preserving token of s using f. See setupTokenizeFields.
+func tokenize(f *ff1, s string) string {
+	r := []rune(s)
+	// Use the format of s as the tweak so values of different formats are encrypted differently.
+	format := make([]rune, len(r))
+	for idx, c := range r {
+		format[idx] = c
+		for _, tc := range tokenClasses {
+			if c >= tc.first && c < tc.first+rune(tc.radix) {
+				format[idx] = tc.first
+			}
+		}
+	}
+	for classIdx, tc := range tokenClasses { // for each class of characters...
+		positions := make([]int, 0, len(r))
+		numerals := make([]int, 0, len(r))
+		for idx, c := range r {
+			if c >= tc.first && c < tc.first+rune(tc.radix) {
+				positions = append(positions, idx)
+				numerals = append(numerals, int(c-tc.first))
+			}
+		}
+		if len(numerals) == 0 {
+			continue
+		}
+		tweak := append([]byte{byte(classIdx)}, string(format)...)
+		for idx, n := range f.encrypt(tweak, tc.radix, numerals) {
+			r[positions[idx]] = tc.first + rune(n)
+		}
+	}
+	return string(r)
+}
+
+// ff1 implements the FF1 format preserving encryption algorithm of NIST SP 800-38G.
+type ff1 struct {
+	block cipher.Block
+}
+
+func newFF1(key []byte) (*ff1, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ff1{block: block}, nil
+}
+
+// encrypt returns the FF1 encryption of numeral string x, in the given radix, using tweak.
+func (f *ff1) encrypt(tweak []byte, radix int, x []int) []int {
+	n := len(x)
+	u := n / 2
+	v := n - u
+	bigRadix := big.NewInt(int64(radix))
+	num := func(x []int) *big.Int {
+		y := new(big.Int)
+		for _, d := range x {
+			y.Mul(y, bigRadix).Add(y, big.NewInt(int64(d)))
+		}
+		return y
+	}
+	modulus := map[int]*big.Int{
+		u: new(big.Int).Exp(bigRadix, big.NewInt(int64(u)), nil),
+		v: new(big.Int).Exp(bigRadix, big.NewInt(int64(v)), nil),
+	}
+	b := (new(big.Int).Sub(modulus[v], big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((b+3)/4) + 4
+	// P = [1, 2, 1] || [radix]^3 || [10] || [u mod 256] || [n]^4 || [t]^4.
+	p := []byte{1, 2, 1, byte(radix >> 16), byte(radix >> 8), byte(radix), 10, byte(u)}
+	p = append(p, make([]byte, 8)...)
+	binary.BigEndian.PutUint32(p[8:], uint32(n))
+	binary.BigEndian.PutUint32(p[12:], uint32(len(tweak)))
+	// Q = T || [0]^((-t-b-1) mod 16) || [i] || [NUM(B)]^b.
+	padLen := (16 - (len(tweak)+b+1)%16) % 16
+	q := make([]byte, len(tweak)+padLen+1+b)
+	copy(q, tweak)
+	a, bb := x[:u], x[u:]
+	s := make([]byte, ((d+15)/16)*16)
+	y := new(big.Int)
+	for i := 0; i < 10; i++ {
+		q[len(tweak)+padLen] = byte(i)
+		num(bb).FillBytes(q[len(q)-b:])
+		// R = PRF(P || Q) using AES-CBC-MAC.
+		r := make([]byte, 16)
+		for _, msg := range [][]byte{p, q} {
+			for j := 0; j < len(msg); j += 16 {
+				for k := 0; k < 16; k++ {
+					r[k] ^= msg[j+k]
+				}
+				f.block.Encrypt(r, r)
+			}
+		}
+		// S = R || CIPH(R xor [1]^16) || CIPH(R xor [2]^16) ..., truncated to d bytes.
+		copy(s, r)
+		for j := 1; j*16 < d; j++ {
+			blk := s[j*16 : j*16+16]
+			copy(blk, r)
+			binary.BigEndian.PutUint64(blk[8:], binary.BigEndian.Uint64(r[8:])^uint64(j))
+			f.block.Encrypt(blk, blk)
+		}
+		y.SetBytes(s[:d])
+		m := u
+		if i%2 == 1 {
+			m = v
+		}
+		c := new(big.Int).Add(num(a), y)
+		c.Mod(c, modulus[m])
+		// C = STR(c) with m numerals.
+		cs := make([]int, m)
+		for j := m - 1; j >= 0; j-- {
+			cs[j] = int(new(big.Int).Mod(c, bigRadix).Int64())
+			c.Div(c, bigRadix)
+		}
+		a, bb = bb, cs
+	}
+	return append(append(make([]int, 0, n), a...), bb...)
+}
+
+// getFieldNames returns the field names found in comma separated list s.
+func getFieldNames(s string) []string {
+	retval := make([]string, 0)
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			retval = append(retval, f)
+		}
+	}
+	return retval
+}
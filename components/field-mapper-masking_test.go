@@ -0,0 +1,194 @@
+package components
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func newMaskingTestRecord() stream.Record {
+	rec := stream.NewRecord()
+	rec.SetData("name", "Jane Smith")
+	rec.SetData("card", "4111-1111-1111-1234")
+	rec.SetData("age", 42)
+	rec.SetData("empty", nil)
+	return rec
+}
+
+func TestHashFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	// Test 1 - salt and fieldNames are mandatory.
+	if _, err := setupHashFields(log, map[string]string{"fieldNames": "name"}); err == nil {
+		t.Fatal("Test 1, expected error due to missing salt")
+	}
+	if _, err := setupHashFields(log, map[string]string{"salt": "x", "fieldNames": " , "}); err == nil {
+		t.Fatal("Test 1, expected error due to missing fieldNames")
+	}
+	// Test 2 - fields are replaced by the salted hash and nulls are kept.
+	fn, err := setupHashFields(log, map[string]string{"fieldNames": "name, age, empty", "salt": "pepper"})
+	if err != nil {
+		t.Fatal("Test 2, unexpected error: ", err)
+	}
+	rec := fn(newMaskingTestRecord())
+	h := sha256.Sum256([]byte("pepperJane Smith"))
+	if got := rec.GetData("name"); got != hex.EncodeToString(h[:]) {
+		t.Fatal("Test 2, unexpected hash of name: ", got)
+	}
+	h = sha256.Sum256([]byte("pepper42"))
+	if got := rec.GetData("age"); got != hex.EncodeToString(h[:]) {
+		t.Fatal("Test 2, unexpected hash of age: ", got)
+	}
+	if rec.GetData("empty") != nil || rec.GetData("card") != "4111-1111-1111-1234" {
+		t.Fatal("Test 2, expected other fields to be unchanged")
+	}
+}
+
+func TestMaskFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	// Test 1 - bad config.
+	for _, cfg := range []map[string]string{
+		{},
+		{"fieldNames": "card", "keepLast": "-1"},
+		{"fieldNames": "card", "keepFirst": "x"},
+		{"fieldNames": "card", "maskChar": "##"},
+	} {
+		if _, err := setupMaskFields(log, cfg); err == nil {
+			t.Fatalf("Test 1, expected error for config %v", cfg)
+		}
+	}
+	// Test 2 - keep the last characters.
+	fn, err := setupMaskFields(log, map[string]string{"fieldNames": "card,empty", "keepLast": "4"})
+	if err != nil {
+		t.Fatal("Test 2, unexpected error: ", err)
+	}
+	rec := fn(newMaskingTestRecord())
+	if got := rec.GetData("card"); got != "***************1234" {
+		t.Fatal("Test 2, unexpected mask: ", got)
+	}
+	if rec.GetData("empty") != nil {
+		t.Fatal("Test 2, expected null to be kept")
+	}
+	// Test 3 - keep the first characters using another mask character.
+	fn, err = setupMaskFields(log, map[string]string{"fieldNames": "name", "keepFirst": "1", "maskChar": "x"})
+	if err != nil {
+		t.Fatal("Test 3, unexpected error: ", err)
+	}
+	if got := fn(newMaskingTestRecord()).GetData("name"); got != "Jxxxxxxxxx" {
+		t.Fatal("Test 3, unexpected mask: ", got)
+	}
+	// Test 4 - short values are masked completely.
+	fn, err = setupMaskFields(log, map[string]string{"fieldNames": "age", "keepFirst": "1", "keepLast": "1"})
+	if err != nil {
+		t.Fatal("Test 4, unexpected error: ", err)
+	}
+	if got := fn(newMaskingTestRecord()).GetData("age"); got != "**" {
+		t.Fatal("Test 4, unexpected mask: ", got)
+	}
+}
+
+func TestNullifyFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	if _, err := setupNullifyFields(log, map[string]string{}); err == nil {
+		t.Fatal("expected error due to missing fieldNames")
+	}
+	fn, err := setupNullifyFields(log, map[string]string{"fieldNames": "name,empty"})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	rec := fn(newMaskingTestRecord())
+	if rec.GetData("name") != nil || rec.GetData("empty") != nil || rec.GetData("age") != 42 {
+		t.Fatal("unexpected record after nullify: ", rec.GetDataMap())
+	}
+}
+
+func TestTokenizeFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	// Test 1 - secret is mandatory.
+	if _, err := setupTokenizeFields(log, map[string]string{"fieldNames": "card"}); err == nil {
+		t.Fatal("Test 1, expected error due to missing secret")
+	}
+	// Test 2 - tokens keep the format of the input.
+	fn, err := setupTokenizeFields(log, map[string]string{"fieldNames": "name,card,empty", "secret": "s3cret"})
+	if err != nil {
+		t.Fatal("Test 2, unexpected error: ", err)
+	}
+	rec := fn(newMaskingTestRecord())
+	name, card := rec.GetData("name").(string), rec.GetData("card").(string)
+	if !regexp.MustCompile(`^[A-Z][a-z]{3} [A-Z][a-z]{4}$`).MatchString(name) || name == "Jane Smith" {
+		t.Fatal("Test 2, unexpected token for name: ", name)
+	}
+	if !regexp.MustCompile(`^\d{4}-\d{4}-\d{4}-\d{4}$`).MatchString(card) || card == "4111-1111-1111-1234" {
+		t.Fatal("Test 2, unexpected token for card: ", card)
+	}
+	if rec.GetData("empty") != nil {
+		t.Fatal("Test 2, expected null to be kept")
+	}
+	// Test 3 - tokens are deterministic per secret.
+	if got := fn(newMaskingTestRecord()).GetData("card"); got != card {
+		t.Fatalf("Test 3, expected the same token; got %v and %v", card, got)
+	}
+	fn, _ = setupTokenizeFields(log, map[string]string{"fieldNames": "card", "secret": "other"})
+	if got := fn(newMaskingTestRecord()).GetData("card"); got == card {
+		t.Fatal("Test 3, expected a different token for a different secret")
+	}
+	tokenizeCard := func(v string) string {
+		rec := stream.NewRecord()
+		rec.SetData("card", v)
+		return fn(rec).GetData("card").(string)
+	}
+	// Test 4 - long values keep their format.
+	if got := tokenizeCard(strings.Repeat("7", 100)); len(got) != 100 || !regexp.MustCompile(`^\d+$`).MatchString(got) {
+		t.Fatal("Test 4, unexpected token for long value: ", got)
+	}
+	// Test 5 - different values produce different tokens, including short values.
+	tokens := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		v := fmt.Sprintf("%03d", i)
+		got := tokenizeCard(v)
+		if prev, ok := tokens[got]; ok {
+			t.Fatalf("Test 5, values %v and %v produced the same token %v", prev, v, got)
+		}
+		tokens[got] = v
+	}
+}
+
+func TestFF1(t *testing.T) {
+	// NIST SP 800-38G FF1 samples 1 to 3.
+	key, _ := hex.DecodeString("2B7E151628AED2A6ABF7158809CF4F3C")
+	f, err := newFF1(key)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	cases := []struct {
+		tweak    string
+		radix    int
+		input    string
+		expected string
+	}{
+		{"", 10, "0123456789", "2433477484"},
+		{"39383736353433323130", 10, "0123456789", "6124200773"},
+		{"3737373770717273373737", 36, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+	}
+	for idx, c := range cases {
+		tweak, _ := hex.DecodeString(c.tweak)
+		x := make([]int, len(c.input))
+		for i, ch := range c.input {
+			n, _ := strconv.ParseInt(string(ch), 36, 64)
+			x[i] = int(n)
+		}
+		got := strings.Builder{}
+		for _, n := range f.encrypt(tweak, c.radix, x) {
+			got.WriteString(strconv.FormatInt(int64(n), 36))
+		}
+		if got.String() != c.expected {
+			t.Fatalf("case %v expected %v; got %v", idx+1, c.expected, got.String())
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// The field mappers in this file reshape records, e.g. to match the columns of a target table.
+// Fields named in the config must exist in each record, so that mistakes in the pipe are reported.
+
+// setupRenameFields returns a fieldMapperFunc that renames fields using fieldNames, which is a comma separated list
+// of old:new name pairs. Fields are renamed together, so names can be swapped.
+// It is an error if a new name matches an existing field that is not being renamed.
+func setupRenameFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	pairs := getFieldNames(cfg["fieldNames"])
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: fieldNames", fieldMapperRenameFields)
+	}
+	oldNames := make([]string, 0, len(pairs))
+	newNames := make([]string, 0, len(pairs))
+	renamed := make(map[string]bool)
+	seenNew := make(map[string]bool)
+	for _, p := range pairs { // for each old:new pair...
+		x := strings.Split(p, ":")
+		if len(x) != 2 || strings.TrimSpace(x[0]) == "" || strings.TrimSpace(x[1]) == "" {
+			return nil, fmt.Errorf("%v fieldNames must be a comma separated list of old:new names, got %q", fieldMapperRenameFields, p)
+		}
+		o, n := strings.TrimSpace(x[0]), strings.TrimSpace(x[1])
+		if renamed[o] || seenNew[n] {
+			return nil, fmt.Errorf("%v fieldNames contains duplicate names in %q", fieldMapperRenameFields, p)
+		}
+		renamed[o], seenNew[n] = true, true
+		oldNames = append(oldNames, o)
+		newNames = append(newNames, n)
+	}
+	values := make([]interface{}, len(oldNames))
+	return func(data stream.Record) stream.Record {
+		for idx, o := range oldNames { // for each field to rename...
+			values[idx] = data.GetData(o)
+		}
+		for idx, n := range newNames { // for each new name...
+			if _, exists := data.GetDataMap()[n]; exists && !renamed[n] {
+				log.Panic(fmt.Sprintf("%v unable to rename field %q to %q since the field already exists", fieldMapperRenameFields, oldNames[idx], n))
+			}
+		}
+		for _, o := range oldNames {
+			data.DeleteData(o)
+		}
+		for idx, n := range newNames {
+			data.SetData(n, values[idx])
+		}
+		return data
+	}, nil
+}
+
+// setupDropFields returns a fieldMapperFunc that removes the fields in fieldNames, a comma separated list.
+func setupDropFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	fieldNames := getFieldNames(cfg["fieldNames"])
+	if len(fieldNames) == 0 {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: fieldNames", fieldMapperDropFields)
+	}
+	return func(data stream.Record) stream.Record {
+		for _, f := range fieldNames { // for each field to drop...
+			_ = data.GetData(f) // panic if the field doesn't exist.
+			data.DeleteData(f)
+		}
+		return data
+	}, nil
+}
+
+// setupSelectFields returns a fieldMapperFunc that removes all fields except those in fieldNames, a comma
+// separated list.
+func setupSelectFields(log logger.Logger, cfg map[string]string) (fieldMapperFunc, error) {
+	fieldNames := getFieldNames(cfg["fieldNames"])
+	if len(fieldNames) == 0 {
+		return nil, fmt.Errorf("missing field mapper configuration; please supply %v with: fieldNames", fieldMapperSelectFields)
+	}
+	keep := make(map[string]bool)
+	for _, f := range fieldNames {
+		keep[f] = true
+	}
+	return func(data stream.Record) stream.Record {
+		for _, f := range fieldNames { // for each field to keep...
+			_ = data.GetData(f) // panic if the field doesn't exist.
+		}
+		for k := range data.GetDataMap() { // for each field in the record...
+			if !keep[k] {
+				data.DeleteData(k)
+			}
+		}
+		return data
+	}, nil
+}
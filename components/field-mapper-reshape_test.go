@@ -0,0 +1,86 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func newReshapeTestRecord() stream.Record {
+	rec := stream.NewRecord()
+	rec.SetData("CUST_ID", 1)
+	rec.SetData("CUST_NAME", "Jane")
+	rec.SetData("A", "a")
+	rec.SetData("B", "b")
+	return rec
+}
+
+func assertPanics(t *testing.T, msg string, fn func()) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal(msg)
+		}
+	}()
+	fn()
+}
+
+func TestRenameFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	// Test 1 - bad config.
+	for _, fieldNames := range []string{"", "CUST_ID", "CUST_ID:", "A:x,A:y", "A:x,B:x"} {
+		if _, err := setupRenameFields(log, map[string]string{"fieldNames": fieldNames}); err == nil {
+			t.Fatalf("Test 1, expected error for fieldNames %q", fieldNames)
+		}
+	}
+	// Test 2 - rename and swap fields.
+	fn, err := setupRenameFields(log, map[string]string{"fieldNames": "CUST_ID:id, CUST_NAME:name, A:B, B:A"})
+	if err != nil {
+		t.Fatal("Test 2, unexpected error: ", err)
+	}
+	got := fn(newReshapeTestRecord()).GetDataMap()
+	expected := map[string]interface{}{"id": 1, "name": "Jane", "A": "b", "B": "a"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Test 2, expected %v; got %v", expected, got)
+	}
+	// Test 3 - renaming to an existing field or from a missing field panics.
+	fn, _ = setupRenameFields(log, map[string]string{"fieldNames": "CUST_ID:A"})
+	assertPanics(t, "Test 3, expected panic renaming to an existing field", func() { fn(newReshapeTestRecord()) })
+	fn, _ = setupRenameFields(log, map[string]string{"fieldNames": "missing:x"})
+	assertPanics(t, "Test 3, expected panic renaming a missing field", func() { fn(newReshapeTestRecord()) })
+}
+
+func TestDropFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	if _, err := setupDropFields(log, map[string]string{}); err == nil {
+		t.Fatal("expected error due to missing fieldNames")
+	}
+	fn, err := setupDropFields(log, map[string]string{"fieldNames": "A,B"})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	got := fn(newReshapeTestRecord()).GetDataMap()
+	if expected := map[string]interface{}{"CUST_ID": 1, "CUST_NAME": "Jane"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v; got %v", expected, got)
+	}
+	fn, _ = setupDropFields(log, map[string]string{"fieldNames": "missing"})
+	assertPanics(t, "expected panic dropping a missing field", func() { fn(newReshapeTestRecord()) })
+}
+
+func TestSelectFields(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	if _, err := setupSelectFields(log, map[string]string{}); err == nil {
+		t.Fatal("expected error due to missing fieldNames")
+	}
+	fn, err := setupSelectFields(log, map[string]string{"fieldNames": "CUST_NAME, A"})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	got := fn(newReshapeTestRecord()).GetDataMap()
+	if expected := map[string]interface{}{"CUST_NAME": "Jane", "A": "a"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v; got %v", expected, got)
+	}
+	fn, _ = setupSelectFields(log, map[string]string{"fieldNames": "A,missing"})
+	assertPanics(t, "expected panic selecting a missing field", func() { fn(newReshapeTestRecord()) })
+}
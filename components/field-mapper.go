@@ -27,6 +27,14 @@ const (
 	fieldMapperRegexpReplace = "RegexpReplace"
 	fieldMapperConcatenateAB = "ConcatenateFieldsAB"
 	fieldMapperJsonLogic     = "JsonLogic"
+	fieldMapperHashFields    = "HashFields"
+	fieldMapperMaskFields    = "MaskFields"
+	fieldMapperNullifyFields = "NullifyFields"
+	fieldMapperTokenize      = "TokenizeFields"
+	fieldMapperCastFields    = "CastFields"
+	fieldMapperRenameFields  = "RenameFields"
+	fieldMapperDropFields    = "DropFields"
+	fieldMapperSelectFields  = "SelectFields"
 )
 
 var fieldMappers = mapFieldMappers{
@@ -34,24 +42,37 @@ var fieldMappers = mapFieldMappers{
 	fieldMapperRegexpReplace: setupRegexpReplace,
 	fieldMapperConcatenateAB: setupConcatenateAB,
 	fieldMapperJsonLogic:     setupJsonLogicMapper,
+	fieldMapperHashFields:    setupHashFields,
+	fieldMapperMaskFields:    setupMaskFields,
+	fieldMapperNullifyFields: setupNullifyFields,
+	fieldMapperTokenize:      setupTokenizeFields,
+	fieldMapperCastFields:    setupCastFields,
+	fieldMapperRenameFields:  setupRenameFields,
+	fieldMapperDropFields:    setupDropFields,
+	fieldMapperSelectFields:  setupSelectFields,
 }
 
 type FieldMapperConfig struct {
-	Log            logger.Logger
-	Name           string
-	InputChan      chan stream.Record // input channel containing time.Time
-	Steps          []ComponentStep
-	StepWatcher    *stats.StepWatcher
-	WaitCounter    ComponentWaiter
-	PanicHandlerFn PanicHandlerFunc
+	Log             logger.Logger
+	Name            string
+	InputChan       chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // input channel containing time.Time
+	Steps           []ComponentStep
+	StepWatcher     *stats.StepWatcher
+	WaitCounter     ComponentWaiter
+	PanicHandlerFn  PanicHandlerFunc
+	RowErrorHandler *RowErrorHandler // optional handler for records that cause a mapper to fail; defaults to fail.
 }
 
 // NewFieldMapper uses FieldMapperConfig to map fields in records read from InputChan.
 // Supply a slice of map step actions in cfg.Steps, where:
 // Steps.Type is one of the entries in mapFieldMappers to lookup a map function.
 // Steps.Data is a map of further config values to supply to the chosen map function.
+// Records that cause a mapper to panic are passed to cfg.RowErrorHandler.
 func NewFieldMapper(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
 	cfg := i.(*FieldMapperConfig)
+	if cfg.RowErrorHandler == nil {
+		cfg.RowErrorHandler, _ = NewRowErrorHandler(cfg.Log, cfg.Name, OnErrorFail, 0)
+	}
 	// Setup all field mapper functions.
 	mappers := make([]fieldMapperFunc, len(cfg.Steps), len(cfg.Steps))
 	var err error
@@ -94,9 +115,15 @@ func NewFieldMapper(i interface{}) (outputChan chan stream.Record, controlChan c
 					cfg.InputChan = nil // disable this case.
 				} else { // else we have input to process...
 					// Apply the mappers and output a row.
-					for idx := range mappers { // for each mapper function...
-						// Apply the mapper to mutate the input record.
-						rec = mappers[idx](rec)
+					err := cfg.RowErrorHandler.Catch(func() {
+						for idx := range mappers { // for each mapper function...
+							// Apply the mapper to mutate the input record.
+							rec = mappers[idx](rec)
+						}
+					})
+					if err != nil { // if a mapper failed...
+						cfg.RowErrorHandler.Reject(rec, err)
+						break // skip to the next record.
 					}
 					// Richard 20200911 - disable check for nil since we converted to pure struct:
 					// if rec != nil { // if the record exists...
@@ -108,6 +135,9 @@ func NewFieldMapper(i interface{}) (outputChan chan stream.Record, controlChan c
 					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
 				}
 			case controlAction = <-controlChan: // if we were asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 			}
 			if cfg.InputChan == nil || controlAction.Action == Shutdown {
 				break
@@ -118,6 +148,7 @@ func NewFieldMapper(i interface{}) (outputChan chan stream.Record, controlChan c
 			cfg.Log.Info(cfg.Name, " shutdown")
 			return
 		} else { // else we ran out of rows to process...
+			cfg.RowErrorHandler.Close()
 			close(outputChan) // we're done so close the channel we created.
 			cfg.Log.Info(cfg.Name, " complete")
 		}
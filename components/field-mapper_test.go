@@ -397,3 +397,51 @@ func TestNewFieldMapper(t *testing.T) {
 		t.Fatal("Test 20, json logic failed to create new field: got: ", got)
 	}
 }
+
+func TestFieldMapperDeadLetter(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	h, _ := NewRowErrorHandler(log, "testDeadLetter", OnErrorDeadLetter, 0)
+	inputChan := make(chan stream.Record, 5)
+	cfg := &FieldMapperConfig{
+		Log:       log,
+		Name:      "testDeadLetter",
+		InputChan: inputChan,
+		Steps: []ComponentStep{{Type: fieldMapperRegexpReplace, Data: map[string]string{
+			"fieldName":     "fieldA",
+			"regexpMatch":   ".+(1234).+",
+			"regexpReplace": "$1",
+			"resultField":   "outputA",
+		}}},
+		RowErrorHandler: h,
+	}
+	good := stream.NewRecord()
+	good.SetData("fieldA", "abc_1234_abc")
+	bad := stream.NewRecord()
+	bad.SetData("fieldB", "abc_1234_abc") // fieldA is missing.
+	inputChan <- bad
+	inputChan <- good
+	close(inputChan)
+	outputChan, _ := NewFieldMapper(cfg)
+	// Test 1 - the good record is output.
+	count := 0
+	for rec := range outputChan {
+		count++
+		if rec.GetData("outputA") != "1234" {
+			t.Fatal("Test 1, unexpected output ", rec.GetDataMap())
+		}
+	}
+	if count != 1 {
+		t.Fatal("Test 1, expected 1 record; got ", count)
+	}
+	// Test 2 - the bad record is sent to the dead-letter channel.
+	count = 0
+	for rec := range h.DeadLetterChan() {
+		count++
+		if rec.GetData("fieldB") != "abc_1234_abc" || rec.GetData(Defaults.ChanField4ErrorStep) != "testDeadLetter" || rec.GetData(Defaults.ChanField4ErrorText) == "" {
+			t.Fatal("Test 2, unexpected dead letter ", rec.GetDataMap())
+		}
+	}
+	if count != 1 {
+		t.Fatal("Test 2, expected 1 dead letter; got ", count)
+	}
+}
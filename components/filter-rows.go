@@ -44,9 +44,9 @@ var (
 type FilterRowsConfig struct {
 	Log            log.Logger
 	Name           string
-	InputChan      chan stream.Record // input channel containing time.Time
-	FilterType     FilterType         // one of the keys in the filterTypes map.
-	FilterMetadata FilterMetadata     // the field found in stream.StreamRecordIface data map to operate on.
+	InputChan      chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // input channel containing time.Time
+	FilterType     FilterType         `data:"filterType" mandatory:"yes"`       // one of the keys in the filterTypes map.
+	FilterMetadata FilterMetadata     `data:"filterMetadata"`                   // the field found in stream.StreamRecordIface data map to operate on.
 	StepWatcher    *stats.StepWatcher
 	WaitCounter    ComponentWaiter
 	PanicHandlerFn PanicHandlerFunc
@@ -103,6 +103,9 @@ func NewFilterRows(i interface{}) (outputChan chan stream.Record, controlChan ch
 					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
 				}
 			case controlAction = <-controlChan: // if we were asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 			}
 			if cfg.InputChan == nil || controlAction.Action == Shutdown {
 				break
@@ -16,15 +16,15 @@ import (
 type DateRangeGeneratorConfig struct {
 	Log                         log.Logger
 	Name                        string
-	InputChan                   chan stream.Record // input channel containing time.Time
-	InputChanFieldName4FromDate string             // name of the field on InputChan which contains the FromDate values expected to be of type time.Time.
-	InputChanFieldName4ToDate   string             // name of the field on InputChan which contains the ToDate values expected to be of type time.Time. This takes precedence over use of field ToDateRFC3339orNow.
-	ToDateRFC3339orNow          string             // either supply "now" or a date in RFC3339 format which includes a time zone offset. If used, "now" will be truncated to the nearest second.
-	UseUTC                      bool               // if true then the date generated by supplying "now" will be in UTC; else we expect local times.
-	IntervalSizeSeconds         int                // number of seconds to split the duration between FromDate and ToDate into.
-	OutputChanFieldName4LowDate string
-	OutputChanFieldName4HiDate  string
-	PassInputFieldsToOutput     bool
+	InputChan                   chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // input channel containing time.Time
+	InputChanFieldName4FromDate string             `data:"inputFieldName4FromDate"`          // name of the field on InputChan which contains the FromDate values expected to be of type time.Time.
+	InputChanFieldName4ToDate   string             `data:"inputFieldName4ToDate"`            // name of the field on InputChan which contains the ToDate values expected to be of type time.Time. This takes precedence over use of field ToDateRFC3339orNow.
+	ToDateRFC3339orNow          string             `data:"toDate"`                           // either supply "now" or a date in RFC3339 format which includes a time zone offset. If used, "now" will be truncated to the nearest second.
+	UseUTC                      bool               `data:"useUTC"`                           // if true then the date generated by supplying "now" will be in UTC; else we expect local times.
+	IntervalSizeSeconds         int                `data:"intervalSeconds" mandatory:"yes"`  // number of seconds to split the duration between FromDate and ToDate into.
+	OutputChanFieldName4LowDate string             `data:"outputFieldName4LowDate"`
+	OutputChanFieldName4HiDate  string             `data:"outputFieldName4HiDate"`
+	PassInputFieldsToOutput     bool               `data:"passInputFieldsToOutput"`
 	StepWatcher                 *stats.StepWatcher
 	WaitCounter                 ComponentWaiter
 	PanicHandlerFn              PanicHandlerFunc
@@ -93,52 +93,59 @@ func NewDateRangeGenerator(i interface{}) (outputChan chan stream.Record, contro
 			}
 			return
 		}
-		select {
-		case controlAction := <-controlChan: // if we have been asked to shutdown...
-			controlAction.ResponseChan <- nil // respond that we're done with a nil error.
-			cfg.Log.Info(cfg.Name, " shutdown")
-			return
-		case rec, ok := <-cfg.InputChan: // for each FromDate record...
-			if !ok { // if the input chan was closed...
-				cfg.InputChan = nil // disable this case.
-			} else {
-				// Get the toDate.
-				if cfg.InputChanFieldName4ToDate != "" {
-					var castOK bool
-					toDate, castOK = rec.GetData(cfg.InputChanFieldName4ToDate).(time.Time)
-					if !castOK {
-						cfg.Log.Panic(cfg.Name, " unexpected datatype for input field name ", cfg.InputChanFieldName4ToDate, ", expected time.Time")
-					}
-				}
-				cfg.Log.Info(cfg.Name, " splitting date range ", rec.GetData(cfg.InputChanFieldName4FromDate), " to ", toDate, " using interval ", cfg.IntervalSizeSeconds, " seconds")
-				// Get the FromDate.
-				fromDate, err := getTimeFromInterface(rec.GetData(cfg.InputChanFieldName4FromDate))
-				if err != nil {
-					cfg.Log.Panic(cfg.Name, " error - ", err)
+		for resumed := true; resumed; { // until we have processed input or been asked to shutdown...
+			resumed = false
+			select {
+			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					resumed = true // wait for input again.
+					break
 				}
-				// Add the increment and emit rows until it is greater than the ToDate.
-				for { // while we are outputting less than ToDate...
-					to := fromDate.Add(time.Second * time.Duration(cfg.IntervalSizeSeconds))
-					if to.After(toDate) { // if this increment overruns the max date...
-						break // don't output a row!
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			case rec, ok := <-cfg.InputChan: // for each FromDate record...
+				if !ok { // if the input chan was closed...
+					cfg.InputChan = nil // disable this case.
+				} else {
+					// Get the toDate.
+					if cfg.InputChanFieldName4ToDate != "" {
+						var castOK bool
+						toDate, castOK = rec.GetData(cfg.InputChanFieldName4ToDate).(time.Time)
+						if !castOK {
+							cfg.Log.Panic(cfg.Name, " unexpected datatype for input field name ", cfg.InputChanFieldName4ToDate, ", expected time.Time")
+						}
 					}
-					if rowSentOK := sendRow(rec, &fromDate, &to); !rowSentOK {
-						return
+					cfg.Log.Info(cfg.Name, " splitting date range ", rec.GetData(cfg.InputChanFieldName4FromDate), " to ", toDate, " using interval ", cfg.IntervalSizeSeconds, " seconds")
+					// Get the FromDate.
+					fromDate, err := getTimeFromInterface(rec.GetData(cfg.InputChanFieldName4FromDate))
+					if err != nil {
+						cfg.Log.Panic(cfg.Name, " error - ", err)
 					}
-					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
-					fromDate = to                 // save FromDate with increment added.
-				}
-				if fromDate.Before(toDate) || atomic.AddInt64(&rowCount, 0) == 0 {
-					// if we have a final portion of time time to output a row for;
-					// or we have not output a row (i.e. when min value = max value)...
-					if rowSentOK := sendRow(rec, &fromDate, &toDate); !rowSentOK { // emit the final gap.
-						return
+					// Add the increment and emit rows until it is greater than the ToDate.
+					for { // while we are outputting less than ToDate...
+						to := fromDate.Add(time.Second * time.Duration(cfg.IntervalSizeSeconds))
+						if to.After(toDate) { // if this increment overruns the max date...
+							break // don't output a row!
+						}
+						if rowSentOK := sendRow(rec, &fromDate, &to); !rowSentOK {
+							return
+						}
+						atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+						fromDate = to                 // save FromDate with increment added.
+					}
+					if fromDate.Before(toDate) || atomic.AddInt64(&rowCount, 0) == 0 {
+						// if we have a final portion of time time to output a row for;
+						// or we have not output a row (i.e. when min value = max value)...
+						if rowSentOK := sendRow(rec, &fromDate, &toDate); !rowSentOK { // emit the final gap.
+							return
+						}
+						atomic.AddInt64(&rowCount, 1) // add a row count.
 					}
-					atomic.AddInt64(&rowCount, 1) // add a row count.
 				}
-			}
-			if cfg.InputChan == nil { // if we processed all data...
-				break // end gracefully.
+				if cfg.InputChan == nil { // if we processed all data...
+					break // end gracefully.
+				}
 			}
 		}
 		// Calculate output.
@@ -15,14 +15,14 @@ import (
 type NumberRangeGeneratorConfig struct {
 	Log                         log.Logger
 	Name                        string
-	InputChan                   chan stream.Record // input channel containing low and high numbers to split by IntervalSize
-	InputChanFieldName4LowNum   string             // name of the field on InputChan which contains the Low value ,expected to be of type int
-	InputChanFieldName4HighNum  string             // name of the field on InputChan which contains the Hi value, expected to be of type int
-	IntervalSize                float64            // number of units to split the difference between LowNum and HighNum into
-	OutputLeftPaddedNumZeros    int
-	OutputChanFieldName4LowNum  string
-	OutputChanFieldName4HighNum string
-	PassInputFieldsToOutput     bool
+	InputChan                   chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // input channel containing low and high numbers to split by IntervalSize
+	InputChanFieldName4LowNum   string             `data:"inputFieldName4LowNum"`            // name of the field on InputChan which contains the Low value ,expected to be of type int
+	InputChanFieldName4HighNum  string             `data:"inputFieldName4HighNum"`           // name of the field on InputChan which contains the Hi value, expected to be of type int
+	IntervalSize                float64            `data:"intervalSize" mandatory:"yes"`     // number of units to split the difference between LowNum and HighNum into
+	OutputLeftPaddedNumZeros    int                `data:"outputLeftPaddedNumZeros"`
+	OutputChanFieldName4LowNum  string             `data:"outputFieldName4LowNum"`
+	OutputChanFieldName4HighNum string             `data:"outputFieldName4HighNum"`
+	PassInputFieldsToOutput     bool               `data:"passInputFieldsToOutput"`
 	StepWatcher                 *stats.StepWatcher
 	WaitCounter                 ComponentWaiter
 	PanicHandlerFn              PanicHandlerFunc
@@ -74,57 +74,64 @@ func NewNumberRangeGenerator(i interface{}) (outputChan chan stream.Record, cont
 			}
 			return
 		}
-		select {
-		case controlAction := <-controlChan: // if we have been asked to shutdown...
-			controlAction.ResponseChan <- nil // respond that we're done with a nil error.
-			cfg.Log.Info(cfg.Name, " shutdown")
-			return
-		case rec, ok := <-cfg.InputChan: // for each FromDate record...
-			if !ok { // if the input chan was closed...
-				cfg.InputChan = nil // disable this case.
-			} else {
-				cfg.Log.Info(cfg.Name, " splitting number range ", rec.GetData(cfg.InputChanFieldName4LowNum), " to ", rec.GetData(cfg.InputChanFieldName4HighNum), " using interval value ", cfg.IntervalSize)
-				// Get the FromDate and ToDate as strings.
-				fromNumStr := rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.InputChanFieldName4LowNum)
-				toNumStr := rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.InputChanFieldName4HighNum)
-				// Convert to float(64)
-				fromNum, err := strconv.ParseFloat(fromNumStr, 64)
-				if err != nil {
-					cfg.Log.Panic(cfg.Name, " error parsing input field for low number: ", err)
-				}
-				toNum, err := strconv.ParseFloat(toNumStr, 64)
-				if err != nil {
-					cfg.Log.Panic(cfg.Name, " error parsing input field for high number: ", err)
+		for resumed := true; resumed; { // until we have processed input or been asked to shutdown...
+			resumed = false
+			select {
+			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					resumed = true // wait for input again.
+					break
 				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			case rec, ok := <-cfg.InputChan: // for each FromDate record...
+				if !ok { // if the input chan was closed...
+					cfg.InputChan = nil // disable this case.
+				} else {
+					cfg.Log.Info(cfg.Name, " splitting number range ", rec.GetData(cfg.InputChanFieldName4LowNum), " to ", rec.GetData(cfg.InputChanFieldName4HighNum), " using interval value ", cfg.IntervalSize)
+					// Get the FromDate and ToDate as strings.
+					fromNumStr := rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.InputChanFieldName4LowNum)
+					toNumStr := rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.InputChanFieldName4HighNum)
+					// Convert to float(64)
+					fromNum, err := strconv.ParseFloat(fromNumStr, 64)
+					if err != nil {
+						cfg.Log.Panic(cfg.Name, " error parsing input field for low number: ", err)
+					}
+					toNum, err := strconv.ParseFloat(toNumStr, 64)
+					if err != nil {
+						cfg.Log.Panic(cfg.Name, " error parsing input field for high number: ", err)
+					}
 
-				// Richard 20191011 - old extract field values direct to float:
-				// fromNum, err := getFloat64FromInterface(rec.GetData(cfg.InputChanFieldName4LowNum))
-				// toNum, err := getFloat64FromInterface(rec.GetData(cfg.InputChanFieldName4HighNum))
+					// Richard 20191011 - old extract field values direct to float:
+					// fromNum, err := getFloat64FromInterface(rec.GetData(cfg.InputChanFieldName4LowNum))
+					// toNum, err := getFloat64FromInterface(rec.GetData(cfg.InputChanFieldName4HighNum))
 
-				// Add the increment and emit rows until it is greater than the ToDate.
-				for { // while we are outputting less than ToDate...
-					to := fromNum + cfg.IntervalSize
-					if to > toNum { // if this increment overruns the high number...
-						break // don't output a row!
+					// Add the increment and emit rows until it is greater than the ToDate.
+					for { // while we are outputting less than ToDate...
+						to := fromNum + cfg.IntervalSize
+						if to > toNum { // if this increment overruns the high number...
+							break // don't output a row!
+						}
+						if rowSentOK := sendRow(rec, &fromNum, &to); !rowSentOK {
+							return
+						}
+						atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+						fromNum = to                  // save FromDate with increment added.
 					}
-					if rowSentOK := sendRow(rec, &fromNum, &to); !rowSentOK {
-						return
+					if fromNum < toNum || atomic.AddInt64(&rowCount, 0) == 0 {
+						// if we have a final portion of number to output a row for;
+						// or we have not output a row (i.e. when min value = max value)...
+						if rowSentOK := sendRow(rec, &fromNum, &toNum); !rowSentOK { // emit the final gap.
+							return
+						}
+						atomic.AddInt64(&rowCount, 1) // add a row count.
 					}
-					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
-					fromNum = to                  // save FromDate with increment added.
 				}
-				if fromNum < toNum || atomic.AddInt64(&rowCount, 0) == 0 {
-					// if we have a final portion of number to output a row for;
-					// or we have not output a row (i.e. when min value = max value)...
-					if rowSentOK := sendRow(rec, &fromNum, &toNum); !rowSentOK { // emit the final gap.
-						return
-					}
-					atomic.AddInt64(&rowCount, 1) // add a row count.
+				if cfg.InputChan == nil { // if we processed all data...
+					break // end gracefully.
 				}
 			}
-			if cfg.InputChan == nil { // if we processed all data...
-				break // end gracefully.
-			}
 		}
 		// Calculate output.
 		close(outputChan)
@@ -15,10 +15,10 @@ import (
 type GenerateRowsConfig struct {
 	Log                    logger.Logger
 	Name                   string
-	FieldName4Sequence     string // optional field name to hold 1-based sequence number on the outputChan.
-	MapFieldNamesValuesCSV string // optional CSV string of fieldName:fieldValue tokens to use for row generation.
-	NumRows                int    // number of rows to generate on outputChan.
-	SleepIntervalSeconds   int    // number of seconds to sleep between emitting rows.
+	FieldName4Sequence     string `data:"sequenceFieldName"`                    // optional field name to hold 1-based sequence number on the outputChan.
+	MapFieldNamesValuesCSV string `data:"fieldNamesValuesCSV"`                  // optional CSV string of fieldName:fieldValue tokens to use for row generation.
+	NumRows                int    `data:"numRows" mandatory:"yes"`              // number of rows to generate on outputChan.
+	SleepIntervalSeconds   int    `data:"sleepIntervalSeconds" mandatory:"yes"` // number of seconds to sleep between emitting rows.
 	StepWatcher            *stats.StepWatcher
 	WaitCounter            ComponentWaiter
 	PanicHandlerFn         PanicHandlerFunc
@@ -65,8 +65,10 @@ func NewGenerateRows(i interface{}) (outputChan chan stream.Record, controlChan
 				select { // sleep with ability to catch shutdown requests...
 				case <-time.After(time.Duration(cfg.SleepIntervalSeconds) * time.Second):
 				case controlAction := <-controlChan:
-					fnShutdown(controlAction)
-					return
+					if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action != Resume {
+						fnShutdown(controlAction)
+						return
+					}
 				}
 			}
 			rec := stream.NewRecord() // make the record.
@@ -79,10 +81,7 @@ func NewGenerateRows(i interface{}) (outputChan chan stream.Record, controlChan
 			if fieldName4Seq != "" {      // if we should add a sequence counter to our output...
 				rec.SetData(fieldName4Seq, rowCount)
 			}
-			select {
-			case outputChan <- rec: // send the generated row...
-			case controlAction := <-controlChan: // or if we have been asked to shutdown...
-				fnShutdown(controlAction)
+			if rowSentOK := safeSend(rec, outputChan, controlChan, fnShutdown); !rowSentOK { // send the generated row...
 				return
 			}
 		}
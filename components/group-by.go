@@ -0,0 +1,653 @@
+package components
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	om "github.com/cevaris/ordered_map"
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	s "github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// Values for the mode of a GroupBy step.
+const (
+	GroupByModeHash   = "hash"   // hold groups in memory, spilling them to disk when there are too many (default).
+	GroupByModeSorted = "sorted" // stream groups from input that is sorted by the key fields.
+)
+
+// Aggregate functions supported by GroupBy.
+const (
+	groupByCount = "count"
+	groupBySum   = "sum"
+	groupByMin   = "min"
+	groupByMax   = "max"
+	groupByAvg   = "avg"
+	groupByFirst = "first"
+	groupByLast  = "last"
+)
+
+// groupByDefaultMaxGroups is the number of groups held in memory when a GroupBy does not specify maxGroupsInMemory.
+const groupByDefaultMaxGroups = 100000
+
+// groupBySpillPartitions is the number of files that groups are spread across when they spill to disk.
+// Each file is aggregated in memory separately once all input has been read. Files holding more groups than fit in
+// memory are spilled again to the same number of sub-partitions, up to groupByMaxSpillDepth times.
+const (
+	groupBySpillPartitions = 16
+	groupByMaxSpillDepth   = 8
+)
+
+// groupByAggregateRegexp matches aggregate specs like sum(AMOUNT) or count(*).
+var groupByAggregateRegexp = regexp.MustCompile(`^\s*(\w+)\s*\(\s*([^()]+?)\s*\)\s*$`)
+
+type GroupByConfig struct {
+	Log               logger.Logger
+	Name              string
+	InputChan         chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	KeyFields         []string           `data:"keyFields"`                  // fields to group by; omit to aggregate all records into one.
+	Aggregates        *om.OrderedMap     `data:"aggregates" mandatory:"yes"` // map of output field name to function(inputField).
+	Mode              string             `data:"mode"`                       // one of the GroupByMode* constants.
+	MaxGroupsInMemory int                `data:"maxGroupsInMemory"`          // hash mode spills groups to disk when there are more than this.
+	SpillDir          string             `data:"spillDir"`                   // directory for spill files; defaults to OS temp space.
+	StepWatcher       *s.StepWatcher
+	WaitCounter       ComponentWaiter
+	PanicHandlerFn    PanicHandlerFunc
+}
+
+// NewGroupBy outputs one record per group of records on InputChan that share the same values for KeyFields.
+// Each output record contains the KeyFields plus the Aggregates, which are supplied as outputField:function(inputField)
+// pairs, where function is one of:
+// count - the number of non-null values, or the number of records when inputField is *.
+// sum and avg - the sum or mean of non-null numbers; sum is an integer if all values are integers.
+// min and max - the smallest or largest non-null value, where numbers, times and strings can be compared.
+// first and last - the first or last value, including nulls.
+// Aggregates are null if a group has no non-null values to aggregate.
+// Key values are compared by their string representation.
+// If Mode is sorted then InputChan must be sorted by KeyFields and each group is output as soon as the keys change.
+// If Mode is hash then groups are output after all input has been read. Groups are output in the order they were
+// first seen unless there are more than MaxGroupsInMemory, in which case they are spilled to files in SpillDir and
+// output in no particular order.
+// If KeyFields is empty then one record is output, even if there is no input.
+func NewGroupBy(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*GroupByConfig)
+	aggregates, err := parseGroupByAggregates(cfg.Aggregates)
+	if err != nil {
+		cfg.Log.Panic(cfg.Name, " ", err)
+	}
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = GroupByModeHash
+	case GroupByModeHash, GroupByModeSorted:
+	default:
+		cfg.Log.Panic(fmt.Sprintf("%v unsupported mode %q: use %v or %v", cfg.Name, cfg.Mode, GroupByModeHash, GroupByModeSorted))
+	}
+	if cfg.MaxGroupsInMemory <= 0 {
+		cfg.MaxGroupsInMemory = groupByDefaultMaxGroups
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a stepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		g := &groupBy{
+			log:        cfg.Log,
+			name:       cfg.Name,
+			keyFields:  cfg.KeyFields,
+			aggregates: aggregates,
+			groups:     make(map[string]*groupByState),
+			maxGroups:  cfg.MaxGroupsInMemory,
+		}
+		defer g.removeSpillFiles()
+		send := func(st *groupByState) bool {
+			if rowSentOK := safeSend(g.getRecord(st), outputChan, controlChan, sendNilControlResponse); !rowSentOK { // if we couldn't output the row due to shutdown...
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return false
+			}
+			return true
+		}
+		var controlAction ControlAction
+		for {
+			select {
+			case rec, ok := <-cfg.InputChan:
+				if !ok { // if we have run out of rows...
+					cfg.InputChan = nil // disable this case
+				} else { // process the row...
+					key, keyValues := g.getKey(rec)
+					st, found := g.groups[key]
+					if !found { // if this is a new group...
+						if cfg.Mode == GroupByModeSorted && len(g.order) > 0 { // if the previous group is complete...
+							if !send(g.groups[g.order[0]]) {
+								return
+							}
+							g.reset()
+						} else if cfg.Mode == GroupByModeHash && len(g.order) >= cfg.MaxGroupsInMemory { // if there are too many groups...
+							if err := g.spill(cfg.SpillDir); err != nil {
+								cfg.Log.Panic(cfg.Name, " unable to spill groups to disk: ", err)
+							}
+						}
+						st = g.newState(keyValues)
+						g.groups[key] = st
+						g.order = append(g.order, key)
+					}
+					for idx, a := range g.aggregates { // for each aggregate...
+						if err := a.add(&st.Aggs[idx], rec); err != nil {
+							cfg.Log.Panic(fmt.Sprintf("%v unable to compute %v: %v", cfg.Name, a.outputField, err))
+						}
+					}
+					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+				}
+			case controlAction = <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+			if cfg.InputChan == nil { // if we should exit gracefully...
+				break
+			}
+		}
+		// Output the remaining groups.
+		if len(g.order) == 0 && len(g.keyFields) == 0 && g.spillFiles == nil { // if there was no input to aggregate...
+			g.order = append(g.order, "")
+			g.groups[""] = g.newState(nil)
+		}
+		if g.spillFiles != nil { // if groups were spilled to disk...
+			if err := g.spill(cfg.SpillDir); err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to spill groups to disk: ", err)
+			}
+			ok, err := g.outputSpillFiles(g.spillFiles, 0, send)
+			if err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to read groups from disk: ", err)
+			}
+			if !ok {
+				return
+			}
+		} else {
+			for _, key := range g.order {
+				if !send(g.groups[key]) {
+					return
+				}
+			}
+		}
+		g.removeSpillFiles() // remove files before downstream steps see that we're done.
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return outputChan, controlChan
+}
+
+// groupByAggregate is an aggregate function applied to inputField to produce outputField.
+type groupByAggregate struct {
+	outputField string
+	function    string
+	inputField  string // empty for count(*).
+}
+
+// groupByState holds the key values and aggregate state of a group.
+// Fields are exported so that groups can be spilled to disk using gob.
+type groupByState struct {
+	Keys []interface{}
+	Aggs []groupByAggState
+}
+
+type groupByAggState struct {
+	Count    int64
+	Sum      *big.Rat
+	NonInt   bool        // true if any value added to Sum was not an integer type.
+	Value    interface{} // the min, max, first or last value.
+	HasValue bool
+}
+
+// groupBy holds the groups being aggregated.
+type groupBy struct {
+	log        logger.Logger
+	name       string
+	keyFields  []string
+	aggregates []groupByAggregate
+	groups     map[string]*groupByState
+	order      []string // keys of groups in the order they were first seen.
+	maxGroups  int      // the number of groups to hold in memory before spilling.
+	spillDir   string
+	spillFiles []string // one file per partition.
+}
+
+// parseGroupByAggregates returns the aggregates in the ordered map of outputField:function(inputField).
+func parseGroupByAggregates(aggregates *om.OrderedMap) ([]groupByAggregate, error) {
+	if aggregates == nil || aggregates.Len() == 0 {
+		return nil, fmt.Errorf("missing aggregates")
+	}
+	retval := make([]groupByAggregate, 0, aggregates.Len())
+	iter := aggregates.IterFunc()
+	for kv, ok := iter(); ok; kv, ok = iter() { // for each outputField:function(inputField)...
+		spec := fmt.Sprintf("%v", kv.Value)
+		m := groupByAggregateRegexp.FindStringSubmatch(spec)
+		if m == nil {
+			return nil, fmt.Errorf("aggregate for %v must be of the form function(field), got %q", kv.Key, spec)
+		}
+		a := groupByAggregate{outputField: fmt.Sprintf("%v", kv.Key), function: strings.ToLower(m[1]), inputField: m[2]}
+		switch a.function {
+		case groupByCount:
+			if a.inputField == "*" {
+				a.inputField = ""
+			}
+		case groupBySum, groupByMin, groupByMax, groupByAvg, groupByFirst, groupByLast:
+			if a.inputField == "*" {
+				return nil, fmt.Errorf("aggregate for %v only supports * with %v", a.outputField, groupByCount)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported aggregate function %q for %v: use %v", m[1], a.outputField,
+				strings.Join([]string{groupByCount, groupBySum, groupByMin, groupByMax, groupByAvg, groupByFirst, groupByLast}, ", "))
+		}
+		retval = append(retval, a)
+	}
+	return retval, nil
+}
+
+// add adds the value of inputField in rec to the aggregate state st.
+func (a groupByAggregate) add(st *groupByAggState, rec stream.Record) error {
+	if a.inputField == "" { // if this is count(*)...
+		st.Count++
+		return nil
+	}
+	v := rec.GetData(a.inputField)
+	switch a.function {
+	case groupByFirst:
+		if !st.HasValue {
+			st.Value, st.HasValue = v, true
+		}
+		return nil
+	case groupByLast:
+		st.Value, st.HasValue = v, true
+		return nil
+	}
+	if v == nil { // if there is nothing to aggregate...
+		return nil
+	}
+	switch a.function {
+	case groupByCount:
+		st.Count++
+	case groupBySum, groupByAvg:
+		r, err := getRatFromInterface(v)
+		if err != nil {
+			return err
+		}
+		if st.Sum == nil {
+			st.Sum = new(big.Rat)
+		}
+		st.Sum.Add(st.Sum, r)
+		st.Count++
+		if !isGroupByInteger(v) {
+			st.NonInt = true
+		}
+	case groupByMin, groupByMax:
+		return a.setMinMax(st, v)
+	}
+	return nil
+}
+
+// merge adds the aggregate state other, which was computed from later records, to st.
+func (a groupByAggregate) merge(st *groupByAggState, other *groupByAggState) error {
+	st.Count += other.Count
+	switch a.function {
+	case groupBySum, groupByAvg:
+		if other.Sum != nil {
+			if st.Sum == nil {
+				st.Sum = new(big.Rat)
+			}
+			st.Sum.Add(st.Sum, other.Sum)
+		}
+		st.NonInt = st.NonInt || other.NonInt
+	case groupByFirst:
+		if !st.HasValue {
+			st.Value, st.HasValue = other.Value, other.HasValue
+		}
+	case groupByLast:
+		if other.HasValue {
+			st.Value, st.HasValue = other.Value, true
+		}
+	case groupByMin, groupByMax:
+		if other.HasValue {
+			return a.setMinMax(st, other.Value)
+		}
+	}
+	return nil
+}
+
+func (a groupByAggregate) setMinMax(st *groupByAggState, v interface{}) error {
+	if !st.HasValue {
+		st.Value, st.HasValue = v, true
+		return nil
+	}
+	cmp, err := compareGroupByValues(v, st.Value)
+	if err != nil {
+		return err
+	}
+	if (a.function == groupByMin && cmp < 0) || (a.function == groupByMax && cmp > 0) {
+		st.Value = v
+	}
+	return nil
+}
+
+// result returns the output value of the aggregate state st.
+func (a groupByAggregate) result(st *groupByAggState) interface{} {
+	switch a.function {
+	case groupByCount:
+		return st.Count
+	case groupBySum:
+		if st.Sum == nil {
+			return nil
+		}
+		if !st.NonInt && st.Sum.IsInt() && st.Sum.Num().IsInt64() {
+			return st.Sum.Num().Int64()
+		}
+		f, _ := st.Sum.Float64()
+		return f
+	case groupByAvg:
+		if st.Count == 0 {
+			return nil
+		}
+		f, _ := new(big.Rat).Quo(st.Sum, new(big.Rat).SetInt64(st.Count)).Float64()
+		return f
+	default:
+		return st.Value
+	}
+}
+
+func isGroupByInteger(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+// compareGroupByValues returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// Numbers are compared with numbers, times with times and strings with strings.
+func compareGroupByValues(a interface{}, b interface{}) (int, error) {
+	isNumber := func(v interface{}) bool {
+		switch v.(type) {
+		case float32, float64:
+			return true
+		}
+		return isGroupByInteger(v)
+	}
+	toString := func(v interface{}) (string, bool) {
+		switch x := v.(type) {
+		case string:
+			return x, true
+		case []byte:
+			return string(x), true
+		}
+		return "", false
+	}
+	switch {
+	case isNumber(a) && isNumber(b):
+		ra, err := getRatFromInterface(a)
+		if err != nil {
+			return 0, err
+		}
+		rb, err := getRatFromInterface(b)
+		if err != nil {
+			return 0, err
+		}
+		return ra.Cmp(rb), nil
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1, nil
+			case ta.After(tb):
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	if sa, ok := toString(a); ok {
+		if sb, ok := toString(b); ok {
+			return strings.Compare(sa, sb), nil
+		}
+	}
+	return 0, fmt.Errorf("unable to compare %v with %v", reflect.TypeOf(a), reflect.TypeOf(b))
+}
+
+// getKey returns the group key of rec and the values of the key fields.
+func (g *groupBy) getKey(rec stream.Record) (string, []interface{}) {
+	keyValues := make([]interface{}, len(g.keyFields))
+	for idx, k := range g.keyFields {
+		keyValues[idx] = rec.GetData(k)
+	}
+	return getLookupKey(g.log, keyValues), keyValues
+}
+
+func (g *groupBy) newState(keyValues []interface{}) *groupByState {
+	return &groupByState{Keys: keyValues, Aggs: make([]groupByAggState, len(g.aggregates))}
+}
+
+// getRecord returns the output record for group st.
+func (g *groupBy) getRecord(st *groupByState) stream.Record {
+	rec := stream.NewRecord()
+	for idx, k := range g.keyFields {
+		rec.SetData(k, st.Keys[idx])
+	}
+	for idx, a := range g.aggregates {
+		rec.SetData(a.outputField, a.result(&st.Aggs[idx]))
+	}
+	return rec
+}
+
+// reset removes all groups from memory.
+func (g *groupBy) reset() {
+	g.groups = make(map[string]*groupByState)
+	g.order = nil
+}
+
+// spill appends the groups in memory to the partition files in a new directory under dir and removes them from
+// memory.
+func (g *groupBy) spill(dir string) error {
+	if g.spillFiles == nil { // if this is the first spill...
+		var err error
+		if g.spillDir, err = ioutil.TempDir(dir, "halfpipe-groupby-"); err != nil {
+			return err
+		}
+		gob.Register(time.Time{})
+		g.spillFiles = getGroupBySpillFileNames(filepath.Join(g.spillDir, "partition"))
+		g.log.Info(g.name, " spilling groups to disk in ", g.spillDir)
+	}
+	return g.spillTo(g.spillFiles, 0)
+}
+
+// getGroupBySpillFileNames returns the names of groupBySpillPartitions files starting with prefix.
+func getGroupBySpillFileNames(prefix string) []string {
+	files := make([]string, groupBySpillPartitions)
+	for p := range files {
+		files[p] = fmt.Sprintf("%v-%v.gob", prefix, p)
+	}
+	return files
+}
+
+// spillTo appends the groups in memory to files and removes them from memory.
+// Partitions are chosen by hashing the group key, seeded by depth, so that all states of a group are saved to the
+// same file and groups in one file are spread across all sub-partitions at the next depth.
+func (g *groupBy) spillTo(files []string, depth int) error {
+	g.log.Debug(g.name, " spilling ", len(g.order), " groups to disk at depth ", depth)
+	encoders := make([]*gob.Encoder, len(files))
+	writers := make([]*bufio.Writer, len(files))
+	handles := make([]*os.File, len(files))
+	defer func() {
+		for _, f := range handles {
+			if f != nil {
+				_ = f.Close()
+			}
+		}
+	}()
+	for p, fileName := range files { // for each partition file...
+		f, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		handles[p] = f
+		writers[p] = bufio.NewWriter(f)
+		encoders[p] = gob.NewEncoder(writers[p])
+	}
+	partitions := make([][]*groupByState, len(files))
+	for _, key := range g.order { // for each group in memory...
+		p := getSpillPartition(key, depth, len(files))
+		partitions[p] = append(partitions[p], g.groups[key])
+	}
+	for p, states := range partitions { // for each partition...
+		if err := encoders[p].Encode(len(states)); err != nil { // save the number of groups that follow.
+			return err
+		}
+		for _, st := range states {
+			if err := encoders[p].Encode(st); err != nil {
+				return err
+			}
+		}
+	}
+	for p := range handles {
+		if err := writers[p].Flush(); err != nil {
+			return err
+		}
+		if err := handles[p].Close(); err != nil {
+			return err
+		}
+		handles[p] = nil
+	}
+	g.reset()
+	return nil
+}
+
+// getSpillPartition returns the partition, out of n, for key at the given depth of spill files.
+// The FNV hash of the key is mixed with the depth using the SplitMix64 finalizer, so that keys sharing a partition
+// at one depth are spread across partitions at the next.
+func getSpillPartition(key string, depth int, n int) int {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	z := h.Sum64() + uint64(depth+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return int(z % uint64(n))
+}
+
+// outputSpillFiles loads, merges and sends the groups saved in files, which are partitions at the given depth.
+// Partitions that hold more than maxGroups groups are spilled again to sub-partitions, which are output in turn.
+// It returns false if send failed.
+func (g *groupBy) outputSpillFiles(files []string, depth int, send func(st *groupByState) bool) (bool, error) {
+	for _, fileName := range files { // for each partition of groups...
+		var subFiles []string
+		if depth < groupByMaxSpillDepth { // if the partition can be split further...
+			subFiles = getGroupBySpillFileNames(strings.TrimSuffix(fileName, ".gob"))
+		}
+		split, err := g.loadSpillFile(fileName, subFiles, depth+1)
+		if err != nil {
+			return false, err
+		}
+		if split { // if the partition had too many groups to hold in memory...
+			if err = g.spillTo(subFiles, depth+1); err != nil {
+				return false, err
+			}
+			if ok, err := g.outputSpillFiles(subFiles, depth+1, send); !ok || err != nil {
+				return ok, err
+			}
+		} else {
+			for _, key := range g.order {
+				if !send(g.groups[key]) {
+					return false, nil
+				}
+			}
+			g.reset()
+		}
+		if err = os.Remove(fileName); err != nil && !os.IsNotExist(err) { // free the disk space.
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// loadSpillFile reads and merges the groups saved in fileName.
+// Each spill appends a new gob stream to the file, starting with the number of groups in it, so a new decoder is
+// used for each one. If there are more than maxGroups groups in memory and subFiles is not nil, the groups are
+// spilled to subFiles at subDepth and split is returned as true, in which case the caller must spill the groups
+// remaining in memory to subFiles too.
+func (g *groupBy) loadSpillFile(fileName string, subFiles []string, subDepth int) (split bool, err error) {
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		if _, err := r.Peek(1); err == io.EOF { // if there are no more spills...
+			return split, nil
+		}
+		dec := gob.NewDecoder(r)
+		var n int
+		if err := dec.Decode(&n); err != nil {
+			return split, err
+		}
+		for i := 0; i < n; i++ { // for each group in this spill...
+			st := &groupByState{}
+			if err := dec.Decode(st); err != nil {
+				return split, err
+			}
+			key := getLookupKey(g.log, st.Keys)
+			if existing, ok := g.groups[key]; ok { // if the group was spilled before...
+				for idx, a := range g.aggregates {
+					if err := a.merge(&existing.Aggs[idx], &st.Aggs[idx]); err != nil {
+						return split, err
+					}
+				}
+				continue
+			}
+			if subFiles != nil && len(g.order) >= g.maxGroups { // if there are too many groups...
+				if err := g.spillTo(subFiles, subDepth); err != nil {
+					return split, err
+				}
+				split = true
+			}
+			g.groups[key] = st
+			g.order = append(g.order, key)
+		}
+	}
+}
+
+// removeSpillFiles deletes the spill directory, if any. It is safe to call more than once.
+func (g *groupBy) removeSpillFiles() {
+	if g.spillDir != "" {
+		if err := os.RemoveAll(g.spillDir); err != nil {
+			g.log.Warn(g.name, " unable to remove spill files: ", err)
+		}
+		g.spillDir = ""
+	}
+}
@@ -0,0 +1,174 @@
+package components
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func runGroupBy(cfg *GroupByConfig, input []map[string]interface{}) []map[string]interface{} {
+	inputChan := make(chan stream.Record, len(input)+1)
+	for _, m := range input {
+		rec := stream.NewRecord()
+		for k, v := range m {
+			rec.SetData(k, v)
+		}
+		inputChan <- rec
+	}
+	close(inputChan)
+	cfg.InputChan = inputChan
+	outputChan, _ := NewGroupBy(cfg)
+	got := make([]map[string]interface{}, 0)
+	for rec := range outputChan {
+		got = append(got, rec.GetDataMap())
+	}
+	return got
+}
+
+func TestNewGroupBy(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	day1 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	input := []map[string]interface{}{
+		{"ACCOUNT": "A", "AMOUNT": 10, "STATUS": "open", "UPDATED": day2},
+		{"ACCOUNT": "A", "AMOUNT": 5, "STATUS": nil, "UPDATED": day1},
+		{"ACCOUNT": "B", "AMOUNT": 2.5, "STATUS": "closed", "UPDATED": day1},
+		{"ACCOUNT": "B", "AMOUNT": nil, "STATUS": "open", "UPDATED": day1},
+		{"ACCOUNT": "C", "AMOUNT": "7", "STATUS": "open", "UPDATED": day2},
+	}
+	aggregates := helper.TokensToOrderedMap("ROWS:count(*),AMOUNTS:count(AMOUNT),TOTAL:sum(AMOUNT),MEAN:avg(AMOUNT)," +
+		"LOWEST:min(AMOUNT),LATEST:max(UPDATED),FIRST_STATUS:first(STATUS),LAST_STATUS:last(STATUS)")
+	expected := []map[string]interface{}{
+		{"ACCOUNT": "A", "ROWS": int64(2), "AMOUNTS": int64(2), "TOTAL": int64(15), "MEAN": 7.5, "LOWEST": 5, "LATEST": day2, "FIRST_STATUS": "open", "LAST_STATUS": nil},
+		{"ACCOUNT": "B", "ROWS": int64(2), "AMOUNTS": int64(1), "TOTAL": 2.5, "MEAN": 2.5, "LOWEST": 2.5, "LATEST": day1, "FIRST_STATUS": "closed", "LAST_STATUS": "open"},
+		{"ACCOUNT": "C", "ROWS": int64(1), "AMOUNTS": int64(1), "TOTAL": 7.0, "MEAN": 7.0, "LOWEST": "7", "LATEST": day2, "FIRST_STATUS": "open", "LAST_STATUS": "open"},
+	}
+
+	// Test 1 - sorted and hash modes produce the same groups in input order.
+	for _, mode := range []string{GroupByModeSorted, GroupByModeHash} {
+		cfg := &GroupByConfig{Log: log, Name: "Test GroupBy", KeyFields: []string{"ACCOUNT"}, Aggregates: aggregates, Mode: mode}
+		if got := runGroupBy(cfg, input); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Test 1, mode %v expected %v; got %v", mode, expected, got)
+		}
+	}
+
+	// Test 2 - hash mode spills groups to disk and merges them in order.
+	spillDir := t.TempDir()
+	spillInput := make([]map[string]interface{}, 0)
+	for i := 0; i < 3; i++ { // for each pass over the accounts...
+		for _, acc := range []string{"A", "B", "C", "D", "E"} {
+			spillInput = append(spillInput, map[string]interface{}{"ACCOUNT": acc, "AMOUNT": i, "UPDATED": day1.AddDate(0, 0, i)})
+		}
+	}
+	cfg := &GroupByConfig{
+		Log:               log,
+		Name:              "Test GroupBy",
+		KeyFields:         []string{"ACCOUNT"},
+		Aggregates:        helper.TokensToOrderedMap("ROWS:count(*),TOTAL:sum(AMOUNT),FIRST:first(AMOUNT),LAST:last(AMOUNT),LATEST:max(UPDATED)"),
+		MaxGroupsInMemory: 2,
+		SpillDir:          spillDir,
+	}
+	got := runGroupBy(cfg, spillInput)
+	sort.Slice(got, func(i, j int) bool { return got[i]["ACCOUNT"].(string) < got[j]["ACCOUNT"].(string) })
+	if len(got) != 5 {
+		t.Fatal("Test 2, expected 5 groups; got ", got)
+	}
+	for _, m := range got {
+		exp := map[string]interface{}{"ACCOUNT": m["ACCOUNT"], "ROWS": int64(3), "TOTAL": int64(3), "FIRST": 0, "LAST": 2, "LATEST": day1.AddDate(0, 0, 2)}
+		if !reflect.DeepEqual(m, exp) {
+			t.Fatalf("Test 2, expected %v; got %v", exp, m)
+		}
+	}
+	if files, _ := ioutil.ReadDir(spillDir); len(files) != 0 {
+		t.Fatal("Test 2, expected spill files to be removed; got ", files)
+	}
+
+	// Test 3 - partitions with too many groups are split again and no more than MaxGroupsInMemory are held.
+	aggregates = helper.TokensToOrderedMap("ROWS:count(*),TOTAL:sum(AMOUNT)")
+	aggs, _ := parseGroupByAggregates(aggregates)
+	g := &groupBy{log: log, name: "Test GroupBy", keyFields: []string{"ACCOUNT"}, aggregates: aggs, groups: make(map[string]*groupByState), maxGroups: 3}
+	for pass := 0; pass < 2; pass++ { // for each pass over the accounts...
+		for i := 0; i < 500; i++ {
+			rec := stream.NewRecord()
+			rec.SetData("ACCOUNT", i)
+			rec.SetData("AMOUNT", i)
+			key, keyValues := g.getKey(rec)
+			st, ok := g.groups[key]
+			if !ok {
+				if len(g.order) >= g.maxGroups {
+					if err := g.spill(spillDir); err != nil {
+						t.Fatal("Test 3, unexpected error: ", err)
+					}
+				}
+				st = g.newState(keyValues)
+				g.groups[key] = st
+				g.order = append(g.order, key)
+			}
+			for idx, a := range g.aggregates {
+				if err := a.add(&st.Aggs[idx], rec); err != nil {
+					t.Fatal("Test 3, unexpected error: ", err)
+				}
+			}
+		}
+	}
+	if err := g.spill(spillDir); err != nil {
+		t.Fatal("Test 3, unexpected error: ", err)
+	}
+	totals := make(map[interface{}]interface{})
+	ok, err := g.outputSpillFiles(g.spillFiles, 0, func(st *groupByState) bool {
+		if len(g.order) > g.maxGroups {
+			t.Fatalf("Test 3, expected at most %v groups in memory; got %v", g.maxGroups, len(g.order))
+		}
+		rec := g.getRecord(st)
+		if rec.GetData("ROWS") != int64(2) {
+			t.Fatal("Test 3, expected 2 rows per group; got ", rec.GetDataMap())
+		}
+		totals[rec.GetData("ACCOUNT")] = rec.GetData("TOTAL")
+		return true
+	})
+	if !ok || err != nil {
+		t.Fatal("Test 3, unexpected error: ", err)
+	}
+	if len(totals) != 500 || totals[499] != int64(998) {
+		t.Fatalf("Test 3, expected 500 groups; got %v with total %v for the last", len(totals), totals[499])
+	}
+	g.removeSpillFiles()
+
+	// Test 4 - no key fields aggregates everything into one record, even without input.
+	cfg = &GroupByConfig{Log: log, Name: "Test GroupBy", Aggregates: helper.TokensToOrderedMap("ROWS:count(*),TOTAL:sum(AMOUNT)")}
+	if got := runGroupBy(cfg, nil); !reflect.DeepEqual(got, []map[string]interface{}{{"ROWS": int64(0), "TOTAL": nil}}) {
+		t.Fatal("Test 4, unexpected output: ", got)
+	}
+
+	// Test 5 - bad aggregates.
+	for _, spec := range []string{"X:sum", "X:median(AMOUNT)", "X:sum(*)", ""} {
+		if _, err := parseGroupByAggregates(helper.TokensToOrderedMap(spec)); err == nil {
+			t.Fatalf("Test 5, expected error for aggregates %q", spec)
+		}
+	}
+}
+
+func TestCompareGroupByValues(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     interface{}
+		expected int
+	}{
+		{1, 2.5, -1},
+		{int64(3), uint8(3), 0},
+		{"b", []byte("a"), 1},
+		{time.Unix(1, 0), time.Unix(0, 0), 1},
+	} {
+		if got, err := compareGroupByValues(tc.a, tc.b); err != nil || got != tc.expected {
+			t.Fatalf("comparing %v with %v expected %v; got %v, %v", tc.a, tc.b, tc.expected, got, err)
+		}
+	}
+	if _, err := compareGroupByValues("1", 1); err == nil {
+		t.Fatal("expected error comparing a string with a number")
+	}
+}
@@ -0,0 +1,557 @@
+package components
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	om "github.com/cevaris/ordered_map"
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	s "github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// hashDiffDefaultMaxKeys is the number of keys held in memory when a HashDiff does not specify maxKeysInMemory.
+const hashDiffDefaultMaxKeys = 1000000
+
+// hashDiffSpillPartitions is the number of files that each side is spread across when keys spill to disk.
+// Partitions holding more keys than fit in memory are split again into the same number of sub-partitions, up to
+// hashDiffMaxSpillDepth times.
+const (
+	hashDiffSpillPartitions = 16
+	hashDiffMaxSpillDepth   = 8
+)
+
+// Values for HashDiffConfig.BuildSide.
+const (
+	HashDiffBuildSideOld = "old" // hold the keys of old records in memory (default).
+	HashDiffBuildSideNew = "new" // hold new records in memory.
+)
+
+type HashDiffConfig struct {
+	Log                 logger.Logger
+	Name                string
+	ChanOld             chan stream.Record `data:"readOldDataFromStep" mandatory:"yes"`
+	ChanNew             chan stream.Record `data:"readNewDataFromStep" mandatory:"yes"`
+	JoinKeys            *om.OrderedMap     `data:"joinKeys" mandatory:"yes"`
+	CompareKeys         *om.OrderedMap     `data:"compareKeys"`
+	ResultFlagKeyName   string             `data:"flagFieldName"`
+	OutputIdenticalRows bool               `data:"outputIdenticalRows"`
+	BuildSide           string             `data:"buildSide"`       // one of the HashDiffBuildSide* constants; use the smaller input.
+	MaxKeysInMemory     int                `data:"maxKeysInMemory"` // keys are spilled to disk when there are more than this.
+	SpillDir            string             `data:"spillDir"`        // directory for spill files; defaults to OS temp space.
+	StepWatcher         *s.StepWatcher
+	WaitCounter         ComponentWaiter
+	PanicHandlerFn      PanicHandlerFunc
+}
+
+// NewHashDiff compares the records on ChanOld and ChanNew like NewMergeDiff, but the inputs don't need to be sorted.
+// It outputs the same flags, where records on ChanNew are NEW, CHANGED or IDENTICAL and records left on ChanOld are
+// DELETED. JoinKeys and CompareKeys map field names on ChanOld to field names on ChanNew and values are compared as
+// strings with times in UTC, the same as NewMergeDiff.
+// All of the input given by BuildSide is read first to build a map of the join key values to a hash of the compare
+// values, then the other input is streamed against it, so BuildSide should be the smaller input. The map holds only
+// keys and hashes when BuildSide is old (the default), but whole records when it is new since they are output.
+// DELETED records contain the join key fields with the compare fields set to null. If there are more than
+// MaxKeysInMemory keys on BuildSide then both inputs are partitioned by key into files in SpillDir and each
+// partition is compared separately, where partitions that are still too big are split again.
+// Records are output in no particular order.
+func NewHashDiff(i interface{}) (chan stream.Record, chan ControlAction) {
+	cfg := i.(*HashDiffConfig)
+	outputChan := make(chan stream.Record, c.ChanSize)
+	controlChan := make(chan ControlAction, 1)
+	resultKeyName := "mergeDiffResult"
+	if cfg.ResultFlagKeyName != "" {
+		resultKeyName = cfg.ResultFlagKeyName
+	}
+	if cfg.MaxKeysInMemory <= 0 {
+		cfg.MaxKeysInMemory = hashDiffDefaultMaxKeys
+	}
+	buildChan, probeChan := cfg.ChanOld, cfg.ChanNew
+	switch cfg.BuildSide {
+	case "", HashDiffBuildSideOld:
+	case HashDiffBuildSideNew:
+		buildChan, probeChan = cfg.ChanNew, cfg.ChanOld
+	default:
+		cfg.Log.Panic(fmt.Sprintf("%v unsupported build side %q: use %v or %v", cfg.Name, cfg.BuildSide, HashDiffBuildSideOld, HashDiffBuildSideNew))
+	}
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a stepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		d := newHashDiff(cfg.Log, cfg.Name, cfg.JoinKeys, cfg.CompareKeys, cfg.BuildSide == HashDiffBuildSideNew, cfg.MaxKeysInMemory)
+		defer d.removeSpillFiles()
+		// getNextRecord fetches the next record from ch or returns false if there was a shutdown request.
+		getNextRecord := func(ch chan stream.Record) (stream.Record, bool, bool) {
+			for { // until we have input data or a shutdown request...
+				select {
+				case rec, ok := <-ch:
+					return rec, ok, true
+				case controlAction := <-controlChan:
+					if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+						continue // wait for input data again.
+					}
+					sendNilControlResponse(controlAction)
+					cfg.Log.Info(cfg.Name, " shutdown")
+					return stream.Record{}, false, false
+				}
+			}
+		}
+		send := func(rec stream.Record, flag string) bool {
+			if flag == c.MergeDiffValueIdentical && !cfg.OutputIdenticalRows {
+				return true
+			}
+			rec.SetData(resultKeyName, flag)
+			if rowSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !rowSentOK { // if we couldn't output the row due to shutdown...
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return false
+			}
+			atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+			return true
+		}
+		// Build the map of keys.
+		for {
+			rec, ok, running := getNextRecord(buildChan)
+			if !running {
+				return
+			} else if !ok {
+				break
+			}
+			if err := d.addBuild(rec, cfg.SpillDir); err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to spill keys to disk: ", err)
+			}
+		}
+		// Compare the other records.
+		if d.spillFiles == nil { // if all keys fit in memory...
+			for {
+				rec, ok, running := getNextRecord(probeChan)
+				if !running {
+					return
+				} else if !ok {
+					break
+				}
+				if !send(d.probe(rec)) {
+					return
+				}
+			}
+			if !d.sendUnmatched(send) {
+				return
+			}
+		} else { // else partition the other records and compare each partition...
+			for {
+				rec, ok, running := getNextRecord(probeChan)
+				if !running {
+					return
+				} else if !ok {
+					break
+				}
+				if err := d.spillProbe(rec); err != nil {
+					cfg.Log.Panic(cfg.Name, " unable to spill records to disk: ", err)
+				}
+			}
+			if err := d.closeSpillFiles(); err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to spill records to disk: ", err)
+			}
+			for p := 0; p < hashDiffSpillPartitions; p++ { // for each partition...
+				running, err := d.comparePartition(d.buildFileNames[p], d.probeFileNames[p], 0, send)
+				if err != nil {
+					cfg.Log.Panic(cfg.Name, " unable to compare records from disk: ", err)
+				}
+				if !running {
+					return
+				}
+			}
+		}
+		d.removeSpillFiles() // remove files before downstream steps see that we're done.
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return outputChan, controlChan
+}
+
+// hashDiffEntry holds the join key and the hash of the compare values of a record on the build side, plus the
+// values of the old join keys or the whole record if the build side is new.
+// Fields are exported so that entries can be spilled to disk using gob.
+type hashDiffEntry struct {
+	Key  string
+	Keys []interface{}
+	Hash [sha256.Size]byte
+	Data map[string]interface{}
+}
+
+// hashDiffSpillFile is a partition file of build entries or probe records.
+type hashDiffSpillFile struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *gob.Encoder
+}
+
+type hashDiff struct {
+	log            logger.Logger
+	name           string
+	buildNew       bool // true if the map is built from new records, so old records are streamed against it.
+	maxKeys        int
+	oldJoinKeys    []string
+	newJoinKeys    []string
+	oldCompare     []string
+	newCompare     []string
+	entries        map[string]*hashDiffEntry
+	spillDir       string
+	spillFiles     map[string]*hashDiffSpillFile // open files keyed by name.
+	buildFileNames []string                      // partition files of build entries.
+	probeFileNames []string                      // partition files of probe records.
+}
+
+func newHashDiff(log logger.Logger, name string, joinKeys *om.OrderedMap, compareKeys *om.OrderedMap, buildNew bool, maxKeys int) *hashDiff {
+	d := &hashDiff{log: log, name: name, buildNew: buildNew, maxKeys: maxKeys, entries: make(map[string]*hashDiffEntry)}
+	getFields := func(m *om.OrderedMap) (oldFields []string, newFields []string) {
+		if m == nil {
+			return
+		}
+		iter := m.IterFunc()
+		for kv, ok := iter(); ok; kv, ok = iter() { // for each old:new field pair...
+			oldFields = append(oldFields, fmt.Sprintf("%v", kv.Key))
+			newFields = append(newFields, fmt.Sprintf("%v", kv.Value))
+		}
+		return
+	}
+	d.oldJoinKeys, d.newJoinKeys = getFields(joinKeys)
+	d.oldCompare, d.newCompare = getFields(compareKeys)
+	return d
+}
+
+// getFields returns the join key and compare fields of records on the build side, or the probe side if probe is true.
+func (d *hashDiff) getFields(probe bool) (joinKeys []string, compareFields []string) {
+	if probe == d.buildNew { // if the records are old...
+		return d.oldJoinKeys, d.oldCompare
+	}
+	return d.newJoinKeys, d.newCompare
+}
+
+// getKeyAndHash returns the join key of rec using fields and the hash of the values of compareFields.
+func (d *hashDiff) getKeyAndHash(rec stream.Record, fields []string, compareFields []string) (string, [sha256.Size]byte) {
+	key := strings.Builder{}
+	for idx, f := range fields {
+		if idx > 0 {
+			key.WriteString(lookupKeySeparator)
+		}
+		key.WriteString(rec.GetDataAsStringUseUtcTime(d.log, f))
+	}
+	h := sha256.New()
+	for _, f := range compareFields {
+		v := rec.GetDataAsStringUseUtcTime(d.log, f)
+		_, _ = fmt.Fprintf(h, "%d:%s", len(v), v) // prefix lengths so that values can't run together.
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return key.String(), sum
+}
+
+// addBuild saves the key and hash of a build record in memory, or to a partition file once there are more than
+// maxKeys entries.
+func (d *hashDiff) addBuild(rec stream.Record, dir string) error {
+	joinKeys, compareFields := d.getFields(false)
+	key, sum := d.getKeyAndHash(rec, joinKeys, compareFields)
+	e := &hashDiffEntry{Key: key, Hash: sum}
+	if d.buildNew {
+		e.Data = rec.GetDataMap()
+	} else {
+		e.Keys = d.getOldKeyValues(rec)
+	}
+	if d.spillFiles == nil && len(d.entries) < d.maxKeys { // if the entry fits in memory...
+		d.entries[key] = e
+		return nil
+	}
+	if d.spillFiles == nil { // if this is the first spill...
+		if err := d.startSpill(dir); err != nil {
+			return err
+		}
+		for k, v := range d.entries { // for each entry in memory...
+			if err := d.spillFiles[d.buildFileNames[getSpillPartition(k, 0, hashDiffSpillPartitions)]].enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		d.entries = make(map[string]*hashDiffEntry)
+	}
+	return d.spillFiles[d.buildFileNames[getSpillPartition(key, 0, hashDiffSpillPartitions)]].enc.Encode(e)
+}
+
+func (d *hashDiff) getOldKeyValues(rec stream.Record) []interface{} {
+	keys := make([]interface{}, len(d.oldJoinKeys))
+	for idx, f := range d.oldJoinKeys {
+		keys[idx] = rec.GetData(f)
+	}
+	return keys
+}
+
+func (d *hashDiff) startSpill(dir string) error {
+	var err error
+	if d.spillDir, err = ioutil.TempDir(dir, "halfpipe-hashdiff-"); err != nil {
+		return err
+	}
+	gob.Register(time.Time{})
+	d.log.Info(d.name, " spilling keys to disk in ", d.spillDir)
+	d.buildFileNames = getHashDiffSpillFileNames(filepath.Join(d.spillDir, "build"))
+	d.probeFileNames = getHashDiffSpillFileNames(filepath.Join(d.spillDir, "probe"))
+	return d.openSpillFiles(append(append([]string{}, d.buildFileNames...), d.probeFileNames...))
+}
+
+// getHashDiffSpillFileNames returns the names of hashDiffSpillPartitions files starting with prefix.
+func getHashDiffSpillFileNames(prefix string) []string {
+	files := make([]string, hashDiffSpillPartitions)
+	for p := range files {
+		files[p] = fmt.Sprintf("%v-%v.gob", prefix, p)
+	}
+	return files
+}
+
+// openSpillFiles creates the files in fileNames and adds them to the map of open files.
+func (d *hashDiff) openSpillFiles(fileNames []string) error {
+	if d.spillFiles == nil {
+		d.spillFiles = make(map[string]*hashDiffSpillFile)
+	}
+	for _, fileName := range fileNames {
+		f, err := os.Create(fileName)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(f)
+		d.spillFiles[fileName] = &hashDiffSpillFile{f: f, w: w, enc: gob.NewEncoder(w)}
+	}
+	return nil
+}
+
+// spillProbe saves a probe record to its partition file.
+func (d *hashDiff) spillProbe(rec stream.Record) error {
+	joinKeys, _ := d.getFields(true)
+	key, _ := d.getKeyAndHash(rec, joinKeys, nil)
+	return d.spillFiles[d.probeFileNames[getSpillPartition(key, 0, hashDiffSpillPartitions)]].enc.Encode(rec.GetDataMap())
+}
+
+func (d *hashDiff) closeSpillFiles() error {
+	for _, sf := range d.spillFiles {
+		if err := sf.w.Flush(); err != nil {
+			return err
+		}
+		if err := sf.f.Close(); err != nil {
+			return err
+		}
+	}
+	d.spillFiles = map[string]*hashDiffSpillFile{}
+	return nil
+}
+
+// probe returns the record to output and its flag for probe record rec. It removes the key from the map so that
+// the keys left over are DELETED, or NEW if the build side is new.
+func (d *hashDiff) probe(rec stream.Record) (stream.Record, string) {
+	joinKeys, compareFields := d.getFields(true)
+	key, sum := d.getKeyAndHash(rec, joinKeys, compareFields)
+	e, ok := d.entries[key]
+	if !ok {
+		if d.buildNew { // if rec is old...
+			return d.getDeletedRecord(d.getOldKeyValues(rec)), c.MergeDiffValueDeleted
+		}
+		return rec, c.MergeDiffValueNew
+	}
+	delete(d.entries, key)
+	if d.buildNew { // if rec is old...
+		rec = getRecordFromMap(e.Data) // output the new record.
+	}
+	if e.Hash == sum {
+		return rec, c.MergeDiffValueIdentical
+	}
+	return rec, c.MergeDiffValueChanged
+}
+
+// getDeletedRecord returns a record with the old join keys set to keys and the old compare fields set to null.
+func (d *hashDiff) getDeletedRecord(keys []interface{}) stream.Record {
+	rec := stream.NewRecord()
+	for idx, f := range d.oldJoinKeys {
+		rec.SetData(f, keys[idx])
+	}
+	for _, f := range d.oldCompare {
+		rec.SetData(f, nil)
+	}
+	return rec
+}
+
+func getRecordFromMap(data map[string]interface{}) stream.Record {
+	rec := stream.NewRecord()
+	for k, v := range data {
+		rec.SetData(k, v)
+	}
+	return rec
+}
+
+// sendUnmatched calls send with a record for each key in the map that was not found on the probe side.
+// It returns false if send does.
+func (d *hashDiff) sendUnmatched(send func(rec stream.Record, flag string) bool) bool {
+	for _, e := range d.entries {
+		var ok bool
+		if d.buildNew {
+			ok = send(getRecordFromMap(e.Data), c.MergeDiffValueNew)
+		} else {
+			ok = send(d.getDeletedRecord(e.Keys), c.MergeDiffValueDeleted)
+		}
+		if !ok {
+			return false
+		}
+	}
+	d.entries = make(map[string]*hashDiffEntry)
+	return true
+}
+
+// comparePartition loads the build entries in buildFile and compares the probe records in probeFile, which are
+// partitions at the given depth. If there are more than maxKeys entries, both files are split into sub-partitions
+// that are compared in turn. It returns false if send does.
+func (d *hashDiff) comparePartition(buildFile string, probeFile string, depth int, send func(rec stream.Record, flag string) bool) (bool, error) {
+	err := readGobFile(buildFile, func(dec *gob.Decoder) error {
+		e := &hashDiffEntry{}
+		if err := dec.Decode(e); err != nil {
+			return err
+		}
+		if _, ok := d.entries[e.Key]; !ok && len(d.entries) >= d.maxKeys && depth < hashDiffMaxSpillDepth { // if the partition is too big...
+			return errHashDiffSplit
+		}
+		d.entries[e.Key] = e
+		return nil
+	})
+	if err == errHashDiffSplit {
+		d.entries = make(map[string]*hashDiffEntry)
+		return d.splitPartition(buildFile, probeFile, depth, send)
+	} else if err != nil {
+		return false, err
+	}
+	err = readGobFile(probeFile, func(dec *gob.Decoder) error {
+		data := make(map[string]interface{})
+		if err := dec.Decode(&data); err != nil {
+			return err
+		}
+		if !send(d.probe(getRecordFromMap(data))) {
+			return errHashDiffShutdown
+		}
+		return nil
+	})
+	if err == errHashDiffShutdown {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if !d.sendUnmatched(send) {
+		return false, nil
+	}
+	return true, d.removeFiles(buildFile, probeFile)
+}
+
+// splitPartition moves the build entries in buildFile and the probe records in probeFile, which are partitions at
+// the given depth, to sub-partitions at the next depth and compares each one. It returns false if send does.
+func (d *hashDiff) splitPartition(buildFile string, probeFile string, depth int, send func(rec stream.Record, flag string) bool) (bool, error) {
+	d.log.Debug(d.name, " splitting partition ", buildFile, " at depth ", depth)
+	subBuild := getHashDiffSpillFileNames(strings.TrimSuffix(buildFile, ".gob"))
+	subProbe := getHashDiffSpillFileNames(strings.TrimSuffix(probeFile, ".gob"))
+	if err := d.openSpillFiles(append(append([]string{}, subBuild...), subProbe...)); err != nil {
+		return false, err
+	}
+	err := readGobFile(buildFile, func(dec *gob.Decoder) error {
+		e := &hashDiffEntry{}
+		if err := dec.Decode(e); err != nil {
+			return err
+		}
+		return d.spillFiles[subBuild[getSpillPartition(e.Key, depth+1, hashDiffSpillPartitions)]].enc.Encode(e)
+	})
+	if err != nil {
+		return false, err
+	}
+	joinKeys, _ := d.getFields(true)
+	err = readGobFile(probeFile, func(dec *gob.Decoder) error {
+		data := make(map[string]interface{})
+		if err := dec.Decode(&data); err != nil {
+			return err
+		}
+		key, _ := d.getKeyAndHash(getRecordFromMap(data), joinKeys, nil)
+		return d.spillFiles[subProbe[getSpillPartition(key, depth+1, hashDiffSpillPartitions)]].enc.Encode(data)
+	})
+	if err != nil {
+		return false, err
+	}
+	if err = d.closeSpillFiles(); err != nil {
+		return false, err
+	}
+	if err = d.removeFiles(buildFile, probeFile); err != nil {
+		return false, err
+	}
+	for p := range subBuild { // for each sub-partition...
+		if running, err := d.comparePartition(subBuild[p], subProbe[p], depth+1, send); !running || err != nil {
+			return running, err
+		}
+	}
+	return true, nil
+}
+
+// removeFiles deletes fileNames to free disk space once they have been compared or split.
+func (d *hashDiff) removeFiles(fileNames ...string) error {
+	for _, f := range fileNames {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	errHashDiffShutdown = errors.New("shutdown")
+	errHashDiffSplit    = errors.New("split")
+)
+
+// readGobFile calls fn with a decoder for fileName until the end of the file.
+func readGobFile(fileName string, fn func(dec *gob.Decoder) error) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	dec := gob.NewDecoder(r)
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			return nil
+		}
+		if err := fn(dec); err != nil {
+			return err
+		}
+	}
+}
+
+// removeSpillFiles deletes the spill directory, if any. It is safe to call more than once.
+func (d *hashDiff) removeSpillFiles() {
+	for _, sf := range d.spillFiles {
+		_ = sf.f.Close()
+	}
+	d.spillFiles = map[string]*hashDiffSpillFile{}
+	if d.spillDir != "" {
+		if err := os.RemoveAll(d.spillDir); err != nil {
+			d.log.Warn(d.name, " unable to remove spill files: ", err)
+		}
+		d.spillDir = ""
+	}
+}
@@ -0,0 +1,104 @@
+package components
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"testing"
+
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestNewHashDiff(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	newChan := func(rows [][]interface{}) chan stream.Record {
+		ch := make(chan stream.Record, len(rows))
+		for _, r := range rows {
+			rec := stream.NewRecord()
+			rec.SetData("ID", r[0])
+			rec.SetData("NAME", r[1])
+			ch <- rec
+		}
+		close(ch)
+		return ch
+	}
+	// Unsorted inputs where 1 is identical, 2 is changed, 3 is deleted and 4 is new.
+	oldRows := [][]interface{}{{3, "c"}, {1, "a"}, {2, "b"}}
+	newRows := [][]interface{}{{"4", "d"}, {"2", "x"}, {"1", "a"}}
+	expected := []map[string]interface{}{
+		{"ID": "1", "NAME": "a", "#flag": c.MergeDiffValueIdentical},
+		{"ID": "2", "NAME": "x", "#flag": c.MergeDiffValueChanged},
+		{"ID": 3, "NAME": nil, "#flag": c.MergeDiffValueDeleted},
+		{"ID": "4", "NAME": "d", "#flag": c.MergeDiffValueNew},
+	}
+	runHashDiff := func(oldRows [][]interface{}, newRows [][]interface{}, buildSide string, maxKeys int) []map[string]interface{} {
+		spillDir := t.TempDir()
+		cfg := &HashDiffConfig{
+			Log:                 log,
+			Name:                "Test HashDiff",
+			ChanOld:             newChan(oldRows),
+			ChanNew:             newChan(newRows),
+			JoinKeys:            helper.TokensToOrderedMap("ID:ID"),
+			CompareKeys:         helper.TokensToOrderedMap("NAME:NAME"),
+			ResultFlagKeyName:   "#flag",
+			OutputIdenticalRows: true,
+			BuildSide:           buildSide,
+			MaxKeysInMemory:     maxKeys,
+			SpillDir:            spillDir,
+		}
+		outputChan, _ := NewHashDiff(cfg)
+		got := make([]map[string]interface{}, 0)
+		for rec := range outputChan {
+			got = append(got, rec.GetDataMap())
+		}
+		sort.Slice(got, func(i, j int) bool {
+			return helper.GetStringFromInterfacePreserveTimeZone(log, got[i]["ID"]) < helper.GetStringFromInterfacePreserveTimeZone(log, got[j]["ID"])
+		})
+		if files, _ := ioutil.ReadDir(spillDir); len(files) != 0 {
+			t.Fatal("expected spill files to be removed")
+		}
+		return got
+	}
+	for _, buildSide := range []string{"", HashDiffBuildSideOld, HashDiffBuildSideNew} { // for each side to hold in memory...
+		for _, maxKeys := range []int{0, 1} { // for in memory and spilled comparisons...
+			if got := runHashDiff(oldRows, newRows, buildSide, maxKeys); !reflect.DeepEqual(got, expected) {
+				t.Fatalf("buildSide %q maxKeysInMemory %v expected %v; got %v", buildSide, maxKeys, expected, got)
+			}
+		}
+	}
+	// Partitions with too many keys are split again.
+	oldRows, newRows = nil, nil
+	for i := 0; i < 500; i++ {
+		oldRows = append(oldRows, []interface{}{i, "a"})
+		if i%5 == 0 { // if the record should be changed...
+			newRows = append(newRows, []interface{}{i, "b"})
+		} else if i%5 != 1 { // else if the record should not be deleted...
+			newRows = append(newRows, []interface{}{i, "a"})
+		}
+	}
+	for _, buildSide := range []string{HashDiffBuildSideOld, HashDiffBuildSideNew} {
+		flags := make(map[interface{}]int)
+		for _, m := range runHashDiff(oldRows, newRows, buildSide, 3) {
+			flags[m["#flag"]]++
+		}
+		if exp := map[interface{}]int{c.MergeDiffValueChanged: 100, c.MergeDiffValueDeleted: 100, c.MergeDiffValueIdentical: 300}; !reflect.DeepEqual(flags, exp) {
+			t.Fatalf("buildSide %v expected %v; got %v", buildSide, exp, flags)
+		}
+	}
+	// Identical rows are not output by default.
+	cfg := &HashDiffConfig{
+		Log:         log,
+		Name:        "Test HashDiff",
+		ChanOld:     newChan(oldRows),
+		ChanNew:     newChan(oldRows),
+		JoinKeys:    helper.TokensToOrderedMap("ID:ID"),
+		CompareKeys: helper.TokensToOrderedMap("NAME:NAME"),
+	}
+	outputChan, _ := NewHashDiff(cfg)
+	for rec := range outputChan {
+		t.Fatal("expected no differences; got ", rec.GetDataMap())
+	}
+}
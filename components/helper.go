@@ -4,20 +4,66 @@ import (
 	"github.com/relloyd/halfpipe/stream"
 )
 
+// safeSend sends rec to outputChan unless a Shutdown action is received on controlChan first, in which case
+// controlFunc is called to handle it. Pause actions block the send until a Resume or Shutdown action is received.
 func safeSend(rec stream.Record,
 	outputChan chan stream.Record,
 	controlChan chan ControlAction,
 	controlFunc func(c ControlAction),
 ) (recordSentOK bool) {
-	select {
-	case outputChan <- rec: // if we can send the record to the outputChan...
-		return true // signal that data was sent OK.
-	case c := <-controlChan: // if we were asked to shutdown...
-		controlFunc(c) // handle the control action...
-		return false   // signal that the caller should shutdown.
+	for {
+		select {
+		case outputChan <- rec: // if we can send the record to the outputChan...
+			return true // signal that data was sent OK.
+		case c := <-controlChan: // if we were asked to pause or shutdown...
+			if c = HandlePauseAction(c, controlChan, nil); c.Action == Resume { // if we were paused and resumed...
+				continue // try to send the record again.
+			}
+			controlFunc(c) // handle the control action...
+			return false   // signal that the caller should shutdown.
+		}
 	}
 }
 
 func sendNilControlResponse(c ControlAction) {
 	c.ResponseChan <- nil // respond that we're done with a nil error.
 }
+
+// HandlePauseAction blocks a component that received Pause action c on controlChan until a Resume or Shutdown action
+// arrives. Function beforePauseFn is called first, if it is not nil, so the component can commit work in progress
+// and any error it returns is sent in response to the Pause. If beforePauseFn fails, the component does not pause and
+// the Pause action is returned as a Resume so the caller carries on. Resume actions are acknowledged and returned, so
+// the caller can carry on processing. All other actions, including a Shutdown received while paused, are returned without
+// a response for the caller to handle as usual.
+func HandlePauseAction(c ControlAction, controlChan chan ControlAction, beforePauseFn func() error) ControlAction {
+	switch c.Action {
+	case Resume: // if we were not paused...
+		c.ResponseChan <- nil // there is nothing to do.
+		return c
+	case Pause:
+	default:
+		return c
+	}
+	var err error
+	if beforePauseFn != nil {
+		err = beforePauseFn()
+	}
+	c.ResponseChan <- err // respond that we're paused or failed to pause.
+	if err != nil {       // if we failed to pause...
+		c.Action = Resume // carry on as if resumed.
+		return c
+	}
+	// Wait until we are resumed or shutdown.
+	for {
+		c = <-controlChan
+		switch c.Action {
+		case Pause: // if we are already paused...
+			c.ResponseChan <- nil
+		case Resume:
+			c.ResponseChan <- nil
+			return c
+		default:
+			return c
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package components
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestHandlePauseAction(t *testing.T) {
+	defaultTimeout := 5 * time.Second
+	controlChan := make(chan ControlAction, 1)
+	runPause := func(beforePauseFn func() error) (pauseResponse chan error, done chan ControlAction) {
+		pauseResponse = make(chan error, 1)
+		done = make(chan ControlAction, 1)
+		go func() {
+			done <- HandlePauseAction(ControlAction{Action: Pause, ResponseChan: pauseResponse}, controlChan, beforePauseFn)
+		}()
+		select {
+		case <-pauseResponse:
+		case <-time.After(defaultTimeout):
+			t.Fatal("timeout waiting for pause response")
+		}
+		return
+	}
+
+	// Test 1 - actions other than pause and resume are returned without a response.
+	c := HandlePauseAction(ControlAction{Action: Shutdown, ResponseChan: make(chan error, 1)}, controlChan, nil)
+	if c.Action != Shutdown || len(c.ResponseChan) != 0 {
+		t.Fatal("Test 1, expected shutdown to be returned without a response")
+	}
+
+	// Test 2 - resume without pause is acknowledged and returned.
+	c = HandlePauseAction(ControlAction{Action: Resume, ResponseChan: make(chan error, 1)}, controlChan, nil)
+	if c.Action != Resume || len(c.ResponseChan) != 1 {
+		t.Fatal("Test 2, expected resume to be acknowledged and returned")
+	}
+
+	// Test 3 - pause blocks until resume.
+	_, done := runPause(nil)
+	secondPause := ControlAction{Action: Pause, ResponseChan: make(chan error, 1)}
+	controlChan <- secondPause
+	select {
+	case <-secondPause.ResponseChan: // expect a repeat pause to be acknowledged.
+	case <-time.After(defaultTimeout):
+		t.Fatal("Test 3, timeout waiting for response to repeat pause")
+	}
+	select {
+	case <-done:
+		t.Fatal("Test 3, expected pause to block")
+	case <-time.After(100 * time.Millisecond):
+	}
+	resume := ControlAction{Action: Resume, ResponseChan: make(chan error, 1)}
+	controlChan <- resume
+	select {
+	case c = <-done:
+		if c.Action != Resume {
+			t.Fatal("Test 3, expected resume to be returned; got ", c.Action)
+		}
+	case <-time.After(defaultTimeout):
+		t.Fatal("Test 3, timeout waiting for resume")
+	}
+	if len(resume.ResponseChan) != 1 {
+		t.Fatal("Test 3, expected resume to be acknowledged")
+	}
+
+	// Test 4 - shutdown while paused is returned without a response.
+	_, done = runPause(nil)
+	shutdown := ControlAction{Action: Shutdown, ResponseChan: make(chan error, 1)}
+	controlChan <- shutdown
+	select {
+	case c = <-done:
+		if c.Action != Shutdown || len(shutdown.ResponseChan) != 0 {
+			t.Fatal("Test 4, expected shutdown to be returned without a response")
+		}
+	case <-time.After(defaultTimeout):
+		t.Fatal("Test 4, timeout waiting for shutdown")
+	}
+
+	// Test 5 - beforePauseFn is called, its error is sent in response to the pause and the component doesn't pause.
+	called := false
+	pauseResponse := make(chan error, 1)
+	c = HandlePauseAction(ControlAction{Action: Pause, ResponseChan: pauseResponse}, controlChan, func() error {
+		called = true
+		return errors.New("commit failed")
+	})
+	if c.Action != Resume {
+		t.Fatal("Test 5, expected resume to be returned after beforePauseFn failed; got ", c.Action)
+	}
+	if err := <-pauseResponse; !called || err == nil || err.Error() != "commit failed" {
+		t.Fatal("Test 5, expected the error from beforePauseFn in response to pause; got ", err)
+	}
+}
+
+func TestSafeSendPause(t *testing.T) {
+	defaultTimeout := 5 * time.Second
+	outputChan := make(chan stream.Record) // unbuffered so safeSend blocks.
+	controlChan := make(chan ControlAction, 1)
+	result := make(chan bool, 1)
+	go func() {
+		result <- safeSend(stream.NewRecord(), outputChan, controlChan, sendNilControlResponse)
+	}()
+	// Test 1 - safeSend tries again after pause and resume.
+	for _, a := range []Action{Pause, Resume} {
+		c := ControlAction{Action: a, ResponseChan: make(chan error, 1)}
+		controlChan <- c
+		select {
+		case <-c.ResponseChan:
+		case <-time.After(defaultTimeout):
+			t.Fatal("Test 1, timeout waiting for response to ", a)
+		}
+	}
+	select {
+	case <-outputChan:
+	case <-time.After(defaultTimeout):
+		t.Fatal("Test 1, timeout waiting for record after resume")
+	}
+	if ok := <-result; !ok {
+		t.Fatal("Test 1, expected safeSend to succeed after resume")
+	}
+	// Test 2 - safeSend returns false after shutdown while paused.
+	go func() {
+		result <- safeSend(stream.NewRecord(), outputChan, controlChan, sendNilControlResponse)
+	}()
+	for _, a := range []Action{Pause, Shutdown} {
+		c := ControlAction{Action: a, ResponseChan: make(chan error, 1)}
+		controlChan <- c
+		select {
+		case <-c.ResponseChan:
+		case <-time.After(defaultTimeout):
+			t.Fatal("Test 2, timeout waiting for response to ", a)
+		}
+	}
+	if ok := <-result; ok {
+		t.Fatal("Test 2, expected safeSend to fail after shutdown")
+	}
+}
@@ -0,0 +1,439 @@
+package components
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/kafka"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+const (
+	kafkaDefaultBatchSize         = 100
+	kafkaDefaultKeySeparator      = "|"
+	kafkaDefaultCommitWaitSeconds = 30
+)
+
+type KafkaProducerConfig struct {
+	Log            logger.Logger
+	Name           string
+	InputChan      chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	Brokers        []string           `data:"brokersCSV" mandatory:"yes"` // list of host:port.
+	Topic          string             `data:"topic" mandatory:"yes"`
+	KeyFields      []string           `data:"keyFieldsCSV"`   // fields joined to make the message key; leave empty for no key.
+	KeySeparator   string             `data:"keySeparator"`   // defaults to "|".
+	ValueFields    []string           `data:"valueFieldsCSV"` // fields in the message value; defaults to all fields not starting with "#"; mandatory for Avro.
+	Format         string             `data:"format"`         // json (default) or avro, see kafka.NewCodec().
+	BatchSize      int                `data:"batchSize"`      // max number of messages produced at once; defaults to 100.
+	StepWatcher    *stats.StepWatcher
+	WaitCounter    ComponentWaiter
+	PanicHandlerFn PanicHandlerFunc
+	fnNewProducer  func(brokers []string, topic string) (kafka.Producer, error)
+}
+
+// NewKafkaProducer writes each record found on InputChan as a message to Kafka topic Topic.
+// The message value is encoded using Format with fields ValueFields and the key is made by joining the values of
+// KeyFields, so records with the same key are written to the same partition.
+// Messages are produced in batches of up to BatchSize or whenever the input is drained.
+// Records are passed to outputChan once their batch has been acknowledged by the brokers.
+func NewKafkaProducer(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*KafkaProducerConfig)
+	if cfg.fnNewProducer == nil {
+		cfg.fnNewProducer = kafka.NewProducer
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = kafkaDefaultBatchSize
+	}
+	if cfg.KeySeparator == "" {
+		cfg.KeySeparator = kafkaDefaultKeySeparator
+	}
+	codec, err := kafka.NewCodec(cfg.Format, cfg.ValueFields)
+	if err != nil {
+		cfg.Log.Panic(cfg.Name, " error - ", err)
+	}
+	producer, err := cfg.fnNewProducer(cfg.Brokers, cfg.Topic)
+	if err != nil {
+		cfg.Log.Panic(cfg.Name, " unable to create Kafka producer: ", err)
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		defer producer.Close()
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a StepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		msgs := make([]kafka.Message, 0, cfg.BatchSize)
+		recs := make([]stream.Record, 0, cfg.BatchSize)
+		flush := func() (recsSentOK bool) {
+			if len(msgs) == 0 {
+				return true
+			}
+			if err := producer.Produce(context.Background(), msgs...); err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to produce Kafka messages: ", err)
+			}
+			cfg.Log.Debug(cfg.Name, " produced ", len(msgs), " messages to topic ", cfg.Topic)
+			for _, rec := range recs {
+				if recSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSentOK {
+					return false
+				}
+			}
+			msgs = msgs[:0]
+			recs = recs[:0]
+			return true
+		}
+		for { // loop until the input channel is closed...
+			select {
+			case rec, ok := <-cfg.InputChan:
+				if !ok { // if there is no more input data...
+					cfg.InputChan = nil
+					break
+				}
+				atomic.AddInt64(&rowCount, 1)
+				msg, err := getKafkaMessage(cfg.Log, rec, codec, cfg.KeyFields, cfg.KeySeparator, cfg.ValueFields)
+				if err != nil {
+					cfg.Log.Panic(cfg.Name, " error - ", err)
+				}
+				msgs = append(msgs, msg)
+				recs = append(recs, rec)
+				if len(msgs) >= cfg.BatchSize || len(cfg.InputChan) == 0 { // if the batch is full or there is no more input waiting...
+					if recsSentOK := flush(); !recsSentOK {
+						cfg.Log.Info(cfg.Name, " shutdown")
+						return
+					}
+				}
+			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+			if cfg.InputChan == nil {
+				break
+			}
+		}
+		if recsSentOK := flush(); !recsSentOK {
+			cfg.Log.Info(cfg.Name, " shutdown")
+			return
+		}
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return
+}
+
+// getKafkaMessage returns a message with the key and value built from the fields of rec.
+// If valueFields is empty, all fields not starting with "#" are used.
+func getKafkaMessage(log logger.Logger, rec stream.Record, codec kafka.Codec, keyFields []string, keySeparator string, valueFields []string) (kafka.Message, error) {
+	msg := kafka.Message{}
+	if len(keyFields) > 0 {
+		msg.Key = []byte(strings.Join(rec.GetDataKeysAsSlice(log, keyFields), keySeparator))
+	}
+	fields := valueFields
+	if len(fields) == 0 {
+		fields = make([]string, 0, rec.GetDataLen())
+		for _, k := range rec.GetSortedDataMapKeys() {
+			if !strings.HasPrefix(k, "#") {
+				fields = append(fields, k)
+			}
+		}
+	}
+	values := make(map[string]interface{}, len(fields))
+	for _, k := range fields {
+		if rec.GetData(k) == nil {
+			values[k] = nil
+		} else {
+			values[k] = rec.GetDataAsStringPreserveTimeZone(log, k)
+		}
+	}
+	var err error
+	msg.Value, err = codec.Encode(fields, values)
+	return msg, err
+}
+
+type KafkaConsumerConfig struct {
+	Log                logger.Logger
+	Name               string
+	Brokers            []string `data:"brokersCSV" mandatory:"yes"` // list of host:port.
+	Topic              string   `data:"topic" mandatory:"yes"`
+	GroupID            string   `data:"groupId" mandatory:"yes"`
+	Format             string   `data:"format"`              // json (default) or avro, see kafka.NewCodec().
+	Fields             []string `data:"fieldsCSV"`           // fields to output from the message value; defaults to all fields found in JSON messages; mandatory for Avro.
+	OutputField4Key    string   `data:"outputFieldName4Key"` // optional field to contain the message key.
+	OutputField4Ack    string   `data:"outputFieldName4Ack"` // field to contain the handle used by KafkaCommit; defaults to Defaults.ChanField4KafkaAck.
+	MaxMessages        int      `data:"maxMessages"`         // stop after this many messages; 0 reads forever.
+	IdleTimeoutSeconds int      `data:"idleTimeoutSeconds"`  // stop when no message arrives for this many seconds; 0 waits forever.
+	CommitWaitSeconds  int      `data:"commitWaitSeconds"`   // time to wait for outstanding messages to be committed before closing the consumer; defaults to 30.
+	StepWatcher        *stats.StepWatcher
+	WaitCounter        ComponentWaiter
+	PanicHandlerFn     PanicHandlerFunc
+	fnNewConsumer      func(brokers []string, topic string, groupID string) (kafka.Consumer, error)
+}
+
+// NewKafkaConsumer reads messages from Kafka topic Topic as a member of consumer group GroupID and produces a
+// record per message on outputChan containing the fields decoded from the message value using Format.
+// Offsets are not committed here: each record contains a handle in field OutputField4Ack that should be passed to
+// a downstream KafkaCommit step, so messages are only committed once they have been processed successfully.
+// The step reads forever unless MaxMessages or IdleTimeoutSeconds are set.
+// When it stops, outputChan is closed and the consumer remains open for up to CommitWaitSeconds so downstream steps
+// can commit outstanding messages.
+func NewKafkaConsumer(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*KafkaConsumerConfig)
+	if cfg.fnNewConsumer == nil {
+		cfg.fnNewConsumer = kafka.NewConsumer
+	}
+	if cfg.OutputField4Ack == "" {
+		cfg.OutputField4Ack = Defaults.ChanField4KafkaAck
+	}
+	if cfg.CommitWaitSeconds <= 0 {
+		cfg.CommitWaitSeconds = kafkaDefaultCommitWaitSeconds
+	}
+	codec, err := kafka.NewCodec(cfg.Format, cfg.Fields)
+	if err != nil {
+		cfg.Log.Panic(cfg.Name, " error - ", err)
+	}
+	consumer, err := cfg.fnNewConsumer(cfg.Brokers, cfg.Topic, cfg.GroupID)
+	if err != nil {
+		cfg.Log.Panic(cfg.Name, " unable to create Kafka consumer: ", err)
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		defer consumer.Close()
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a StepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		// Fetch messages in the background so we can respond to shutdown requests.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		msgChan := make(chan kafka.Message)
+		errChan := make(chan error, 1)
+		go func() {
+			for {
+				msg, err := consumer.Fetch(ctx)
+				if err != nil {
+					errChan <- err
+					return
+				}
+				select {
+				case msgChan <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		outstanding := &sync.WaitGroup{} // count of messages sent downstream but not yet committed.
+		for {                            // loop until we reach a limit...
+			var idleTimeout <-chan time.Time
+			if cfg.IdleTimeoutSeconds > 0 {
+				idleTimeout = time.After(time.Duration(cfg.IdleTimeoutSeconds) * time.Second)
+			}
+			select {
+			case msg := <-msgChan:
+				rec, err := getKafkaRecord(msg, codec, cfg.Fields)
+				if err != nil {
+					cfg.Log.Panic(cfg.Name, " error at topic ", msg.Topic, " partition ", msg.Partition, " offset ", msg.Offset, ": ", err)
+				}
+				if cfg.OutputField4Key != "" {
+					rec.SetData(cfg.OutputField4Key, string(msg.Key))
+				}
+				outstanding.Add(1)
+				rec.SetData(cfg.OutputField4Ack, kafka.NewAck(consumer, msg, outstanding.Done))
+				if recSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSentOK {
+					cfg.Log.Info(cfg.Name, " shutdown")
+					return
+				}
+				atomic.AddInt64(&rowCount, 1)
+			case err := <-errChan:
+				cfg.Log.Panic(cfg.Name, " unable to fetch Kafka message: ", err)
+			case <-idleTimeout:
+				cfg.Log.Info(cfg.Name, " no messages received for ", cfg.IdleTimeoutSeconds, " seconds")
+				cfg.IdleTimeoutSeconds = -1 // flag that we should stop.
+			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+			if cfg.IdleTimeoutSeconds < 0 || (cfg.MaxMessages > 0 && atomic.LoadInt64(&rowCount) >= int64(cfg.MaxMessages)) {
+				break
+			}
+		}
+		cancel() // stop fetching.
+		close(outputChan)
+		// Wait for downstream steps to commit outstanding messages before the deferred close of the consumer.
+		done := make(chan struct{})
+		go func() {
+			outstanding.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Duration(cfg.CommitWaitSeconds) * time.Second):
+			cfg.Log.Warn(cfg.Name, " timed out waiting for outstanding Kafka messages to be committed")
+		}
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return
+}
+
+// getKafkaRecord returns a new record containing the fields decoded from the value of msg.
+// If fields is not empty, only those fields are output and any missing from the message are set to nil.
+func getKafkaRecord(msg kafka.Message, codec kafka.Codec, fields []string) (stream.Record, error) {
+	rec := stream.NewRecord()
+	values, err := codec.Decode(msg.Value)
+	if err != nil {
+		return rec, err
+	}
+	if len(fields) > 0 {
+		for _, k := range fields {
+			rec.SetData(k, values[k])
+		}
+	} else {
+		for k, v := range values {
+			rec.SetData(k, v)
+		}
+	}
+	return rec, nil
+}
+
+type KafkaCommitConfig struct {
+	Log                   logger.Logger
+	Name                  string
+	InputChan             chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	InputField4Ack        string             `data:"inputFieldName4Ack"`    // field containing the handle produced by KafkaConsumer; defaults to Defaults.ChanField4KafkaAck.
+	CommitSequenceKeyName string             `data:"commitSequenceKeyName"` // optional field incremented by an upstream step, like TableSync, when it commits a batch.
+	StepWatcher           *stats.StepWatcher
+	WaitCounter           ComponentWaiter
+	PanicHandlerFn        PanicHandlerFunc
+}
+
+// NewKafkaCommit commits the offsets of the Kafka messages for records found on InputChan, which must contain
+// the handle produced by a KafkaConsumer step in field InputField4Ack.
+// Records are passed to outputChan once their messages have been committed.
+// If CommitSequenceKeyName is empty, messages are committed per record.
+// Else, records are held until the value in field CommitSequenceKeyName changes, which means the upstream step has
+// committed all records received before it, e.g. TableSync increments field #commitSequence when it commits.
+// The held records are committed when the input is closed, since upstream steps commit all work before closing
+// their output.
+func NewKafkaCommit(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*KafkaCommitConfig)
+	if cfg.InputField4Ack == "" {
+		cfg.InputField4Ack = Defaults.ChanField4KafkaAck
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a StepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		acks := make([]*kafka.Ack, 0)
+		recs := make([]stream.Record, 0)
+		var lastSequence interface{}
+		commit := func() (recsSentOK bool) {
+			if len(acks) == 0 {
+				return true
+			}
+			if err := kafka.CommitAcks(context.Background(), acks...); err != nil {
+				cfg.Log.Panic(cfg.Name, " error - ", err)
+			}
+			cfg.Log.Debug(cfg.Name, " committed ", len(acks), " Kafka messages")
+			for _, rec := range recs {
+				if recSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSentOK {
+					return false
+				}
+			}
+			acks = acks[:0]
+			recs = recs[:0]
+			return true
+		}
+		for { // loop until the input channel is closed...
+			select {
+			case rec, ok := <-cfg.InputChan:
+				if !ok { // if there is no more input data...
+					cfg.InputChan = nil
+					break
+				}
+				atomic.AddInt64(&rowCount, 1)
+				ack, ok := rec.GetData(cfg.InputField4Ack).(*kafka.Ack)
+				if !ok {
+					cfg.Log.Panic(cfg.Name, " error - field ", cfg.InputField4Ack, " does not contain a Kafka message handle")
+				}
+				if cfg.CommitSequenceKeyName != "" { // if we should wait for the upstream commit...
+					seq := rec.GetData(cfg.CommitSequenceKeyName)
+					if len(acks) > 0 && seq != lastSequence { // if upstream committed the records we're holding...
+						if recsSentOK := commit(); !recsSentOK {
+							cfg.Log.Info(cfg.Name, " shutdown")
+							return
+						}
+					}
+					lastSequence = seq
+				}
+				acks = append(acks, ack)
+				recs = append(recs, rec)
+				if cfg.CommitSequenceKeyName == "" {
+					if recsSentOK := commit(); !recsSentOK {
+						cfg.Log.Info(cfg.Name, " shutdown")
+						return
+					}
+				}
+			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+			if cfg.InputChan == nil {
+				break
+			}
+		}
+		if recsSentOK := commit(); !recsSentOK {
+			cfg.Log.Info(cfg.Name, " shutdown")
+			return
+		}
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return
+}
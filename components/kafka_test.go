@@ -0,0 +1,105 @@
+package components
+
+import (
+	"context"
+	"testing"
+
+	"github.com/relloyd/halfpipe/kafka"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestKafkaProducerConsumerCommit(t *testing.T) {
+	log := logger.NewLogger("kafka test", "info", true)
+	for _, format := range []string{kafka.FormatJson, kafka.FormatAvro} {
+		broker := kafka.NewMockBroker(2)
+		// Produce 5 records.
+		input := make(chan stream.Record, 5)
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			rec := stream.NewRecord()
+			rec.SetData("ID", id)
+			rec.SetData("NAME", "name"+id)
+			rec.SetData("#internal", "ignored")
+			input <- rec
+		}
+		close(input)
+		pcfg := &KafkaProducerConfig{Log: log, Name: "Test KafkaProducer", InputChan: input, Topic: "t1",
+			KeyFields: []string{"ID"}, Format: format, BatchSize: 2, fnNewProducer: broker.NewProducer}
+		if format == kafka.FormatAvro {
+			pcfg.ValueFields = []string{"ID", "NAME"}
+		}
+		o, _ := NewKafkaProducer(pcfg)
+		count := 0
+		for range o {
+			count++
+		}
+		if count != 5 || len(broker.Messages("t1")) != 5 {
+			t.Fatal(format, ": expected 5 records and messages; got ", count, " records and ", len(broker.Messages("t1")), " messages")
+		}
+
+		// Test 1 - consume the messages and commit them per record.
+		ccfg := &KafkaConsumerConfig{Log: log, Name: "Test KafkaConsumer", Topic: "t1", GroupID: "g1", Format: format,
+			OutputField4Key: "#key", MaxMessages: 5, fnNewConsumer: broker.NewConsumer}
+		if format == kafka.FormatAvro {
+			ccfg.Fields = []string{"ID", "NAME"}
+		}
+		o, _ = NewKafkaConsumer(ccfg)
+		o, _ = NewKafkaCommit(&KafkaCommitConfig{Log: log, Name: "Test KafkaCommit", InputChan: o})
+		for rec := range o {
+			id := rec.GetDataAsStringPreserveTimeZone(log, "ID")
+			if rec.GetData("NAME") != "name"+id || rec.GetData("#key") != id || rec.GetDataLen() != 4 {
+				t.Fatal(format, ": unexpected record ", rec.GetDataMap())
+			}
+		}
+		if total := sumOffsets(broker.Committed("g1", "t1")); total != 5 {
+			t.Fatal(format, ": expected 5 messages committed; got ", total)
+		}
+
+		// Test 2 - a new consumer in the same group finds no messages.
+		ccfg.MaxMessages = 0
+		ccfg.IdleTimeoutSeconds = 1
+		o, _ = NewKafkaConsumer(ccfg)
+		for rec := range o {
+			t.Fatal(format, ": expected no records; got ", rec.GetDataMap())
+		}
+	}
+}
+
+func TestKafkaCommitSequence(t *testing.T) {
+	log := logger.NewLogger("kafka test", "info", true)
+	broker := kafka.NewMockBroker(1)
+	p, _ := broker.NewProducer(nil, "t1")
+	consumer, _ := broker.NewConsumer(nil, "t1", "g1")
+	// Build records that look like the output of TableSync with a commit after the second record.
+	input := make(chan stream.Record, 3)
+	for idx, seq := range []int{0, 1, 1} {
+		msg := kafka.Message{Topic: "t1", Partition: 0, Offset: int64(idx)}
+		_ = p.Produce(context.Background(), msg)
+		rec := stream.NewRecord()
+		rec.SetData("#commitSequence", seq)
+		rec.SetData(Defaults.ChanField4KafkaAck, kafka.NewAck(consumer, msg, nil))
+		input <- rec
+	}
+	o, _ := NewKafkaCommit(&KafkaCommitConfig{Log: log, Name: "Test KafkaCommit", InputChan: input, CommitSequenceKeyName: "#commitSequence"})
+	// Test 1 - the first record is committed when the sequence changes.
+	<-o
+	if got := broker.Committed("g1", "t1")[0]; got != 1 {
+		t.Fatal("expected committed offset 1; got ", got)
+	}
+	// Test 2 - remaining records are committed when the input is closed.
+	close(input)
+	count := 0
+	for range o {
+		count++
+	}
+	if got := broker.Committed("g1", "t1")[0]; count != 2 || got != 3 {
+		t.Fatal("expected 2 more records and committed offset 3; got ", count, " records and offset ", got)
+	}
+}
+
+func sumOffsets(m map[int]int64) (total int64) {
+	for _, v := range m {
+		total += v
+	}
+	return
+}
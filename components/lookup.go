@@ -0,0 +1,465 @@
+package components
+
+import (
+	"container/list"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	om "github.com/cevaris/ordered_map"
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	s "github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// Values for the lookupMode of a DatabaseLookup step.
+const (
+	LookupModeCache = "cache" // load all reference rows into memory before processing input (default).
+	LookupModeQuery = "query" // query the database per key and keep recent results in a LRU cache.
+)
+
+// Values for the onMiss setting of lookup steps, which control what happens to records whose keys are not found.
+const (
+	LookupOnMissNull    = "null"    // add the return fields with null values (default).
+	LookupOnMissDefault = "default" // add the return fields using defaultValues.
+	LookupOnMissReject  = "reject"  // pass the record to the RowErrorHandler.
+)
+
+// lookupDefaultCacheSize is the number of keys held by the LRU cache when a DatabaseLookup does not specify cacheSize.
+const lookupDefaultCacheSize = 10000
+
+// lookupKeySeparator joins the string form of composite key values.
+const lookupKeySeparator = "\x1f"
+
+type DatabaseLookupConfig struct {
+	Log             logger.Logger
+	Name            string
+	InputChan       chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	Db              shared.Connector   `data:"databaseConnectionName" mandatory:"yes"`
+	SqlText         string             `data:"sqlText" mandatory:"yes"`
+	KeyFields       *om.OrderedMap     `data:"keyFields" mandatory:"yes"`    // map of input field name to reference column name.
+	ReturnFields    []string           `data:"returnFields" mandatory:"yes"` // reference columns to add to each record.
+	LookupMode      string             `data:"lookupMode"`                   // one of the LookupMode* constants.
+	CacheSize       int                `data:"cacheSize"`                    // max keys held in the LRU cache when lookupMode is query.
+	OnMiss          string             `data:"onMiss"`                       // one of the LookupOnMiss* constants.
+	DefaultValues   map[string]string  `data:"defaultValues"`                // map of return field name to value used when onMiss is default.
+	StepWatcher     *s.StepWatcher
+	WaitCounter     ComponentWaiter
+	PanicHandlerFn  PanicHandlerFunc
+	RowErrorHandler *RowErrorHandler // optional handler for records whose keys are not found; defaults to fail.
+}
+
+type FileLookupConfig struct {
+	Log             logger.Logger
+	Name            string
+	InputChan       chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	FileName        string             `data:"fileName" mandatory:"yes"`     // path to a local CSV file with a header row.
+	Delimiter       string             `data:"delimiter"`                    // single character field delimiter; defaults to comma.
+	KeyFields       *om.OrderedMap     `data:"keyFields" mandatory:"yes"`    // map of input field name to CSV column name.
+	ReturnFields    []string           `data:"returnFields" mandatory:"yes"` // CSV columns to add to each record.
+	OnMiss          string             `data:"onMiss"`                       // one of the LookupOnMiss* constants.
+	DefaultValues   map[string]string  `data:"defaultValues"`                // map of return field name to value used when onMiss is default.
+	StepWatcher     *s.StepWatcher
+	WaitCounter     ComponentWaiter
+	PanicHandlerFn  PanicHandlerFunc
+	RowErrorHandler *RowErrorHandler // optional handler for records whose keys are not found; defaults to fail.
+}
+
+// lookupFunc returns the reference row matching the key values supplied in the order of the lookup key fields.
+// It returns a nil row if there is no match.
+type lookupFunc func(keyValues []interface{}) (map[string]interface{}, error)
+
+// lookupStep holds the settings shared by lookup components.
+type lookupStep struct {
+	log             logger.Logger
+	name            string
+	inputChan       chan stream.Record
+	inputKeys       []string // input field names.
+	refKeys         []string // reference column names in the same order as inputKeys.
+	returnFields    []string
+	onMiss          string
+	defaultValues   map[string]string
+	stepWatcher     *s.StepWatcher
+	waitCounter     ComponentWaiter
+	panicHandlerFn  PanicHandlerFunc
+	rowErrorHandler *RowErrorHandler
+}
+
+// NewDatabaseLookup adds ReturnFields to each record on InputChan using reference data fetched by SqlText.
+// KeyFields maps the input fields to the reference columns that they join to.
+// If LookupMode is cache then SqlText should return all reference rows, which are loaded into memory before
+// processing input; keys must be unique.
+// If LookupMode is query then SqlText is executed once per key not found in the LRU cache, where bind variables are
+// populated with the values of the input fields in the order of KeyFields. The query must return at most one row.
+// Records whose keys are not found, or contain nulls, are handled according to OnMiss.
+func NewDatabaseLookup(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*DatabaseLookupConfig)
+	step := newLookupStep(cfg.Log, cfg.Name, cfg.InputChan, cfg.KeyFields, cfg.ReturnFields, cfg.OnMiss,
+		cfg.DefaultValues, cfg.StepWatcher, cfg.WaitCounter, cfg.PanicHandlerFn, cfg.RowErrorHandler)
+	return startLookup(step, func() lookupFunc {
+		switch cfg.LookupMode {
+		case "", LookupModeCache:
+			cfg.Log.Info(cfg.Name, " loading reference data using SQL: ", cfg.SqlText)
+			rows, err := cfg.Db.Query(cfg.SqlText)
+			if err != nil {
+				cfg.Log.Panic(fmt.Sprintf("%v received error during database query using SQL: '%v' %v", cfg.Name, cfg.SqlText, err))
+			}
+			cache := newLookupCache(step)
+			if err = scanLookupRows(rows, cache.add); err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to load reference data: ", err)
+			}
+			cfg.Log.Info(cfg.Name, " loaded ", len(cache.rows), " reference rows")
+			return cache.get
+		case LookupModeQuery:
+			size := cfg.CacheSize
+			if size <= 0 {
+				size = lookupDefaultCacheSize
+			}
+			return newLookupLRU(cfg.Log, size, func(keyValues []interface{}) (map[string]interface{}, error) {
+				return queryLookupRow(cfg.Db, cfg.SqlText, keyValues, step.returnFields)
+			}).get
+		default:
+			cfg.Log.Panic(fmt.Sprintf("%v unsupported lookupMode %q: use %v or %v", cfg.Name, cfg.LookupMode, LookupModeCache, LookupModeQuery))
+			return nil
+		}
+	})
+}
+
+// NewFileLookup adds ReturnFields to each record on InputChan using reference data read from the local CSV file
+// FileName, which must have a header row. All CSV rows are loaded into memory before processing input and keys
+// must be unique. KeyFields maps the input fields to the CSV columns that they join to.
+// Values are compared as strings and CSV values are added to records as strings.
+// Records whose keys are not found, or contain nulls, are handled according to OnMiss.
+func NewFileLookup(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*FileLookupConfig)
+	step := newLookupStep(cfg.Log, cfg.Name, cfg.InputChan, cfg.KeyFields, cfg.ReturnFields, cfg.OnMiss,
+		cfg.DefaultValues, cfg.StepWatcher, cfg.WaitCounter, cfg.PanicHandlerFn, cfg.RowErrorHandler)
+	return startLookup(step, func() lookupFunc {
+		cfg.Log.Info(cfg.Name, " loading reference data from file: ", cfg.FileName)
+		cache := newLookupCache(step)
+		if err := readLookupFile(cfg.FileName, cfg.Delimiter, cache.add); err != nil {
+			cfg.Log.Panic(cfg.Name, " unable to load reference data: ", err)
+		}
+		cfg.Log.Info(cfg.Name, " loaded ", len(cache.rows), " reference rows")
+		return cache.get
+	})
+}
+
+func newLookupStep(log logger.Logger, name string, inputChan chan stream.Record, keyFields *om.OrderedMap,
+	returnFields []string, onMiss string, defaultValues map[string]string, stepWatcher *s.StepWatcher,
+	waitCounter ComponentWaiter, panicHandlerFn PanicHandlerFunc, rowErrorHandler *RowErrorHandler) *lookupStep {
+	step := &lookupStep{
+		log:             log,
+		name:            name,
+		inputChan:       inputChan,
+		returnFields:    returnFields,
+		onMiss:          onMiss,
+		defaultValues:   defaultValues,
+		stepWatcher:     stepWatcher,
+		waitCounter:     waitCounter,
+		panicHandlerFn:  panicHandlerFn,
+		rowErrorHandler: rowErrorHandler,
+	}
+	if keyFields != nil {
+		iter := keyFields.IterFunc()
+		for kv, ok := iter(); ok; kv, ok = iter() { // for each input field:reference column pair...
+			step.inputKeys = append(step.inputKeys, kv.Key.(string))
+			step.refKeys = append(step.refKeys, kv.Value.(string))
+		}
+	}
+	if step.onMiss == "" {
+		step.onMiss = LookupOnMissNull
+	}
+	if step.rowErrorHandler == nil {
+		step.rowErrorHandler, _ = NewRowErrorHandler(log, name, OnErrorFail, 0)
+	}
+	return step
+}
+
+// startLookup runs the lookup step, where setup is called once the step is running to load reference data and
+// return the function used to find rows.
+func startLookup(step *lookupStep, setup func() lookupFunc) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if step.panicHandlerFn != nil {
+			defer step.panicHandlerFn()
+		}
+		step.log.Info(step.name, " is running")
+		if step.waitCounter != nil {
+			step.waitCounter.Add()
+			defer step.waitCounter.Done()
+		}
+		rowCount := int64(0)
+		if step.stepWatcher != nil { // if we have been given a stepWatcher struct that can watch our rowCount and output channel length...
+			step.stepWatcher.StartWatching(&rowCount, &outputChan)
+			defer step.stepWatcher.StopWatching()
+		}
+		if len(step.inputKeys) == 0 {
+			step.log.Panic(step.name, " missing keyFields")
+		}
+		if len(step.returnFields) == 0 {
+			step.log.Panic(step.name, " missing returnFields")
+		}
+		switch step.onMiss {
+		case LookupOnMissNull, LookupOnMissDefault, LookupOnMissReject:
+		default:
+			step.log.Panic(fmt.Sprintf("%v unsupported onMiss value %q: use %v", step.name, step.onMiss,
+				strings.Join([]string{LookupOnMissNull, LookupOnMissDefault, LookupOnMissReject}, ", ")))
+		}
+		lookup := setup()
+		keyValues := make([]interface{}, len(step.inputKeys))
+		var controlAction ControlAction
+		for {
+			select {
+			case rec, ok := <-step.inputChan:
+				if !ok { // if we have run out of rows...
+					step.inputChan = nil // disable this case
+				} else { // process the row...
+					var row map[string]interface{}
+					var err error
+					hasNull := false
+					for idx, k := range step.inputKeys { // for each key field...
+						keyValues[idx] = rec.GetData(k)
+						if keyValues[idx] == nil {
+							hasNull = true // nulls never match.
+						}
+					}
+					if !hasNull {
+						if row, err = lookup(keyValues); err != nil {
+							step.rowErrorHandler.Reject(rec, fmt.Errorf("lookup failed for key %v: %v", keyValues, err))
+							break // skip to the next record.
+						}
+					}
+					if row == nil { // if the key was not found...
+						if step.onMiss == LookupOnMissReject {
+							step.rowErrorHandler.Reject(rec, fmt.Errorf("lookup key %v not found", keyValues))
+							break // skip to the next record.
+						}
+						for _, f := range step.returnFields {
+							if v, ok := step.defaultValues[f]; ok && step.onMiss == LookupOnMissDefault {
+								rec.SetData(f, v)
+							} else {
+								rec.SetData(f, nil)
+							}
+						}
+					} else {
+						for _, f := range step.returnFields {
+							rec.SetData(f, row[f])
+						}
+					}
+					if rowSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !rowSentOK { // if we couldn't output the row due to shutdown...
+						step.log.Info(step.name, " shutdown")
+						return
+					}
+					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+				}
+			case controlAction = <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				step.log.Info(step.name, " shutdown")
+				return
+			}
+			if step.inputChan == nil { // if we should exit gracefully...
+				break
+			}
+		}
+		step.rowErrorHandler.Close()
+		close(outputChan)
+		step.log.Info(step.name, " complete")
+	}()
+	return outputChan, controlChan
+}
+
+// getLookupKey returns the string used to compare key values, so that e.g. numbers from a database match strings
+// read from a file.
+func getLookupKey(log logger.Logger, keyValues []interface{}) string {
+	b := strings.Builder{}
+	for idx, v := range keyValues {
+		if idx > 0 {
+			b.WriteString(lookupKeySeparator)
+		}
+		b.WriteString(helper.GetStringFromInterfacePreserveTimeZone(log, v))
+	}
+	return b.String()
+}
+
+// lookupCache holds all reference rows in memory.
+type lookupCache struct {
+	step *lookupStep
+	rows map[string]map[string]interface{}
+}
+
+func newLookupCache(step *lookupStep) *lookupCache {
+	return &lookupCache{step: step, rows: make(map[string]map[string]interface{})}
+}
+
+// add saves a reference row, which must contain the key and return columns.
+// Rows with null keys are ignored since they can never match.
+func (l *lookupCache) add(row map[string]interface{}) error {
+	keyValues := make([]interface{}, len(l.step.refKeys))
+	for idx, k := range l.step.refKeys {
+		v, ok := row[k]
+		if !ok {
+			return fmt.Errorf("key column %q not found in reference data", k)
+		}
+		if v == nil {
+			return nil
+		}
+		keyValues[idx] = v
+	}
+	for _, f := range l.step.returnFields {
+		if _, ok := row[f]; !ok {
+			return fmt.Errorf("return column %q not found in reference data", f)
+		}
+	}
+	key := getLookupKey(l.step.log, keyValues)
+	if _, exists := l.rows[key]; exists {
+		return fmt.Errorf("duplicate key %v found in reference data", keyValues)
+	}
+	l.rows[key] = row
+	return nil
+}
+
+func (l *lookupCache) get(keyValues []interface{}) (map[string]interface{}, error) {
+	return l.rows[getLookupKey(l.step.log, keyValues)], nil
+}
+
+// lookupLRU caches the results of fetch for the most recently used keys, including keys that were not found.
+type lookupLRU struct {
+	log   logger.Logger
+	size  int
+	fetch lookupFunc
+	order *list.List               // most recently used keys at the front.
+	items map[string]*list.Element // elements hold *lookupLRUItem.
+}
+
+type lookupLRUItem struct {
+	key string
+	row map[string]interface{}
+}
+
+func newLookupLRU(log logger.Logger, size int, fetch lookupFunc) *lookupLRU {
+	return &lookupLRU{log: log, size: size, fetch: fetch, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (l *lookupLRU) get(keyValues []interface{}) (map[string]interface{}, error) {
+	key := getLookupKey(l.log, keyValues)
+	if e, ok := l.items[key]; ok { // if the key is cached...
+		l.order.MoveToFront(e)
+		return e.Value.(*lookupLRUItem).row, nil
+	}
+	row, err := l.fetch(keyValues)
+	if err != nil { // if the fetch failed then don't cache the result so it is retried.
+		return nil, err
+	}
+	l.items[key] = l.order.PushFront(&lookupLRUItem{key: key, row: row})
+	if l.order.Len() > l.size { // if the cache is full...
+		e := l.order.Back()
+		l.order.Remove(e)
+		delete(l.items, e.Value.(*lookupLRUItem).key)
+	}
+	return row, nil
+}
+
+// queryLookupRow executes sqlText with bind variables args and returns the single row found or nil if there are no
+// rows. The row must contain returnFields.
+func queryLookupRow(db shared.Connector, sqlText string, args []interface{}, returnFields []string) (map[string]interface{}, error) {
+	rows, err := db.Query(sqlText, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error during database query using SQL '%v': %v", sqlText, err)
+	}
+	var found map[string]interface{}
+	err = scanLookupRows(rows, func(row map[string]interface{}) error {
+		if found != nil {
+			return fmt.Errorf("more than one row found using SQL '%v'", sqlText)
+		}
+		for _, f := range returnFields {
+			if _, ok := row[f]; !ok {
+				return fmt.Errorf("return column %q not found in reference data", f)
+			}
+		}
+		found = row
+		return nil
+	})
+	return found, err
+}
+
+// scanLookupRows calls fn for each row in rows, where map keys are the column names, and closes rows.
+// A nil rows, as returned by mock connections, has no rows.
+func scanLookupRows(rows *shared.HpRows, fn func(row map[string]interface{}) error) error {
+	if rows == nil {
+		return nil
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	scanPtrs := make([]interface{}, len(cols))
+	scanVals := make([]interface{}, len(cols))
+	for idx := range cols {
+		scanPtrs[idx] = &scanVals[idx]
+	}
+	for rows.Next() {
+		if err = rows.Scan(scanPtrs...); err != nil {
+			return fmt.Errorf("unable to scan row: %v", err)
+		}
+		row := make(map[string]interface{}, len(cols))
+		for idx, col := range cols {
+			row[col] = scanVals[idx]
+		}
+		if err = fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// readLookupFile calls fn for each row in the CSV file fileName, where map keys are the column names in the header
+// row.
+func readLookupFile(fileName string, delimiter string, fn func(row map[string]interface{}) error) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	if delimiter != "" {
+		d := []rune(delimiter)
+		if len(d) != 1 {
+			return fmt.Errorf("delimiter must be a single character, got %q", delimiter)
+		}
+		r.Comma = d[0]
+	}
+	header, err := r.Read()
+	if err == io.EOF {
+		return fmt.Errorf("missing header row in file %v", fileName)
+	} else if err != nil {
+		return err
+	}
+	for {
+		values, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		row := make(map[string]interface{}, len(header))
+		for idx, col := range header {
+			row[col] = values[idx]
+		}
+		if err = fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
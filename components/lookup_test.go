@@ -0,0 +1,163 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func newLookupTestInput(codes ...interface{}) chan stream.Record {
+	inputChan := make(chan stream.Record, int(c.ChanSize))
+	for idx, code := range codes {
+		rec := stream.NewRecord()
+		rec.SetData("ID", idx)
+		rec.SetData("CODE", code)
+		rec.SetData("REGION", "EU")
+		inputChan <- rec
+	}
+	close(inputChan)
+	return inputChan
+}
+
+func TestNewFileLookup(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	newCfg := func(onMiss string, codes ...interface{}) *FileLookupConfig {
+		return &FileLookupConfig{
+			Log:           log,
+			Name:          "Test FileLookup",
+			InputChan:     newLookupTestInput(codes...),
+			FileName:      "testdata/lookup-countries.csv",
+			KeyFields:     helper.TokensToOrderedMap("CODE:CODE,REGION:REGION"),
+			ReturnFields:  []string{"COUNTRY_ID", "COUNTRY_NAME"},
+			OnMiss:        onMiss,
+			DefaultValues: map[string]string{"COUNTRY_ID": "-1"},
+		}
+	}
+	collect := func(cfg *FileLookupConfig) []map[string]interface{} {
+		outputChan, _ := NewFileLookup(cfg)
+		got := make([]map[string]interface{}, 0)
+		for rec := range outputChan {
+			got = append(got, rec.GetDataMap())
+		}
+		return got
+	}
+
+	// Test 1 - matched fields are added and misses are null by default.
+	got := collect(newCfg("", "FR", "US", nil))
+	expected := []map[string]interface{}{
+		{"ID": 0, "CODE": "FR", "REGION": "EU", "COUNTRY_ID": "2", "COUNTRY_NAME": "France"},
+		{"ID": 1, "CODE": "US", "REGION": "EU", "COUNTRY_ID": nil, "COUNTRY_NAME": nil},
+		{"ID": 2, "CODE": nil, "REGION": "EU", "COUNTRY_ID": nil, "COUNTRY_NAME": nil},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Test 1, expected %v; got %v", expected, got)
+	}
+
+	// Test 2 - misses use default values.
+	got = collect(newCfg(LookupOnMissDefault, "XX"))
+	if got[0]["COUNTRY_ID"] != "-1" || got[0]["COUNTRY_NAME"] != nil {
+		t.Fatal("Test 2, unexpected default values: ", got[0])
+	}
+
+	// Test 3 - misses are rejected to the dead-letter channel.
+	cfg := newCfg(LookupOnMissReject, "GB", "XX")
+	cfg.RowErrorHandler, _ = NewRowErrorHandler(log, cfg.Name, OnErrorDeadLetter, 0)
+	deadLetterChan := cfg.RowErrorHandler.DeadLetterChan()
+	got = collect(cfg)
+	if len(got) != 1 || got[0]["COUNTRY_NAME"] != "United Kingdom" {
+		t.Fatal("Test 3, unexpected output: ", got)
+	}
+	rejected := <-deadLetterChan
+	if rejected.GetData("CODE") != "XX" || rejected.GetData(Defaults.ChanField4ErrorText) == nil {
+		t.Fatal("Test 3, unexpected rejected record: ", rejected.GetDataMap())
+	}
+}
+
+func TestNewDatabaseLookup(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	db, _ := shared.NewMockConnectionWithMockTx(log, "mockDbType")
+	defer db.Close()
+	// Test 1 - query mode adds nulls when no rows are found.
+	cfg := &DatabaseLookupConfig{
+		Log:          log,
+		Name:         "Test DatabaseLookup",
+		InputChan:    newLookupTestInput("GB"),
+		Db:           db,
+		SqlText:      "select country_id from countries where code = :1",
+		KeyFields:    helper.TokensToOrderedMap("CODE:CODE"),
+		ReturnFields: []string{"COUNTRY_ID"},
+		LookupMode:   LookupModeQuery,
+	}
+	outputChan, _ := NewDatabaseLookup(cfg)
+	rec := <-outputChan
+	if v, ok := rec.GetDataMap()["COUNTRY_ID"]; !ok || v != nil {
+		t.Fatal("Test 1, expected null COUNTRY_ID; got ", rec.GetDataMap())
+	}
+	if _, ok := <-outputChan; ok {
+		t.Fatal("Test 1, expected the output channel to be closed")
+	}
+	// Test 2 - cache mode skips rejected misses.
+	cfg.InputChan = newLookupTestInput("GB", "FR")
+	cfg.LookupMode = LookupModeCache
+	cfg.OnMiss = LookupOnMissReject
+	cfg.RowErrorHandler, _ = NewRowErrorHandler(log, cfg.Name, OnErrorSkip, 0)
+	outputChan, _ = NewDatabaseLookup(cfg)
+	for range outputChan {
+		t.Fatal("Test 2, expected no output")
+	}
+	if cfg.RowErrorHandler.ErrorCount() != 2 {
+		t.Fatal("Test 2, expected 2 rejected records; got ", cfg.RowErrorHandler.ErrorCount())
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	step := &lookupStep{log: log, refKeys: []string{"K"}, returnFields: []string{"V"}}
+	cache := newLookupCache(step)
+	if err := cache.add(map[string]interface{}{"K": int64(1), "V": "a"}); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if err := cache.add(map[string]interface{}{"K": nil, "V": "null keys are ignored"}); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if err := cache.add(map[string]interface{}{"K": "1", "V": "b"}); err == nil {
+		t.Fatal("expected error due to duplicate key")
+	}
+	if err := cache.add(map[string]interface{}{"K": 2}); err == nil {
+		t.Fatal("expected error due to missing return column")
+	}
+	// Keys match regardless of type.
+	if row, _ := cache.get([]interface{}{"1"}); row == nil || row["V"] != "a" {
+		t.Fatal("unexpected row: ", row)
+	}
+}
+
+func TestLookupLRU(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	fetched := make([]interface{}, 0)
+	lru := newLookupLRU(log, 2, func(keyValues []interface{}) (map[string]interface{}, error) {
+		fetched = append(fetched, keyValues[0])
+		if keyValues[0] == "miss" {
+			return nil, nil
+		}
+		return map[string]interface{}{"V": keyValues[0]}, nil
+	})
+	for _, k := range []string{"a", "b", "a", "c", "a", "b", "miss", "miss"} {
+		row, err := lru.get([]interface{}{k})
+		if err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+		if k != "miss" && row["V"] != k {
+			t.Fatalf("expected row for key %v; got %v", k, row)
+		}
+	}
+	// b is evicted by c, then c is evicted by b, and misses are cached.
+	if expected := []interface{}{"a", "b", "c", "b", "miss"}; !reflect.DeepEqual(fetched, expected) {
+		t.Fatalf("expected fetches %v; got %v", expected, fetched)
+	}
+}
@@ -17,12 +17,12 @@ import (
 type S3ManifestReaderConfig struct {
 	Log                          logger.Logger
 	Name                         string
-	InputChan                    chan stream.Record // the input channel of rows to write to an output CSV file.
-	InputChanField4ManifestName  string             // path to manifest files (s3:// or file://)
-	BucketName                   string             // bucket containing manifest files
-	BucketPrefix                 string
-	Region                       string
-	OutputChanField4DataFileName string // outputChan field to produce file names onto
+	InputChan                    chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // the input channel of rows to write to an output CSV file.
+	InputChanField4ManifestName  string             `data:"inputFieldName4ManifestName"`      // path to manifest files (s3:// or file://)
+	BucketName                   string             `data:"bucketName"`                       // bucket containing manifest files
+	BucketPrefix                 string             `data:"bucketPrefix"`
+	Region                       string             `data:"bucketRegion"`
+	OutputChanField4DataFileName string             `data:"outputFieldName4DataFileName"` // outputChan field to produce file names onto
 	StepWatcher                  *stats.StepWatcher
 	WaitCounter                  ComponentWaiter
 	PanicHandlerFn               PanicHandlerFunc
@@ -103,6 +103,9 @@ func NewS3ManifestReader(i interface{}) (outputChan chan stream.Record, controlC
 					firstTime = true // reset flag to get header row for next manifest / inputChan record.
 				}
 			case controlAction := <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				cfg.Log.Info(cfg.Name, " shutdown")
 				return
@@ -16,23 +16,24 @@ import (
 type ManifestWriterConfig struct {
 	Log                                       logger.Logger
 	Name                                      string
-	InputChan                                 chan stream.Record // the input channel of rows to write to an output CSV file.
-	InputChanField4FilePath                   string
-	OutputDir                                 string // set to empty string to use a system generated sub directory in OS temp space.
-	ManifestFileNamePrefix                    string
-	ManifestFileNameSuffixAppendCreationStamp bool
-	ManifestFileNameSuffixDateFormat          string // golang Time format to be appended to Prefix. If not supplied, the default value is constants.TimeFormatYearSeconds.
-	ManifestFileNameExtension                 string
-	OutputChanField4ManifestDir               string
-	OutputChanField4ManifestName              string
-	OutputChanField4ManifestFullPath          string
+	InputChan                                 chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // the input channel of rows to write to an output CSV file.
+	InputChanField4FilePath                   string             `data:"inputFieldName4FilePath"`
+	OutputDir                                 string             `data:"outputDir"` // set to empty string to use a system generated sub directory in OS temp space.
+	ManifestFileNamePrefix                    string             `data:"fileNamePrefix" mandatory:"yes"`
+	ManifestFileNameSuffixAppendCreationStamp bool               `data:"fileNameSuffixAppendCreationStamp"`
+	ManifestFileNameSuffixDateFormat          string             `data:"fileNameSuffixDateTimeFormat"` // golang Time format to be appended to Prefix. If not supplied, the default value is constants.TimeFormatYearSeconds.
+	ManifestFileNameExtension                 string             `data:"fileNameExtension" mandatory:"yes"`
+	OutputChanField4ManifestDir               string             `data:"outputFieldName4ManifestDir"`
+	OutputChanField4ManifestName              string             `data:"outputFieldName4ManifestName"`
+	OutputChanField4ManifestFullPath          string             `data:"outputFieldName4ManifestFullPath"`
 	StepWatcher                               *s.StepWatcher
 	WaitCounter                               ComponentWaiter
 	PanicHandlerFn                            PanicHandlerFunc
 }
 
 // NewManifestWriter is expected to be used after CSV file generation.
-// It expects one or more file names on the input channel field specified by InputChanField4FilePath.
+// It expects one or more file names on the input channel field specified by InputChanField4FilePath, which defaults
+// to Defaults.ChanField4CSVFileName.
 // It writes a single manifest (CSV txt) file to the output directory specified containing each of the input file names.
 // It produces a single record on outputChan with fields outputDir and manifestFileName.
 // The manifest is only written and filename sent on the output channel once the input channel for this step is closed.
@@ -45,7 +46,7 @@ func NewManifestWriter(i interface{}) (outputChan chan stream.Record, controlCha
 		cfg.Log.Panic(cfg.Name, " error - missing chan input in call to NewManifestFile.")
 	}
 	if cfg.InputChanField4FilePath == "" {
-		cfg.Log.Panic(cfg.Name, " missing input chan field name for the file path")
+		cfg.InputChanField4FilePath = Defaults.ChanField4CSVFileName
 	}
 	if cfg.ManifestFileNamePrefix == "" {
 		cfg.Log.Panic(cfg.Name, " missing file name prefix")
@@ -105,6 +106,9 @@ func NewManifestWriter(i interface{}) (outputChan chan stream.Record, controlCha
 					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
 				}
 			case controlAction := <-controlChan: // if we were asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				f.Cleanup()                       // close the output file, but since it may be incomplete don't send a row to the output stream!
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				cfg.Log.Info(cfg.Name, " shutdown")
@@ -14,12 +14,14 @@ import (
 type MergeDiffConfig struct {
 	Log                 logger.Logger
 	Name                string
-	ChanOld             chan stream.Record
-	ChanNew             chan stream.Record
-	JoinKeys            *om.OrderedMap
-	CompareKeys         *om.OrderedMap
-	ResultFlagKeyName   string
-	OutputIdenticalRows bool
+	ChanOld             chan stream.Record `data:"readOldDataFromStep" mandatory:"yes"`
+	ChanNew             chan stream.Record `data:"readNewDataFromStep" mandatory:"yes"`
+	JoinKeys            *om.OrderedMap     `data:"joinKeys" mandatory:"yes"`
+	CompareKeys         *om.OrderedMap     `data:"compareKeys"`
+	ResultFlagKeyName   string             `data:"flagFieldName"`
+	OutputIdenticalRows bool               `data:"outputIdenticalRows"`
+	OldFieldPrefix      string             `data:"oldFieldPrefix"`         // optionally add the old values of compareKeys to CHANGED records using this prefix.
+	ChangedFieldsName   string             `data:"changedFieldsFieldName"` // optionally add a field to CHANGED records listing the compareKeys fields that differ.
 	StepWatcher         *s.StepWatcher
 	WaitCounter         ComponentWaiter
 	PanicHandlerFn      PanicHandlerFunc
@@ -34,7 +36,13 @@ type MergeDiffConfig struct {
 //   D == record not found on chanNew (chanOutput contains row from the chanOld rowset so you have the key required to perform a database DELETE)
 //   I == records are identical for compareKeyMap columns (chanOutput contains the row from the chanNew rowset)
 //
+// If OldFieldPrefix is set then C records also contain the value of each compareKeys field from chanOld,
+// named using the prefix plus the chanNew field name, so downstream steps can see what changed.
+// If ChangedFieldsName is set then C records also contain a field of this name holding a []string of the chanNew
+// names of the compareKeys fields that differ, which TableSync can use to UPDATE only the changed columns.
+//
 // NOTE that input channel records MUST be pre-sorted by the key fields for this to work!
+// Keys are compared byte-wise as strings, so use NewSort if the sources can't be ordered this way.
 // NOTE that the output channel (chanOutput) is closed by this function when it is done.
 //
 // Here's how it works:
@@ -109,14 +117,19 @@ func NewMergeDiff(i interface{}) (chan stream.Record, chan ControlAction) {
 			controlAction ControlAction
 		)
 		getNextRecord := func(rec *stream.Record, ok *bool, c chan stream.Record) bool {
-			select { // fetch the (old or new) record...
-			case *rec, *ok = <-c:
-			case controlAction = <-controlChan:
-				sendNilControlResponse(controlAction)
-				log.Info(cfg.Name, " shutdown")
-				return false
+			for { // until we have input data or a shutdown request...
+				select { // fetch the (old or new) record...
+				case *rec, *ok = <-c:
+					return true // we have input data so signal continue.
+				case controlAction = <-controlChan:
+					if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+						continue // wait for input data again.
+					}
+					sendNilControlResponse(controlAction)
+					log.Info(cfg.Name, " shutdown")
+					return false
+				}
 			}
-			return true // we have input data so signal continue.
 		}
 		if ok := getNextRecord(&recOld, &okOld, chanOld); !ok { // fetch the old record...
 			return // return if we have a shutdown request.
@@ -191,6 +204,12 @@ func NewMergeDiff(i interface{}) (chan stream.Record, chan ControlAction) {
 						// Output recNew...
 						log.Debug(cfg.Name, " maps are CHANGED after join, outputting row")
 						recNew.SetData(resultKeyName, c.MergeDiffValueChanged)
+						if cfg.ChangedFieldsName != "" { // if we should list the fields that changed...
+							recNew.SetData(cfg.ChangedFieldsName, getChangedFieldNames(log, recOld, recNew, compareKeys))
+						}
+						if cfg.OldFieldPrefix != "" { // if we should output the old values alongside the new...
+							setOldFieldValues(recOld, recNew, compareKeys, cfg.OldFieldPrefix)
+						}
 						if recSentOK := safeSend(recNew, outputChan, controlChan, sendNilControlResponse); !recSentOK {
 							log.Info(cfg.Name, " shutdown")
 							return
@@ -236,6 +255,9 @@ func NewMergeDiff(i interface{}) (chan stream.Record, chan ControlAction) {
 			// Check for shutdown requests.
 			select {
 			case controlAction = <-controlChan: // if there was a shutdown request...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				sendNilControlResponse(controlAction)
 				return
 			default: // else we should continue to process rows...
@@ -248,3 +270,25 @@ func NewMergeDiff(i interface{}) (chan stream.Record, chan ControlAction) {
 	cfg.Log.Debug(cfg.Name, " launched goroutine...")
 	return outputChan, controlChan
 }
+
+// setOldFieldValues adds the value of each compareKeys field found on recOld to recNew, where the field names are
+// prefix plus the name of the field on recNew.
+func setOldFieldValues(recOld stream.Record, recNew stream.Record, compareKeys *om.OrderedMap, prefix string) {
+	iter := compareKeys.IterFunc()
+	for kv, ok := iter(); ok; kv, ok = iter() { // for each field compared...
+		recNew.SetData(prefix+kv.Value.(string), recOld.GetData(kv.Key.(string)))
+	}
+}
+
+// getChangedFieldNames returns the names of the compareKeys fields on recNew whose values differ from recOld.
+// Values are compared in the same way as DataIsDeepEqual.
+func getChangedFieldNames(log logger.Logger, recOld stream.Record, recNew stream.Record, compareKeys *om.OrderedMap) []string {
+	changed := make([]string, 0)
+	iter := compareKeys.IterFunc()
+	for kv, ok := iter(); ok; kv, ok = iter() { // for each field compared...
+		if recOld.GetDataAsStringUseUtcTime(log, kv.Key.(string)) != recNew.GetDataAsStringUseUtcTime(log, kv.Value.(string)) {
+			changed = append(changed, kv.Value.(string))
+		}
+	}
+	return changed
+}
@@ -182,6 +182,61 @@ func TestMergeDiff(t *testing.T) {
 	case <-responseChan: // if MergeDiff confirmed shutdown...
 		// continue
 	}
+
+	// Test 4 - confirm old values are added to CHANGED rows when OldFieldPrefix is set.
+	log.Info("Test 4 - confirm old values are output alongside CHANGED rows...")
+	chanOld4 := make(chan stream.Record, 1)
+	chanNew4 := make(chan stream.Record, 1)
+	chanOld4 <- oldRowC
+	chanNew4 <- newRowC
+	close(chanOld4)
+	close(chanNew4)
+	chanMergeDiff4, _ := NewMergeDiff(&MergeDiffConfig{
+		Log:               log,
+		Name:              "MergeDiff test 4",
+		ChanOld:           chanOld4,
+		ChanNew:           chanNew4,
+		JoinKeys:          joinKeys,
+		CompareKeys:       compareKeys,
+		ResultFlagKeyName: "flagField",
+		OldFieldPrefix:    "old.",
+	})
+	rec := <-chanMergeDiff4
+	if rec.GetData("old.field1") != "oldData1" || rec.GetData("old.field2") != 123 || rec.GetData("field1") != "changedData1" {
+		t.Fatal("Merge Diff didn't output old values for CHANGED records: ", rec.GetDataMap())
+	}
+	// Test 5 - confirm the names of changed fields are added to CHANGED rows when ChangedFieldsName is set.
+	log.Info("Test 5 - confirm changed field names are output with CHANGED rows...")
+	oldRow5 := stream.NewRecord()
+	oldRow5.SetData("JoinKey1", 789)
+	oldRow5.SetData("JoinKey2", "matching")
+	oldRow5.SetData("field1", "oldData1")
+	oldRow5.SetData("field2", 123)
+	newRow5 := stream.NewRecord()
+	newRow5.SetData("JoinKey1", 789)
+	newRow5.SetData("JoinKey2", "matching")
+	newRow5.SetData("field1", "oldData1")
+	newRow5.SetData("field2", 456)
+	chanOld5 := make(chan stream.Record, 1)
+	chanNew5 := make(chan stream.Record, 1)
+	chanOld5 <- oldRow5
+	chanNew5 <- newRow5
+	close(chanOld5)
+	close(chanNew5)
+	chanMergeDiff5, _ := NewMergeDiff(&MergeDiffConfig{
+		Log:               log,
+		Name:              "MergeDiff test 5",
+		ChanOld:           chanOld5,
+		ChanNew:           chanNew5,
+		JoinKeys:          joinKeys,
+		CompareKeys:       compareKeys,
+		ResultFlagKeyName: "flagField",
+		ChangedFieldsName: "changedFields",
+	})
+	rec = <-chanMergeDiff5
+	if !reflect.DeepEqual(rec.GetData("changedFields"), []string{"field2"}) {
+		t.Fatal("Merge Diff didn't output the changed field names for CHANGED records: ", rec.GetDataMap())
+	}
 	// End OK.
 }
 
@@ -0,0 +1,181 @@
+package components
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	om "github.com/cevaris/ordered_map"
+	c "github.com/relloyd/halfpipe/constants"
+	f "github.com/relloyd/halfpipe/file"
+	"github.com/relloyd/halfpipe/logger"
+	s "github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+type ParquetFileWriterConfig struct {
+	Log                               logger.Logger
+	Name                              string
+	InputChan                         chan stream.Record `data:"readDataFromStep" mandatory:"yes"` // the input channel of rows to write to an output Parquet file.
+	OutputDir                         string             `data:"outputDir"`                        // set to empty string to use a system generated sub directory in OS temp space.
+	FileNamePrefix                    string             `data:"fileNamePrefix"`
+	FileNameSuffixAppendCreationStamp bool               `data:"fileNameSuffixAppendCreationStamp"`
+	FileNameSuffixDateFormat          string             `data:"fileNameSuffixDateTimeFormat"`
+	FileNameExtension                 string             `data:"fileNameExtension"` // defaults to "parquet".
+	UseGzip                           bool               `data:"useGzip"`           // set to true to use GZIP compression instead of SNAPPY.
+	MaxFileRows                       int                `data:"maxFileRows" mandatory:"yes"`
+	MaxFileBytes                      int                `data:"maxFileBytes" mandatory:"yes"`
+	HeaderFields                      []string           `data:"headerFieldsCSV"`          // the slice of key names to be found in InputChan that will be used as the Parquet columns; defaults to the keys of FieldTypes.
+	FieldTypes                        *om.OrderedMap     `data:"fieldTypes"`               // ordered map of: key = chan field name; value = Parquet type, see file.ParquetType* and file.ParquetDecimalType; fields not found here are written as strings.
+	OutputChanField4FilePath          string             `data:"outputFieldName4FilePath"` // the field on outputChan that will contain the file name.
+	StepWatcher                       *s.StepWatcher
+	WaitCounter                       ComponentWaiter
+	PanicHandlerFn                    PanicHandlerFunc
+}
+
+// NewParquetFileWriter will dump cfg.InputChan to Parquet files with spec defined in cfg.
+// The columns are the HeaderFields in the order supplied, else the keys of FieldTypes.
+// Each column uses the Parquet type found in FieldTypes, else it is written as a string.
+// outputChan contains the Parquet file names produced (it does not pass input records to the output).
+func NewParquetFileWriter(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*ParquetFileWriterConfig)
+	if cfg.PanicHandlerFn != nil {
+		defer cfg.PanicHandlerFn()
+	}
+	if cfg.InputChan == nil {
+		cfg.Log.Panic(cfg.Name, " error - missing input channel.")
+	}
+	if cfg.OutputChanField4FilePath == "" {
+		cfg.OutputChanField4FilePath = Defaults.ChanField4CSVFileName
+	}
+	cols, err := getParquetColumns(cfg.HeaderFields, cfg.FieldTypes)
+	if err != nil {
+		cfg.Log.Panic(cfg.Name, " error - ", err)
+	}
+	fields := make([]string, len(cols))
+	for idx := range cols {
+		fields[idx] = cols[idx].Name
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		// Build the file name with timestamp suffix if needed.
+		var filePrefix string
+		if cfg.FileNameSuffixAppendCreationStamp { // if we should append the creation time stamp to the file name...
+			if cfg.FileNameSuffixDateFormat == "" { // if no date format was supplied...
+				cfg.FileNameSuffixDateFormat = c.TimeFormatYearSeconds // use the global constant/default date format.
+			}
+			filePrefix = fmt.Sprintf("%v-%v", cfg.FileNamePrefix, time.Now().Format(cfg.FileNameSuffixDateFormat))
+		} else { // else we don't want a file time stamp...
+			filePrefix = cfg.FileNamePrefix
+		}
+		cfg.Log.Debug(cfg.Name, " starting NewParquetFileOutput with config: outputDir=", cfg.OutputDir, "; filePrefix=", filePrefix, "; extension=", cfg.FileNameExtension, "; maxFileRows=", cfg.MaxFileRows, "; maxFileBytes=", cfg.MaxFileBytes)
+		// Create new Parquet file (lazy creation).
+		fi, err := f.NewParquetFileOutput(cfg.Log, cfg.OutputDir, filePrefix, cfg.FileNameExtension, cfg.MaxFileRows, cfg.MaxFileBytes, cfg.UseGzip, cols)
+		if err != nil {
+			cfg.Log.Panic(cfg.Name, " error - ", err)
+		}
+		defer fi.Cleanup()
+		// Capture row count stats.
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a StepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		var prevFileName, curFileName, fileName string
+		var controlAction ControlAction
+		sendRow := func(fileName string, doCleanup bool) (rowSentOK bool) {
+			if doCleanup {
+				fi.Cleanup() // force closure of the last Parquet file.
+			}
+			row := stream.NewRecord()
+			row.SetData(cfg.OutputChanField4FilePath, fileName)
+			cfg.Log.Debug(cfg.Name, " producing filename as a row onto the output channel: ", row)
+			return safeSend(row, outputChan, controlChan, sendNilControlResponse) // forward the record
+		}
+		values := make([]interface{}, len(fields))
+		for { // for each row of input...
+			select {
+			case rec, ok := <-cfg.InputChan:
+				if !ok { // if the input channel was closed...
+					cfg.InputChan = nil // disable this case.
+				} else { // else we have input to process...
+					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+					for idx, k := range fields {
+						values[idx] = rec.GetData(k)
+					}
+					fileName = fi.MustWrite(values)
+					if fileName != "" { // if the file name has changed...
+						// File closure is handled for us while we're still writing.
+						prevFileName = curFileName
+						curFileName = fileName
+						if prevFileName != "" { // if we had a previous file name that is now closed...
+							// Output a row showing the closed Parquet file.
+							if rowSentOK := sendRow(prevFileName, false); !rowSentOK {
+								cfg.Log.Info(cfg.Name, " shutdown")
+								return
+							}
+						}
+					}
+				}
+			case controlAction = <-controlChan:
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlChan = nil
+			}
+			if controlChan == nil || cfg.InputChan == nil { // if we should quit due to a shutdown request or the end or input...
+				break
+			}
+		}
+		if controlAction.Action == Shutdown { // if we were asked to shutdown...
+			controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+			cfg.Log.Info(cfg.Name, " shutdown")
+			return
+		}
+		// Produce final file name onto the output channel.
+		if curFileName != "" { // if there is a file name to send downstream...
+			if rowSentOK := sendRow(curFileName, true); !rowSentOK {
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+		}
+		close(outputChan) // we're done so close the channel we created.
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return
+}
+
+// getParquetColumns returns the Parquet columns for headerFields using the types found in fieldTypes.
+// If headerFields is empty then all fieldTypes are used.
+func getParquetColumns(headerFields []string, fieldTypes *om.OrderedMap) ([]f.ParquetColumn, error) {
+	cols := make([]f.ParquetColumn, 0)
+	if len(headerFields) > 0 { // if there are explicit fields to write...
+		for _, k := range headerFields {
+			typ := f.ParquetTypeString
+			if fieldTypes != nil {
+				if v, ok := fieldTypes.Get(k); ok {
+					typ = v.(string)
+				}
+			}
+			cols = append(cols, f.ParquetColumn{Name: k, Type: typ})
+		}
+	} else if fieldTypes != nil { // else use the field types...
+		iter := fieldTypes.IterFunc()
+		for kv, ok := iter(); ok; kv, ok = iter() {
+			cols = append(cols, f.ParquetColumn{Name: kv.Key.(string), Type: kv.Value.(string)})
+		}
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("missing header fields or field types")
+	}
+	return cols, nil
+}
@@ -0,0 +1,73 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	om "github.com/cevaris/ordered_map"
+	c "github.com/relloyd/halfpipe/constants"
+	f "github.com/relloyd/halfpipe/file"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestNewParquetFileWriter(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	dir, err := ioutil.TempDir("", "test-parquet-file-writer-")
+	if err != nil {
+		t.Fatal("Unable to create tmp dir: ", err)
+	}
+	defer os.RemoveAll(dir)
+	// Test 1 - confirm files are rotated and each file name is produced on the output channel.
+	inputChan := make(chan stream.Record, c.ChanSize)
+	for idx := 1; idx <= 3; idx++ {
+		r := stream.NewRecord()
+		r.SetData("ID", idx)
+		r.SetData("NAME", "name")
+		r.SetData("IGNORED", "ignored")
+		inputChan <- r
+	}
+	close(inputChan)
+	types := om.NewOrderedMap()
+	types.Set("ID", f.ParquetTypeInt64)
+	types.Set("NAME", f.ParquetTypeString)
+	cfg := &ParquetFileWriterConfig{
+		Name:                     "Test Parquet Writer",
+		Log:                      log,
+		InputChan:                inputChan,
+		FileNamePrefix:           "test",
+		FieldTypes:               types,
+		MaxFileRows:              2,
+		OutputDir:                dir,
+		OutputChanField4FilePath: "#filePath",
+	}
+	outputChan, _ := NewParquetFileWriter(cfg)
+	files := make([]string, 0)
+	for rec := range outputChan {
+		files = append(files, rec.GetDataAsStringPreserveTimeZone(log, "#filePath"))
+	}
+	if len(files) != 2 || files[0] != dir+"/test_000001.parquet" || files[1] != dir+"/test_000002.parquet" {
+		t.Fatal("unexpected output files: ", files)
+	}
+	for _, fileName := range files {
+		if fi, err := os.Stat(fileName); err != nil || fi.Size() == 0 {
+			t.Fatal("expected non-empty Parquet file: ", fileName, "; ", err)
+		}
+	}
+	// Test 2 - confirm header fields choose the columns and default to string.
+	cols, err := getParquetColumns([]string{"NAME", "ID"}, types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 2 || cols[0] != (f.ParquetColumn{Name: "NAME", Type: f.ParquetTypeString}) || cols[1] != (f.ParquetColumn{Name: "ID", Type: f.ParquetTypeInt64}) {
+		t.Fatal("unexpected columns: ", cols)
+	}
+	cols, err = getParquetColumns([]string{"OTHER"}, nil)
+	if err != nil || cols[0].Type != f.ParquetTypeString {
+		t.Fatal("expected string column; got ", cols, err)
+	}
+	if _, err = getParquetColumns(nil, nil); err == nil {
+		t.Fatal("expected error for missing columns")
+	}
+}
@@ -0,0 +1,119 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+	"github.com/sirupsen/logrus"
+)
+
+// Values for the onError setting of a step, which control what happens to records that cause row-level errors.
+const (
+	OnErrorFail       = "fail"       // abort the transform on the first error (default).
+	OnErrorSkip       = "skip"       // log the error and discard the record.
+	OnErrorDeadLetter = "deadLetter" // send the record to the dead-letter output of the step.
+)
+
+// RowErrorHandler handles errors caused by individual records so that a component can choose to continue
+// processing instead of panicking.
+// Rejected records are either discarded or sent to a dead-letter channel with fields
+// Defaults.ChanField4ErrorStep and Defaults.ChanField4ErrorText added.
+// An error budget aborts the transform once MaxErrors records have been rejected.
+type RowErrorHandler struct {
+	log            logger.Logger
+	stepName       string
+	onError        string
+	maxErrors      int64
+	errorCount     int64
+	deadLetterChan chan stream.Record
+	closeOnce      sync.Once
+}
+
+// NewRowErrorHandler returns a RowErrorHandler for step stepName where onError is one of the OnError* constants.
+// An empty onError defaults to OnErrorFail.
+// Set maxErrors to the number of rejected records that will abort the transform, or 0 for no limit.
+// If onError is OnErrorDeadLetter then use DeadLetterChan() to consume the rejected records.
+func NewRowErrorHandler(log logger.Logger, stepName string, onError string, maxErrors int) (*RowErrorHandler, error) {
+	h := &RowErrorHandler{log: log, stepName: stepName, onError: onError, maxErrors: int64(maxErrors)}
+	switch onError {
+	case "":
+		h.onError = OnErrorFail
+	case OnErrorFail, OnErrorSkip:
+	case OnErrorDeadLetter:
+		h.deadLetterChan = make(chan stream.Record, c.ChanSize)
+	default:
+		return nil, fmt.Errorf("unsupported onError value %q: use %v", onError, strings.Join([]string{OnErrorFail, OnErrorSkip, OnErrorDeadLetter}, ", "))
+	}
+	if maxErrors < 0 {
+		return nil, fmt.Errorf("maxErrors must not be negative")
+	}
+	return h, nil
+}
+
+// DeadLetterChan returns the channel of rejected records or nil if onError is not OnErrorDeadLetter.
+func (h *RowErrorHandler) DeadLetterChan() chan stream.Record {
+	return h.deadLetterChan
+}
+
+// ErrorCount returns the number of records rejected so far.
+func (h *RowErrorHandler) ErrorCount() int64 {
+	return atomic.LoadInt64(&h.errorCount)
+}
+
+// failsOnError returns true if onError is OnErrorFail, in which case Reject never returns.
+func (h *RowErrorHandler) failsOnError() bool {
+	return h.onError == OnErrorFail
+}
+
+// Reject handles err caused by record rec.
+// If onError is OnErrorFail, or the error budget is used up, this logs a panic.
+// Otherwise it returns and the caller should continue with the next record without outputting rec.
+func (h *RowErrorHandler) Reject(rec stream.Record, err error) {
+	if h.onError == OnErrorFail {
+		h.log.Panic(h.stepName, " error - ", err)
+	}
+	count := atomic.AddInt64(&h.errorCount, 1)
+	if h.maxErrors > 0 && count >= h.maxErrors {
+		h.log.Panic(h.stepName, " aborted after ", count, " rejected records; last error - ", err)
+	}
+	h.log.Warn(h.stepName, " rejected record: ", err)
+	if h.onError == OnErrorDeadLetter {
+		rec.SetData(Defaults.ChanField4ErrorStep, h.stepName)
+		rec.SetData(Defaults.ChanField4ErrorText, err.Error())
+		h.deadLetterChan <- rec
+	}
+}
+
+// Catch calls fn and returns an error for any panic raised by it, so row-level panics can be passed to Reject().
+// If onError is OnErrorFail then fn is called without recovering, which leaves existing behaviour in place.
+func (h *RowErrorHandler) Catch(fn func()) (err error) {
+	if h.onError != OnErrorFail {
+		defer func() {
+			if r := recover(); r != nil { // if there was a panic...
+				switch x := r.(type) {
+				case *logrus.Entry:
+					err = errors.New(x.Message)
+				case error:
+					err = x
+				default:
+					err = fmt.Errorf("%v", x)
+				}
+			}
+		}()
+	}
+	fn()
+	return
+}
+
+// Close closes the dead-letter channel, if any. Call it when the component completes normally.
+func (h *RowErrorHandler) Close() {
+	if h.deadLetterChan != nil {
+		h.closeOnce.Do(func() { close(h.deadLetterChan) })
+	}
+}
@@ -0,0 +1,85 @@
+package components
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestRowErrorHandler(t *testing.T) {
+	log := logger.NewLogger("row error test", "info", true)
+	badRecord := func() stream.Record {
+		rec := stream.NewRecord()
+		rec.SetData("A", "1")
+		return rec
+	}
+
+	// Test 1 - fail panics and Catch does not recover.
+	h, err := NewRowErrorHandler(log, "step1", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !panics(func() { h.Reject(badRecord(), errors.New("bad")) }) {
+		t.Fatal("expected Reject to panic for onError fail")
+	}
+	if !panics(func() { _ = h.Catch(func() { panic("boom") }) }) {
+		t.Fatal("expected Catch to panic for onError fail")
+	}
+
+	// Test 2 - skip counts errors and Catch recovers panics.
+	h, _ = NewRowErrorHandler(log, "step1", OnErrorSkip, 0)
+	h.Reject(badRecord(), errors.New("bad"))
+	if h.ErrorCount() != 1 {
+		t.Fatal("expected 1 error; got ", h.ErrorCount())
+	}
+	if err = h.Catch(func() { log.Panic("boom") }); err == nil || err.Error() != "boom" {
+		t.Fatal("expected error 'boom' from Catch; got ", err)
+	}
+	if err = h.Catch(func() { badRecord().GetData("missing") }); err == nil {
+		t.Fatal("expected error from Catch for missing field")
+	}
+	h.Close() // no dead-letter channel to close.
+
+	// Test 3 - dead letters contain the error and step name.
+	h, _ = NewRowErrorHandler(log, "step1", OnErrorDeadLetter, 0)
+	h.Reject(badRecord(), errors.New("bad"))
+	h.Close()
+	h.Close() // closing twice is safe.
+	count := 0
+	for rec := range h.DeadLetterChan() {
+		count++
+		if rec.GetData(Defaults.ChanField4ErrorStep) != "step1" || rec.GetData(Defaults.ChanField4ErrorText) != "bad" || rec.GetData("A") != "1" {
+			t.Fatal("unexpected dead letter ", rec.GetDataMap())
+		}
+	}
+	if count != 1 {
+		t.Fatal("expected 1 dead letter; got ", count)
+	}
+
+	// Test 4 - the error budget aborts.
+	h, _ = NewRowErrorHandler(log, "step1", OnErrorSkip, 2)
+	h.Reject(badRecord(), errors.New("bad"))
+	if !panics(func() { h.Reject(badRecord(), errors.New("bad")) }) {
+		t.Fatal("expected Reject to panic once maxErrors is reached")
+	}
+
+	// Test 5 - bad config.
+	if _, err = NewRowErrorHandler(log, "step1", "ignore", 0); err == nil {
+		t.Fatal("expected error for unsupported onError")
+	}
+	if _, err = NewRowErrorHandler(log, "step1", OnErrorSkip, -1); err == nil {
+		t.Fatal("expected error for negative maxErrors")
+	}
+}
+
+func panics(fn func()) (retval bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			retval = true
+		}
+	}()
+	fn()
+	return
+}
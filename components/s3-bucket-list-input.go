@@ -15,16 +15,16 @@ import (
 type S3BucketListerConfig struct {
 	Log                               logger.Logger
 	Name                              string
-	Region                            string // AWS region for the bucket.
-	BucketName                        string // AWS bucket name.
-	BucketPrefix                      string // AWS bucket prefix.
-	ObjectNamePrefix                  string // list files where the beginning of their names matches this string (this is not the AWS bucket prefix). This is given to S3 list command and a dumb filter.
-	ObjectNameRegexp                  string // used to further filter the list of files fetched using the ObjectNamePrefix.
-	OutputField4FileName              string // the map key on outputChan that contains the file names found in the S3 bucket. If this is an empty string then default to value found in this package var, Defaults.
-	OutputField4FileNameWithoutPrefix string
-	OutputField4BucketName            string             // the map key on outputChan that contains the bucket name. If this is an empty string then default to value found in this package var, Defaults.
-	OutputField4BucketPrefix          string             // the map key on outputChan that contains the bucket prefix. If this is an empty string then default to value found in this package var, Defaults.
-	OutputField4BucketRegion          string             // the map key on outputChan that contains the bucket region. If this is an empty string then default to value found in this package var, Defaults.
+	Region                            string             `data:"bucketRegion"`               // AWS region for the bucket.
+	BucketName                        string             `data:"bucketName" mandatory:"yes"` // AWS bucket name.
+	BucketPrefix                      string             `data:"bucketPrefix"`               // AWS bucket prefix.
+	ObjectNamePrefix                  string             `data:"fileNamePrefix"`             // list files where the beginning of their names matches this string (this is not the AWS bucket prefix). This is given to S3 list command and a dumb filter.
+	ObjectNameRegexp                  string             `data:"fileNameRegexp"`             // used to further filter the list of files fetched using the ObjectNamePrefix.
+	OutputField4FileName              string             `data:"outputField4FileName"`       // the map key on outputChan that contains the file names found in the S3 bucket. If this is an empty string then default to value found in this package var, Defaults.
+	OutputField4FileNameWithoutPrefix string             `data:"outputField4FileNameWithoutPrefix"`
+	OutputField4BucketName            string             `data:"outputField4BucketName"`   // the map key on outputChan that contains the bucket name. If this is an empty string then default to value found in this package var, Defaults.
+	OutputField4BucketPrefix          string             `data:"outputField4BucketPrefix"` // the map key on outputChan that contains the bucket prefix. If this is an empty string then default to value found in this package var, Defaults.
+	OutputField4BucketRegion          string             `data:"outputField4BucketRegion"` // the map key on outputChan that contains the bucket region. If this is an empty string then default to value found in this package var, Defaults.
 	StepWatcher                       *stats.StepWatcher // supply a StepWatcher or nil.
 	WaitCounter                       ComponentWaiter
 	PanicHandlerFn                    PanicHandlerFunc
@@ -22,14 +22,15 @@ type SnowflakeSqlBuilderFunc func(tableName rdbms.SchemaTable, stageName string,
 type SnowflakeLoaderConfig struct {
 	Log                     logger.Logger
 	Name                    string
-	InputChan               chan stream.Record
-	Db                      shared.Connector        // connection to target snowflake database abstracted via interface.
-	InputChanField4FileName string                  // the field name found on InputChan that contains the file name to load.
-	StageName               string                  // the external stage that can access the files to load.
-	TargetSchemaTableName   rdbms.SchemaTable       // the [schema.]table to load into.
-	DeleteAll               bool                    // set to true to SQL DELETE all table rows before loading begins (set Use1Transaction = true for a safe reload of data).
+	InputChan               chan stream.Record      `data:"readDataFromStep" mandatory:"yes"`
+	Db                      shared.Connector        `data:"logicalConnectionName" mandatory:"yes"` // connection to target snowflake database abstracted via interface.
+	InputChanField4FileName string                  `data:"fieldName4FileName"`                    // the field name found on InputChan that contains the file name to load.
+	StageName               string                  `data:"stageName" mandatory:"yes"`             // the external stage that can access the files to load.
+	TargetSchemaTableName   rdbms.SchemaTable       `data:"schemaTableName" mandatory:"yes"`       // the [schema.]table to load into.
+	DeleteAll               bool                    `data:"deleteAllRows"`                         // set to true to SQL DELETE all table rows before loading begins (set Use1Transaction = true for a safe reload of data).
+	FileFormat              string                  `data:"fileFormat"`                            // the format of the files to load: FileFormatCsv (default) or FileFormatParquet.
 	FnGetSnowflakeSqlSlice  SnowflakeSqlBuilderFunc // func that will be used by NewSnowflakeLoader to fetch a slice of SQL statements to execute per input row.
-	CommitSequenceKeyName   string                  // the field name added by this component to the outputChan record, incremented when a batch is committed; used by downstream components - see also TableSync component.
+	CommitSequenceKeyName   string                  `data:"commitSequenceKeyName"` // the field name added by this component to the outputChan record, incremented when a batch is committed; used by downstream components - see also TableSync component.
 	StepWatcher             *stats.StepWatcher
 	WaitCounter             ComponentWaiter
 	PanicHandlerFn          PanicHandlerFunc
@@ -42,8 +43,20 @@ type SnowflakeLoaderConfig struct {
 // This component generates and executes COPY INTO SQL statements
 // If Use1Transaction is true, AUTOCOMMIT will be on; else it will turn AUTOCOMMIT OFF and commit once InputChan is closed.
 // InputChan rows are copied to the outputChan.
+// If FnGetSnowflakeSqlSlice is nil then GetSqlSliceSnowflakeCopyInto is used, or GetSqlSliceSnowflakeCopyIntoParquet
+// if FileFormat is FileFormatParquet.
 func NewSnowflakeLoader(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
 	cfg := i.(*SnowflakeLoaderConfig)
+	if _, err := getSnowflakeFileFormatSql(cfg.FileFormat); err != nil {
+		cfg.Log.Panic(cfg.Name, " error - ", err)
+	}
+	if cfg.FnGetSnowflakeSqlSlice == nil {
+		if cfg.FileFormat == FileFormatParquet {
+			cfg.FnGetSnowflakeSqlSlice = GetSqlSliceSnowflakeCopyIntoParquet
+		} else {
+			cfg.FnGetSnowflakeSqlSlice = GetSqlSliceSnowflakeCopyInto
+		}
+	}
 	outputChan = make(chan stream.Record, int(c.ChanSize))
 	controlChan = make(chan ControlAction, 1) // make a control channel that receives a chan error.
 	var rollbackRequired bool
@@ -122,7 +135,7 @@ func NewSnowflakeLoader(i interface{}) (outputChan chan stream.Record, controlCh
 					controlChan = nil
 				}
 			case controlAction = <-controlChan: // if we are told to shutdown...
-				if controlAction.Action == Shutdown {
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Shutdown {
 					cfg.InputChan = nil // disable the data input channel case.
 					controlChan = nil   // disable the controlChan case as we're going to exit.
 					// Rollback is deferred.
@@ -173,16 +186,21 @@ func safeSnowflakeExec(tx shared.Transacter, controlChan chan ControlAction, que
 		res, err = tx.ExecContext(ctx, query, nil)
 		doneChan <- struct{}{} // signal success.
 	}()
-	select {
-	case controlAction := <-controlChan: // if we were shutdown...
-		// Cancel the query above.
-		cancelFunc()
-		// Rollback is deferred in the caller!
-		controlAction.ResponseChan <- nil // signal that shutdown completed with a nil error.
-		return nil, err, true
-	case <-doneChan: // all OK, continue...
+	for {
+		select {
+		case controlAction := <-controlChan: // if we were shutdown...
+			if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+				continue // carry on waiting for the query, which holds its transaction open while we're paused.
+			}
+			// Cancel the query above.
+			cancelFunc()
+			// Rollback is deferred in the caller!
+			controlAction.ResponseChan <- nil // signal that shutdown completed with a nil error.
+			return nil, err, true
+		case <-doneChan: // all OK, continue...
+			return res, err, false
+		}
 	}
-	return res, err, false
 }
 
 func snowflakeRollback(log logger.Logger, stepName string, tx shared.Transacter, rollbackRequired *bool) {
@@ -197,6 +215,25 @@ func snowflakeRollback(log logger.Logger, stepName string, tx shared.Transacter,
 	}
 }
 
+// File formats supported by the Snowflake loaders.
+const (
+	FileFormatCsv     = "csv"
+	FileFormatParquet = "parquet"
+)
+
+// getSnowflakeFileFormatSql returns the COPY INTO options required to load files of the given fileFormat.
+// Parquet columns are loaded into table columns of the same name.
+func getSnowflakeFileFormatSql(fileFormat string) (string, error) {
+	switch fileFormat {
+	case "", FileFormatCsv:
+		return "", nil // use the file format of the stage.
+	case FileFormatParquet:
+		return " FILE_FORMAT=(TYPE=PARQUET) MATCH_BY_COLUMN_NAME=CASE_INSENSITIVE", nil
+	default:
+		return "", fmt.Errorf("unsupported file format %q: use %q or %q", fileFormat, FileFormatCsv, FileFormatParquet)
+	}
+}
+
 // GetSqlSliceSnowflakeCopyInto generates SQL to copy data from the supplied Snowflake STAGE/fileName into
 // the given tableName.
 func GetSqlSliceSnowflakeCopyInto(schemaTableName rdbms.SchemaTable, stageName string, fileName string, force bool) []string {
@@ -208,3 +245,12 @@ func GetSqlSliceSnowflakeCopyInto(schemaTableName rdbms.SchemaTable, stageName s
 	// Return single element slice.
 	return []string{fmt.Sprintf("copy into %v from '@%v'%v", schemaTableName.SchemaTable, stagedFile, forceSql)}
 }
+
+// GetSqlSliceSnowflakeCopyIntoParquet generates SQL to copy data from the supplied Snowflake STAGE/fileName
+// containing Parquet into the given tableName.
+func GetSqlSliceSnowflakeCopyIntoParquet(schemaTableName rdbms.SchemaTable, stageName string, fileName string, force bool) []string {
+	s := GetSqlSliceSnowflakeCopyInto(schemaTableName, stageName, fileName, force)
+	formatSql, _ := getSnowflakeFileFormatSql(FileFormatParquet)
+	s[0] += formatSql
+	return s
+}
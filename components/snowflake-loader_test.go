@@ -111,3 +111,11 @@ func TestSnowflakeLoader(t *testing.T) {
 		t.Fatal("unexpected SQL string produced for COPY statement. Expected: ", expected5, ". Found: ", res2[4])
 	}
 }
+
+func TestGetSqlSliceSnowflakeCopyIntoParquet(t *testing.T) {
+	s := components.GetSqlSliceSnowflakeCopyIntoParquet(rdbms.SchemaTable{SchemaTable: "TEST_B"}, "stage", "file1.parquet", true)
+	expected := "copy into TEST_B from '@stage/file1.parquet' force=true FILE_FORMAT=(TYPE=PARQUET) MATCH_BY_COLUMN_NAME=CASE_INSENSITIVE"
+	if len(s) != 1 || s[0] != expected {
+		t.Fatalf("expected sql: '%v'; got '%v'", expected, s)
+	}
+}
@@ -17,14 +17,15 @@ import (
 type SnowflakeMergeConfig struct {
 	Log                     logger.Logger
 	Name                    string
-	InputChan               chan stream.Record
-	Db                      shared.Connector  // connection to target snowflake database abstracted via interface.
-	InputChanField4FileName string            // the field name found on InputChan that contains the file name to load.
-	StageName               string            // the external stage that can access the files to load.
-	TargetSchemaTableName   rdbms.SchemaTable // the [schema.]table to load into.
-	CommitSequenceKeyName   string            // the field name added by this component to the outputChan record, incremented when a batch is committed; used by downstream components - see also TableSync component.
-	TargetKeyCols           *om.OrderedMap    // ordered map of: key = chan field name; value = target table column name
-	TargetOtherCols         *om.OrderedMap    // ordered map of: key = chan field name; value = target table column name
+	InputChan               chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	Db                      shared.Connector   `data:"logicalConnectionName" mandatory:"yes"` // connection to target snowflake database abstracted via interface.
+	InputChanField4FileName string             `data:"fieldName4FileName"`                    // the field name found on InputChan that contains the file name to load.
+	StageName               string             `data:"stageName" mandatory:"yes"`             // the external stage that can access the files to load.
+	TargetSchemaTableName   rdbms.SchemaTable  `data:"schemaTableName" mandatory:"yes"`       // the [schema.]table to load into.
+	CommitSequenceKeyName   string             `data:"commitSequenceKeyName"`                 // the field name added by this component to the outputChan record, incremented when a batch is committed; used by downstream components - see also TableSync component.
+	TargetKeyCols           *om.OrderedMap     `data:"keyCols"`                               // ordered map of: key = chan field name; value = target table column name
+	TargetOtherCols         *om.OrderedMap     `data:"otherCols"`                             // ordered map of: key = chan field name; value = target table column name
+	FileFormat              string             `data:"fileFormat"`                            // the format of the files to load: FileFormatCsv (default) or FileFormatParquet.
 	StepWatcher             *stats.StepWatcher
 	WaitCounter             ComponentWaiter
 	PanicHandlerFn          PanicHandlerFunc
@@ -35,6 +36,7 @@ func NewSnowflakeMerge(i interface{}) (outputChan chan stream.Record, controlCha
 	fn := GetSqlSliceSnowflakeMerge(&SnowflakeMergeSqlConfig{
 		TargetKeyCols:   cfg.TargetKeyCols,
 		TargetOtherCols: cfg.TargetOtherCols,
+		FileFormat:      cfg.FileFormat,
 	})
 	return NewSnowflakeLoader(&SnowflakeLoaderConfig{
 		Log:                     cfg.Log,
@@ -46,6 +48,7 @@ func NewSnowflakeMerge(i interface{}) (outputChan chan stream.Record, controlCha
 		StageName:               cfg.StageName,
 		InputChanField4FileName: cfg.InputChanField4FileName,
 		DeleteAll:               false,
+		FileFormat:              cfg.FileFormat,
 		CommitSequenceKeyName:   cfg.CommitSequenceKeyName,
 		WaitCounter:             cfg.WaitCounter,
 		StepWatcher:             cfg.StepWatcher,
@@ -56,6 +59,7 @@ func NewSnowflakeMerge(i interface{}) (outputChan chan stream.Record, controlCha
 type SnowflakeMergeSqlConfig struct {
 	TargetKeyCols   *om.OrderedMap // ordered map of: key = chan field name; value = target table column name
 	TargetOtherCols *om.OrderedMap // ordered map of: key = chan field name; value = target table column name
+	FileFormat      string         // the format of the files to load: FileFormatCsv (default) or FileFormatParquet.
 }
 
 // GetSqlSliceSnowflakeSyncTable will return a slice of SQL statements required to sync data from a given fileName
@@ -81,6 +85,7 @@ func GetSqlSliceSnowflakeMerge(cfg *SnowflakeMergeSqlConfig,
 	pkColsCsv := strings.Join(pkSlice, ",")
 	otherColsCsv := strings.Join(otherSlice, ",")
 	allColsCsv := pkColsCsv + "," + otherColsCsv
+	formatSql, _ := getSnowflakeFileFormatSql(cfg.FileFormat) // the loader validates the file format.
 	// Return the generator func.
 	return func(schemaTableName rdbms.SchemaTable, stageName string, fileName string, force bool) []string {
 		s := make([]string, 0, 5)
@@ -100,7 +105,7 @@ func GetSqlSliceSnowflakeMerge(cfg *SnowflakeMergeSqlConfig,
 			firstTime = false
 		}
 		// Copy into the tmp table from the staged file.
-		s = append(s, fmt.Sprintf("copy into %v from '@%v'", tmpSchemaTable, stagedFile))
+		s = append(s, fmt.Sprintf("copy into %v from '@%v'%v", tmpSchemaTable, stagedFile, formatSql))
 		// DELETE from T1...
 		s = append(s, fmt.Sprintf(`merge into %v a using ( select %v from %v ) b on %v when matched then update set %v when not matched then insert values (%v,%v)`,
 			schemaTableName.String(),
@@ -57,3 +57,20 @@ func TestGetSqlSliceSnowflakeMerge(t *testing.T) {
 		t.Fatalf("expected sql: '%v'; got '%v'", expected, s2[2])
 	}
 }
+
+func TestGetSqlSliceSnowflakeMergeParquet(t *testing.T) {
+	omKeys := ordered_map.NewOrderedMap()
+	omKeys.Set("pk1", "pk1")
+	omOther := ordered_map.NewOrderedMap()
+	omOther.Set("colA", "valA")
+	cfg := &components.SnowflakeMergeSqlConfig{
+		TargetKeyCols:   omKeys,
+		TargetOtherCols: omOther,
+		FileFormat:      components.FileFormatParquet,
+	}
+	s := components.GetSqlSliceSnowflakeMerge(cfg)(rdbms.SchemaTable{SchemaTable: `schema.table`}, "stage", "s3file1.parquet", false)
+	expected := `copy into schema.table_tmp from '@stage/s3file1.parquet' FILE_FORMAT=(TYPE=PARQUET) MATCH_BY_COLUMN_NAME=CASE_INSENSITIVE`
+	if s[1] != expected {
+		t.Fatalf("expected sql: '%v'; got '%v'", expected, s[1])
+	}
+}
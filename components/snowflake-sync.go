@@ -18,15 +18,15 @@ import (
 type SnowflakeSyncConfig struct {
 	Log                     logger.Logger
 	Name                    string
-	InputChan               chan stream.Record
-	Db                      shared.Connector  // connection to target snowflake database abstracted via interface.
-	InputChanField4FileName string            // the field name found on InputChan that contains the file name to load.
-	StageName               string            // the external stage that can access the files to load.
-	TargetSchemaTableName   rdbms.SchemaTable // the [schema.]table to load into.
-	CommitSequenceKeyName   string            // the field name added by this component to the outputChan record, incremented when a batch is committed; used by downstream components - see also TableSync component.
-	TargetKeyCols           *om.OrderedMap    // ordered map of: key = chan field name; value = target table column name
-	TargetOtherCols         *om.OrderedMap    // ordered map of: key = chan field name; value = target table column name
-	FlagField               string
+	InputChan               chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	Db                      shared.Connector   `data:"logicalConnectionName" mandatory:"yes"` // connection to target snowflake database abstracted via interface.
+	InputChanField4FileName string             `data:"fieldName4FileName"`                    // the field name found on InputChan that contains the file name to load.
+	StageName               string             `data:"stageName" mandatory:"yes"`             // the external stage that can access the files to load.
+	TargetSchemaTableName   rdbms.SchemaTable  `data:"schemaTableName" mandatory:"yes"`       // the [schema.]table to load into.
+	CommitSequenceKeyName   string             `data:"commitSequenceKeyName"`                 // the field name added by this component to the outputChan record, incremented when a batch is committed; used by downstream components - see also TableSync component.
+	TargetKeyCols           *om.OrderedMap     `data:"keyCols"`                               // ordered map of: key = chan field name; value = target table column name
+	TargetOtherCols         *om.OrderedMap     `data:"otherCols"`                             // ordered map of: key = chan field name; value = target table column name
+	FlagField               string             `data:"flagFieldName"`
 	StepWatcher             *stats.StepWatcher
 	WaitCounter             ComponentWaiter
 	PanicHandlerFn          PanicHandlerFunc
@@ -0,0 +1,349 @@
+package components
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	s "github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// Sort directions that can follow the field names in sortKeys.
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// sortDefaultMaxRecords is the number of records held in memory when a Sort does not specify maxRecordsInMemory.
+const sortDefaultMaxRecords = 100000
+
+type SortConfig struct {
+	Log                logger.Logger
+	Name               string
+	InputChan          chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	SortKeys           []string           `data:"sortKeys" mandatory:"yes"` // field names, each optionally followed by a space and asc or desc.
+	MaxRecordsInMemory int                `data:"maxRecordsInMemory"`       // sorted runs are spilled to disk when there are more than this.
+	SpillDir           string             `data:"spillDir"`                 // directory for spill files; defaults to OS temp space.
+	StepWatcher        *s.StepWatcher
+	WaitCounter        ComponentWaiter
+	PanicHandlerFn     PanicHandlerFunc
+}
+
+// NewSort outputs the records on InputChan sorted by SortKeys.
+// Values are compared byte-wise using their string representation with times in UTC, which is the same comparison
+// used by MergeDiff, so the output is ready to join regardless of the collation of the source. Nulls sort as empty
+// strings. Records with equal keys keep their input order.
+// Records are sorted in memory until there are more than MaxRecordsInMemory, at which point each sorted run is
+// written to a file in SpillDir and the runs are merged once all input has been read. Times read back from disk
+// keep their offset but not the name of their location.
+func NewSort(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
+	cfg := i.(*SortConfig)
+	fields, descending, err := parseSortKeys(cfg.SortKeys)
+	if err != nil {
+		cfg.Log.Panic(cfg.Name, " ", err)
+	}
+	if cfg.MaxRecordsInMemory <= 0 {
+		cfg.MaxRecordsInMemory = sortDefaultMaxRecords
+	}
+	outputChan = make(chan stream.Record, c.ChanSize)
+	controlChan = make(chan ControlAction, 1)
+	go func() {
+		if cfg.PanicHandlerFn != nil {
+			defer cfg.PanicHandlerFn()
+		}
+		cfg.Log.Info(cfg.Name, " is running")
+		if cfg.WaitCounter != nil {
+			cfg.WaitCounter.Add()
+			defer cfg.WaitCounter.Done()
+		}
+		rowCount := int64(0)
+		if cfg.StepWatcher != nil { // if we have been given a stepWatcher struct that can watch our rowCount and output channel length...
+			cfg.StepWatcher.StartWatching(&rowCount, &outputChan)
+			defer cfg.StepWatcher.StopWatching()
+		}
+		srt := &sorter{log: cfg.Log, name: cfg.Name, fields: fields, descending: descending}
+		defer srt.removeRunFiles()
+		var controlAction ControlAction
+		for {
+			select {
+			case rec, ok := <-cfg.InputChan:
+				if !ok { // if we have run out of rows...
+					cfg.InputChan = nil // disable this case
+				} else { // save the row...
+					if len(srt.records) >= cfg.MaxRecordsInMemory { // if memory is full...
+						if err := srt.spill(cfg.SpillDir); err != nil {
+							cfg.Log.Panic(cfg.Name, " unable to spill records to disk: ", err)
+						}
+					}
+					srt.records = append(srt.records, sortItem{Keys: srt.getKeys(rec), Data: rec.GetDataMap()})
+					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
+				}
+			case controlAction = <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
+				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return
+			}
+			if cfg.InputChan == nil { // if we should exit gracefully...
+				break
+			}
+		}
+		send := func(item sortItem) bool {
+			rec := stream.NewRecord()
+			for k, v := range item.Data {
+				rec.SetData(k, v)
+			}
+			if rowSentOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !rowSentOK { // if we couldn't output the row due to shutdown...
+				cfg.Log.Info(cfg.Name, " shutdown")
+				return false
+			}
+			return true
+		}
+		if srt.runFiles == nil { // if all records fit in memory...
+			srt.sortRecords()
+			for _, item := range srt.records {
+				if !send(item) {
+					return
+				}
+			}
+		} else { // else merge the sorted runs...
+			if len(srt.records) > 0 {
+				if err := srt.spill(cfg.SpillDir); err != nil {
+					cfg.Log.Panic(cfg.Name, " unable to spill records to disk: ", err)
+				}
+			}
+			if err := srt.merge(send); err != nil {
+				cfg.Log.Panic(cfg.Name, " unable to merge sorted records from disk: ", err)
+			}
+			if srt.shutdown {
+				return
+			}
+		}
+		srt.removeRunFiles() // remove files before downstream steps see that we're done.
+		close(outputChan)
+		cfg.Log.Info(cfg.Name, " complete")
+	}()
+	return outputChan, controlChan
+}
+
+// parseSortKeys returns the field names in sortKeys and whether each one should be sorted in descending order.
+func parseSortKeys(sortKeys []string) (fields []string, descending []bool, err error) {
+	for _, k := range sortKeys {
+		x := strings.Fields(k)
+		if len(x) == 0 {
+			continue
+		}
+		if len(x) > 2 {
+			return nil, nil, fmt.Errorf("sortKeys must be field names optionally followed by %v or %v, got %q", SortAscending, SortDescending, k)
+		}
+		desc := false
+		if len(x) == 2 {
+			switch strings.ToLower(x[1]) {
+			case SortAscending:
+			case SortDescending:
+				desc = true
+			default:
+				return nil, nil, fmt.Errorf("unsupported sort direction in %q: use %v or %v", k, SortAscending, SortDescending)
+			}
+		}
+		fields = append(fields, x[0])
+		descending = append(descending, desc)
+	}
+	if len(fields) == 0 {
+		return nil, nil, fmt.Errorf("missing sortKeys")
+	}
+	return fields, descending, nil
+}
+
+// sortItem is a record and the string values of its sort keys.
+// Fields are exported so that records can be spilled to disk using gob.
+type sortItem struct {
+	Keys []string
+	Data map[string]interface{}
+}
+
+// sorter holds records to be sorted and the files containing runs of sorted records.
+type sorter struct {
+	log        logger.Logger
+	name       string
+	fields     []string
+	descending []bool
+	records    []sortItem
+	runDir     string
+	runFiles   []string // runs in the order they were written.
+	shutdown   bool     // true if the output was shutdown while merging.
+}
+
+func (srt *sorter) getKeys(rec stream.Record) []string {
+	keys := make([]string, len(srt.fields))
+	for idx, f := range srt.fields {
+		keys[idx] = rec.GetDataAsStringUseUtcTime(srt.log, f)
+	}
+	return keys
+}
+
+// compare returns -1, 0 or 1 if keys a sort before, the same as or after keys b.
+func (srt *sorter) compare(a []string, b []string) int {
+	for idx := range a {
+		cmp := strings.Compare(a[idx], b[idx])
+		if srt.descending[idx] {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+func (srt *sorter) sortRecords() {
+	sort.SliceStable(srt.records, func(i, j int) bool {
+		return srt.compare(srt.records[i].Keys, srt.records[j].Keys) < 0
+	})
+}
+
+// spill sorts the records in memory and writes them to a new run file in a directory under dir.
+func (srt *sorter) spill(dir string) error {
+	if srt.runDir == "" { // if this is the first spill...
+		var err error
+		if srt.runDir, err = ioutil.TempDir(dir, "halfpipe-sort-"); err != nil {
+			return err
+		}
+		gob.Register(time.Time{})
+		srt.log.Info(srt.name, " spilling sorted runs to disk in ", srt.runDir)
+	}
+	srt.sortRecords()
+	fileName := filepath.Join(srt.runDir, fmt.Sprintf("run-%v.gob", len(srt.runFiles)))
+	srt.log.Debug(srt.name, " spilling ", len(srt.records), " records to ", fileName)
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	for idx := range srt.records {
+		if err := enc.Encode(&srt.records[idx]); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	srt.runFiles = append(srt.runFiles, fileName)
+	srt.records = nil
+	return nil
+}
+
+// sortRun reads sorted records from a run file.
+type sortRun struct {
+	idx  int // the position of the run in runFiles, used to keep equal keys in input order.
+	f    *os.File
+	dec  *gob.Decoder
+	item sortItem // the next record.
+}
+
+func (r *sortRun) next() (bool, error) {
+	r.item = sortItem{}
+	if err := r.dec.Decode(&r.item); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sortRunHeap orders runs by their next record.
+type sortRunHeap struct {
+	srt  *sorter
+	runs []*sortRun
+}
+
+func (h *sortRunHeap) Len() int { return len(h.runs) }
+func (h *sortRunHeap) Less(i, j int) bool {
+	if cmp := h.srt.compare(h.runs[i].item.Keys, h.runs[j].item.Keys); cmp != 0 {
+		return cmp < 0
+	}
+	return h.runs[i].idx < h.runs[j].idx
+}
+func (h *sortRunHeap) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *sortRunHeap) Push(x interface{}) { h.runs = append(h.runs, x.(*sortRun)) }
+func (h *sortRunHeap) Pop() interface{} {
+	r := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return r
+}
+
+// merge calls send with the records in all run files in sorted order.
+// If send returns false then merge sets srt.shutdown and returns early.
+func (srt *sorter) merge(send func(item sortItem) bool) error {
+	h := &sortRunHeap{srt: srt}
+	defer func() {
+		for _, r := range h.runs {
+			_ = r.f.Close()
+		}
+	}()
+	for idx, fileName := range srt.runFiles { // for each run...
+		f, err := os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		r := &sortRun{idx: idx, f: f, dec: gob.NewDecoder(bufio.NewReader(f))}
+		ok, err := r.next()
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		if ok {
+			h.runs = append(h.runs, r)
+		} else {
+			_ = f.Close()
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 0 {
+		r := h.runs[0]
+		if !send(r.item) {
+			srt.shutdown = true
+			return nil
+		}
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			_ = r.f.Close()
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// removeRunFiles deletes the run directory, if any. It is safe to call more than once.
+func (srt *sorter) removeRunFiles() {
+	if srt.runDir != "" {
+		if err := os.RemoveAll(srt.runDir); err != nil {
+			srt.log.Warn(srt.name, " unable to remove spill files: ", err)
+		}
+		srt.runDir = ""
+	}
+}
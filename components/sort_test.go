@@ -0,0 +1,80 @@
+package components
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	c "github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestNewSort(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	run := func(cfg *SortConfig, input []map[string]interface{}) []map[string]interface{} {
+		inputChan := make(chan stream.Record, int(c.ChanSize))
+		go func() {
+			for _, m := range input {
+				rec := stream.NewRecord()
+				for k, v := range m {
+					rec.SetData(k, v)
+				}
+				inputChan <- rec
+			}
+			close(inputChan)
+		}()
+		cfg.InputChan = inputChan
+		outputChan, _ := NewSort(cfg)
+		got := make([]map[string]interface{}, 0)
+		for rec := range outputChan {
+			got = append(got, rec.GetDataMap())
+		}
+		return got
+	}
+	ts := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	input := []map[string]interface{}{
+		{"ID": 10, "NAME": "b", "SEQ": 0, "TS": ts},
+		{"ID": 9, "NAME": "a", "SEQ": 1, "TS": nil},
+		{"ID": 10, "NAME": "c", "SEQ": 2, "TS": ts},
+		{"ID": 10, "NAME": "c", "SEQ": 3, "TS": ts},
+		{"ID": "B", "NAME": "a", "SEQ": 4, "TS": ts},
+		{"ID": "a", "NAME": "a", "SEQ": 5, "TS": ts},
+		{"ID": nil, "NAME": "z", "SEQ": 6, "TS": ts},
+	}
+	// Expect byte-wise order of IDs: "" < "10" < "9" < "B" < "a", then NAME descending, then input order.
+	expectedSeq := []int{6, 2, 3, 0, 1, 4, 5}
+
+	// Test 1 - sort in memory and with spills to disk.
+	for _, maxRecords := range []int{0, 1, 2, 3} {
+		spillDir := t.TempDir()
+		cfg := &SortConfig{Log: log, Name: "Test Sort", SortKeys: []string{"ID", "NAME desc"}, MaxRecordsInMemory: maxRecords, SpillDir: spillDir}
+		got := run(cfg, input)
+		seq := make([]int, 0, len(got))
+		for _, m := range got {
+			seq = append(seq, m["SEQ"].(int))
+		}
+		if !reflect.DeepEqual(seq, expectedSeq) {
+			t.Fatalf("Test 1, maxRecordsInMemory %v expected order %v; got %v", maxRecords, expectedSeq, seq)
+		}
+		if !reflect.DeepEqual(got[3], input[0]) {
+			t.Fatalf("Test 1, maxRecordsInMemory %v expected record %v; got %v", maxRecords, input[0], got[3])
+		}
+		if files, _ := ioutil.ReadDir(spillDir); len(files) != 0 {
+			t.Fatal("Test 1, expected spill files to be removed")
+		}
+	}
+
+	// Test 2 - no input.
+	if got := run(&SortConfig{Log: log, Name: "Test Sort", SortKeys: []string{"ID"}}, nil); len(got) != 0 {
+		t.Fatal("Test 2, expected no output; got ", got)
+	}
+
+	// Test 3 - bad sort keys.
+	for _, keys := range [][]string{nil, {" "}, {"ID up"}, {"ID asc x"}} {
+		if _, _, err := parseSortKeys(keys); err == nil {
+			t.Fatalf("Test 3, expected error for sortKeys %q", keys)
+		}
+	}
+}
@@ -15,17 +15,23 @@ import (
 type SqlExecConfig struct {
 	Log                      logger.Logger
 	Name                     string
-	InputChan                chan stream.Record
-	SqlQueryFieldName        string
-	SqlRowsAffectedFieldName string
-	OutputDb                 shared.Connector
+	InputChan                chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	SqlQueryFieldName        string             `data:"sqlQueryFieldName" mandatory:"yes"`
+	SqlRowsAffectedFieldName string             `data:"sqlRowsAffectedFieldName"`
+	OutputDb                 shared.Connector   `data:"databaseConnectionName" mandatory:"yes"`
 	StepWatcher              *s.StepWatcher
 	WaitCounter              ComponentWaiter
 	PanicHandlerFn           PanicHandlerFunc
+	RowErrorHandler          *RowErrorHandler // optional handler for records whose SQL fails; defaults to fail.
 }
 
+// NewSqlExec executes the SQL found in field SqlQueryFieldName of each record on InputChan.
+// Records whose SQL fails are passed to RowErrorHandler.
 func NewSqlExec(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
 	cfg := i.(*SqlExecConfig)
+	if cfg.RowErrorHandler == nil {
+		cfg.RowErrorHandler, _ = NewRowErrorHandler(cfg.Log, cfg.Name, OnErrorFail, 0)
+	}
 	outputChan = make(chan stream.Record, c.ChanSize)
 	controlChan = make(chan ControlAction, 1)
 	go func() {
@@ -53,12 +59,14 @@ func NewSqlExec(i interface{}) (outputChan chan stream.Record, controlChan chan
 					// TODO: add args handling to SqlExec (NOTE if you supply nil for args then DDL doesn't work due to: ORA-01036: illegal variable name/number)
 					res, err := cfg.OutputDb.ExecContext(context.Background(), rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.SqlQueryFieldName))
 					if err != nil {
-						cfg.Log.Panic(fmt.Sprintf("error executing SQL '%v': %v", rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.SqlQueryFieldName), err))
+						cfg.RowErrorHandler.Reject(rec, fmt.Errorf("error executing SQL '%v': %v", rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.SqlQueryFieldName), err))
+						break // skip to the next record.
 					}
 					if cfg.SqlRowsAffectedFieldName != "" { // if the user supplied a field name to output the number of rows affected...
 						rowsAffected, err := res.RowsAffected()
 						if err != nil { // if we couldn't get the num rows affected...
-							cfg.Log.Panic(fmt.Sprintf("error checking number of rows affected after SQL '%v': %v", rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.SqlQueryFieldName), err))
+							cfg.RowErrorHandler.Reject(rec, fmt.Errorf("error checking number of rows affected after SQL '%v': %v", rec.GetDataAsStringPreserveTimeZone(cfg.Log, cfg.SqlQueryFieldName), err))
+							break // skip to the next record.
 						}
 						rec.SetData(cfg.SqlRowsAffectedFieldName, rowsAffected)
 					}
@@ -69,6 +77,9 @@ func NewSqlExec(i interface{}) (outputChan chan stream.Record, controlChan chan
 					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
 				}
 			case controlAction = <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				cfg.Log.Info(cfg.Name, " shutdown")
 				return
@@ -77,6 +88,7 @@ func NewSqlExec(i interface{}) (outputChan chan stream.Record, controlChan chan
 				break
 			}
 		}
+		cfg.RowErrorHandler.Close()
 		close(outputChan)
 		cfg.Log.Info(cfg.Name, " complete")
 	}()
@@ -3,6 +3,7 @@ package components
 import (
 	"fmt"
 	"io"
+	"os"
 	"sync/atomic"
 
 	c "github.com/relloyd/halfpipe/constants"
@@ -15,10 +16,10 @@ import (
 type StdOutPassThroughConfig struct {
 	Log             logger.Logger
 	Name            string
-	InputChan       chan stream.Record
-	Writer          io.Writer // the write to output records to, usually STDOUT
-	OutputFields    []string  // the list of fields to write to the Writer (leave empty for all fields)
-	AbortAfterCount int64
+	InputChan       chan stream.Record `data:"readDataFromStep" mandatory:"yes"`
+	Writer          io.Writer          // the write to output records to, usually STDOUT
+	OutputFields    []string           `data:"outputFieldsCsv"` // the list of fields to write to the Writer (leave empty for all fields)
+	AbortAfterCount int64              `data:"abortAfterNumRecords"`
 	StepWatcher     *stats.StepWatcher
 	WaitCounter     ComponentWaiter
 	PanicHandlerFn  PanicHandlerFunc
@@ -29,15 +30,15 @@ type StdOutPassThroughConfig struct {
 // OutputFields may either be empty to write all fields found on the input stream, or supply a slice of field names,
 // which must exist on the input stream.
 // Optionally use AbortAfterCount to cause a panic after the supplied number of records has been sent.
-// Supply an io.Writer for the records to be output to (the default launcher func uses STDOUT).
+// Supply an io.Writer for the records to be output to or leave it nil to use STDOUT.
 func NewStdOutPassThrough(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
 	cfg := i.(*StdOutPassThroughConfig)
+	if cfg.Writer == nil {
+		cfg.Writer = os.Stdout
+	}
 	outputChan = make(chan stream.Record, c.ChanSize)
 	controlChan = make(chan ControlAction, 1) // make a control channel that receives a chan error.
 	go func() {
-		if cfg.Writer == nil {
-			cfg.Log.Panic(cfg.Name, " bad config supplied: missing io.Writer")
-		}
 		if cfg.PanicHandlerFn != nil {
 			defer cfg.PanicHandlerFn()
 		}
@@ -89,7 +90,7 @@ func NewStdOutPassThrough(i interface{}) (outputChan chan stream.Record, control
 					controlChan = nil
 				}
 			case controlAction = <-controlChan: // if we are told to shutdown...
-				if controlAction.Action == Shutdown {
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Shutdown {
 					cfg.InputChan = nil // disable the data input channel case.
 					controlChan = nil   // disable the controlChan case as we're going to exit.
 					// Rollback is deferred.
@@ -445,6 +445,7 @@ func cqnChannelBridge(
 					flagChan <- struct{}{} // notify that the current input channel ended.
 					// TODO: avoid blocking above!
 				} else { // else we have an output record from TableSync to forward...
+					resumed := false
 					for {
 						// Forward the record to the output channel.
 						// TODO: add row counts
@@ -464,17 +465,24 @@ func cqnChannelBridge(
 								// TODO: avoid blocking above!
 							}
 						case controlAction := <-controlChan: // if we are told to shutdown...
+							if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+								resumed = true // go back to waiting for input below.
+								break
+							}
 							fnShutdownTableSync()
 							controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 							log.Info(name, " shutdown")
 							return
 						}
-						if dataChan == nil { // if we ran out of data...
+						if dataChan == nil || resumed { // if we ran out of data or were paused...
 							break // get back out and wait for new input.
 						}
 					}
 				}
 			case controlAction := <-controlChan: // if we are told to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				fnShutdownTableSync()
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				log.Info(name, " shutdown")
@@ -15,10 +15,10 @@ import (
 type SqlQueryWithArgsConfig struct {
 	Log            logger.Logger
 	Name           string
-	Db             shared.Connector
-	StepWatcher    *s.StepWatcher // optional ptr to object that can gather step stats.
+	Db             shared.Connector `data:"databaseConnectionName" mandatory:"yes"`
+	StepWatcher    *s.StepWatcher   // optional ptr to object that can gather step stats.
 	WaitCounter    ComponentWaiter
-	Sqltext        string
+	Sqltext        string `data:"sqlText" mandatory:"yes"`
 	Args           []interface{}
 	PanicHandlerFn PanicHandlerFunc
 }
@@ -26,24 +26,24 @@ type SqlQueryWithArgsConfig struct {
 type SqlQueryWithChanConfig struct {
 	Log             logger.Logger
 	Name            string
-	Db              shared.Connector
-	StepWatcher     *s.StepWatcher // optional ptr to object that can gather step stats.
+	Db              shared.Connector `data:"databaseConnectionName" mandatory:"yes"`
+	StepWatcher     *s.StepWatcher   // optional ptr to object that can gather step stats.
 	WaitCounter     ComponentWaiter
-	Sqltext         string
-	InputChan       chan stream.Record // optional input channel from which values for bind variables are fetched. If omitted, then Sqltext must not use binds.
-	InputChanFields []string           // list of field names in the input channel for which to use as bind variables.
+	Sqltext         string             `data:"sqlText" mandatory:"yes"`
+	InputChan       chan stream.Record `data:"readDataFromStep"`    // optional input channel from which values for bind variables are fetched. If omitted, then Sqltext must not use binds.
+	InputChanFields []string           `data:"readDataUsingFields"` // list of field names in the input channel for which to use as bind variables.
 	PanicHandlerFn  PanicHandlerFunc
 }
 
 type SqlQueryWithReplace struct {
 	Log            logger.Logger
 	Name           string
-	Db             shared.Connector
-	StepWatcher    *s.StepWatcher // optional ptr to object that can gather step stats.
+	Db             shared.Connector `data:"databaseConnectionName" mandatory:"yes"`
+	StepWatcher    *s.StepWatcher   // optional ptr to object that can gather step stats.
 	WaitCounter    ComponentWaiter
-	Sqltext        string
+	Sqltext        string `data:"sqlText" mandatory:"yes"`
 	Args           []interface{}
-	Replacements   map[string]string
+	Replacements   map[string]string `data:"replacements"`
 	PanicHandlerFn PanicHandlerFunc
 }
 
@@ -235,6 +235,9 @@ func execSql(log logger.Logger,
 			// Check for shutdown requests.
 			select {
 			case controlAction = <-controlChan: // if we have been asked to shutdown...
+				if controlAction = HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				err = rows.Close()
 				var errResponse error
 				if err != nil { // if there was an error closing the row set...
@@ -13,11 +13,11 @@ import (
 type TableMergeConfig struct {
 	Log                                logger.Logger // TODO: do we need to find a way to stub this out?
 	Name                               string
-	InputChan                          chan stream.Record // input rows to write to database table.
-	OutputDb                           shared.Connector   // target database connection for writes.
-	ExecBatchSize                      int                // commit interval in num rows
-	CommitBatchSize                    int
-	shared.SqlStatementGeneratorConfig // config for target database table
+	InputChan                          chan stream.Record `data:"readDataFromStep" mandatory:"yes"`       // input rows to write to database table.
+	OutputDb                           shared.Connector   `data:"databaseConnectionName" mandatory:"yes"` // target database connection for writes.
+	ExecBatchSize                      int                `data:"execBatchSize" mandatory:"yes"`          // commit interval in num rows
+	CommitBatchSize                    int                `data:"commitBatchSize" mandatory:"yes"`
+	shared.SqlStatementGeneratorConfig                    // config for target database table
 	StepWatcher                        *s.StepWatcher
 	WaitCounter                        ComponentWaiter
 	PanicHandlerFn                     PanicHandlerFunc
@@ -158,6 +158,22 @@ func startTableMerge(s *tableMerge) (outputChan chan stream.Record, controlChan
 				}
 			// TODO: send rows to outputChan - and use a safeSend().
 			case controlAction = <-controlChan:
+				controlAction = HandlePauseAction(controlAction, controlChan, func() error {
+					if tx != nil { // if there is an open transaction...
+						// Commit it so we don't hold locks on the target while paused.
+						if !needNewBatchMerge { // if there is a partial batch...
+							mustExecSqlTransaction(s.log, tx, sqlMergeGenerator.GetStatement(), sqlMergeGenerator.GetValues()...)
+							needNewBatchMerge = true
+						}
+						mustCommitSqlTransaction(s.log, tx, nil)
+						tx = nil
+						needNewTx = true
+					}
+					return nil
+				})
+				if controlAction.Action == Resume {
+					continue // carry on processing input.
+				}
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				s.log.Info(s.name, " shutdown")
 				// TODO: rollback the tx in the case of shutdown.
@@ -1,9 +1,11 @@
 package components
 
 import (
-	"log"
+	"fmt"
+	"strings"
 	"sync/atomic"
 
+	om "github.com/cevaris/ordered_map"
 	c "github.com/relloyd/halfpipe/constants"
 	"github.com/relloyd/halfpipe/logger"
 	"github.com/relloyd/halfpipe/rdbms/shared"
@@ -14,17 +16,19 @@ import (
 type TableSyncConfig struct {
 	Log             logger.Logger
 	Name            string
-	InputChan       chan stream.Record // input rows to write to database table.
-	OutputDb        shared.Connector   // target database connection for writes.
-	CommitBatchSize int                // commit interval in num rows
-	TxtBatchNumRows int                // number of rows in a single SQL statement.
+	InputChan       chan stream.Record `data:"readDataFromStep" mandatory:"yes"`       // input rows to write to database table.
+	OutputDb        shared.Connector   `data:"databaseConnectionName" mandatory:"yes"` // target database connection for writes.
+	CommitBatchSize int                `data:"commitBatchSize" mandatory:"yes"`        // commit interval in num rows
+	TxtBatchNumRows int                `data:"txtBatchNumRows" mandatory:"yes"`        // number of rows in a single SQL statement.
 	// outputRowsAfterCommit bool                 // FEATURE NOT USED YET - this component will forward rows to its outputChan as they are processed (False) or after each transaction is committed (True). The latter means that extra memory is used to buffer rows the amount of which matches the batch size before they are released downstream.
-	FlagKeyName                        string // name of the key in channel inputChan that contains values "N", "C", "D" (see constants for actual values) that can be used to distinguish, "new", "changed" and "deleted" rows, which resolve to database INSERTs/UPDATEs/DELETEs respectively.
-	CommitSequenceKeyName              string // the field name added by this component to the outputChan record, incremented when a batch is committed.
+	FlagKeyName                        string `data:"flagFieldName"`          // name of the key in channel inputChan that contains values "N", "C", "D" (see constants for actual values) that can be used to distinguish, "new", "changed" and "deleted" rows, which resolve to database INSERTs/UPDATEs/DELETEs respectively.
+	CommitSequenceKeyName              string `data:"commitSequenceKeyName"`  // the field name added by this component to the outputChan record, incremented when a batch is committed.
+	ChangedFieldsName                  string `data:"changedFieldsFieldName"` // optional field on CHANGED records that lists the fields that changed, so that only their columns are UPDATEd.
 	shared.SqlStatementGeneratorConfig        // config for target database table
 	StepWatcher                        *s.StepWatcher
 	WaitCounter                        ComponentWaiter
 	PanicHandlerFn                     PanicHandlerFunc
+	RowErrorHandler                    *RowErrorHandler // optional handler for records that are missing fields or rejected by the database; defaults to fail.
 }
 
 type tableSyncCfg struct {
@@ -36,13 +40,18 @@ type tableSyncCfg struct {
 	txtBatchNumRows       int
 	flagKeyName           string
 	commitSequenceKeyName string
+	changedFieldsKeyName  string
 	shared.SqlStatementGeneratorConfig
+	dml                shared.DmlGenerator
 	sqlInsertGenerator shared.SqlStmtGenerator // require the simple interface SqlStmtGenerator, but note that code below may cast this to the broader SqlStmtTxtBatcher interface
 	sqlUpdateGenerator shared.SqlStmtGenerator
 	sqlDeleteGenerator shared.SqlStmtGenerator
+	updates            map[string]*tableSyncUpdate // UPDATEs keyed by the columns they set, see getUpdate().
+	updateKeys         []string                    // keys of updates in the order they were created.
 	stepWatcher        *s.StepWatcher
 	waitCounter        ComponentWaiter
 	panicHandlerFn     PanicHandlerFunc
+	rowErrorHandler    *RowErrorHandler
 }
 
 // NewTableSync can be used to apply the output of a MergeDiff step to a target database table.
@@ -50,6 +59,12 @@ type tableSyncCfg struct {
 // The TableSync component adds a zero-based integer field to the output stream that increments per commit.
 // This helps consumers because the component releases rows as they are processed instead of after each commit.
 // It moves the problem of whether a batch has been committed downstream though.
+// Records that are missing the flag field or the columns required by the flag are passed to RowErrorHandler.
+// Unless RowErrorHandler fails on error, a batch that the database rejects is rolled back and its records are
+// applied again one at a time, each in its own transaction, so that those that fail can be passed to
+// RowErrorHandler too. In this case records are output after they are committed instead of as they are processed.
+// If ChangedFieldsName is set then CHANGED records that contain this field, like the output of MergeDiff
+// with the same option, only UPDATE the columns of the fields listed. UPDATEs are batched per set of columns.
 func NewTableSync(i interface{}) (outputChan chan stream.Record, controlChan chan ControlAction) {
 	cfg := i.(*TableSyncConfig)
 	dbType := cfg.OutputDb.GetType()
@@ -72,6 +87,9 @@ func NewTableSync(i interface{}) (outputChan chan stream.Record, controlChan cha
 		// Use default value.
 		cfg.CommitSequenceKeyName = c.TableSyncDefaultCommitSequenceKeyName
 	}
+	if cfg.RowErrorHandler == nil {
+		cfg.RowErrorHandler, _ = NewRowErrorHandler(cfg.Log, cfg.Name, OnErrorFail, 0)
+	}
 	dml := cfg.OutputDb.GetDmlGenerator()
 	t := &tableSyncCfg{
 		log:                         cfg.Log,
@@ -82,13 +100,16 @@ func NewTableSync(i interface{}) (outputChan chan stream.Record, controlChan cha
 		txtBatchNumRows:             cfg.TxtBatchNumRows,
 		flagKeyName:                 cfg.FlagKeyName,
 		commitSequenceKeyName:       cfg.CommitSequenceKeyName,
+		changedFieldsKeyName:        cfg.ChangedFieldsName,
 		SqlStatementGeneratorConfig: cfg.SqlStatementGeneratorConfig,
+		dml:                         dml,
 		sqlInsertGenerator:          dml.NewInsertGenerator(&cfg.SqlStatementGeneratorConfig),
 		sqlUpdateGenerator:          dml.NewUpdateGenerator(&cfg.SqlStatementGeneratorConfig),
 		sqlDeleteGenerator:          dml.NewDeleteGenerator(&cfg.SqlStatementGeneratorConfig),
 		stepWatcher:                 cfg.StepWatcher,
 		waitCounter:                 cfg.WaitCounter,
-		panicHandlerFn:              cfg.PanicHandlerFn}
+		panicHandlerFn:              cfg.PanicHandlerFn,
+		rowErrorHandler:             cfg.RowErrorHandler}
 	// Choose the type and start the table sync.
 	if dbType == "oracle" { // if we can use Oracle array binding...
 		cfg.Log.Debug("Creating TableSync of type Oracle (using array binds)...")
@@ -125,75 +146,83 @@ func startTableSyncOracleArrayBind(cfg *tableSyncCfg) (outputChan chan stream.Re
 			cntDeleted = 0
 
 			preparedNew     = false
-			preparedChanged = false
 			preparedDeleted = false
 
 			needNewTx = true
 			tx        shared.Transacter
 
 			stmtNew     shared.StatementBatch
-			stmtChanged shared.StatementBatch
 			stmtDeleted shared.StatementBatch
+
+			txRecs  = make([]stream.Record, 0) // records in the current transaction, kept so they can be re-applied one at a time after an error.
+			pending = make([]stream.Record, 0) // records waiting to be output.
 		)
+		bufferOutput := !cfg.rowErrorHandler.failsOnError() // if records can be rejected, only output them after they are committed.
 		// Make slices to hold data for each of the columns we're going to write.
 		numColsIU := cfg.TargetKeyCols.Len() + cfg.TargetOtherCols.Len()
 		colsI := make([][]interface{}, numColsIU, numColsIU) // the slice that contains one slice per data column.
 		for idx := 0; idx < numColsIU; idx++ {               // for each column...
 			colsI[idx] = make([]interface{}, 0, cfg.commitBatchSize) // make the data slice, empty to start with.
 		}
-		colsU := make([][]interface{}, numColsIU, numColsIU) // the slice that contains one slice per data column.
-		for idx := 0; idx < numColsIU; idx++ {               // for each column...
-			colsU[idx] = make([]interface{}, 0, cfg.commitBatchSize) // make the data slice, empty to start with.
-		}
 		numColsD := cfg.TargetKeyCols.Len()
 		colsD := make([][]interface{}, numColsD, numColsD) // the slice that contains one slice per data column.
 		for idx := 0; idx < numColsD; idx++ {              // for each column...
 			colsD[idx] = make([]interface{}, 0, cfg.commitBatchSize) // make the data slice, empty to start with.
 		}
+		// Function to reset the batch after a commit or rollback.
+		fnReset := func() {
+			// Reset the column slices in the array.
+			for idx = 0; idx < numColsIU; idx++ { // for each column array...
+				colsI[idx] = colsI[idx][:0] // reset the INSERT slice; keep capacity.
+			}
+			for _, u := range cfg.updates { // for each set of columns to UPDATE...
+				for idx = range u.cols { // for each column array...
+					u.cols[idx] = u.cols[idx][:0] // reset the UPDATE slice; keep capacity.
+				}
+			}
+			for idx = 0; idx < numColsD; idx++ { // for each column array...
+				colsD[idx] = colsD[idx][:0] // reset the DELETE slice; keep capacity.
+			}
+			needNewTx = true
+			cntNew = 0
+			cntChanged = 0
+			cntDeleted = 0
+			txRecs = txRecs[:0]
+		}
 		// Function to help with commit and reset.
-		fnCommitAndReset := func() {
+		fnCommitAndReset := func() error {
 			// Execute the batches: 1) DELETE, 2) UPDATE, 3) INSERT in that order to avoid unique constraint errors
 			// TODO: there is the possibility of failure where the target table has a unique constraint in addition to the primary key.
 			if preparedDeleted {
-				_, err = stmtDeleted.ExecBatch(colsD) // 1) DELETE - discard the result.
-				if err != nil {
-					err2 := tx.Rollback()
-					cfg.log.Panic(err, err2)
+				if _, err := stmtDeleted.ExecBatch(colsD); err != nil { // 1) DELETE - discard the result.
+					return err
 				}
 			}
-			if preparedChanged {
-				_, err = stmtChanged.ExecBatch(colsU) // 2) UPDATE - discard the result.
-				if err != nil {
-					err2 := tx.Rollback()
-					cfg.log.Panic(err, err2)
+			for _, k := range cfg.updateKeys { // for each set of columns to UPDATE...
+				if u := cfg.updates[k]; u.prepared {
+					if _, err := u.stmt.ExecBatch(u.cols); err != nil { // 2) UPDATE - discard the result.
+						return err
+					}
 				}
 			}
 			if preparedNew {
-				_, err = stmtNew.ExecBatch(colsI) // 3) INSERT - discard the result.
-				if err != nil {
-					err2 := tx.Rollback()
-					cfg.log.Panic(err, err2)
+				if _, err := stmtNew.ExecBatch(colsI); err != nil { // 3) INSERT - discard the result.
+					return err
 				}
 			}
 			// Commit.
-			err = tx.Commit()
-			// runtime.GC()
-			if err != nil {
-				cfg.log.Panic("error committing transaction containing 'new' records: ", err)
-			}
-			commitSequence++ // increment counter which is added to the TableSync output record.
-			// Reset the column slices in the array.
-			for idx = 0; idx < numColsIU; idx++ { // for each column array...
-				colsI[idx] = colsI[idx][:0] // reset the INSERT slice; keep capacity.
-				colsU[idx] = colsU[idx][:0] // reset the UPDATE slice; keep capacity.
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("error committing transaction: %w", err)
 			}
-			for idx = 0; idx < numColsD; idx++ { // for each column array...
-				colsD[idx] = colsD[idx][:0] // reset the DELETE slice; keep capacity.
+			if bufferOutput { // if records are output after commit...
+				for _, rec := range txRecs {
+					rec.SetData(cfg.commitSequenceKeyName, commitSequence)
+					pending = append(pending, rec)
+				}
 			}
-			needNewTx = true
-			cntNew = 0
-			cntChanged = 0
-			cntDeleted = 0
+			commitSequence++ // increment counter which is added to the TableSync output record.
+			fnReset()
+			return nil
 		}
 		fnStartNewTx := func() {
 			tx, err = cfg.outputDb.Begin()
@@ -202,12 +231,113 @@ func startTableSyncOracleArrayBind(cfg *tableSyncCfg) (outputChan chan stream.Re
 			}
 			needNewTx = false
 			preparedNew = false
-			preparedChanged = false
 			preparedDeleted = false
+			for _, u := range cfg.updates {
+				u.prepared = false
+			}
 			cntNew = 0
 			cntChanged = 0
 			cntDeleted = 0
 		}
+		// Function to add a record to the batch.
+		fnAdd := func(rec stream.Record) error {
+			switch rec.GetDataAsStringPreserveTimeZone(cfg.log, cfg.flagKeyName) {
+			case c.MergeDiffValueNew: // if we have NEW row for INSERT...
+				// NEW
+				if !preparedNew { // if we haven't prepared the statement yet...
+					if stmtNew, err = tx.Prepare(cfg.sqlInsertGenerator.GetStatement()); err != nil { // if there was an error preparing...
+						return err
+					}
+					preparedNew = true
+				}
+				// Extract values from the record.
+				idx = 0 // reset the position at which we start populating array below.
+				rec.GetDataToColArray(cfg.log, cfg.TargetKeyCols, &colsI, &idx)
+				rec.GetDataToColArray(cfg.log, cfg.TargetOtherCols, &colsI, &idx)
+				cntNew++
+			case c.MergeDiffValueChanged: // if we have a changed record...
+				// CHANGED (UPDATED)
+				u := cfg.getUpdate(rec)
+				if u == nil { // if there are no columns to UPDATE...
+					break
+				}
+				if !u.prepared { // if we haven't prepared a statement yet...
+					if u.stmt, err = tx.Prepare(u.generator.GetStatement()); err != nil { // if there was an error preparing...
+						return err
+					}
+					u.prepared = true
+				}
+				if u.cols == nil { // if we haven't made slices to hold data for each of the columns yet...
+					u.cols = make([][]interface{}, cfg.TargetKeyCols.Len()+u.otherCols.Len())
+					for idx = range u.cols { // for each column...
+						u.cols[idx] = make([]interface{}, 0, cfg.commitBatchSize) // make the data slice, empty to start with.
+					}
+				}
+				// Extract values from the record.
+				idx = 0                                                    // reset the position at which we start populating array below.
+				rec.GetDataToColArray(cfg.log, u.otherCols, &u.cols, &idx) // ensure columns to SET come before the WHERE columns.
+				rec.GetDataToColArray(cfg.log, cfg.TargetKeyCols, &u.cols, &idx)
+				cntChanged++
+			case c.MergeDiffValueDeleted: // if we have a deleted record...
+				// DELETED
+				if !preparedDeleted { // if we haven't prepared a statement yet...
+					if stmtDeleted, err = tx.Prepare(cfg.sqlDeleteGenerator.GetStatement()); err != nil { // if there was an error preparing...
+						return err
+					}
+					preparedDeleted = true
+				}
+				// Extract values from the record.
+				idx = 0 // reset the position at which we start populating array below.
+				rec.GetDataToColArray(cfg.log, cfg.TargetKeyCols, &colsD, &idx)
+				cntDeleted++
+			}
+			return nil
+		}
+		// Function to handle a failed batch.
+		// Unless the RowErrorHandler fails on error, roll back and apply the records one at a time so the bad ones
+		// can be rejected.
+		fnRecover := func(errBatch error) {
+			err2 := tx.Rollback()
+			if cfg.rowErrorHandler.failsOnError() {
+				cfg.log.Panic(errBatch, err2)
+			}
+			cfg.log.Warn(cfg.name, " re-applying ", len(txRecs), " records one at a time after error: ", errBatch)
+			recs := txRecs
+			txRecs = make([]stream.Record, 0, len(recs))
+			fnReset()
+			for _, rec := range recs { // for each record in the failed transaction...
+				fnStartNewTx()
+				txRecs = append(txRecs, rec)
+				err := fnAdd(rec)
+				if err == nil {
+					err = fnCommitAndReset()
+				}
+				if err != nil { // if the record can't be applied...
+					_ = tx.Rollback()
+					fnReset()
+					cfg.rowErrorHandler.Reject(rec, err)
+				}
+			}
+		}
+		// Function to commit the current transaction, if any.
+		fnCommitPending := func() {
+			if needNewTx { // if there is no transaction...
+				return
+			}
+			if err := fnCommitAndReset(); err != nil {
+				fnRecover(err)
+			}
+		}
+		// Function to send pending records.
+		fnSendPending := func() bool {
+			for _, rec := range pending {
+				if recSendOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSendOK {
+					return false
+				}
+			}
+			pending = pending[:0]
+			return true
+		}
 		// Process input rows.
 		for {
 			select {
@@ -215,70 +345,48 @@ func startTableSyncOracleArrayBind(cfg *tableSyncCfg) (outputChan chan stream.Re
 				if !ok { // if we have run out of rows...
 					cfg.inputChan = nil // disable this case
 				} else { // else we can process the row...
+					if err := checkTableSyncRecord(rec, cfg); err != nil { // if the record can't be applied...
+						cfg.rowErrorHandler.Reject(rec, err)
+						break // skip to the next record.
+					}
 					// New transaction.
 					if needNewTx { // if we need to start a new transaction...
 						fnStartNewTx()
 					}
 					// Interpret the row.
-					switch rec.GetDataAsStringPreserveTimeZone(cfg.log, cfg.flagKeyName) {
-					case c.MergeDiffValueNew: // if we have NEW row for INSERT...
-						// NEW
-						if !preparedNew { // if we haven't prepared the statement yet...
-							stmtNew, err = tx.Prepare(cfg.sqlInsertGenerator.GetStatement())
-							if err != nil { // if there was an error preparing...
-								cfg.log.Panic(err)
-							}
-							preparedNew = true
-						}
-						// Extract values from the record.
-						idx = 0 // reset the position at which we start populating array below.
-						rec.GetDataToColArray(cfg.log, cfg.TargetKeyCols, &colsI, &idx)
-						rec.GetDataToColArray(cfg.log, cfg.TargetOtherCols, &colsI, &idx)
-						cntNew++
-					case c.MergeDiffValueChanged: // if we have a changed record...
-						// CHANGED (UPDATED)
-						if !preparedChanged { // if we haven't prepared a statement yet...
-							stmtChanged, err = tx.Prepare(cfg.sqlUpdateGenerator.GetStatement()) // prepare the statement.
-							if err != nil {                                                      // if there was an error preparing...
-								cfg.log.Panic(err)
-							}
-							preparedChanged = true
-						}
-						// Extract values from the record.
-						idx = 0                                                           // reset the position at which we start populating array below.
-						rec.GetDataToColArray(cfg.log, cfg.TargetOtherCols, &colsU, &idx) // ensure columns to SET come before the WHERE columns.
-						rec.GetDataToColArray(cfg.log, cfg.TargetKeyCols, &colsU, &idx)
-						cntChanged++
-					case c.MergeDiffValueDeleted: // if we have a deleted record...
-						// DELETED
-						if !preparedDeleted { // if we haven't prepared a statement yet...
-							stmtDeleted, err = tx.Prepare(cfg.sqlDeleteGenerator.GetStatement()) // prepare the statement.
-							if err != nil {                                                      // if there was an error preparing...
-								cfg.log.Panic(err)
-							}
-							preparedDeleted = true
-						}
-						// Extract values from the record.
-						idx = 0 // reset the position at which we start populating array below.
-						rec.GetDataToColArray(cfg.log, cfg.TargetKeyCols, &colsD, &idx)
-						cntDeleted++
-					}
-					// Commit and reset.
-					if cntNew+cntChanged+cntDeleted >= cfg.commitBatchSize { // if the batch is full...
-						fnCommitAndReset()
+					txRecs = append(txRecs, rec)
+					if err := fnAdd(rec); err != nil {
+						fnRecover(err)
+					} else if cntNew+cntChanged+cntDeleted >= cfg.commitBatchSize { // if the batch is full...
+						// Commit and reset.
+						fnCommitPending()
 					}
 					// Maintain our row count for external watchers.
 					atomic.AddInt64(&rowCount, 1)
 					// Output the row.
-					// TODO: decide if we should implement buffering in TableSync and only send output rows after commit.
-					rec.SetData(cfg.commitSequenceKeyName, commitSequence)
-					if recSendOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSendOK {
+					if !bufferOutput { // if we should output records as they are processed...
+						rec.SetData(cfg.commitSequenceKeyName, commitSequence)
+						pending = append(pending, rec)
+					}
+					if !fnSendPending() {
 						cfg.Log.Info(cfg.name, " shutdown")
 						return
 					}
 				}
 			case controlAction := <-controlChan:
-				if preparedNew || preparedChanged || preparedDeleted {
+				controlAction = HandlePauseAction(controlAction, controlChan, func() error {
+					fnCommitPending() // commit so we don't hold locks on the target while paused.
+					return nil
+				})
+				if controlAction.Action == Resume {
+					if !fnSendPending() {
+						cfg.Log.Info(cfg.name, " shutdown")
+						return
+					}
+					continue // carry on processing input.
+				}
+				err = nil
+				if !needNewTx { // if there is a transaction to roll back...
 					err = tx.Rollback()
 				}
 				if err != nil {
@@ -294,9 +402,12 @@ func startTableSyncOracleArrayBind(cfg *tableSyncCfg) (outputChan chan stream.Re
 			}
 		}
 		// Normal completion - execute partial batches.
-		if cntNew > 0 || cntChanged > 0 || cntDeleted > 0 { // if there is a partial batch to execute...
-			fnCommitAndReset()
+		fnCommitPending()
+		if !fnSendPending() {
+			cfg.Log.Info(cfg.name, " shutdown")
+			return
 		}
+		cfg.rowErrorHandler.Close()
 		close(outputChan)
 		cfg.log.Info(cfg.name, " complete")
 	}()
@@ -318,17 +429,15 @@ func startTableSyncSqlTextBatch(cfg *tableSyncCfg) (outputChan chan stream.Recor
 	if !ok {
 		cfg.Log.Panic(cfg.name, ", SQL text batch updates are not supported")
 	}
-	sqlUpdateGenerator, ok := cfg.sqlUpdateGenerator.(shared.SqlStmtTxtBatcher)
-	if !ok {
+	if _, ok := cfg.sqlUpdateGenerator.(shared.SqlStmtTxtBatcher); !ok {
 		cfg.Log.Panic(cfg.name, ", SQL text batch deletes are not supported")
 	}
 	needNewBatchInsert := true
-	needNewBatchUpdate := true
 	needNewBatchDelete := true
 	needNewTx := true
 	needExecInsert := false
-	needExecUpdate := false
 	needExecDelete := false
+	txtBatchNumRows := cfg.txtBatchNumRows
 	// Make slices to hold values per record, used by INSERT/UPDATE/DELETE.
 	valuesForIU := make([]interface{}, cfg.TargetKeyCols.Len()+cfg.TargetOtherCols.Len())
 	typesForIU := make([]stream.FieldType, cfg.TargetKeyCols.Len()+cfg.TargetOtherCols.Len())
@@ -355,7 +464,200 @@ func startTableSyncSqlTextBatch(cfg *tableSyncCfg) (outputChan chan stream.Recor
 			defer cfg.stepWatcher.StopWatching()
 		}
 		commitSequence := 0
-		// Read input channel, check the flagField, add to batch accordingly and exec batch when full.
+		txRecs := make([]stream.Record, 0)                  // records in the current transaction, kept so they can be re-applied one at a time after an error.
+		pending := make([]stream.Record, 0)                 // records waiting to be output.
+		bufferOutput := !cfg.rowErrorHandler.failsOnError() // if records can be rejected, only output them after they are committed.
+		// Reset batches after a commit or rollback.
+		fnReset := func() {
+			needNewTx = true
+			numRowsInTx = 0
+			needNewBatchInsert = true
+			needNewBatchDelete = true
+			needExecInsert = false
+			needExecDelete = false
+			for _, u := range cfg.updates {
+				u.needNewBatch = true
+				u.needExec = false
+			}
+			txRecs = txRecs[:0]
+		}
+		// Exec the SQL in a text batch.
+		fnExec := func(batch shared.SqlStmtTxtBatcher) error {
+			cfg.log.Debug("Exec trying...")
+			if _, err := tx.Exec(batch.GetStatement(), batch.GetValues()...); err != nil {
+				return fmt.Errorf("error during exec of SQL (%v) %w", batch.GetStatement(), err)
+			}
+			cfg.log.Debug("Exec complete")
+			return nil
+		}
+		// Exec partial batches and commit.
+		fnCommitPending := func() error {
+			if needNewTx { // if there is nothing to commit...
+				return nil
+			}
+			if needExecDelete {
+				if err := fnExec(sqlDeleteGenerator); err != nil {
+					return err
+				}
+			}
+			for _, k := range cfg.updateKeys { // for each set of columns to UPDATE...
+				if u := cfg.updates[k]; u.needExec {
+					if err := fnExec(u.generator.(shared.SqlStmtTxtBatcher)); err != nil {
+						return err
+					}
+				}
+			}
+			if needExecInsert {
+				if err := fnExec(sqlInsertGenerator); err != nil {
+					return err
+				}
+			}
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("error committing transaction: %w", err)
+			}
+			if bufferOutput { // if records are output after commit...
+				for _, rec := range txRecs {
+					rec.SetData(cfg.commitSequenceKeyName, commitSequence)
+					pending = append(pending, rec)
+				}
+			}
+			commitSequence++ // increment counter which is added to the TableSync output record.
+			fnReset()
+			return nil
+		}
+		// Start a new transaction.
+		fnStartNewTx := func() {
+			tx, err = cfg.outputDb.Begin() // new transaction
+			if err != nil {
+				cfg.log.Panic(cfg.name, " - unable to start new transaction!")
+			}
+			needNewTx = false
+		}
+		// Check the flagField of rec, add it to a batch accordingly and exec the batch when full.
+		fnAdd := func(rec stream.Record) error {
+			cfg.log.Debug(cfg.name, " - processing: flagField name = ", cfg.flagKeyName, "; value = ", rec.GetData(cfg.flagKeyName))
+			flagValue := rec.GetData(cfg.flagKeyName).(string)
+			if flagValue == c.MergeDiffValueNew { // if we should INSERT the current record...
+				if needNewBatchInsert { // if we need to start a new batch...
+					cfg.log.Debug(cfg.name, " - new INSERT batch required.")
+					sqlInsertGenerator.InitBatch(txtBatchNumRows)
+					needNewBatchInsert = false
+				}
+				// TODO: make consistent the method for adding values to a batch - currently they must be in different orders for INSERT/UPDATE/DELETE and this won't work generically for SQL required by other database types.
+				// Save values from all fields into a list of values.
+				listIdx = 0                                                                                     // reset the list index to get mapValuesToList() to overwrite the list.
+				rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetKeyCols, &valuesForIU, &typesForIU, &listIdx) // ordering of cols is not important for INSERT.
+				rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetOtherCols, &valuesForIU, &typesForIU, &listIdx)
+				cfg.log.Debug(cfg.name, " - values for INSERT: ", valuesForIU)
+				txtBatchIsFull, err := sqlInsertGenerator.AddValuesToBatch(valuesForIU)
+				if err != nil {
+					return err
+				}
+				needExecInsert = true
+				numRowsInTx++
+				if txtBatchIsFull { // if the the batch is full...
+					needNewBatchInsert = true // request new batch on next iteration.
+					needExecInsert = false    // set this false so that we can test if a final exec is required.
+					cfg.log.Debug(cfg.name, " - exec for INSERT.")
+					return fnExec(sqlInsertGenerator)
+				}
+			} else if flagValue == c.MergeDiffValueChanged { // if we need to perform an UPDATE...
+				u := cfg.getUpdate(rec)
+				if u == nil { // if there are no columns to UPDATE...
+					return nil
+				}
+				batch := u.generator.(shared.SqlStmtTxtBatcher)
+				if u.needNewBatch {
+					cfg.log.Debug(cfg.name, " - new UPDATE batch required.")
+					batch.InitBatch(txtBatchNumRows)
+					u.needNewBatch = false
+				}
+				if u.values == nil { // if we haven't made a slice to hold values per record yet...
+					u.values = make([]interface{}, cfg.TargetKeyCols.Len()+u.otherCols.Len())
+					u.types = make([]stream.FieldType, len(u.values))
+				}
+				// Save values from all fields into a list of values.
+				listIdx = 0                                                                               // reset the list index to get mapValuesToList() to overwrite the list.
+				rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetKeyCols, &u.values, &u.types, &listIdx) // UPDATE requires key cols before the other cols.
+				rec.GetDataAndFieldTypesByKeys(cfg.log, u.otherCols, &u.values, &u.types, &listIdx)
+				cfg.log.Debug(cfg.name, " - values for UPDATE: ", u.values)
+				txtBatchIsFull, err := batch.AddValuesToBatch(u.values)
+				if err != nil {
+					return err
+				}
+				u.needExec = true
+				numRowsInTx++
+				if txtBatchIsFull {
+					u.needNewBatch = true
+					u.needExec = false // set this false so that we can test if a final exec is required.
+					cfg.log.Debug(cfg.name, " - exec for UPDATE.")
+					return fnExec(batch)
+				}
+			} else if flagValue == c.MergeDiffValueDeleted { // if we need to perform a DELETE...
+				if needNewBatchDelete {
+					cfg.log.Debug(cfg.name, " - new DELETE batch required.")
+					sqlDeleteGenerator.InitBatch(txtBatchNumRows)
+					needNewBatchDelete = false
+				}
+				// Save values from primary key fields into a list.
+				listIdx = 0                                                                                   // reset the list index to get mapValuesToList() to overwrite the list.
+				rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetKeyCols, &valuesForD, &typesForD, &listIdx) // DELETE requires only the primary key cols.
+				cfg.log.Debug(cfg.name, " - values for DELETE: ", valuesForD)
+				txtBatchIsFull, err := sqlDeleteGenerator.AddValuesToBatch(valuesForD)
+				if err != nil {
+					return err
+				}
+				needExecDelete = true
+				numRowsInTx++
+				if txtBatchIsFull {
+					needNewBatchDelete = true
+					needExecDelete = false // set this false so that we can test if a final exec is required.
+					cfg.log.Debug(cfg.name, " - exec for DELETE.")
+					return fnExec(sqlDeleteGenerator)
+				}
+			}
+			return nil
+		}
+		// Handle a failed batch.
+		// Unless the RowErrorHandler fails on error, roll back and apply the records one at a time so the bad ones
+		// can be rejected.
+		fnRecover := func(errBatch error) {
+			err2 := tx.Rollback()
+			if cfg.rowErrorHandler.failsOnError() {
+				cfg.log.Panic(cfg.name, " - ", errBatch, err2)
+			}
+			cfg.log.Warn(cfg.name, " re-applying ", len(txRecs), " records one at a time after error: ", errBatch)
+			recs := txRecs
+			txRecs = make([]stream.Record, 0, len(recs))
+			fnReset()
+			// Exec each record on its own.
+			txtBatchNumRows = 1
+			for _, rec := range recs { // for each record in the failed transaction...
+				fnStartNewTx()
+				txRecs = append(txRecs, rec)
+				err := fnAdd(rec)
+				if err == nil {
+					err = fnCommitPending()
+				}
+				if err != nil { // if the record can't be applied...
+					_ = tx.Rollback()
+					fnReset()
+					cfg.rowErrorHandler.Reject(rec, err)
+				}
+			}
+			txtBatchNumRows = cfg.txtBatchNumRows
+		}
+		// Send pending records.
+		fnSendPending := func() bool {
+			for _, rec := range pending {
+				if recSendOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSendOK {
+					return false
+				}
+			}
+			pending = pending[:0]
+			return true
+		}
+		// Read input channel, add records to batches and commit when the transaction is full.
 		for {
 			select {
 			case rec, ok := <-cfg.inputChan: // for each row of input...
@@ -363,105 +665,46 @@ func startTableSyncSqlTextBatch(cfg *tableSyncCfg) (outputChan chan stream.Recor
 					cfg.log.Debug("Disabling inputChan")
 					cfg.inputChan = nil // disable this case (receive on a nil chan blocks forever; select won't choose a blocking operation).
 				} else {
+					if err := checkTableSyncRecord(rec, cfg); err != nil { // if the record can't be applied...
+						cfg.rowErrorHandler.Reject(rec, err)
+						break // skip to the next record.
+					}
 					atomic.AddInt64(&rowCount, 1) // increment the row count bearing in mind someone else is reporting on its values.
-					cfg.log.Debug(cfg.name, " - processing: flagField name = ", cfg.flagKeyName, "; value = ", rec.GetData(cfg.flagKeyName))
-					flagValue := rec.GetData(cfg.flagKeyName).(string)
-					if needNewTx { // if we have not started a transaction...
-						tx, err = cfg.outputDb.Begin() // new transaction
-						if err != nil {
-							log.Panic(cfg.name, " - unable to start new transaction!")
-						}
-						needNewTx = false
+					if needNewTx {                // if we have not started a transaction...
+						fnStartNewTx()
 					}
-					if flagValue == c.MergeDiffValueNew { // if we should INSERT the current record...
-						if needNewBatchInsert { // if we need to start a new batch...
-							cfg.log.Debug(cfg.name, " - new INSERT batch required.")
-							sqlInsertGenerator.InitBatch(cfg.txtBatchNumRows)
-							needNewBatchInsert = false
-						}
-						// TODO: make consistent the method for adding values to a batch - currently they must be in different orders for INSERT/UPDATE/DELETE and this won't work generically for SQL required by other database types.
-						// Save values from all fields into a list of values.
-						listIdx = 0                                                                                     // reset the list index to get mapValuesToList() to overwrite the list.
-						rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetKeyCols, &valuesForIU, &typesForIU, &listIdx) // ordering of cols is not important for INSERT.
-						rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetOtherCols, &valuesForIU, &typesForIU, &listIdx)
-						cfg.log.Debug(cfg.name, " - values for INSERT: ", valuesForIU)
-						txtBatchIsFull, err := sqlInsertGenerator.AddValuesToBatch(valuesForIU)
-						if err != nil {
-							cfg.log.Panic(err)
-						}
-						needExecInsert = true
-						if txtBatchIsFull { // if the the batch is full...
-							// Get the SQL and bind values from the generator.
-							mustExecSqlTransaction(cfg.log, tx, sqlInsertGenerator.GetStatement(), sqlInsertGenerator.GetValues()...)
-							// tx = nil                 // set this to nil so the final Exec() and Commit() can be called after this loop.
-							needNewBatchInsert = true // request new batch on next iteration.
-							needExecInsert = false    // set this false so that we can test if a final exec is required.
-							cfg.log.Debug(cfg.name, " - exec + commit for INSERT.")
-						}
-						numRowsInTx++
-					} else if flagValue == c.MergeDiffValueChanged { // if we need to perform an UPDATE...
-						if needNewBatchUpdate {
-							cfg.log.Debug(cfg.name, " - new UPDATE batch required.")
-							sqlUpdateGenerator.InitBatch(cfg.txtBatchNumRows)
-							needNewBatchUpdate = false
-						}
-						// Save values from all fields into a list of values.
-						listIdx = 0                                                                                     // reset the list index to get mapValuesToList() to overwrite the list.
-						rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetKeyCols, &valuesForIU, &typesForIU, &listIdx) // UPDATE requires key cols before the other cols.
-						rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetOtherCols, &valuesForIU, &typesForIU, &listIdx)
-						cfg.log.Debug(cfg.name, " - values for UPDATE: ", valuesForIU)
-						txtBatchIsFull, err := sqlUpdateGenerator.AddValuesToBatch(valuesForIU)
-						if err != nil {
-							cfg.log.Panic(err)
-						}
-						needExecUpdate = true
-						if txtBatchIsFull {
-							mustExecSqlTransaction(cfg.log, tx, sqlUpdateGenerator.GetStatement(), sqlUpdateGenerator.GetValues()...)
-							needNewBatchUpdate = true
-							needExecUpdate = false // set this false so that we can test if a final exec is required.
-							cfg.log.Debug(cfg.name, " - exec + commit for UPDATE.")
-						}
-						numRowsInTx++
-					} else if flagValue == c.MergeDiffValueDeleted { // if we need to perform a DELETE...
-						if needNewBatchDelete {
-							cfg.log.Debug(cfg.name, " - new DELETE batch required.")
-							sqlDeleteGenerator.InitBatch(cfg.txtBatchNumRows)
-							needNewBatchDelete = false
-						}
-						// Save values from primary key fields into a list.
-						listIdx = 0                                                                                   // reset the list index to get mapValuesToList() to overwrite the list.
-						rec.GetDataAndFieldTypesByKeys(cfg.log, cfg.TargetKeyCols, &valuesForD, &typesForD, &listIdx) // DELETE requires only the primary key cols.
-						cfg.log.Debug(cfg.name, " - values for DELETE: ", valuesForD)
-						txtBatchIsFull, err := sqlDeleteGenerator.AddValuesToBatch(valuesForD)
-						if err != nil {
-							cfg.log.Panic(err)
-						}
-						needExecDelete = true
-						if txtBatchIsFull {
-							mustExecSqlTransaction(cfg.log, tx, sqlDeleteGenerator.GetStatement(), sqlDeleteGenerator.GetValues()...)
-							needNewBatchDelete = true
-							needExecDelete = false // set this false so that we can test if a final exec is required.
-							cfg.log.Debug(cfg.name, " - exec + commit for DELETE.")
-						}
-						numRowsInTx++
+					txRecs = append(txRecs, rec)
+					err := fnAdd(rec)
+					if err == nil && numRowsInTx > 0 && numRowsInTx >= cfg.commitBatchSize { // if the transaction is full...
+						err = fnCommitPending()
 					}
-					if numRowsInTx > 0 && numRowsInTx >= cfg.commitBatchSize {
-						mustCommitSqlTransaction(cfg.log, tx, &commitSequence)
-						needNewTx = true
-						numRowsInTx = 0
-						needExecInsert = false // set this false so that we can test if a final exec is required.
-						needExecUpdate = false // set this false so that we can test if a final exec is required.
-						needExecDelete = false // set this false so that we can test if a final exec is required.
+					if err != nil {
+						fnRecover(err)
 					}
 					// Output the row.
-					// TODO: decide if we should implement buffering in TableSync and only send output rows after commit.
-					rec.SetData(cfg.commitSequenceKeyName, commitSequence)
-					if recSendOK := safeSend(rec, outputChan, controlChan, sendNilControlResponse); !recSendOK {
+					if !bufferOutput { // if we should output records as they are processed...
+						rec.SetData(cfg.commitSequenceKeyName, commitSequence)
+						pending = append(pending, rec)
+					}
+					if !fnSendPending() {
 						cfg.Log.Info(cfg.name, " shutdown")
 						return
 					}
 				}
 			case controlAction := <-controlChan:
+				controlAction = HandlePauseAction(controlAction, controlChan, func() error {
+					if err := fnCommitPending(); err != nil { // commit so we don't hold locks on the target while paused.
+						fnRecover(err)
+					}
+					return nil
+				})
+				if controlAction.Action == Resume {
+					if !fnSendPending() {
+						cfg.Log.Info(cfg.name, " shutdown")
+						return
+					}
+					continue // carry on processing input.
+				}
 				controlAction.ResponseChan <- nil // send a nil error.
 				cfg.log.Info(cfg.name, " shutdown")
 				return
@@ -471,19 +714,15 @@ func startTableSyncSqlTextBatch(cfg *tableSyncCfg) (outputChan chan stream.Recor
 			}
 		}
 		// Commit pending transactions.
-		if numRowsInTx > 0 {
-			if needExecDelete {
-				mustExecSqlTransaction(cfg.log, tx, sqlDeleteGenerator.GetStatement(), sqlDeleteGenerator.GetValues()...)
-			}
-			if needExecUpdate {
-				mustExecSqlTransaction(cfg.log, tx, sqlUpdateGenerator.GetStatement(), sqlUpdateGenerator.GetValues()...)
-			}
-			if needExecInsert {
-				mustExecSqlTransaction(cfg.log, tx, sqlInsertGenerator.GetStatement(), sqlInsertGenerator.GetValues()...)
-			}
-			mustCommitSqlTransaction(cfg.log, tx, &commitSequence)
-			cfg.log.Debug(cfg.name, " - final exec + commit complete")
+		if err := fnCommitPending(); err != nil {
+			fnRecover(err)
 		}
+		cfg.log.Debug(cfg.name, " - final exec + commit complete")
+		if !fnSendPending() {
+			cfg.Log.Info(cfg.name, " shutdown")
+			return
+		}
+		cfg.rowErrorHandler.Close()
 		close(outputChan) // we're done so close the channel we created.
 		cfg.log.Info(cfg.name, " complete")
 	}()
@@ -494,6 +733,120 @@ func startTableSyncSqlTextBatch(cfg *tableSyncCfg) (outputChan chan stream.Recor
 // -- LOCAL HELPERS
 // ---------------------------------------------------------------------------------------------------------------------
 
+// tableSyncUpdate holds the state of UPDATEs that set the same columns.
+// Array bind syncs use stmt, prepared and cols while text batch syncs use values, types, needNewBatch and needExec.
+type tableSyncUpdate struct {
+	otherCols    *om.OrderedMap // the columns to SET.
+	generator    shared.SqlStmtGenerator
+	stmt         shared.StatementBatch
+	prepared     bool
+	cols         [][]interface{} // one slice of values per column.
+	values       []interface{}   // values for one record.
+	types        []stream.FieldType
+	needNewBatch bool
+	needExec     bool
+}
+
+// getUpdate returns the UPDATE to use for CHANGED record rec, creating it if required.
+// All of TargetOtherCols are updated unless changedFieldsKeyName is set and rec contains it, in which case only
+// the columns of the fields it lists are updated. Fields are matched to the values of TargetOtherCols, which are
+// the record field names read by GetDataToColArray. Nil is returned if none of the fields listed are TargetOtherCols.
+func (cfg *tableSyncCfg) getUpdate(rec stream.Record) *tableSyncUpdate {
+	key := ""
+	otherCols := cfg.TargetOtherCols
+	if v, ok := rec.GetDataMap()[cfg.changedFieldsKeyName]; ok && cfg.changedFieldsKeyName != "" { // if we should only update the changed fields...
+		changed := make(map[string]bool)
+		for _, f := range getChangedFields(v) {
+			changed[f] = true
+		}
+		otherCols = om.NewOrderedMap()
+		fields := make([]string, 0, len(changed))
+		iter := cfg.TargetOtherCols.IterFunc()
+		for kv, ok := iter(); ok; kv, ok = iter() { // for each column that can be updated...
+			if changed[kv.Value.(string)] { // if the record field has changed...
+				otherCols.Set(kv.Key, kv.Value)
+				fields = append(fields, kv.Value.(string))
+			}
+		}
+		if len(fields) == 0 { // if there is nothing to update...
+			return nil
+		}
+		key = "\x00" + strings.Join(fields, "\x00") // prefix the key so it can't clash with the default UPDATE.
+	}
+	if u, ok := cfg.updates[key]; ok {
+		return u
+	}
+	u := &tableSyncUpdate{otherCols: otherCols, needNewBatch: true}
+	if key == "" { // if we should update all columns...
+		u.generator = cfg.sqlUpdateGenerator
+	} else {
+		sqlCfg := cfg.SqlStatementGeneratorConfig
+		sqlCfg.TargetOtherCols = otherCols
+		u.generator = cfg.dml.NewUpdateGenerator(&sqlCfg)
+	}
+	if cfg.updates == nil {
+		cfg.updates = make(map[string]*tableSyncUpdate)
+	}
+	cfg.updates[key] = u
+	cfg.updateKeys = append(cfg.updateKeys, key)
+	return u
+}
+
+// getChangedFields returns the field names in v, which may be a slice of strings, like the output of MergeDiff,
+// or a comma-separated string.
+func getChangedFields(v interface{}) []string {
+	switch x := v.(type) {
+	case []string:
+		return x
+	case []interface{}:
+		fields := make([]string, 0, len(x))
+		for _, f := range x {
+			fields = append(fields, fmt.Sprint(f))
+		}
+		return fields
+	case string:
+		if x == "" {
+			return nil
+		}
+		fields := strings.Split(x, ",")
+		for idx := range fields {
+			fields[idx] = strings.TrimSpace(fields[idx])
+		}
+		return fields
+	default:
+		return nil
+	}
+}
+
+// checkTableSyncRecord returns an error if rec is missing the flag field or any of the fields required to apply it.
+func checkTableSyncRecord(rec stream.Record, cfg *tableSyncCfg) error {
+	data := rec.GetDataMap()
+	v, ok := data[cfg.flagKeyName]
+	if !ok {
+		return fmt.Errorf("missing flag field %q", cfg.flagKeyName)
+	}
+	flag, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("flag field %q contains %v of type %T; expected a string", cfg.flagKeyName, v, v)
+	}
+	maps := []*om.OrderedMap{cfg.TargetKeyCols}
+	if flag == c.MergeDiffValueNew || flag == c.MergeDiffValueChanged { // if the other columns are required...
+		maps = append(maps, cfg.TargetOtherCols)
+	}
+	for _, m := range maps {
+		if m == nil {
+			continue
+		}
+		iter := m.IterFunc()
+		for kv, ok := iter(); ok; kv, ok = iter() {
+			if _, ok := data[kv.Value.(string)]; !ok {
+				return fmt.Errorf("missing field %q", kv.Value)
+			}
+		}
+	}
+	return nil
+}
+
 func mustExecSqlTransaction(log logger.Logger, tx shared.Transacter, sqltext string, values ...interface{}) {
 	log.Debug("Exec trying...")
 	_, err := tx.Exec(sqltext, values...)
@@ -1,7 +1,9 @@
 package components
 
 import (
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -351,3 +353,177 @@ func TestTableSyncTextBatch(t *testing.T) {
 		t.Fatalf("unexpected result list length: expected %v; got %v", 12, len(resultList))
 	}
 }
+
+func TestTableSyncSkipsBadRecords(t *testing.T) {
+	log := logrus.New()
+	db, resultChan := shared.NewMockConnectionWithMockTx(log, "oracleSlow")
+	omKeys := ordered_map.NewOrderedMap()
+	omKeys.Set("key1", "key1")
+	omCols := ordered_map.NewOrderedMap()
+	omCols.Set("col1", "col1")
+	inputChan := make(chan stream.Record, c.ChanSize)
+	good := stream.NewRecord()
+	good.SetData("key1", "1")
+	good.SetData("col1", "val1")
+	good.SetData("flagField", c.MergeDiffValueNew)
+	noFlag := stream.NewRecord()
+	noFlag.SetData("key1", "2")
+	noFlag.SetData("col1", "val2")
+	noCol := stream.NewRecord()
+	noCol.SetData("key1", "3")
+	noCol.SetData("flagField", c.MergeDiffValueChanged)
+	inputChan <- noFlag
+	inputChan <- good
+	inputChan <- noCol
+	close(inputChan)
+	h, _ := NewRowErrorHandler(log, "Test TableSync", OnErrorSkip, 0)
+	cfg := &TableSyncConfig{
+		Log:             log,
+		Name:            "Test TableSync",
+		InputChan:       inputChan,
+		OutputDb:        db,
+		CommitBatchSize: 1000,
+		FlagKeyName:     "flagField",
+		RowErrorHandler: h,
+		SqlStatementGeneratorConfig: shared.SqlStatementGeneratorConfig{
+			Log:             log,
+			SchemaSeparator: ".",
+			OutputTable:     "t2",
+			TargetKeyCols:   omKeys,
+			TargetOtherCols: omCols}}
+	chanOutput, _ := NewTableSync(cfg)
+	count := 0
+	for range chanOutput {
+		count++
+	}
+	db.Close()
+	resultList := make([]string, 0)
+	for str := range resultChan {
+		resultList = append(resultList, str)
+	}
+	if count != 1 || h.ErrorCount() != 2 {
+		t.Fatal("expected 1 record output and 2 rejected; got ", count, " and ", h.ErrorCount())
+	}
+	assertStr(t, log, "1 val1", resultList[len(resultList)-1])
+}
+
+func TestTableSyncChangedFields(t *testing.T) {
+	log := logrus.New()
+	omKeys := ordered_map.NewOrderedMap()
+	omKeys.Set("key1", "key1")
+	omIdentity := ordered_map.NewOrderedMap()
+	omIdentity.Set("col1", "col1")
+	omIdentity.Set("col2", "col2")
+	omRenamed := ordered_map.NewOrderedMap() // keys are the source field names, values are the record fields.
+	omRenamed.Set("src1", "col1")
+	omRenamed.Set("src2", "col2")
+	for name, omCols := range map[string]*ordered_map.OrderedMap{"identity": omIdentity, "renamed": omRenamed} {
+		db, resultChan := shared.NewMockConnectionWithMockTx(log, "oracleSlow")
+		inputChan := make(chan stream.Record, c.ChanSize)
+		for idx, changed := range []interface{}{[]string{"col2"}, "col2", []string{"other"}, nil} {
+			rec := stream.NewRecord()
+			rec.SetData("key1", idx)
+			rec.SetData("col1", "a")
+			rec.SetData("col2", "b")
+			rec.SetData("flagField", c.MergeDiffValueChanged)
+			if changed != nil { // if the record should list the fields that changed...
+				rec.SetData("#changed", changed)
+			}
+			inputChan <- rec
+		}
+		close(inputChan)
+		cfg := &TableSyncConfig{
+			Log:               log,
+			Name:              "Test TableSync",
+			InputChan:         inputChan,
+			OutputDb:          db,
+			CommitBatchSize:   1000,
+			FlagKeyName:       "flagField",
+			ChangedFieldsName: "#changed",
+			SqlStatementGeneratorConfig: shared.SqlStatementGeneratorConfig{
+				Log:             log,
+				SchemaSeparator: ".",
+				OutputTable:     "t2",
+				TargetKeyCols:   omKeys,
+				TargetOtherCols: omCols}}
+		chanOutput, _ := NewTableSync(cfg)
+		count := 0
+		for range chanOutput {
+			count++
+		}
+		db.Close()
+		resultList := make([]string, 0)
+		for str := range resultChan {
+			resultList = append(resultList, str)
+		}
+		if count != 4 || len(resultList) != 4 {
+			t.Fatal(name, ": expected 4 records output and 2 UPDATE statements with values; got ", count, " and ", resultList)
+		}
+		// Records 0 and 1 only update col2, record 2 has nothing to update and record 3 updates all columns.
+		assertStr(t, log, "update t2 tgt set tgt.col2 = src.col2 from ( select :1 as key1,:2 as col2 union all select :3,:4 ) src where src.key1 = tgt.key1", strings.Join(strings.Fields(resultList[0]), " "))
+		assertStr(t, log, "0 b 1 b", resultList[1])
+		assertStr(t, log, "update t2 tgt set tgt.col1 = src.col1,tgt.col2 = src.col2 from ( select :1 as key1,:2 as col1,:3 as col2 ) src where src.key1 = tgt.key1", resultList[2])
+		assertStr(t, log, "3 a b", resultList[3])
+	}
+}
+
+func TestTableSyncRejectsRecordsFailedByDatabase(t *testing.T) {
+	log := logrus.New()
+	for _, dbType := range []string{"oracle", "oracleSlow"} { // for array binds and text batches...
+		conn, resultChan := shared.NewMockConnectionWithMockTx(log, dbType)
+		db := conn.(*shared.MockConnectionWithMockTx)
+		db.ExecErrorValue = "bad" // fail any batch that contains this value.
+		go func() {
+			for range resultChan { // discard the SQL.
+			}
+		}()
+		omKeys := ordered_map.NewOrderedMap()
+		omKeys.Set("key1", "key1")
+		omCols := ordered_map.NewOrderedMap()
+		omCols.Set("col1", "col1")
+		inputChan := make(chan stream.Record, c.ChanSize)
+		for idx, v := range []string{"a", "b", "bad", "d"} {
+			rec := stream.NewRecord()
+			rec.SetData("key1", idx+1)
+			rec.SetData("col1", v)
+			rec.SetData("flagField", c.MergeDiffValueNew)
+			inputChan <- rec
+		}
+		close(inputChan)
+		h, _ := NewRowErrorHandler(log, "Test TableSync", OnErrorDeadLetter, 0)
+		cfg := &TableSyncConfig{
+			Log:             log,
+			Name:            "Test TableSync",
+			InputChan:       inputChan,
+			OutputDb:        db,
+			CommitBatchSize: 1000,
+			TxtBatchNumRows: 2,
+			FlagKeyName:     "flagField",
+			RowErrorHandler: h,
+			SqlStatementGeneratorConfig: shared.SqlStatementGeneratorConfig{
+				Log:             log,
+				SchemaSeparator: ".",
+				OutputTable:     "t2",
+				TargetKeyCols:   omKeys,
+				TargetOtherCols: omCols}}
+		chanOutput, _ := NewTableSync(cfg)
+		got := make([]interface{}, 0)
+		for rec := range chanOutput {
+			got = append(got, rec.GetData("key1"))
+		}
+		db.Close()
+		if expected := []interface{}{1, 2, 4}; !reflect.DeepEqual(got, expected) {
+			t.Fatalf("%v: expected records %v to be output; got %v", dbType, expected, got)
+		}
+		rejected := make([]interface{}, 0)
+		for rec := range h.DeadLetterChan() {
+			rejected = append(rejected, rec.GetData("key1"))
+			if rec.GetData(Defaults.ChanField4ErrorStep) != "Test TableSync" || rec.GetData(Defaults.ChanField4ErrorText) == nil {
+				t.Fatalf("%v: expected error fields on the rejected record; got %v", dbType, rec.GetDataMap())
+			}
+		}
+		if expected := []interface{}{3}; !reflect.DeepEqual(rejected, expected) {
+			t.Fatalf("%v: expected records %v to be rejected; got %v", dbType, expected, rejected)
+		}
+	}
+}
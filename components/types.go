@@ -1,5 +1,7 @@
 package components
 
+import "fmt"
+
 type PanicHandlerFunc func()
 
 type Action uint32
@@ -10,6 +12,19 @@ const (
 	Resume
 )
 
+func (a Action) String() string {
+	switch a {
+	case Shutdown:
+		return "shutdown"
+	case Pause:
+		return "pause"
+	case Resume:
+		return "resume"
+	default:
+		return fmt.Sprintf("action %d", uint32(a))
+	}
+}
+
 // ControlAction is used to communicate with components.
 type ControlAction struct {
 	Action       Action
@@ -42,6 +42,7 @@ const (
 	ConnectionTypeOdbc                    = "odbc" // this is not a real connection type, since we need a suffix to provide the driver name like sqlserver.
 	ConnectionTypeOdbcSqlServer           = "odbc+sqlserver"
 	ConnectionTypeSqlServer               = "sqlserver"
+	ConnectionTypePostgres                = "postgres"
 	ConnectionTypeS3                      = "s3"
 )
 
@@ -0,0 +1,388 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/types"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Parquet column types supported by ParquetFileOutput.
+const (
+	ParquetTypeString    = "string"    // BYTE_ARRAY (UTF8)
+	ParquetTypeInt64     = "int64"     // INT64
+	ParquetTypeDouble    = "double"    // DOUBLE
+	ParquetTypeBoolean   = "boolean"   // BOOLEAN
+	ParquetTypeTimestamp = "timestamp" // INT64 (TIMESTAMP_MILLIS)
+	ParquetTypeDecimal   = "decimal"   // FIXED_LEN_BYTE_ARRAY (DECIMAL), see ParquetDecimalType.
+)
+
+// ParquetMaxDecimalPrecision is the largest precision supported by ParquetTypeDecimal columns.
+const ParquetMaxDecimalPrecision = 38
+
+var parquetTypeMetadata = map[string]string{
+	ParquetTypeString:    "type=BYTE_ARRAY, convertedtype=UTF8",
+	ParquetTypeInt64:     "type=INT64",
+	ParquetTypeDouble:    "type=DOUBLE",
+	ParquetTypeBoolean:   "type=BOOLEAN",
+	ParquetTypeTimestamp: "type=INT64, convertedtype=TIMESTAMP_MILLIS",
+}
+
+// ParquetColumn describes a single nullable column in a Parquet file.
+type ParquetColumn struct {
+	Name      string
+	Type      string // one of the ParquetType* constants or a type returned by ParquetDecimalType.
+	precision int    // the precision of DECIMAL columns.
+	scale     int    // the scale of DECIMAL columns.
+}
+
+// ParquetDecimalType returns the type of a DECIMAL column with the given precision and scale for use in a
+// ParquetColumn. The type is of the form decimal_<precision>_<scale> so it can be used in CSV field type tokens.
+func ParquetDecimalType(precision int, scale int) string {
+	return fmt.Sprintf("%v_%v_%v", ParquetTypeDecimal, precision, scale)
+}
+
+// parseParquetDecimalType returns the precision and scale of a type returned by ParquetDecimalType.
+// ok is false if typ is not a DECIMAL type.
+func parseParquetDecimalType(typ string) (precision int, scale int, ok bool, err error) {
+	if !strings.HasPrefix(typ, ParquetTypeDecimal+"_") {
+		return 0, 0, false, nil
+	}
+	if _, err = fmt.Sscanf(typ, ParquetTypeDecimal+"_%d_%d", &precision, &scale); err != nil {
+		return 0, 0, true, fmt.Errorf("unable to parse Parquet DECIMAL type %q: %w", typ, err)
+	}
+	if precision < 1 || precision > ParquetMaxDecimalPrecision || scale < 0 || scale > precision {
+		return 0, 0, true, fmt.Errorf("unsupported precision and scale for Parquet DECIMAL type %q", typ)
+	}
+	return precision, scale, true, nil
+}
+
+// getParquetDecimalLength returns the number of bytes required to store a two's complement integer with
+// precision digits.
+func getParquetDecimalLength(precision int) int {
+	return int(math.Ceil((float64(precision)*math.Log2(10) + 1) / 8))
+}
+
+// ParquetFileOutput writes rows to a Parquet file that rotates.
+type ParquetFileOutput struct {
+	log               logger.Logger
+	directory         string // set to empty string if you want to use OS temp space with system generated directory
+	prefix            string
+	extension         string
+	columns           []ParquetColumn
+	metadata          []string
+	compression       parquet.CompressionCodec
+	currentSuffixID   int
+	currentName       string
+	file              *os.File
+	fileWriter        *countingWriter
+	pqWriter          *writer.CSVWriter
+	maxFileRows       int
+	currentRowCount   int
+	totalRowCount     int
+	maxFileBytes      int
+	needNewFile       bool
+	ListOfOutputFiles []string
+}
+
+// NewParquetFileOutput creates a new Parquet file struct. Supply a valid directory or empty string to use default ioutil.TempDir().
+// Set maxFileRows to the number of rows you want in the Parquet file or 0 to only generate one file.
+// Set maxFileBytes to the approx number of bytes you want in the Parquet file - only checked per row written.
+// Since Parquet buffers a row group in memory before writing it, the byte count includes the estimated
+// size of buffered rows, which are not yet compressed.
+// Setting useGzip will use GZIP compression instead of the default SNAPPY.
+// An error is returned if any column has an unsupported type.
+func NewParquetFileOutput(log logger.Logger, outputDirectory string, fileNamePrefix string, fileNameExtension string, maxFileRows int, maxFileBytes int, useGzip bool, columns []ParquetColumn) (*ParquetFileOutput, error) {
+	f := &ParquetFileOutput{}
+	f.log = log
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns supplied for the Parquet file schema")
+	}
+	// Build the schema.
+	for _, col := range columns { // for each column...
+		c := ParquetColumn{Name: col.Name, Type: strings.ToLower(col.Type)}
+		md, ok := parquetTypeMetadata[c.Type]
+		if !ok { // if the type may be a DECIMAL...
+			var err error
+			if c.precision, c.scale, ok, err = parseParquetDecimalType(c.Type); err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("unsupported Parquet type %q for column %q", col.Type, col.Name)
+			}
+			md = fmt.Sprintf("type=FIXED_LEN_BYTE_ARRAY, convertedtype=DECIMAL, precision=%v, scale=%v, length=%v",
+				c.precision, c.scale, getParquetDecimalLength(c.precision))
+		}
+		f.metadata = append(f.metadata, fmt.Sprintf("name=%v, %v, repetitiontype=OPTIONAL", col.Name, md))
+		f.columns = append(f.columns, c)
+	}
+	// Create output directory using temp space if needed.
+	if outputDirectory == "" {
+		var err error
+		f.directory, err = ioutil.TempDir("", "parquet-output-")
+		if err != nil {
+			return nil, fmt.Errorf("error creating temp directory for Parquet files: %v", err)
+		}
+	} else {
+		f.directory = outputDirectory
+	}
+	// Save variables from input.
+	f.prefix = fileNamePrefix
+	f.extension = strings.TrimLeft(fileNameExtension, ".")
+	if f.extension == "" {
+		f.extension = "parquet"
+	}
+	f.maxFileRows = maxFileRows
+	f.maxFileBytes = maxFileBytes
+	f.compression = parquet.CompressionCodec_SNAPPY
+	if useGzip {
+		f.compression = parquet.CompressionCodec_GZIP
+	}
+	f.needNewFile = true
+	log.Debug("ParquetFileOutput file prefix=", f.prefix, "; extension=", f.extension, "; maxFileRows=", f.maxFileRows, "; maxFileBytes=", f.maxFileBytes, "; compression=", f.compression)
+	return f, nil
+}
+
+// MustWrite converts each of values to the type of the column in the same position and writes them as a row
+// to the Parquet file.
+// Return fileName if a new file is created else empty string "".
+func (f *ParquetFileOutput) MustWrite(values []interface{}) (fileName string) {
+	if len(values) != len(f.columns) {
+		f.log.Panic("Unable to write to Parquet file: expected ", len(f.columns), " values; got ", len(values))
+	}
+	row := make([]interface{}, len(values))
+	for idx, v := range values { // for each value...
+		var err error
+		if f.columns[idx].precision > 0 { // if the column is a DECIMAL...
+			row[idx], err = convertToParquetDecimal(f.columns[idx].precision, f.columns[idx].scale, v)
+		} else {
+			row[idx], err = convertToParquetType(f.columns[idx].Type, v)
+		}
+		if err != nil {
+			f.log.Panic("Unable to write to Parquet file: column ", f.columns[idx].Name, ": ", err)
+		}
+	}
+	if f.needNewFile {
+		f.closeFileAndReset()
+		f.createNewParquetWriter()
+		fileName = f.currentName
+	}
+	if err := f.pqWriter.Write(row); err != nil {
+		f.log.Panic("Unable to write to Parquet file: ", err)
+	}
+	// Count rows and signal that we need a new file if we are required to rotate the output file.
+	f.currentRowCount++
+	f.totalRowCount++
+	if rotateCheck(f.log, f.maxFileRows, f.currentRowCount) ||
+		rotateCheck(f.log, f.maxFileBytes, int(f.fileWriter.n+f.pqWriter.Size+f.pqWriter.ObjsSize)) { // if we need to rotate the output file...
+		f.needNewFile = true
+	}
+	return
+}
+
+// Cleanup can be deferred by the caller to write the Parquet footer and close the OS file.
+func (f *ParquetFileOutput) Cleanup() {
+	f.closeFileAndReset()
+}
+
+// closeFileAndReset will write the Parquet footer and close the OS file.
+// It will flag that a new file is required at next write time.
+func (f *ParquetFileOutput) closeFileAndReset() {
+	if f.pqWriter != nil {
+		if err := f.pqWriter.WriteStop(); err != nil {
+			f.log.Panic("unable to write Parquet footer to file: ", f.currentName, "; ", err)
+		}
+		f.pqWriter = nil
+	}
+	if f.file != nil {
+		if err := f.file.Close(); err != nil { // if the file didn't close OK...
+			f.log.Panic("unable to close OS file: ", f.currentName, "; ", err)
+		}
+		f.file = nil
+	}
+	f.needNewFile = true
+	f.currentRowCount = 0
+}
+
+func (f *ParquetFileOutput) createNewParquetWriter() {
+	// Get new file name.
+	f.currentSuffixID++
+	f.currentName = path.Join(f.directory, fmt.Sprintf("%v_%06d.%v", f.prefix, f.currentSuffixID, f.extension))
+	f.ListOfOutputFiles = append(f.ListOfOutputFiles, f.currentName)
+	f.log.Info("Creating new Parquet file '", f.currentName, "'")
+	// Create new OS file.
+	var err error
+	if f.file, err = os.Create(f.currentName); err != nil {
+		f.log.Panic("Unable to create OS file with name: ", f.currentName, "; ", err)
+	}
+	f.fileWriter = &countingWriter{w: f.file}
+	if f.pqWriter, err = writer.NewCSVWriterFromWriter(f.metadata, f.fileWriter, 1); err != nil {
+		f.log.Panic("Unable to create Parquet writer for file: ", f.currentName, "; ", err)
+	}
+	f.pqWriter.CompressionType = f.compression
+	f.needNewFile = false
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// convertToParquetType returns v as the Go type expected by the parquet writer for Parquet type typ.
+// Nil values are returned as nil, which are written as NULL.
+// Strings are parsed for numeric and boolean types so values fetched as text, like Oracle NUMBERs, are supported.
+func convertToParquetType(typ string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch typ {
+	case ParquetTypeString:
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case []byte:
+			return string(x), nil
+		case float32:
+			return strconv.FormatFloat(float64(x), 'f', -1, 32), nil
+		case float64:
+			return strconv.FormatFloat(x, 'f', -1, 64), nil
+		case time.Time:
+			return x.UTC().Format(time.RFC3339Nano), nil
+		}
+		return fmt.Sprintf("%v", v), nil
+	case ParquetTypeInt64:
+		switch x := v.(type) {
+		case int:
+			return int64(x), nil
+		case int8:
+			return int64(x), nil
+		case int16:
+			return int64(x), nil
+		case int32:
+			return int64(x), nil
+		case int64:
+			return x, nil
+		case uint8:
+			return int64(x), nil
+		case uint16:
+			return int64(x), nil
+		case uint32:
+			return int64(x), nil
+		case float32:
+			return int64(math.Round(float64(x))), nil
+		case float64:
+			return int64(math.Round(x)), nil
+		case string:
+			return strconv.ParseInt(strings.TrimSpace(x), 10, 64)
+		case []byte:
+			return strconv.ParseInt(strings.TrimSpace(string(x)), 10, 64)
+		}
+	case ParquetTypeDouble:
+		switch x := v.(type) {
+		case int:
+			return float64(x), nil
+		case int8:
+			return float64(x), nil
+		case int16:
+			return float64(x), nil
+		case int32:
+			return float64(x), nil
+		case int64:
+			return float64(x), nil
+		case uint8:
+			return float64(x), nil
+		case uint16:
+			return float64(x), nil
+		case uint32:
+			return float64(x), nil
+		case float32:
+			return float64(x), nil
+		case float64:
+			return x, nil
+		case string:
+			return strconv.ParseFloat(strings.TrimSpace(x), 64)
+		case []byte:
+			return strconv.ParseFloat(strings.TrimSpace(string(x)), 64)
+		}
+	case ParquetTypeBoolean:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case string:
+			return strconv.ParseBool(strings.TrimSpace(x))
+		case []byte:
+			return strconv.ParseBool(strings.TrimSpace(string(x)))
+		}
+	case ParquetTypeTimestamp:
+		switch x := v.(type) {
+		case time.Time:
+			return x.UnixNano() / int64(time.Millisecond), nil
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(x))
+			if err != nil {
+				return nil, err
+			}
+			return t.UnixNano() / int64(time.Millisecond), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Parquet type %q", typ)
+	}
+	return nil, fmt.Errorf("unable to convert value %v of type %T to Parquet type %q", v, v, typ)
+}
+
+// convertToParquetDecimal returns v as the unscaled value of a DECIMAL with the given precision and scale, encoded
+// as a big-endian two's complement integer ready for a FIXED_LEN_BYTE_ARRAY column.
+// Nil values are returned as nil, which are written as NULL.
+// An error is returned if v has more decimal places than scale or more digits than precision allows, so values
+// are never rounded silently.
+func convertToParquetDecimal(precision int, scale int, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var str string
+	switch x := v.(type) {
+	case string:
+		str = strings.TrimSpace(x)
+	case []byte:
+		str = strings.TrimSpace(string(x))
+	case float32:
+		str = strconv.FormatFloat(float64(x), 'f', -1, 32)
+	case float64:
+		str = strconv.FormatFloat(x, 'f', -1, 64)
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		str = fmt.Sprintf("%v", x)
+	default:
+		return nil, fmt.Errorf("unable to convert value %v of type %T to Parquet type %q", v, v, ParquetDecimalType(precision, scale))
+	}
+	r, ok := new(big.Rat).SetString(str)
+	if !ok {
+		return nil, fmt.Errorf("unable to convert value %q to Parquet type %q", str, ParquetDecimalType(precision, scale))
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("value %q has more than %v decimal places", str, scale)
+	}
+	unscaled := r.Num()
+	if len(new(big.Int).Abs(unscaled).String()) > precision {
+		return nil, fmt.Errorf("value %q has more than %v digits", str, precision)
+	}
+	return types.StrIntToBinary(unscaled.String(), "BigEndian", getParquetDecimalLength(precision), true), nil
+}
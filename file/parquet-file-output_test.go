@@ -0,0 +1,171 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestParquetFileOutput(t *testing.T) {
+	log := logger.NewLogger("parquet test", "info", true)
+	dir, err := ioutil.TempDir("", "parquet-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cols := []ParquetColumn{
+		{Name: "ID", Type: ParquetTypeInt64},
+		{Name: "NAME", Type: ParquetTypeString},
+		{Name: "AMOUNT", Type: ParquetTypeDouble},
+		{Name: "ACTIVE", Type: ParquetTypeBoolean},
+		{Name: "CREATED", Type: ParquetTypeTimestamp},
+	}
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := [][]interface{}{
+		{int64(1), "one", "1.5", true, created},
+		{"2", []byte("two"), float64(2.5), "false", created},
+		{3, nil, nil, nil, nil},
+	}
+
+	// Test 1 - rotate files by number of rows.
+	f, err := NewParquetFileOutput(log, dir, "test", "", 2, 0, false, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileNames := make([]string, 0)
+	for _, r := range rows {
+		if fileName := f.MustWrite(r); fileName != "" {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	f.Cleanup()
+	if !reflect.DeepEqual(fileNames, f.ListOfOutputFiles) || len(fileNames) != 2 {
+		t.Fatal("expected 2 files; got ", fileNames)
+	}
+	if fileNames[0] != dir+"/test_000001.parquet" {
+		t.Fatal("unexpected file name ", fileNames[0])
+	}
+	// Read back the first file.
+	b, err := ioutil.ReadFile(fileNames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf, err := buffer.NewBufferFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+	if pr.GetNumRows() != 2 {
+		t.Fatal("expected 2 rows in the first file; got ", pr.GetNumRows())
+	}
+	expected := [][]interface{}{
+		{int64(1), int64(2)},
+		{"one", "two"},
+		{1.5, 2.5},
+		{true, false},
+		{created.UnixNano() / int64(time.Millisecond), created.UnixNano() / int64(time.Millisecond)},
+	}
+	for idx := range cols {
+		values, _, _, err := pr.ReadColumnByIndex(int64(idx), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(values, expected[idx]) {
+			t.Fatalf("column %v: expected %v; got %v", cols[idx].Name, expected[idx], values)
+		}
+	}
+	// Read back the NULLs in the second file.
+	b, err = ioutil.ReadFile(fileNames[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf, err = buffer.NewBufferFile(b); err != nil {
+		t.Fatal(err)
+	}
+	pr2, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr2.ReadStop()
+	values, _, _, err := pr2.ReadColumnByIndex(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values[0] != nil {
+		t.Fatal("expected NULL name; got ", values)
+	}
+
+	// Test 2 - rotate files by number of bytes.
+	f, err = NewParquetFileOutput(log, dir, "bytes", "pq", 0, 1, true, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		f.MustWrite(r)
+	}
+	f.Cleanup()
+	if len(f.ListOfOutputFiles) != 3 || f.ListOfOutputFiles[0] != dir+"/bytes_000001.pq" {
+		t.Fatal("expected 3 files; got ", f.ListOfOutputFiles)
+	}
+
+	// Test 3 - bad types are rejected.
+	if _, err = NewParquetFileOutput(log, dir, "bad", "", 0, 0, false, []ParquetColumn{{Name: "X", Type: "decimal"}}); err == nil {
+		t.Fatal("expected error for unsupported type")
+	}
+	if _, err = convertToParquetType(ParquetTypeInt64, "abc"); err == nil {
+		t.Fatal("expected error converting bad int64")
+	}
+
+	// Test 4 - decimals keep their precision.
+	decCols := []ParquetColumn{{Name: "AMOUNT", Type: ParquetDecimalType(10, 2)}}
+	f, err = NewParquetFileOutput(log, dir, "decimal", "", 0, 0, false, decCols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{"123.45", float64(-1.5), nil, 7} {
+		f.MustWrite([]interface{}{v})
+	}
+	f.Cleanup()
+	if b, err = ioutil.ReadFile(f.ListOfOutputFiles[0]); err != nil {
+		t.Fatal(err)
+	}
+	if pf, err = buffer.NewBufferFile(b); err != nil {
+		t.Fatal(err)
+	}
+	pr3, err := reader.NewParquetColumnReader(pf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr3.ReadStop()
+	if values, _, _, err = pr3.ReadColumnByIndex(0, 4); err != nil {
+		t.Fatal(err)
+	}
+	expectedDecimals := []interface{}{ // big-endian two's complement of the unscaled values in 5 bytes.
+		string([]byte{0, 0, 0, 0x30, 0x39}),
+		string([]byte{0xff, 0xff, 0xff, 0xff, 0x6a}),
+		nil,
+		string([]byte{0, 0, 0, 0x02, 0xbc}),
+	}
+	if !reflect.DeepEqual(values, expectedDecimals) {
+		t.Fatalf("expected decimals %v; got %v", expectedDecimals, values)
+	}
+	if _, err = convertToParquetDecimal(10, 2, "1.234"); err == nil {
+		t.Fatal("expected error converting a decimal with too many decimal places")
+	}
+	if _, err = convertToParquetDecimal(4, 2, "123.4"); err == nil {
+		t.Fatal("expected error converting a decimal with too many digits")
+	}
+	if _, err = NewParquetFileOutput(log, dir, "bad", "", 0, 0, false, []ParquetColumn{{Name: "X", Type: "decimal_39_2"}}); err == nil {
+		t.Fatal("expected error for unsupported decimal precision")
+	}
+}
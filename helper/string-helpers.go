@@ -96,6 +96,9 @@ func CsvStringOfTokensToMap(log logger.Logger, s string) (map[string]string, err
 		for _, v := range line { // for each value component on the line...
 			// log.Debug("CsvStringOfTokensToMap() v: ", v)
 			tokens := strings.Split(v, ":") // split on colon and use left hand side as the key and 2nd element as the value.
+			if len(tokens) < 2 {
+				return nil, fmt.Errorf("missing colon in token %q", v)
+			}
 			m[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
 		}
 	}
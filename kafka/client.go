@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// NewProducer returns a Producer that writes to topic using the list of brokers (host:port).
+// Messages are assigned to partitions using a hash of their key.
+func NewProducer(brokers []string, topic string) (Producer, error) {
+	if len(brokers) == 0 || topic == "" {
+		return nil, fmt.Errorf("missing Kafka brokers or topic")
+	}
+	return &producer{w: &kafkago.Writer{
+		Addr:         kafkago.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: kafkago.RequireAll,
+	}}, nil
+}
+
+type producer struct {
+	w *kafkago.Writer
+}
+
+func (p *producer) Produce(ctx context.Context, msgs ...Message) error {
+	m := make([]kafkago.Message, len(msgs))
+	for idx := range msgs {
+		m[idx] = kafkago.Message{Key: msgs[idx].Key, Value: msgs[idx].Value}
+	}
+	return p.w.WriteMessages(ctx, m...)
+}
+
+func (p *producer) Close() error {
+	return p.w.Close()
+}
+
+// NewConsumer returns a Consumer that reads topic as a member of consumer group groupID using the list of
+// brokers (host:port).
+// Offsets are only committed by calls to Consumer.Commit().
+func NewConsumer(brokers []string, topic string, groupID string) (Consumer, error) {
+	if len(brokers) == 0 || topic == "" || groupID == "" {
+		return nil, fmt.Errorf("missing Kafka brokers, topic or consumer group")
+	}
+	cfg := kafkago.ReaderConfig{
+		Brokers: brokers,
+		Topic:   topic,
+		GroupID: groupID,
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &consumer{r: kafkago.NewReader(cfg)}, nil
+}
+
+type consumer struct {
+	r *kafkago.Reader
+}
+
+func (c *consumer) Fetch(ctx context.Context) (Message, error) {
+	m, err := c.r.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, Key: m.Key, Value: m.Value}, nil
+}
+
+func (c *consumer) Commit(ctx context.Context, msgs ...Message) error {
+	m := make([]kafkago.Message, len(msgs))
+	for idx := range msgs {
+		m[idx] = kafkago.Message{Topic: msgs[idx].Topic, Partition: msgs[idx].Partition, Offset: msgs[idx].Offset}
+	}
+	return c.r.CommitMessages(ctx, m...)
+}
+
+func (c *consumer) Close() error {
+	return c.r.Close()
+}
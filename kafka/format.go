@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// Message value formats supported by NewCodec.
+const (
+	FormatJson = "json"
+	FormatAvro = "avro"
+)
+
+// Codec converts between message values and fields with string values.
+// Nil values represent NULLs.
+type Codec interface {
+	// Encode returns the message value for the fields in values using the order of keys.
+	Encode(keys []string, values map[string]interface{}) ([]byte, error)
+	// Decode returns the fields found in a message value.
+	Decode(b []byte) (map[string]interface{}, error)
+}
+
+// NewCodec returns a Codec for format, which must be one of the Format* constants.
+// Avro requires the list of fields that make up the schema, where each field is a nullable string.
+// The Avro schema is a record named halfpipe.Record, so the field names must be valid Avro names.
+func NewCodec(format string, fields []string) (Codec, error) {
+	switch strings.ToLower(format) {
+	case FormatJson, "":
+		return &jsonCodec{}, nil
+	case FormatAvro:
+		return newAvroCodec(fields)
+	default:
+		return nil, fmt.Errorf("unsupported Kafka message format %q: use %v or %v", format, FormatJson, FormatAvro)
+	}
+}
+
+type jsonCodec struct{}
+
+// Encode returns a JSON object with fields in the order of keys.
+func (j *jsonCodec) Encode(keys []string, values map[string]interface{}) ([]byte, error) {
+	out := make([]string, len(keys))
+	for idx, k := range keys { // for each key...
+		v, err := json.Marshal(values[k])
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling the value of field %q to JSON: %w", k, err)
+		}
+		out[idx] = fmt.Sprintf("%q: %s", k, v)
+	}
+	return []byte(fmt.Sprintf("{%v}", strings.Join(out, ", "))), nil
+}
+
+// Decode returns the fields of a JSON object where values that are not strings or nil are converted to strings.
+func (j *jsonCodec) Decode(b []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	d := json.NewDecoder(strings.NewReader(string(b)))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON message: %w", err)
+	}
+	for k, v := range m {
+		switch x := v.(type) {
+		case nil, string:
+		case json.Number:
+			m[k] = x.String()
+		default:
+			m[k] = fmt.Sprintf("%v", x)
+		}
+	}
+	return m, nil
+}
+
+var rexpAvroName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type avroCodec struct {
+	codec  *goavro.Codec
+	fields []string
+}
+
+func newAvroCodec(fields []string) (*avroCodec, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing fields for the Avro schema")
+	}
+	schemaFields := make([]string, len(fields))
+	for idx, f := range fields {
+		if !rexpAvroName.MatchString(f) {
+			return nil, fmt.Errorf("field %q is not a valid Avro name", f)
+		}
+		schemaFields[idx] = fmt.Sprintf(`{"name": %q, "type": ["null", "string"], "default": null}`, f)
+	}
+	schema := fmt.Sprintf(`{"type": "record", "name": "Record", "namespace": "halfpipe", "fields": [%v]}`, strings.Join(schemaFields, ", "))
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Avro schema: %w", err)
+	}
+	return &avroCodec{codec: codec, fields: fields}, nil
+}
+
+// Encode returns Avro binary data for the fields in the schema.
+// Parameter keys is ignored since the order is fixed by the schema.
+func (a *avroCodec) Encode(keys []string, values map[string]interface{}) ([]byte, error) {
+	native := make(map[string]interface{}, len(a.fields))
+	for _, f := range a.fields {
+		v := values[f]
+		if v == nil {
+			native[f] = nil
+		} else {
+			native[f] = goavro.Union("string", fmt.Sprintf("%v", v))
+		}
+	}
+	return a.codec.BinaryFromNative(nil, native)
+}
+
+// Decode returns the fields in Avro binary data.
+func (a *avroCodec) Decode(b []byte) (map[string]interface{}, error) {
+	native, _, err := a.codec.NativeFromBinary(b)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding Avro message: %w", err)
+	}
+	m, ok := native.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Avro message type %T", native)
+	}
+	for k, v := range m {
+		if u, ok := v.(map[string]interface{}); ok { // if the value is a non-null union...
+			m[k] = u["string"]
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	values := map[string]interface{}{"A": "1", "B": nil, "C": "three"}
+	keys := []string{"C", "A", "B"}
+
+	// Test 1 - JSON preserves the order of keys.
+	j, err := NewCodec(FormatJson, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := j.Encode(keys, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"C": "three", "A": "1", "B": null}` {
+		t.Fatal("unexpected JSON ", string(b))
+	}
+	got, err := j.Decode([]byte(`{"A": 1, "B": null, "C": "three", "D": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{"A": "1", "B": nil, "C": "three", "D": "true"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatal("expected ", expected, "; got ", got)
+	}
+
+	// Test 2 - Avro round trip.
+	a, err := NewCodec(FormatAvro, []string{"A", "B", "C"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err = a.Encode(keys, values); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = a.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Fatal("expected ", values, "; got ", got)
+	}
+
+	// Test 3 - bad config.
+	if _, err = NewCodec(FormatAvro, nil); err == nil {
+		t.Fatal("expected error for Avro without fields")
+	}
+	if _, err = NewCodec(FormatAvro, []string{"#bad"}); err == nil {
+		t.Fatal("expected error for bad Avro field name")
+	}
+	if _, err = NewCodec("xml", nil); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Message is a single Kafka message.
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+}
+
+// Producer writes messages to a topic.
+type Producer interface {
+	// Produce writes msgs to the topic and returns once they have been acknowledged by the broker.
+	Produce(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// Consumer reads messages from a topic as a member of a consumer group.
+type Consumer interface {
+	// Fetch returns the next message and blocks until one is available or ctx is done.
+	Fetch(ctx context.Context) (Message, error)
+	// Commit saves the offsets of msgs for the consumer group so they are not read again.
+	Commit(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// Ack is a handle on a message fetched by a Consumer that can be passed downstream with the data it contains.
+// Commit it once the data has been processed successfully.
+type Ack struct {
+	consumer Consumer
+	msg      Message
+	once     sync.Once
+	onCommit func()
+}
+
+// NewAck returns an Ack for msg fetched by consumer.
+// Optional func onCommit is called once, after the first successful commit of the Ack.
+func NewAck(consumer Consumer, msg Message, onCommit func()) *Ack {
+	return &Ack{consumer: consumer, msg: msg, onCommit: onCommit}
+}
+
+// Message returns the message fetched by the consumer.
+func (a *Ack) Message() Message {
+	return a.msg
+}
+
+// CommitAcks commits the offsets of the messages in acks.
+// Messages are grouped by consumer so that each consumer commits its own messages in a single call.
+func CommitAcks(ctx context.Context, acks ...*Ack) error {
+	consumers := make([]Consumer, 0)
+	msgs := make(map[Consumer][]Message)
+	for _, a := range acks { // for each ack...
+		if _, ok := msgs[a.consumer]; !ok {
+			consumers = append(consumers, a.consumer)
+		}
+		msgs[a.consumer] = append(msgs[a.consumer], a.msg)
+	}
+	for _, c := range consumers { // for each consumer in the order found...
+		if err := c.Commit(ctx, msgs[c]...); err != nil {
+			return fmt.Errorf("error committing Kafka offsets: %w", err)
+		}
+	}
+	for _, a := range acks {
+		if a.onCommit != nil {
+			a.once.Do(a.onCommit)
+		}
+	}
+	return nil
+}
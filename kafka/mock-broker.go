@@ -0,0 +1,181 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// MockBroker is an in-process stand-in for a Kafka cluster that can be used to test producers and consumers.
+// Topics are created on first use with a fixed number of partitions and messages are assigned to a partition
+// using a hash of their key.
+// Consumers start from the offsets committed for their group, or the start of each partition, and read all
+// partitions, i.e. there is no rebalancing between members of a group.
+type MockBroker struct {
+	mu         sync.Mutex
+	partitions int
+	topics     map[string][][]Message
+	committed  map[string]map[string]map[int]int64 // group -> topic -> partition -> next offset
+	notify     chan struct{}                       // closed and replaced each time messages are produced.
+}
+
+// NewMockBroker returns a MockBroker whose topics have the given number of partitions.
+func NewMockBroker(partitions int) *MockBroker {
+	if partitions < 1 {
+		partitions = 1
+	}
+	return &MockBroker{
+		partitions: partitions,
+		topics:     make(map[string][][]Message),
+		committed:  make(map[string]map[string]map[int]int64),
+		notify:     make(chan struct{}),
+	}
+}
+
+// NewProducer returns a Producer that writes to topic.
+// It matches the signature of NewProducer() so it can be used in its place.
+func (b *MockBroker) NewProducer(brokers []string, topic string) (Producer, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("missing Kafka topic")
+	}
+	return &mockProducer{b: b, topic: topic}, nil
+}
+
+// NewConsumer returns a Consumer that reads topic as a member of consumer group groupID.
+// It matches the signature of NewConsumer() so it can be used in its place.
+func (b *MockBroker) NewConsumer(brokers []string, topic string, groupID string) (Consumer, error) {
+	if topic == "" || groupID == "" {
+		return nil, fmt.Errorf("missing Kafka topic or consumer group")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pos := make([]int64, b.partitions)
+	for p, offset := range b.committed[groupID][topic] {
+		pos[p] = offset
+	}
+	return &mockConsumer{b: b, topic: topic, group: groupID, pos: pos}, nil
+}
+
+// Messages returns all messages written to topic in partition order.
+func (b *MockBroker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	retval := make([]Message, 0)
+	for _, p := range b.topics[topic] {
+		retval = append(retval, p...)
+	}
+	return retval
+}
+
+// Committed returns the next offset to be read per partition of topic for consumer group groupID.
+// Partitions without a committed offset are not included.
+func (b *MockBroker) Committed(groupID string, topic string) map[int]int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	retval := make(map[int]int64)
+	for p, offset := range b.committed[groupID][topic] {
+		retval[p] = offset
+	}
+	return retval
+}
+
+// produce appends msgs to topic and wakes any waiting consumers.
+func (b *MockBroker) produce(topic string, msgs ...Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.topics[topic]; !ok {
+		b.topics[topic] = make([][]Message, b.partitions)
+	}
+	for _, m := range msgs {
+		h := fnv.New32a()
+		_, _ = h.Write(m.Key)
+		p := int(h.Sum32() % uint32(b.partitions))
+		m.Topic = topic
+		m.Partition = p
+		m.Offset = int64(len(b.topics[topic][p]))
+		b.topics[topic][p] = append(b.topics[topic][p], m)
+	}
+	close(b.notify)
+	b.notify = make(chan struct{})
+}
+
+// next returns the first message found at or after pos in the partitions of topic.
+// If there is none, found is false and notify can be used to wait for more messages.
+func (b *MockBroker) next(topic string, pos []int64) (msg Message, found bool, notify chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for p, offset := range pos {
+		if p < len(b.topics[topic]) && offset < int64(len(b.topics[topic][p])) {
+			return b.topics[topic][p][offset], true, nil
+		}
+	}
+	return Message{}, false, b.notify
+}
+
+func (b *MockBroker) commit(groupID string, msgs ...Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, m := range msgs {
+		if _, ok := b.committed[groupID]; !ok {
+			b.committed[groupID] = make(map[string]map[int]int64)
+		}
+		if _, ok := b.committed[groupID][m.Topic]; !ok {
+			b.committed[groupID][m.Topic] = make(map[int]int64)
+		}
+		if m.Offset+1 > b.committed[groupID][m.Topic][m.Partition] {
+			b.committed[groupID][m.Topic][m.Partition] = m.Offset + 1
+		}
+	}
+}
+
+type mockProducer struct {
+	b     *MockBroker
+	topic string
+}
+
+func (p *mockProducer) Produce(ctx context.Context, msgs ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.b.produce(p.topic, msgs...)
+	return nil
+}
+
+func (p *mockProducer) Close() error {
+	return nil
+}
+
+type mockConsumer struct {
+	b     *MockBroker
+	topic string
+	group string
+	pos   []int64
+}
+
+func (c *mockConsumer) Fetch(ctx context.Context) (Message, error) {
+	for {
+		msg, found, notify := c.b.next(c.topic, c.pos)
+		if found {
+			c.pos[msg.Partition] = msg.Offset + 1
+			return msg, nil
+		}
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		}
+	}
+}
+
+func (c *mockConsumer) Commit(ctx context.Context, msgs ...Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.b.commit(c.group, msgs...)
+	return nil
+}
+
+func (c *mockConsumer) Close() error {
+	return nil
+}
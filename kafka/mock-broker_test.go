@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMockBroker(t *testing.T) {
+	b := NewMockBroker(2)
+	p, err := b.NewProducer(nil, "topic1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	keys := []string{"a", "b", "c", "a"}
+	for _, k := range keys {
+		if err := p.Produce(ctx, Message{Key: []byte(k), Value: []byte("value-" + k)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(b.Messages("topic1")) != len(keys) {
+		t.Fatal("expected ", len(keys), " messages; got ", len(b.Messages("topic1")))
+	}
+
+	// Test 1 - messages with the same key are in order in the same partition.
+	c1, err := b.NewConsumer(nil, "topic1", "group1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched := make([]Message, 0)
+	for range keys {
+		m, err := c1.Fetch(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fetched = append(fetched, m)
+	}
+	var first, second *Message
+	for idx := range fetched {
+		if string(fetched[idx].Key) == "a" {
+			if first == nil {
+				first = &fetched[idx]
+			} else {
+				second = &fetched[idx]
+			}
+		}
+	}
+	if first == nil || second == nil || first.Partition != second.Partition || second.Offset <= first.Offset {
+		t.Fatal("expected messages with key 'a' to be in order in the same partition; got ", fetched)
+	}
+
+	// Test 2 - Fetch blocks until ctx is done.
+	ctxTimeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := c1.Fetch(ctxTimeout); err == nil {
+		t.Fatal("expected error fetching from empty topic")
+	}
+
+	// Test 3 - Fetch wakes up when a message is produced.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = p.Produce(ctx, Message{Key: []byte("d"), Value: []byte("value-d")})
+	}()
+	m, err := c1.Fetch(ctx)
+	if err != nil || string(m.Value) != "value-d" {
+		t.Fatal("expected value-d; got ", m, err)
+	}
+
+	// Test 4 - a new consumer in the same group starts from committed offsets.
+	if err := CommitAcks(ctx, NewAck(c1, fetched[0], nil), NewAck(c1, fetched[1], nil)); err != nil {
+		t.Fatal(err)
+	}
+	committed := b.Committed("group1", "topic1")
+	total := int64(0)
+	for _, offset := range committed {
+		total += offset
+	}
+	if total != 2 {
+		t.Fatal("expected 2 messages committed; got ", committed)
+	}
+	c2, err := b.NewConsumer(nil, "topic1", "group1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for {
+		ctxTimeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		_, err := c2.Fetch(ctxTimeout)
+		cancel()
+		if err != nil {
+			break
+		}
+		count++
+	}
+	if count != len(keys)+1-2 {
+		t.Fatal("expected ", len(keys)+1-2, " uncommitted messages; got ", count)
+	}
+}
+
+func TestCommitAcks(t *testing.T) {
+	b := NewMockBroker(1)
+	c, _ := b.NewConsumer(nil, "topic1", "group1")
+	calls := 0
+	a := NewAck(c, Message{Topic: "topic1", Partition: 0, Offset: 5}, func() { calls++ })
+	for i := 0; i < 2; i++ {
+		if err := CommitAcks(context.Background(), a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatal("expected onCommit to be called once; got ", calls)
+	}
+	if b.Committed("group1", "topic1")[0] != 6 {
+		t.Fatal("expected committed offset 6; got ", b.Committed("group1", "topic1"))
+	}
+}
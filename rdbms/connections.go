@@ -38,6 +38,8 @@ func OpenDbConnection(log logger.Logger, c shared.ConnectionDetails) (db shared.
 		db, err = newConnectionWithDsn(log, shared.GetDsnConnectionDetails(&c))
 	case constants.ConnectionTypeNetezza:
 		db, err = newNetezzaConnection(log, shared.GetDsnConnectionDetails(&c))
+	case constants.ConnectionTypePostgres:
+		db, err = newPostgresConnection(log, shared.GetDsnConnectionDetails(&c))
 	case constants.ConnectionTypeMockOracle:
 		db, _ = shared.NewMockConnectionWithMockTx(log, constants.ConnectionTypeOracle)
 	default: // else if connection is ODBC or unsupported...
@@ -0,0 +1,35 @@
+package rdbms
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/lib/pq"
+	"github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/xo/dburl"
+)
+
+// newPostgresConnection opens the Postgres database connection specified in d.
+// The connection uses the Postgres-specific DML generators so that bind variables are of the form $N.
+func newPostgresConnection(log logger.Logger, d *shared.DsnConnectionDetails) (shared.Connector, error) {
+	log.Info("Opening database connection: ", d)
+	u, err := dburl.Parse(d.Dsn)
+	if err != nil { // if the DSN could not be parsed...
+		return nil, fmt.Errorf("error parsing DSN %q: %w", d.Dsn, err)
+	}
+	conn := &shared.HpConnection{
+		Dml:    &shared.DmlGeneratorPostgresTxtBatch{},
+		DbType: constants.ConnectionTypePostgres,
+	}
+	if conn.DbSql, err = sql.Open(u.Driver, u.DSN); err != nil {
+		return nil, err
+	}
+	err = conn.DbSql.Ping()
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Successful database connection to Postgres.")
+	return conn, nil
+}
@@ -78,3 +78,9 @@ func (st *SchemaTable) AppendSuffix(suffix string) string {
 func (st *SchemaTable) String() string {
 	return st.SchemaTable
 }
+
+// UnmarshalText implements encoding.TextUnmarshaler so a SchemaTable can be populated directly from config strings.
+func (st *SchemaTable) UnmarshalText(text []byte) error {
+	st.SchemaTable = string(text)
+	return nil
+}
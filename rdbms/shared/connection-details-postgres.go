@@ -0,0 +1,52 @@
+package shared
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/relloyd/halfpipe/constants"
+	"github.com/xo/dburl"
+)
+
+// PostgresConnectionDetails holds a Postgres DSN of the form postgres://<user>:<pass>@<host>/<dbname>.
+// Aliases of the postgres scheme that are supported by dburl, like postgresql:// and pg://, are also accepted.
+type PostgresConnectionDetails struct {
+	Dsn            string `errorTxt:"data source name i.e. connect string" mandatory:"yes"`
+	OriginalScheme string
+}
+
+// String returns the DSN with redacted password.
+func (d PostgresConnectionDetails) String() string {
+	u, err := dburl.Parse(d.Dsn)
+	if err != nil {
+		panic(fmt.Sprintf("error parsing DSN %q: %v", d.Dsn, err))
+	}
+	return u.Redacted()
+}
+
+func (d PostgresConnectionDetails) Parse() error {
+	if d.Dsn == "" { // if the Dsn is invalid...
+		return errors.New("DSN not found")
+	}
+	u, err := dburl.Parse(d.Dsn)
+	if err != nil {
+		return errors.Wrap(err, "DSN could not be parsed")
+	}
+	if u.Driver != constants.ConnectionTypePostgres { // if the scheme isn't one of the postgres aliases...
+		return fmt.Errorf("unsupported Postgres DSN scheme %q", u.OriginalScheme)
+	}
+	return nil
+}
+
+// GetScheme returns the postgres connection type regardless of the alias used in the DSN.
+func (d PostgresConnectionDetails) GetScheme() (string, error) {
+	return constants.ConnectionTypePostgres, nil
+}
+
+func (d PostgresConnectionDetails) GetMap(m map[string]string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+	m[DefaultDsnConnectionKeyNames.Dsn] = d.Dsn
+	return m
+}
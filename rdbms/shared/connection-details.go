@@ -64,6 +64,8 @@ func (c ConnectionDetails) MustGetDateFilterSql(dateVarName string) string {
 		t = `convert(datetime, stuff(stuff(stuff(stuff('${date}', 5, 0, '-'), 8, 0, '-'), 14, 0, ':'), 17, 0, ':'))`
 	case constants.ConnectionTypeOracle, constants.ConnectionTypeMockOracle, constants.ConnectionTypeSnowflake:
 		t = `to_date('${date}','YYYYMMDD\"T\"HH24MISS')`
+	case constants.ConnectionTypePostgres:
+		t = `to_timestamp('${date}','YYYYMMDD\"T\"HH24MISS')`
 	}
 	if t == "" { // if t was not set...
 		panic(fmt.Sprintf("unsupported database type %q in call to get SQL date/time conversion template", c.Type))
@@ -78,13 +80,43 @@ func (c ConnectionDetails) MustGetSysDateSql() string {
 		return "sysdatetime()"
 	case constants.ConnectionTypeOracle, constants.ConnectionTypeMockOracle:
 		return "sysdate"
-	case constants.ConnectionTypeSnowflake:
+	case constants.ConnectionTypeSnowflake, constants.ConnectionTypePostgres:
 		return "current_timestamp"
 	default:
 		panic(fmt.Sprintf("unsupported database type %q in call to get SQL for current date", c.Type))
 	}
 }
 
+// MustGetDateAddDaysSql returns a SQL snippet that adds numDays to the date-time expression dateSql,
+// where the SQL returned is database-type specific.
+func (c ConnectionDetails) MustGetDateAddDaysSql(dateSql string, numDays int) string {
+	switch c.Type {
+	case constants.ConnectionTypeSqlServer, constants.ConnectionTypeOdbcSqlServer, constants.ConnectionTypeSnowflake:
+		return fmt.Sprintf("dateadd(day, %v, %v)", numDays, dateSql)
+	case constants.ConnectionTypeOracle, constants.ConnectionTypeMockOracle:
+		return fmt.Sprintf("(%v + %v)", dateSql, numDays)
+	case constants.ConnectionTypePostgres:
+		return fmt.Sprintf("(%v + interval '%v day')", dateSql, numDays)
+	default:
+		panic(fmt.Sprintf("unsupported database type %q in call to get SQL for date arithmetic", c.Type))
+	}
+}
+
+// GetBindPlaceholder returns the bind variable syntax used by databases of type dbType for the bind at the
+// given position, where position starts at 1.
+func GetBindPlaceholder(dbType string, position int) string {
+	switch dbType {
+	case constants.ConnectionTypeOracle, constants.ConnectionTypeMockOracle, constants.ConnectionTypeSnowflake:
+		return fmt.Sprintf(":%v", position)
+	case constants.ConnectionTypePostgres, constants.ConnectionTypeNetezza:
+		return fmt.Sprintf("$%v", position)
+	case constants.ConnectionTypeSqlServer:
+		return fmt.Sprintf("@p%v", position)
+	default: // ODBC and others.
+		return "?"
+	}
+}
+
 // DBConnections is used by transform code and JSON pipelines definitions.
 type DBConnections map[string]ConnectionDetails
 
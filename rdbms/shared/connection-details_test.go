@@ -30,6 +30,13 @@ func TestConnectionDetails_MustGetSysDateSql(t *testing.T) {
 	if got != expected {
 		t.Fatalf("expected: %v; got: %v", expected, got)
 	}
+	// Test sysdate for Postgres.
+	c.Type = constants.ConnectionTypePostgres
+	got = c.MustGetSysDateSql()
+	expected = "current_timestamp"
+	if got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
 	// Test that panic is caused when given unsupported database type.
 	didPanic := false
 	func() {
@@ -77,4 +84,42 @@ func TestConnectionDetails_MustGetDateFilterSql(t *testing.T) {
 	if got != expected {
 		t.Fatalf("expected: %v; got: %v", expected, got)
 	}
+	// Test date filter for Postgres.
+	c.Type = constants.ConnectionTypePostgres
+	got = c.MustGetDateFilterSql("myVarName")
+	expected = "to_timestamp('${myVarName}','YYYYMMDD\\\"T\\\"HH24MISS')"
+	if got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
+}
+
+func TestConnectionDetails_MustGetDateAddDaysSql(t *testing.T) {
+	c := ConnectionDetails{}
+	cases := map[string]string{
+		constants.ConnectionTypeSnowflake: "dateadd(day, -1, min(x))",
+		constants.ConnectionTypeSqlServer: "dateadd(day, -1, min(x))",
+		constants.ConnectionTypeOracle:    "(min(x) + -1)",
+		constants.ConnectionTypePostgres:  "(min(x) + interval '-1 day')",
+	}
+	for dbType, expected := range cases {
+		c.Type = dbType
+		if got := c.MustGetDateAddDaysSql("min(x)", -1); got != expected {
+			t.Fatalf("type %v expected: %v; got: %v", dbType, expected, got)
+		}
+	}
+}
+
+func TestGetBindPlaceholder(t *testing.T) {
+	cases := map[string]string{
+		constants.ConnectionTypeOracle:        ":2",
+		constants.ConnectionTypeSnowflake:     ":2",
+		constants.ConnectionTypePostgres:      "$2",
+		constants.ConnectionTypeSqlServer:     "@p2",
+		constants.ConnectionTypeOdbcSqlServer: "?",
+	}
+	for dbType, expected := range cases {
+		if got := GetBindPlaceholder(dbType, 2); got != expected {
+			t.Fatalf("type %v expected: %v; got: %v", dbType, expected, got)
+		}
+	}
 }
@@ -16,6 +16,7 @@ type MockConnectionWithMockTx struct {
 	Dml             DmlGenerator // TODO: implement the mock for this!
 	DbType          string
 	DbHasBeenClosed bool
+	ExecErrorValue  string // optional value that causes transaction Exec() and ExecBatch() calls to fail when found in their args.
 }
 
 func NewMockConnectionWithMockTx(log logger.Logger, dbType string) (Connector, chan string) {
@@ -25,7 +26,7 @@ func NewMockConnectionWithMockTx(log logger.Logger, dbType string) (Connector, c
 }
 
 func (c *MockConnectionWithMockTx) Begin() (Transacter, error) {
-	return &txMocked{outputChan: c.OutputChan}, nil // return a dumb txMocked that does nothing!
+	return &txMocked{outputChan: c.OutputChan, errorValue: c.ExecErrorValue}, nil // return a dumb txMocked that does nothing!
 }
 
 func (c *MockConnectionWithMockTx) Exec(query string, args ...interface{}) (Result, error) {
@@ -89,6 +90,7 @@ func (*MockConnectionWithMockTx) NewDeleteGenerator(cfg *SqlStatementGeneratorCo
 
 type txMocked struct {
 	outputChan chan string // channel to be supplied by caller and used to return SQL and values generated by Exec().
+	errorValue string
 }
 
 func (t *txMocked) Prepare(query string) (StatementBatch, error) {
@@ -97,7 +99,7 @@ func (t *txMocked) Prepare(query string) (StatementBatch, error) {
 
 func (t *txMocked) PrepareContext(ctx context.Context, query string) (StatementBatch, error) {
 	t.outputChan <- query
-	return &stmtMocked{outputChan: t.outputChan}, nil
+	return &stmtMocked{outputChan: t.outputChan, errorValue: t.errorValue}, nil
 }
 
 func (t *txMocked) Exec(query string, args ...interface{}) (Result, error) {
@@ -109,6 +111,9 @@ func (t *txMocked) ExecContext(ctx context.Context, query string, args ...interf
 	format := strings.Repeat("%v ", len(args))
 	format = strings.TrimRight(format, " ")
 	t.outputChan <- fmt.Sprintf(format, args...)
+	if mockArgsContain(args, t.errorValue) {
+		return nil, fmt.Errorf("mock error for value %q", t.errorValue)
+	}
 	return mockSqlResult{}, nil
 }
 
@@ -122,13 +127,32 @@ func (t *txMocked) Rollback() error {
 
 type stmtMocked struct {
 	outputChan chan string
+	errorValue string
 }
 
 func (s *stmtMocked) ExecBatch(args [][]interface{}) (Result, error) {
 	s.outputChan <- fmt.Sprintf("num cols = %v; num rows = %v", len(args), len(args[0]))
+	for _, col := range args {
+		if mockArgsContain(col, s.errorValue) {
+			return nil, fmt.Errorf("mock error for value %q", s.errorValue)
+		}
+	}
 	return mockSqlResult{}, nil
 }
 
+// mockArgsContain returns true if v is not empty and is the string form of any of args.
+func mockArgsContain(args []interface{}, v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, a := range args {
+		if fmt.Sprint(a) == v {
+			return true
+		}
+	}
+	return false
+}
+
 type mockSqlResult struct{}
 
 func (s mockSqlResult) LastInsertId() (int64, error) {
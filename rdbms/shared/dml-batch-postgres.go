@@ -0,0 +1,180 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+
+	h "github.com/relloyd/halfpipe/helper"
+)
+
+// DmlGeneratorPostgresTxtBatch implements interface DmlGenerator for Postgres connections.
+// Postgres requires bind variables of the form $N and has no MERGE statement (before v15) so we generate
+// INSERT ... ON CONFLICT instead.
+// UPDATE and DELETE statements use an inline SELECT of bind values, which is prefixed by an empty SELECT from the
+// target table so that Postgres can infer the data type of each bind variable using the UNION type resolution rules.
+type DmlGeneratorPostgresTxtBatch struct{}
+
+// NewInsertGenerator creates a new SqlStmtGenerator that implements interface SqlStmtTxtBatcher.
+func (*DmlGeneratorPostgresTxtBatch) NewInsertGenerator(cfg *SqlStatementGeneratorConfig) SqlStmtGenerator {
+	FixSqlStatementGeneratorConfig(cfg)
+	cfg.Log.Debug("Creating NewInsertGenerator for Postgres")
+	o := &SqlInsertPostgresTxtBatch{SqlInsertTxtBatch: SqlInsertTxtBatch{SqlStatementGeneratorConfig: *cfg}}
+	o.setupSqlStatement()
+	return o
+}
+
+// NewUpdateGenerator creates a new SqlStmtGenerator that implements interface SqlStmtTxtBatcher.
+func (*DmlGeneratorPostgresTxtBatch) NewUpdateGenerator(cfg *SqlStatementGeneratorConfig) SqlStmtGenerator {
+	FixSqlStatementGeneratorConfig(cfg)
+	cfg.Log.Debug("Creating NewUpdateGenerator for Postgres")
+	o := &SqlUpdatePostgresTxtBatch{SqUpdateTxtBatch: SqUpdateTxtBatch{SqlStatementGeneratorConfig: *cfg}}
+	o.setupSqlStatement()
+	return o
+}
+
+// NewDeleteGenerator creates a new SqlStmtGenerator that implements interface SqlStmtTxtBatcher.
+func (*DmlGeneratorPostgresTxtBatch) NewDeleteGenerator(cfg *SqlStatementGeneratorConfig) SqlStmtGenerator {
+	FixSqlStatementGeneratorConfig(cfg)
+	cfg.Log.Debug("Creating NewDeleteGenerator for Postgres")
+	o := &SqlDeletePostgresTxtBatch{SqlDeleteTxtBatch: SqlDeleteTxtBatch{SqlStatementGeneratorConfig: *cfg}}
+	o.setupSqlStatement()
+	return o
+}
+
+// NewMergeGenerator creates a new SqlStmtGenerator that implements interface SqlStmtTxtBatcher.
+// Values must be supplied to the batch as key columns followed by other columns.
+func (*DmlGeneratorPostgresTxtBatch) NewMergeGenerator(cfg *SqlStatementGeneratorConfig) SqlStmtGenerator {
+	FixSqlStatementGeneratorConfig(cfg)
+	cfg.Log.Debug("Creating NewMergeGenerator for Postgres")
+	o := &SqlMergePostgresTxtBatch{}
+	o.SqlStatementGeneratorConfig = *cfg
+	o.setupSqlStatement()
+	return o
+}
+
+// SqlInsertPostgresTxtBatch generates INSERT statements with batches of rows supplied.
+type SqlInsertPostgresTxtBatch struct {
+	SqlInsertTxtBatch
+}
+
+// GetStatement returns the INSERT statement for the number of rows added to the current batch.
+// We always build the SQL from the template since the final batch is usually smaller than the others.
+func (o *SqlInsertPostgresTxtBatch) GetStatement() string {
+	o.sqlStmt = strings.Replace(o.sqlStmtTemplate, "<VALUES>", getPostgresValuesOfBinds(o.rowsInBatch, len(o.ColList)), 1)
+	o.Log.Debug("SQL batch INSERT generated statement: ", o.sqlStmt)
+	return o.sqlStmt
+}
+
+// SqlUpdatePostgresTxtBatch generates UPDATE statements with batches of rows supplied.
+// Values must be supplied to the batch as key columns followed by other columns.
+type SqlUpdatePostgresTxtBatch struct {
+	SqUpdateTxtBatch
+}
+
+// GetStatement returns the UPDATE statement for the number of rows added to the current batch.
+// Example:
+// update table tgt set b = src.b from (select a, b from table where 1 = 0 union all select $1, $2) src where src.a = tgt.a
+func (o *SqlUpdatePostgresTxtBatch) GetStatement() string {
+	o.sqlStmt = o.sqlStmtTemplate
+	// Build <COL-TXT> - Postgres does not allow the target alias on the left-hand side of SET.
+	colList := make([]string, len(o.ColList))
+	for idx, col := range o.ColList {
+		colList[idx] = fmt.Sprintf("%s = src.%s", col, col)
+	}
+	o.sqlStmt = strings.Replace(o.sqlStmt, "<COL-TXT>", strings.Join(colList, ","), 1)
+	// Build <FROM-TXT>
+	allRows := getPostgresInlineSelectOfBinds(&o.SqlStatementGeneratorConfig, o.rowsInBatch, o.AllColList)
+	o.sqlStmt = strings.Replace(o.sqlStmt, "<FROM-TXT>", allRows, 1)
+	// Build <KEY-TXT>
+	keyList := h.GenerateStringOfColsEqualsCols(o.KeyList, "src", "tgt", " and ")
+	o.sqlStmt = strings.Replace(o.sqlStmt, "<KEY-TXT>", keyList, 1)
+	o.Log.Debug("SQL batch UPDATE generated statement: ", o.sqlStmt)
+	return o.sqlStmt
+}
+
+// SqlDeletePostgresTxtBatch generates DELETE statements with batches of rows supplied.
+type SqlDeletePostgresTxtBatch struct {
+	SqlDeleteTxtBatch
+}
+
+// GetStatement returns the DELETE statement for the number of rows added to the current batch.
+// Example:
+// delete from table tgt using (select a from table where 1 = 0 union all select $1 union all select $2) src where src.a = tgt.a
+func (o *SqlDeletePostgresTxtBatch) GetStatement() string {
+	o.sqlStmt = o.sqlStmtTemplate
+	// Build <USING>
+	allRows := getPostgresInlineSelectOfBinds(&o.SqlStatementGeneratorConfig, o.rowsInBatch, o.KeyList)
+	o.sqlStmt = strings.Replace(o.sqlStmt, "<USING>", allRows, 1)
+	// Build <KEY-TXT>
+	keyList := h.GenerateStringOfColsEqualsCols(o.KeyList, "src", "tgt", " and ")
+	o.sqlStmt = strings.Replace(o.sqlStmt, "<KEY-TXT>", keyList, 1)
+	o.Log.Debug("SQL batch DELETE generated statement: ", o.sqlStmt)
+	return o.sqlStmt
+}
+
+// SqlMergePostgresTxtBatch generates INSERT ... ON CONFLICT statements with batches of rows supplied.
+// The target table must have a unique constraint or primary key on the key columns.
+// Postgres will raise an error if the same key is supplied more than once in a single batch.
+type SqlMergePostgresTxtBatch struct {
+	SqlInsertPostgresTxtBatch
+}
+
+// GetStatement returns the INSERT ... ON CONFLICT statement for the number of rows added to the current batch.
+// Example:
+// insert into table (a,b,c) values ($1,$2,$3),($4,$5,$6)
+// on conflict (a) do update set b = excluded.b,c = excluded.c
+func (o *SqlMergePostgresTxtBatch) GetStatement() string {
+	keyCols := make([]string, o.TargetKeyCols.Len())
+	idx := 0
+	h.OrderedMapValuesToStringSlice(o.Log, o.TargetKeyCols, &keyCols, &idx)
+	otherCols := make([]string, o.TargetOtherCols.Len())
+	idx = 0
+	h.OrderedMapValuesToStringSlice(o.Log, o.TargetOtherCols, &otherCols, &idx)
+	var action string
+	if len(otherCols) > 0 { // if there are columns to update...
+		setList := make([]string, len(otherCols))
+		for i, col := range otherCols {
+			setList[i] = fmt.Sprintf("%s = excluded.%s", col, col)
+		}
+		action = "do update set " + strings.Join(setList, ",")
+	} else { // else all columns are keys so there's nothing to update...
+		action = "do nothing"
+	}
+	o.sqlStmt = strings.Replace(o.sqlStmtTemplate, "<VALUES>", getPostgresValuesOfBinds(o.rowsInBatch, len(o.ColList)), 1)
+	o.sqlStmt = fmt.Sprintf("%v on conflict (%v) %v", o.sqlStmt, strings.Join(keyCols, ","), action)
+	o.Log.Debug("SQL batch MERGE generated statement: ", o.sqlStmt)
+	return o.sqlStmt
+}
+
+// getPostgresValuesOfBinds returns a string of the form "($1,$2),($3,$4)" for the given number of rows and columns.
+func getPostgresValuesOfBinds(numRows int, numCols int) string {
+	allRows := make([]string, numRows)
+	valIdx := 1
+	for rowIdx := 0; rowIdx < numRows; rowIdx++ { // for each row...
+		row := make([]string, numCols)
+		for colIdx := 0; colIdx < numCols; colIdx++ { // for each value in the current row...
+			row[colIdx] = fmt.Sprintf("$%v", valIdx)
+			valIdx++
+		}
+		allRows[rowIdx] = fmt.Sprintf("(%v)", strings.Join(row, ","))
+	}
+	return strings.Join(allRows, ",")
+}
+
+// getPostgresInlineSelectOfBinds returns an inline SELECT of bind variables, one SELECT per row, where the first
+// SELECT fetches no rows from the target table and exists only to give the bind variables their data types.
+func getPostgresInlineSelectOfBinds(cfg *SqlStatementGeneratorConfig, numRows int, cols []string) string {
+	allRows := strings.Builder{}
+	allRows.WriteString(fmt.Sprintf("select %v from %v%v%v where 1 = 0",
+		strings.Join(cols, ","), cfg.OutputSchema, cfg.SchemaSeparator, cfg.OutputTable))
+	valIdx := 1
+	for rowIdx := 0; rowIdx < numRows; rowIdx++ { // for each row...
+		row := make([]string, len(cols))
+		for colIdx := range cols { // for each value in the current row...
+			row[colIdx] = fmt.Sprintf("$%v", valIdx)
+			valIdx++
+		}
+		allRows.WriteString(fmt.Sprintf(" union all select %v", strings.Join(row, ",")))
+	}
+	return allRows.String()
+}
@@ -0,0 +1,109 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/cevaris/ordered_map"
+	"github.com/sirupsen/logrus"
+)
+
+func newPostgresTestConfig() *SqlStatementGeneratorConfig {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	omKeys := ordered_map.NewOrderedMap()
+	omKeys.Set("col1", "a")
+	omKeys.Set("col2", "b")
+	omCols := ordered_map.NewOrderedMap()
+	omCols.Set("col3", "c")
+	return &SqlStatementGeneratorConfig{
+		Log:             log,
+		OutputSchema:    "s",
+		OutputTable:     "t2",
+		TargetKeyCols:   omKeys,
+		TargetOtherCols: omCols}
+}
+
+func TestPostgresSqlInsert(t *testing.T) {
+	dml := &DmlGeneratorPostgresTxtBatch{}
+	o := dml.NewInsertGenerator(newPostgresTestConfig()).(SqlStmtTxtBatcher)
+	o.InitBatch(3)
+	if _, err := o.AddValuesToBatch([]interface{}{"x", "y", 123}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.AddValuesToBatch([]interface{}{"p", "q", 2}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `insert into s.t2 (a,b,c) values ($1,$2,$3),($4,$5,$6)`
+	if got := o.GetStatement(); got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
+	if len(o.GetValues()) != 6 {
+		t.Fatalf("expected 6 values; got %v", len(o.GetValues()))
+	}
+	// Confirm that a smaller batch regenerates the SQL.
+	o.InitBatch(3)
+	if _, err := o.AddValuesToBatch([]interface{}{"a", "b", 456}); err != nil {
+		t.Fatal(err)
+	}
+	expected = `insert into s.t2 (a,b,c) values ($1,$2,$3)`
+	if got := o.GetStatement(); got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
+}
+
+func TestPostgresSqlUpdate(t *testing.T) {
+	dml := &DmlGeneratorPostgresTxtBatch{}
+	o := dml.NewUpdateGenerator(newPostgresTestConfig()).(SqlStmtTxtBatcher)
+	o.InitBatch(2)
+	if _, err := o.AddValuesToBatch([]interface{}{"x", "y", 123}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.AddValuesToBatch([]interface{}{"p", "q", 2}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `update s.t2 tgt set c = src.c from ( select a,b,c from s.t2 where 1 = 0 union all select $1,$2,$3 union all select $4,$5,$6 ) src where src.a = tgt.a and src.b = tgt.b`
+	if got := o.GetStatement(); got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
+}
+
+func TestPostgresSqlDelete(t *testing.T) {
+	dml := &DmlGeneratorPostgresTxtBatch{}
+	o := dml.NewDeleteGenerator(newPostgresTestConfig()).(SqlStmtTxtBatcher)
+	o.InitBatch(2)
+	if _, err := o.AddValuesToBatch([]interface{}{"x", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `delete from s.t2 tgt using (select a,b from s.t2 where 1 = 0 union all select $1,$2) src where src.a = tgt.a and src.b = tgt.b`
+	if got := o.GetStatement(); got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
+}
+
+func TestPostgresSqlMerge(t *testing.T) {
+	dml := &DmlGeneratorPostgresTxtBatch{}
+	o := dml.NewMergeGenerator(newPostgresTestConfig()).(SqlStmtTxtBatcher)
+	o.InitBatch(2)
+	if _, err := o.AddValuesToBatch([]interface{}{"x", "y", 123}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.AddValuesToBatch([]interface{}{"p", "q", 2}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `insert into s.t2 (a,b,c) values ($1,$2,$3),($4,$5,$6) on conflict (a,b) do update set c = excluded.c`
+	if got := o.GetStatement(); got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
+	// Test that a table with only key columns does nothing on conflict.
+	cfg := newPostgresTestConfig()
+	cfg.TargetOtherCols = ordered_map.NewOrderedMap()
+	o = dml.NewMergeGenerator(cfg).(SqlStmtTxtBatcher)
+	o.InitBatch(1)
+	if _, err := o.AddValuesToBatch([]interface{}{"x", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	expected = `insert into s.t2 (a,b) values ($1,$2) on conflict (a,b) do nothing`
+	if got := o.GetStatement(); got != expected {
+		t.Fatalf("expected: %v; got: %v", expected, got)
+	}
+}
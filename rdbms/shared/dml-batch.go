@@ -18,11 +18,11 @@ type DmlGeneratorTxtBatch struct{}
 
 type SqlStatementGeneratorConfig struct {
 	Log             logger.Logger
-	OutputSchema    string
+	OutputSchema    string `data:"outputSchemaName"`
 	SchemaSeparator string
-	OutputTable     string
-	TargetKeyCols   *om.OrderedMap // ordered map of: key = chan field name; value = target table column name
-	TargetOtherCols *om.OrderedMap // ordered map of: key = chan field name; value = target table column name
+	OutputTable     string         `data:"outputTable" mandatory:"yes"`
+	TargetKeyCols   *om.OrderedMap `data:"keyCols"`   // ordered map of: key = chan field name; value = target table column name
+	TargetOtherCols *om.OrderedMap `data:"otherCols"` // ordered map of: key = chan field name; value = target table column name
 }
 
 type sqlCoreCfg struct {
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+// oracleMaxBindBytes is the largest string that Oracle accepts as a VARCHAR2 bind in a SQL expression.
+const oracleMaxBindBytes = 4000
+
+// DatabaseStore saves documents in a database table with one row per collection and name.
+// Values are supplied as bind variables using the syntax of the connection's database type.
+type DatabaseStore struct {
+	db    shared.Connector
+	table string
+}
+
+func NewDatabaseStore(db shared.Connector, table string) *DatabaseStore {
+	return &DatabaseStore{db: db, table: table}
+}
+
+func (s *DatabaseStore) Read(collection string, name string) (value []byte, found bool, err error) {
+	rows, err := s.db.Query(fmt.Sprintf("select registry_value from %v where %v", s.table, s.where(1)), collection, name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var v string
+		if err = rows.Scan(&v); err != nil {
+			return nil, false, err
+		}
+		value = []byte(v)
+		found = true
+	}
+	return value, found, rows.Err()
+}
+
+// Write deletes any existing row for collection and name and inserts the new value in a single transaction.
+// Oracle values larger than a VARCHAR2 bind are inserted in chunks that are appended to the CLOB column.
+func (s *DatabaseStore) Write(collection string, name string, value []byte) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tx.Exec(fmt.Sprintf("delete from %v where %v", s.table, s.where(1)), collection, name); err != nil {
+		return err
+	}
+	chunks := []string{string(value)}
+	if t := s.db.GetType(); t == constants.ConnectionTypeOracle || t == constants.ConnectionTypeMockOracle {
+		chunks = splitString(string(value), oracleMaxBindBytes)
+	}
+	if _, err = tx.Exec(fmt.Sprintf("insert into %v (registry_collection, registry_name, registry_value) values (%v, %v, %v)",
+		s.table, s.bind(1), s.bind(2), s.bind(3)), collection, name, chunks[0]); err != nil {
+		return err
+	}
+	for _, chunk := range chunks[1:] { // for each remaining chunk of the value...
+		if _, err = tx.Exec(fmt.Sprintf("update %v set registry_value = registry_value || %v where %v",
+			s.table, s.bind(1), s.where(2)), chunk, collection, name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *DatabaseStore) Delete(collection string, name string) error {
+	_, err := s.db.Exec(fmt.Sprintf("delete from %v where %v", s.table, s.where(1)), collection, name)
+	return err
+}
+
+func (s *DatabaseStore) List(collection string) ([]string, error) {
+	rows, err := s.db.Query(fmt.Sprintf("select registry_name from %v where registry_collection = %v order by registry_name",
+		s.table, s.bind(1)), collection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make([]string, 0)
+	for rows.Next() {
+		var n string
+		if err = rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+// where returns the predicate on collection and name, using binds starting at position.
+func (s *DatabaseStore) where(position int) string {
+	return fmt.Sprintf("registry_collection = %v and registry_name = %v", s.bind(position), s.bind(position+1))
+}
+
+func (s *DatabaseStore) bind(position int) string {
+	return shared.GetBindPlaceholder(s.db.GetType(), position)
+}
+
+// splitString splits v into chunks of at most maxBytes without breaking multi-byte characters.
+// There is always at least one chunk.
+func splitString(v string, maxBytes int) []string {
+	chunks := make([]string, 0, len(v)/maxBytes+1)
+	for len(v) > maxBytes {
+		n := maxBytes
+		for n > 0 && !utf8.RuneStart(v[n]) { // while we're part way through a character...
+			n--
+		}
+		chunks = append(chunks, v[:n])
+		v = v[n:]
+	}
+	return append(chunks, v)
+}
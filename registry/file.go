@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const fileExtension = ".json"
+
+// FileStore saves documents in files in a local directory, using a sub-directory per collection.
+// Names must be safe to use as file names, which is enforced by Registry.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) Read(collection string, name string) (value []byte, found bool, err error) {
+	b, err := ioutil.ReadFile(s.fileName(collection, name))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// Write saves value to a temporary file which is renamed over the existing one, so a failure part way through
+// leaves the previous document in place.
+func (s *FileStore) Write(collection string, name string, value []byte) error {
+	dir := filepath.Join(s.dir, collection)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(value); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), s.fileName(collection, name))
+}
+
+func (s *FileStore) Delete(collection string, name string) error {
+	err := os.Remove(s.fileName(collection, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) List(collection string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, collection))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for _, f := range files { // for each file...
+		if !f.IsDir() && strings.HasSuffix(f.Name(), fileExtension) { // if it is a document and not a temporary file...
+			names = append(names, strings.TrimSuffix(f.Name(), fileExtension))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *FileStore) fileName(collection string, name string) string {
+	return filepath.Join(s.dir, collection, name+fileExtension)
+}
@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/relloyd/halfpipe/transform"
+)
+
+const (
+	collectionPipes = "pipes"
+	collectionRuns  = "runs"
+)
+
+// MaxRunHistory is the number of runs kept per pipe, after which the oldest are removed.
+var MaxRunHistory = 100
+
+// Run triggers.
+const (
+	TriggerManual   = "manual"
+	TriggerSchedule = "schedule"
+	TriggerRestart  = "restart"
+)
+
+// SubmittedByScheduler is the credential name recorded for runs launched by the Scheduler.
+const SubmittedByScheduler = "scheduler"
+
+var rexpPipeName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Pipe is a named pipe definition saved in the registry.
+type Pipe struct {
+	Name       string                        `json:"name"`
+	Schedule   string                        `json:"schedule,omitempty"` // cron expression, see Schedule; leave empty to run on demand only.
+	Transform  transform.TransformDefinition `json:"pipe"`
+	UpdateTime time.Time                     `json:"updateTime"`
+}
+
+// Run records a launch of a registered pipe.
+type Run struct {
+	PipeId      string           `json:"pipeId"` // GUID of the launched transform
+	PipeName    string           `json:"pipeName"`
+	Trigger     string           `json:"trigger"`
+	SubmittedBy string           `json:"submittedBy,omitempty"`
+	StartTime   time.Time        `json:"startTime"`
+	EndTime     time.Time        `json:"endTime"`
+	Status      transform.Status `json:"pipeStatus"`
+	Error       string           `json:"error"`
+}
+
+// IsFinished returns true if the run has ended.
+func (r *Run) IsFinished() bool {
+	s := transform.TransformStatus{Status: r.Status}
+	return s.TransformIsFinished()
+}
+
+// Registry saves named pipe definitions and their run history in a Store.
+type Registry struct {
+	mu    sync.Mutex // serialise updates to run history
+	store Store
+}
+
+func NewRegistry(store Store) *Registry {
+	return &Registry{store: store}
+}
+
+// ValidatePipe returns an error if the name or schedule of p are invalid.
+// The transform definition is validated separately, see transform.ValidateTransformDefinition.
+func ValidatePipe(p *Pipe) error {
+	if !rexpPipeName.MatchString(p.Name) {
+		return fmt.Errorf("bad pipe name %q: use letters, numbers, dots, dashes and underscores", p.Name)
+	}
+	if p.Schedule != "" {
+		if _, err := ParseSchedule(p.Schedule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SavePipe validates and saves p, replacing any existing pipe of the same name.
+func (r *Registry) SavePipe(p Pipe) error {
+	if err := ValidatePipe(&p); err != nil {
+		return err
+	}
+	p.UpdateTime = time.Now()
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return r.store.Write(collectionPipes, p.Name, b)
+}
+
+// GetPipe returns the pipe saved with name and found=false if there is none.
+func (r *Registry) GetPipe(name string) (p Pipe, found bool, err error) {
+	if !rexpPipeName.MatchString(name) {
+		return p, false, nil
+	}
+	b, found, err := r.store.Read(collectionPipes, name)
+	if err != nil || !found {
+		return p, found, err
+	}
+	if err = json.Unmarshal(b, &p); err != nil {
+		return p, false, fmt.Errorf("error reading pipe %q from registry: %v", name, err)
+	}
+	return p, true, nil
+}
+
+// ListPipes returns all saved pipes sorted by name.
+func (r *Registry) ListPipes() ([]Pipe, error) {
+	names, err := r.store.List(collectionPipes)
+	if err != nil {
+		return nil, err
+	}
+	pipes := make([]Pipe, 0, len(names))
+	for _, name := range names { // for each pipe...
+		p, found, err := r.GetPipe(name)
+		if err != nil {
+			return nil, err
+		}
+		if found { // if the pipe was not deleted since we listed it...
+			pipes = append(pipes, p)
+		}
+	}
+	return pipes, nil
+}
+
+// DeletePipe removes the pipe saved with name and its run history.
+// It returns found=false if there is no such pipe.
+func (r *Registry) DeletePipe(name string) (found bool, err error) {
+	if _, found, err = r.GetPipe(name); err != nil || !found {
+		return found, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err = r.store.Delete(collectionPipes, name); err != nil {
+		return true, err
+	}
+	return true, r.store.Delete(collectionRuns, name)
+}
+
+// SaveRun adds run to the history of its pipe or replaces the entry with the same PipeId.
+// Only the latest MaxRunHistory runs are kept.
+func (r *Registry) SaveRun(run Run) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs, err := r.listRuns(run.PipeName)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for idx := range runs { // for each existing run...
+		if runs[idx].PipeId == run.PipeId {
+			runs[idx] = run
+			replaced = true
+		}
+	}
+	if !replaced {
+		runs = append(runs, run)
+	}
+	if len(runs) > MaxRunHistory { // if there are too many runs...
+		runs = runs[len(runs)-MaxRunHistory:] // drop the oldest.
+	}
+	b, err := json.Marshal(runs)
+	if err != nil {
+		return err
+	}
+	return r.store.Write(collectionRuns, run.PipeName, b)
+}
+
+// ListRuns returns the run history of the pipe saved with name, oldest first.
+func (r *Registry) ListRuns(name string) ([]Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.listRuns(name)
+}
+
+func (r *Registry) listRuns(name string) ([]Run, error) {
+	runs := make([]Run, 0)
+	if !rexpPipeName.MatchString(name) {
+		return runs, nil
+	}
+	b, found, err := r.store.Read(collectionRuns, name)
+	if err != nil || !found {
+		return runs, err
+	}
+	if err = json.Unmarshal(b, &runs); err != nil {
+		return nil, fmt.Errorf("error reading run history of pipe %q from registry: %v", name, err)
+	}
+	return runs, nil
+}
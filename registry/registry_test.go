@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/transform"
+)
+
+func TestNewStore(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	db, _ := shared.NewMockConnectionWithMockTx(log, "mock")
+	// Test 1 - good specs.
+	for spec, expected := range map[string]interface{}{
+		"file:///tmp/registry": &FileStore{},
+		"table://schema.table": &DatabaseStore{},
+	} {
+		s, err := NewStore(spec, db)
+		if err != nil {
+			t.Fatalf("unexpected error for spec %v: %v", spec, err)
+		}
+		if reflect.TypeOf(s) != reflect.TypeOf(expected) {
+			t.Fatalf("expected %T for spec %v; got %T", expected, spec, s)
+		}
+	}
+	// Test 2 - bad specs.
+	for _, spec := range []string{"/tmp/no-scheme", "file://", "table://bad;table", "s3://bucket/prefix"} {
+		if _, err := NewStore(spec, db); err == nil {
+			t.Fatalf("expected error for spec %v", spec)
+		}
+	}
+	// Test 3 - database store requires a connection.
+	if _, err := NewStore("table://t", nil); err == nil {
+		t.Fatal("expected error for missing database connection")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "halfpipe-registry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := NewRegistry(NewFileStore(dir))
+	// Test 1 - bad pipes are rejected.
+	for _, p := range []Pipe{{Name: "../etc"}, {Name: ""}, {Name: "p", Schedule: "every day"}} {
+		if err := r.SavePipe(p); err == nil {
+			t.Fatalf("expected error saving pipe %+v", p)
+		}
+	}
+	// Test 2 - save, get and list.
+	for _, name := range []string{"b", "a"} {
+		p := Pipe{Name: name, Schedule: "0 2 * * *", Transform: transform.TransformDefinition{Type: transform.TransformOnce}}
+		if err := r.SavePipe(p); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+	p, found, err := r.GetPipe("a")
+	if err != nil || !found || p.Schedule != "0 2 * * *" || p.Transform.Type != transform.TransformOnce || p.UpdateTime.IsZero() {
+		t.Fatalf("unexpected pipe %+v, found = %v, err = %v", p, found, err)
+	}
+	pipes, err := r.ListPipes()
+	if err != nil || len(pipes) != 2 || pipes[0].Name != "a" || pipes[1].Name != "b" {
+		t.Fatalf("unexpected pipes %+v, err = %v", pipes, err)
+	}
+	// Test 3 - run history is updated by GUID and trimmed.
+	MaxRunHistory = 2
+	defer func() { MaxRunHistory = 100 }()
+	for _, run := range []Run{
+		{PipeId: "1", PipeName: "a", Status: transform.StatusRunning},
+		{PipeId: "1", PipeName: "a", Status: transform.StatusComplete},
+		{PipeId: "2", PipeName: "a", Status: transform.StatusCompleteWithError, Error: "failed"},
+		{PipeId: "3", PipeName: "a", Status: transform.StatusRunning},
+	} {
+		if err := r.SaveRun(run); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+	runs, err := r.ListRuns("a")
+	if err != nil || len(runs) != 2 || runs[0].PipeId != "2" || runs[0].Status != transform.StatusCompleteWithError || runs[1].PipeId != "3" {
+		t.Fatalf("unexpected runs %+v, err = %v", runs, err)
+	}
+	// Test 4 - delete removes the pipe and its history.
+	if found, err := r.DeletePipe("a"); err != nil || !found {
+		t.Fatalf("unexpected delete result found = %v, err = %v", found, err)
+	}
+	if found, err := r.DeletePipe("a"); err != nil || found {
+		t.Fatalf("expected pipe to be gone; got found = %v, err = %v", found, err)
+	}
+	if runs, err := r.ListRuns("a"); err != nil || len(runs) != 0 {
+		t.Fatalf("expected no runs; got %+v, err = %v", runs, err)
+	}
+}
+
+func TestDatabaseStoreWrite(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	// Test 1 - values are bound using the database's syntax.
+	db, out := shared.NewMockConnectionWithMockTx(log, "postgres")
+	s := NewDatabaseStore(db, "s.registry")
+	if err := s.Write("pipes", "p", []byte(`{"name":"it's"}`)); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := []string{
+		"delete from s.registry where registry_collection = $1 and registry_name = $2",
+		"pipes p",
+		"insert into s.registry (registry_collection, registry_name, registry_value) values ($1, $2, $3)",
+		`pipes p {"name":"it's"}`,
+	}
+	for _, e := range expected {
+		if got := <-out; got != e {
+			t.Fatalf("expected %q; got %q", e, got)
+		}
+	}
+	// Test 2 - large Oracle values are appended in chunks.
+	db, out = shared.NewMockConnectionWithMockTx(log, "oracle")
+	s = NewDatabaseStore(db, "s.registry")
+	value := strings.Repeat("a", oracleMaxBindBytes-1) + "é" + strings.Repeat("b", 10)
+	if err := s.Write("pipes", "p", []byte(value)); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected = []string{
+		"delete from s.registry where registry_collection = :1 and registry_name = :2",
+		"pipes p",
+		"insert into s.registry (registry_collection, registry_name, registry_value) values (:1, :2, :3)",
+		"pipes p " + strings.Repeat("a", oracleMaxBindBytes-1),
+		"update s.registry set registry_value = registry_value || :1 where registry_collection = :2 and registry_name = :3",
+		"é" + strings.Repeat("b", 10) + " pipes p",
+	}
+	for _, e := range expected {
+		if got := <-out; got != e {
+			t.Fatalf("expected %q; got %q", e, got)
+		}
+	}
+}
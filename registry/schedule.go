@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression of the form "minute hour day-of-month month day-of-week".
+// Each field is a "*" or a comma separated list of numbers and ranges with an optional "/step", for example
+// "0 2 * * *" is every day at 02:00 and "*/15 9-17 * * 1-5" is every 15 minutes during office hours.
+// Descriptors @hourly, @daily, @midnight, @weekly, @monthly, @yearly and @annually are also supported.
+// Times are evaluated in the local time zone of the server.
+type Schedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64 // bit n is set if value n matches
+	dayOfMonthStar, dayOfWeekStar              bool
+}
+
+var scheduleDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+var scheduleFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day-of-month", 1, 31},
+	{"month", 1, 12},
+	{"day-of-week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// ParseSchedule parses cron expression spec. See Schedule for the supported syntax.
+func ParseSchedule(spec string) (*Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if d, ok := scheduleDescriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("bad schedule %q: expected %v fields: minute hour day-of-month month day-of-week", spec, len(scheduleFields))
+	}
+	bits := make([]uint64, len(fields))
+	for idx, f := range fields { // for each field...
+		b, err := parseScheduleField(f, scheduleFields[idx].min, scheduleFields[idx].max)
+		if err != nil {
+			return nil, fmt.Errorf("bad %v in schedule %q: %v", scheduleFields[idx].name, spec, err)
+		}
+		bits[idx] = b
+	}
+	s := &Schedule{
+		minute:         bits[0],
+		hour:           bits[1],
+		dayOfMonth:     bits[2],
+		month:          bits[3],
+		dayOfWeek:      bits[4],
+		dayOfMonthStar: strings.HasPrefix(fields[2], "*"),
+		dayOfWeekStar:  strings.HasPrefix(fields[4], "*"),
+	}
+	if s.dayOfWeek&(1<<7) > 0 { // if Sunday was supplied as 7...
+		s.dayOfWeek |= 1
+	}
+	return s, nil
+}
+
+// parseScheduleField returns a bit set of the values matched by cron field f, where values must be between min and max.
+func parseScheduleField(f string, min int, max int) (bits uint64, err error) {
+	for _, item := range strings.Split(f, ",") { // for each list item...
+		rng, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 { // if there is a step...
+			rng = item[:idx]
+			if step, err = strconv.Atoi(item[idx+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("bad step in %q", item)
+			}
+		}
+		lo, hi := min, max
+		if rng != "*" { // if there is a number or range...
+			bounds := strings.SplitN(rng, "-", 2)
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("bad value in %q", item)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("bad value in %q", item)
+				}
+			} else if step > 1 { // else if there is a step after a single number, it runs to max...
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside the range %v-%v", item, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t that matches the schedule, or the zero time if there is none in the
+// next five years, which can happen for dates like 31 February.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := time.Local
+	t = t.In(loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc) // round up to the next whole minute
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron in that a day matches either day field when both are restricted,
+// otherwise it must match both.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dayOfMonth&(1<<uint(t.Day())) > 0
+	dow := s.dayOfWeek&(1<<uint(t.Weekday())) > 0
+	if !s.dayOfMonthStar && !s.dayOfWeekStar {
+		return dom || dow
+	}
+	return dom && dow
+}
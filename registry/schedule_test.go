@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2020, 1, 31, 10, 7, 30, 0, time.Local) // a Friday
+	for spec, expected := range map[string]time.Time{
+		"* * * * *":          time.Date(2020, 1, 31, 10, 8, 0, 0, time.Local),
+		"0 2 * * *":          time.Date(2020, 2, 1, 2, 0, 0, 0, time.Local),
+		"@daily":             time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local),
+		"*/15 9-17 * * 1-5":  time.Date(2020, 1, 31, 10, 15, 0, 0, time.Local),
+		"0 9 * * 1":          time.Date(2020, 2, 3, 9, 0, 0, 0, time.Local),
+		"0 9 * * 7":          time.Date(2020, 2, 2, 9, 0, 0, 0, time.Local), // 7 is Sunday.
+		"0 0 29 2 *":         time.Date(2020, 2, 29, 0, 0, 0, 0, time.Local),
+		"0 0 1 * 0":          time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local), // either day field matches.
+		"30 10,11 31 1,3 *":  time.Date(2020, 1, 31, 10, 30, 0, 0, time.Local),
+		"5/20 * * * *":       time.Date(2020, 1, 31, 10, 25, 0, 0, time.Local),
+		"0 0 31 2 *":         {}, // never.
+		"  @Hourly  ":        time.Date(2020, 1, 31, 11, 0, 0, 0, time.Local),
+		"7 10 31 1 *":        time.Date(2021, 1, 31, 10, 7, 0, 0, time.Local), // the current minute does not match.
+		"59 23 31 12 *":      time.Date(2020, 12, 31, 23, 59, 0, 0, time.Local),
+		"0-10/5 10 31 1 *":   time.Date(2020, 1, 31, 10, 10, 0, 0, time.Local),
+		"0 12 1-7 1,2,3 1-5": time.Date(2020, 1, 31, 12, 0, 0, 0, time.Local), // Friday matches although the 31st does not.
+	} {
+		s, err := ParseSchedule(spec)
+		if err != nil {
+			t.Fatalf("unexpected error for schedule %q: %v", spec, err)
+		}
+		if got := s.Next(from); !got.Equal(expected) {
+			t.Fatalf("expected next time for schedule %q to be %v; got %v", spec, expected, got)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@sometimes",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Fatalf("expected error for schedule %q", spec)
+		}
+	}
+}
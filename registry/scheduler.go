@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/transform"
+)
+
+// DefaultCheckInterval is how often the Scheduler looks for pipes that are due and runs that have finished.
+var DefaultCheckInterval = 10 * time.Second
+
+// LaunchFunc launches transform t without blocking, recording the name of the credential in submittedBy,
+// and returns its GUID.
+type LaunchFunc func(t *transform.TransformDefinition, submittedBy string) (guid string, err error)
+
+// Scheduler launches registered pipes according to their schedule and records their run history.
+type Scheduler struct {
+	log              logger.Logger
+	reg              *Registry
+	allTransformInfo *transform.SafeMapTransformInfo
+	launchFn         LaunchFunc
+	interval         time.Duration
+	mu               sync.Mutex
+	running          map[string]Run // map[pipe GUID]run of the transforms that we launched which are not finished
+	lastCheck        time.Time
+	chanStop         chan struct{}
+	wg               sync.WaitGroup
+}
+
+// NewScheduler returns a Scheduler that launches pipes saved in reg using launchFn and watches their status in
+// allTransformInfo, which launchFn must update.
+func NewScheduler(log logger.Logger, reg *Registry, allTransformInfo *transform.SafeMapTransformInfo, launchFn LaunchFunc) *Scheduler {
+	return &Scheduler{
+		log:              log,
+		reg:              reg,
+		allTransformInfo: allTransformInfo,
+		launchFn:         launchFn,
+		interval:         DefaultCheckInterval,
+		running:          make(map[string]Run),
+		chanStop:         make(chan struct{}),
+	}
+}
+
+// Start recovers runs that were interrupted by the server stopping and relaunches those of repeating pipes.
+// It then checks for pipes that are due every DefaultCheckInterval until Stop is called.
+func (s *Scheduler) Start() error {
+	if err := s.recover(); err != nil {
+		return err
+	}
+	s.lastCheck = time.Now()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.chanStop:
+				return
+			case now := <-ticker.C:
+				s.check(now)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends the scheduler loop without touching the pipes that are running. Any runs that are not finished are
+// left unfinished in the run history so they can be recovered by the next call to Start.
+func (s *Scheduler) Stop() {
+	close(s.chanStop)
+	s.wg.Wait()
+}
+
+// Launch runs the pipe saved with name now and records the run in its history along with the name of the
+// credential in submittedBy.
+func (s *Scheduler) Launch(name string, trigger string, submittedBy string) (Run, error) {
+	p, found, err := s.reg.GetPipe(name)
+	if err != nil {
+		return Run{}, err
+	}
+	if !found {
+		return Run{}, fmt.Errorf("pipe %q does not exist", name)
+	}
+	return s.launch(&p, trigger, submittedBy)
+}
+
+func (s *Scheduler) launch(p *Pipe, trigger string, submittedBy string) (Run, error) {
+	guid, err := s.launchFn(&p.Transform, submittedBy)
+	if err != nil {
+		return Run{}, fmt.Errorf("error launching pipe %q: %v", p.Name, err)
+	}
+	s.log.Info("Launched pipe ", p.Name, " as ", guid, " by ", trigger)
+	run := Run{PipeId: guid, PipeName: p.Name, Trigger: trigger, SubmittedBy: submittedBy, StartTime: time.Now(), Status: transform.StatusRunning}
+	s.mu.Lock()
+	s.running[guid] = run
+	s.mu.Unlock()
+	return run, s.reg.SaveRun(run)
+}
+
+// recover marks the unfinished runs found in the history of each pipe as shutdown and relaunches pipes of type
+// repeating that were interrupted.
+func (s *Scheduler) recover() error {
+	pipes, err := s.reg.ListPipes()
+	if err != nil {
+		return err
+	}
+	for idx := range pipes { // for each pipe...
+		p := &pipes[idx]
+		runs, err := s.reg.ListRuns(p.Name)
+		if err != nil {
+			return err
+		}
+		interrupted := false
+		for _, run := range runs { // for each run...
+			if !run.IsFinished() { // if the server stopped before the run did...
+				run.Status = transform.StatusShutdown
+				run.Error = "interrupted by server restart"
+				if err := s.reg.SaveRun(run); err != nil {
+					return err
+				}
+				interrupted = true
+			}
+		}
+		if interrupted && p.Transform.Type == transform.TransformRepeating {
+			if _, err := s.launch(p, TriggerRestart, SubmittedByScheduler); err != nil {
+				s.log.Error(err)
+			}
+		}
+	}
+	return nil
+}
+
+// check records the status of finished runs and launches pipes whose schedule was due between the last check and now.
+func (s *Scheduler) check(now time.Time) {
+	// Update the history of finished runs.
+	busy := make(map[string]bool) // map[pipe name]true if the pipe is still running
+	s.mu.Lock()
+	for guid, run := range s.running { // for each run...
+		ti, ok := s.allTransformInfo.Load(guid)
+		if ok && !ti.Status.TransformIsFinished() { // if the transform is still running...
+			busy[run.PipeName] = true
+			continue
+		}
+		delete(s.running, guid)
+		run.Status = ti.Status.Status
+		run.EndTime = ti.Status.EndTime
+		run.Error = ti.Status.Error
+		if !ok { // if the transform info has gone...
+			run.Status = transform.StatusMissing
+		}
+		if err := s.reg.SaveRun(run); err != nil {
+			s.log.Error("error saving run history of pipe ", run.PipeName, ": ", err)
+		}
+	}
+	s.mu.Unlock()
+	// Launch pipes that are due.
+	pipes, err := s.reg.ListPipes()
+	if err != nil {
+		s.log.Error("error reading pipe registry: ", err)
+		return
+	}
+	for idx := range pipes { // for each pipe...
+		p := &pipes[idx]
+		if p.Schedule == "" {
+			continue
+		}
+		sched, err := ParseSchedule(p.Schedule)
+		if err != nil {
+			s.log.Error(err)
+			continue
+		}
+		next := sched.Next(s.lastCheck)
+		if next.IsZero() || next.After(now) { // if the pipe is not due...
+			continue
+		}
+		if busy[p.Name] { // if the previous run has not finished...
+			s.log.Warn("Skipping scheduled run of pipe ", p.Name, " since the previous run is not finished")
+			continue
+		}
+		if _, err := s.launch(p, TriggerSchedule, SubmittedByScheduler); err != nil {
+			s.log.Error(err)
+		}
+	}
+	s.lastCheck = now
+}
@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/transform"
+)
+
+func TestScheduler(t *testing.T) {
+	log := logger.NewLogger("test", "error", true)
+	dir, err := ioutil.TempDir("", "halfpipe-scheduler-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := NewRegistry(NewFileStore(dir))
+	ti := transform.NewSafeMapTransformInfo()
+	launched := make([]string, 0)
+	launchFn := func(td *transform.TransformDefinition, submittedBy string) (string, error) {
+		guid := strconv.Itoa(len(launched))
+		launched = append(launched, td.Description)
+		ti.Store(guid, transform.TransformInfo{Status: transform.TransformStatus{Status: transform.StatusRunning}})
+		return guid, nil
+	}
+	for _, p := range []Pipe{
+		{Name: "nightly", Schedule: "0 2 * * *", Transform: transform.TransformDefinition{Description: "nightly", Type: transform.TransformOnce}},
+		{Name: "adhoc", Transform: transform.TransformDefinition{Description: "adhoc", Type: transform.TransformOnce}},
+		{Name: "stream", Transform: transform.TransformDefinition{Description: "stream", Type: transform.TransformRepeating}},
+	} {
+		if err := r.SavePipe(p); err != nil {
+			t.Fatal("unexpected error: ", err)
+		}
+	}
+	s := NewScheduler(log, r, ti, launchFn)
+	// Test 1 - nothing is due before 02:00.
+	s.lastCheck = time.Date(2020, 1, 1, 1, 59, 0, 0, time.Local)
+	s.check(time.Date(2020, 1, 1, 1, 59, 50, 0, time.Local))
+	if len(launched) != 0 {
+		t.Fatal("expected no launches; got: ", launched)
+	}
+	// Test 2 - the nightly pipe is launched at 02:00.
+	s.check(time.Date(2020, 1, 1, 2, 0, 5, 0, time.Local))
+	if len(launched) != 1 || launched[0] != "nightly" {
+		t.Fatal("expected nightly pipe to be launched; got: ", launched)
+	}
+	// Test 3 - a manual launch is recorded.
+	if _, err := s.Launch("adhoc", TriggerManual, "ops"); err != nil || len(launched) != 2 {
+		t.Fatalf("unexpected launches %v, err = %v", launched, err)
+	}
+	if _, err := s.Launch("missing", TriggerManual, "ops"); err == nil {
+		t.Fatal("expected error launching a pipe that does not exist")
+	}
+	// Test 4 - a run that is not finished blocks the next scheduled run.
+	s.lastCheck = time.Date(2020, 1, 2, 1, 59, 0, 0, time.Local)
+	s.check(time.Date(2020, 1, 2, 2, 0, 5, 0, time.Local))
+	if len(launched) != 2 {
+		t.Fatal("expected the nightly pipe to be skipped; got: ", launched)
+	}
+	// Test 5 - finished runs are saved to the history.
+	end := time.Date(2020, 1, 2, 3, 0, 0, 0, time.Local)
+	ti.Store("0", transform.TransformInfo{Status: transform.TransformStatus{Status: transform.StatusCompleteWithError, EndTime: end, Error: "failed"}})
+	s.check(time.Date(2020, 1, 2, 3, 0, 5, 0, time.Local))
+	runs, _ := r.ListRuns("nightly")
+	if len(runs) != 1 || runs[0].Trigger != TriggerSchedule || runs[0].SubmittedBy != SubmittedByScheduler || runs[0].Status != transform.StatusCompleteWithError || !runs[0].EndTime.Equal(end) || runs[0].Error != "failed" {
+		t.Fatalf("unexpected nightly runs %+v", runs)
+	}
+	runs, _ = r.ListRuns("adhoc")
+	if len(runs) != 1 || runs[0].Trigger != TriggerManual || runs[0].SubmittedBy != "ops" || runs[0].IsFinished() {
+		t.Fatalf("unexpected adhoc runs %+v", runs)
+	}
+	// Test 6 - after a restart the unfinished runs are closed and repeating pipes are relaunched.
+	if _, err := s.Launch("stream", TriggerManual, "ops"); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	launched = launched[:0]
+	ti = transform.NewSafeMapTransformInfo()
+	s = NewScheduler(log, r, ti, launchFn)
+	if err := s.recover(); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if len(launched) != 1 || launched[0] != "stream" {
+		t.Fatal("expected the repeating pipe to be relaunched; got: ", launched)
+	}
+	runs, _ = r.ListRuns("adhoc")
+	if len(runs) != 1 || runs[0].Status != transform.StatusShutdown || runs[0].Error == "" {
+		t.Fatalf("expected interrupted adhoc run; got %+v", runs)
+	}
+	runs, _ = r.ListRuns("stream")
+	if len(runs) != 2 || runs[0].Status != transform.StatusShutdown || runs[1].Trigger != TriggerRestart || runs[1].IsFinished() {
+		t.Fatalf("unexpected stream runs %+v", runs)
+	}
+}
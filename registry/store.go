@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+// Store saves documents, such as pipe definitions and their run history, by collection and name.
+type Store interface {
+	// Read returns the document saved for name in collection and found=false if there is none.
+	Read(collection string, name string) (value []byte, found bool, err error)
+	// Write saves value for name in collection, replacing any existing document.
+	Write(collection string, name string, value []byte) error
+	// Delete removes the document saved for name in collection. It is not an error if there is none.
+	Delete(collection string, name string) error
+	// List returns the sorted names of all documents in collection.
+	List(collection string) ([]string, error)
+}
+
+// NewStore returns the Store described by spec, which takes one of the following forms:
+// file://<directory> saves each document in a file under a sub-directory per collection.
+// table://[<schema>.]<table> saves each document in a row of the table using database connection db.
+// The table must have VARCHAR columns REGISTRY_COLLECTION, REGISTRY_NAME and REGISTRY_VALUE, where
+// REGISTRY_VALUE is large enough to hold the JSON of a pipe definition.
+func NewStore(spec string, db shared.Connector) (Store, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pipe registry %q: %v", spec, err)
+	}
+	switch u.Scheme {
+	case "file":
+		dir := u.Host + u.Path // support relative paths like file://dir as well as absolute ones like file:///dir
+		if dir == "" {
+			return nil, fmt.Errorf("missing directory in pipe registry %q", spec)
+		}
+		return NewFileStore(dir), nil
+	case "table":
+		table := u.Host + u.Path
+		if !rexpTableName.MatchString(table) {
+			return nil, fmt.Errorf("missing or bad table name in pipe registry %q", spec)
+		}
+		if db == nil {
+			return nil, fmt.Errorf("pipe registry %q requires a database connection", spec)
+		}
+		return NewDatabaseStore(db, table), nil
+	default:
+		return nil, fmt.Errorf("unsupported pipe registry %q: use file:// or table://", spec)
+	}
+}
+
+var rexpTableName = regexp.MustCompile(`^[A-Za-z0-9_$#]+(\.[A-Za-z0-9_$#]+)?$`)
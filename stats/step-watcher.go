@@ -28,6 +28,7 @@ type StepWatcher struct {
 	ticker          *time.Ticker
 	tickerDone      chan struct{}
 	isRunning       h.AtomBool
+	errorCountFn    func() int64 // optional func that returns the number of records rejected by the step.
 }
 
 type Stats struct {
@@ -39,6 +40,7 @@ type Stats struct {
 	RowsPerSecondAvg   int    `json:"rowsPerSecondAvg"`
 	RowsPerSecondDelta int    `json:"rowsPerSecondDelta"`
 	OutputBufferLen    int    `json:"outputBufferLen"`
+	ErrorCount         int    `json:"errorCount"`
 }
 
 func NewStepWatcher(log logger.Logger, stepName string) *StepWatcher {
@@ -73,6 +75,13 @@ func (n *StepWatcher) StartWatching(rowCountPtr *int64, chanPtr *chan stream.Rec
 	}()
 }
 
+// WatchErrors saves a function that returns the number of records rejected by the step so far,
+// which is included in the stats rendered by RenderStats().
+// Call this before the step starts.
+func (n *StepWatcher) WatchErrors(errorCountFn func() int64) {
+	n.errorCountFn = errorCountFn
+}
+
 func (n *StepWatcher) StopWatching() {
 	n.ticker.Stop()
 	n.tickerDone <- struct{}{} // stop the goroutine that calculates stats.
@@ -116,6 +125,10 @@ func (n *StepWatcher) RenderStats() Stats {
 		statusText = "complete"
 		statusEmoji = "\U00002705" // green tick
 	}
+	errorCount := int64(0)
+	if n.errorCountFn != nil {
+		errorCount = n.errorCountFn()
+	}
 	return Stats{
 		StepName:           n.stepName,
 		StatusText:         statusText,
@@ -125,6 +138,7 @@ func (n *StepWatcher) RenderStats() Stats {
 		RowsPerSecondAvg:   int(atomic.AddInt64(&n.rowsPerSecAvg, 0)),
 		RowsPerSecondDelta: int(atomic.AddInt64(&n.rowsPerSecDelta, 0)),
 		OutputBufferLen:    int(atomic.AddInt64(&n.chanLen, 0)),
+		ErrorCount:         int(errorCount),
 	}
 }
 
@@ -26,6 +26,7 @@ type TransformStatsManager struct {
 	mu                  sync.Mutex
 	log                 logger.Logger           // error|info|debug logging
 	mapStepStats        *ordered_map.OrderedMap // map containing StepWatcher{} details of all steps that we are gathering stats from.
+	statsHandlerFn      func(s []Stats)         // optional func to receive the stats each time they are dumped.
 }
 
 // SetStatsDumpFrequency returns a function that can be supplied as an option to constructor NewTransformStats().
@@ -36,6 +37,14 @@ func SetStatsDumpFrequency(seconds int) func(t *TransformStatsManager) {
 	}
 }
 
+// SetStatsHandler returns a function that can be supplied as an option to constructor NewTransformStats().
+// fn is called with the stats of all steps each time they are dumped.
+func SetStatsHandler(fn func(s []Stats)) func(t *TransformStatsManager) {
+	return func(t *TransformStatsManager) {
+		t.statsHandlerFn = fn
+	}
+}
+
 // Create a new TransformStatsManager struct.
 // Optionally supply func SetStatsDumpFrequency() to override the default stats dump frequency.
 func NewTransformStats(log logger.Logger, options ...func(t *TransformStatsManager)) *TransformStatsManager {
@@ -104,9 +113,12 @@ func (t *TransformStatsManager) StopDumping() {
 
 // Method to be called periodically to output stats of each registered transform node/step.
 func (t *TransformStatsManager) logStats() {
-	iter := t.mapStepStats.IterFunc()
-	for kv, ok := iter(); ok; kv, ok = iter() { // for each element in the map of transform steps...
-		t.log.Warn(kv.Value.(*StepWatcher).RenderStats().String())
+	s := t.GetStats()
+	for _, stepStats := range s { // for each transform step...
+		t.log.Warn(stepStats.String())
+	}
+	if t.statsHandlerFn != nil {
+		t.statsHandlerFn(s)
 	}
 }
 
@@ -74,6 +74,11 @@ func (sr Record) SetData(name string, value interface{}) {
 	sr.data[name] = value
 }
 
+// DeleteData removes the field name from the record, if it exists.
+func (sr Record) DeleteData(name string) {
+	delete(sr.data, name)
+}
+
 func (sr Record) GetData(name string) interface{} {
 	val, ok := sr.data[name]
 	if !ok {
@@ -44,3 +44,14 @@ func TestRecord_GetSortedDataMapKeys(t *testing.T) {
 		t.Fatalf("TestRecord_GetSortedDataMapKeys failed: expected = %v; got = %v", expected, got)
 	}
 }
+
+func TestRecord_DeleteData(t *testing.T) {
+	r1 := NewRecord()
+	r1.SetData("keyA", "valueA")
+	r1.SetData("keyB", nil)
+	r1.DeleteData("keyB")
+	r1.DeleteData("missing") // expect no panic.
+	if !reflect.DeepEqual(r1.GetDataMap(), map[string]interface{}{"keyA": "valueA"}) {
+		t.Fatalf("TestRecord_DeleteData: unexpected data after delete: %v", r1.GetDataMap())
+	}
+}
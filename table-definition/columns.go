@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/file"
 	"github.com/relloyd/halfpipe/helper"
 	"github.com/relloyd/halfpipe/logger"
 	"github.com/relloyd/halfpipe/rdbms"
@@ -16,6 +17,9 @@ import (
 
 type mapTabDefinitionConfigT map[string]tabDefinitionConfigT
 
+// ErrNoColumnMetadata is returned by GetTableDefinition when the table does not exist.
+var ErrNoColumnMetadata = errors.New("no column metadata found")
+
 // tabDefinitionConfig contains SQL statements able to get table definition data for each connection type
 // where the connection type string matches that stored in shared.ConnectionDetails -> Type.
 // The type prefix "odbc+" should not be used here.
@@ -100,6 +104,26 @@ var tabDefinitionConfig = mapTabDefinitionConfigT{
 							order by table_schema, table_name, column_id`,
 		fnGetMapper: NewNetezzaToSnowflakeDataTypeMapper,
 	},
+	constants.ConnectionTypePostgres: {
+		// Postgres folds unquoted identifiers to lower case so quote the column aliases.
+		withSchema: `select table_schema as "OWNER", table_name as "TABLE_NAME", column_name as "COLUMN_NAME", data_type as "DATA_TYPE",
+							coalesce(character_maximum_length, datetime_precision) as "DATA_LENGTH",
+							numeric_precision as "DATA_PRECISION", numeric_scale as "DATA_SCALE", is_nullable as "NULLABLE",
+							ordinal_position as "COLUMN_ID"
+							from information_schema.columns
+							where table_schema = lower($1)
+							and table_name = lower($2)
+							order by table_schema, table_name, ordinal_position`,
+		withoutSchema: `select table_schema as "OWNER", table_name as "TABLE_NAME", column_name as "COLUMN_NAME", data_type as "DATA_TYPE",
+							coalesce(character_maximum_length, datetime_precision) as "DATA_LENGTH",
+							numeric_precision as "DATA_PRECISION", numeric_scale as "DATA_SCALE", is_nullable as "NULLABLE",
+							ordinal_position as "COLUMN_ID"
+							from information_schema.columns
+							where table_schema = current_schema()
+							and table_name = lower($1)
+							order by table_name, ordinal_position`,
+		fnGetMapper: NewPostgresToSnowflakeDataTypeMapper,
+	},
 }
 
 // Mapper Map takes and input and returns it's equivalent output.
@@ -230,7 +254,7 @@ func GetTableDefinition(log logger.Logger, fnGetColumns GetColumnsFuncT, srcSche
 	}
 	con.Close() // TODO: refactor TableDefinitionToChan to remove use of chan so we can close connections more cleanly.
 	if len(tabCols.Columns) == 0 {
-		err = fmt.Errorf("no column metadata found for table %q", srcSchemaTable.SchemaTable)
+		err = fmt.Errorf("%w for table %q", ErrNoColumnMetadata, srcSchemaTable.SchemaTable)
 		return
 	}
 	return
@@ -323,9 +347,12 @@ func ColumnIsNumberOrDate(log logger.Logger, fnGetColumns GetColumnsFuncT, mappe
 	o, c := fnGetColumns(log, schemaTable.SchemaTable)
 	defer c.Close()
 	columnNameUpper := helper.ToUpperQuotedIfNotQuoted([]string{columnName})
+	columnNameIsQuoted := strings.HasPrefix(columnName, `"`)
 	for rec := range o { // for each output record containing a column/field definition...
 		// Read the record COLUMN_NAME field as a string
-		if rec.GetDataAsStringPreserveTimeZone(log, "COLUMN_NAME") == strings.Trim(columnNameUpper[0], `"`) { // if we have found the column by name...
+		recColumnName := rec.GetDataAsStringPreserveTimeZone(log, "COLUMN_NAME")
+		if recColumnName == strings.Trim(columnNameUpper[0], `"`) ||
+			(!columnNameIsQuoted && strings.EqualFold(recColumnName, columnName)) { // if we have found the column by name (unquoted names match any case, since Postgres folds them to lower case)...
 			// Check the data type.
 			dt := rec.GetDataAsStringPreserveTimeZone(log, "DATA_TYPE")
 			if mapper.GetDeltaDataType(dt) == DeltaTypeNumber {
@@ -342,3 +369,65 @@ func ColumnIsNumberOrDate(log logger.Logger, fnGetColumns GetColumnsFuncT, mappe
 	err = fmt.Errorf("column %q not found in table/view %q", columnName, schemaTable.SchemaTable)
 	return
 }
+
+// GetTableParquetFieldTypes fetches the set of columns that comprise a given [SCHEMA.]TABLE and returns
+// a CSV of <column>:<parquet type> tokens, in column order, ready for use by the ParquetFileWriter component.
+// Whole numbers that fit in 18 digits are INT64, floating point numbers are DOUBLE, other numbers with a known
+// precision are DECIMAL, dates are TIMESTAMP and all other columns are strings, including numbers of unknown
+// precision so no digits are lost.
+func GetTableParquetFieldTypes(log logger.Logger, fnGetColumns GetColumnsFuncT, mapper Mapper, schemaTable *rdbms.SchemaTable) (string, error) {
+	r, c := fnGetColumns(log, schemaTable.SchemaTable)
+	defer c.Close()
+	tokens := make([]string, 0)
+	for rec := range r { // for each record containing a column definition...
+		col := TableColumn{
+			ColName:  rec.GetDataAsStringPreserveTimeZone(log, "COLUMN_NAME"),
+			DataType: rec.GetDataAsStringPreserveTimeZone(log, "DATA_TYPE"),
+		}
+		m := rec.GetDataMap()
+		if v := m["DATA_PRECISION"]; v != nil {
+			col.DataPrecision, _ = strconv.Atoi(rec.GetDataAsStringPreserveTimeZone(log, "DATA_PRECISION"))
+		}
+		if v := m["DATA_SCALE"]; v != nil {
+			col.DataScale, _ = strconv.Atoi(rec.GetDataAsStringPreserveTimeZone(log, "DATA_SCALE"))
+		}
+		tokens = append(tokens, fmt.Sprintf("%v:%v", col.ColName, getParquetType(mapper, col)))
+	}
+	if len(tokens) == 0 { // if no columns were found (bad table name)...
+		return "", fmt.Errorf("no columns found for object %q", schemaTable.SchemaTable)
+	}
+	return strings.Join(tokens, ","), nil
+}
+
+// parquetFloatTypes are the Snowflake data types, as returned by Mapper.Map, that hold floating point numbers.
+var parquetFloatTypes = map[string]bool{
+	"float":            true,
+	"float4":           true,
+	"float8":           true,
+	"double":           true,
+	"double precision": true,
+	"real":             true,
+}
+
+// getParquetType returns the file.ParquetType* that can hold values of col.
+func getParquetType(mapper Mapper, col TableColumn) string {
+	switch mapper.GetDeltaDataType(col.DataType) {
+	case DeltaTypeNumber:
+		switch {
+		case parquetFloatTypes[strings.ToLower(mapper.Map(col.DataType))]: // if the number is floating point...
+			return file.ParquetTypeDouble
+		case col.DataPrecision <= 0 || col.DataScale < 0: // if the precision is unknown or the scale can't be used by a DECIMAL...
+			return file.ParquetTypeString
+		case col.DataScale == 0 && col.DataPrecision <= 18: // if the number is a whole number that fits in an int64...
+			return file.ParquetTypeInt64
+		case col.DataPrecision <= file.ParquetMaxDecimalPrecision:
+			return file.ParquetDecimalType(col.DataPrecision, col.DataScale)
+		default: // else the number is too big for a DECIMAL...
+			return file.ParquetTypeString
+		}
+	case DeltaTypeDateTime:
+		return file.ParquetTypeTimestamp
+	default:
+		return file.ParquetTypeString
+	}
+}
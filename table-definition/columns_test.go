@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/relloyd/halfpipe/constants"
+	"github.com/relloyd/halfpipe/file"
 	"github.com/relloyd/halfpipe/logger"
 	"github.com/relloyd/halfpipe/rdbms"
 	"github.com/relloyd/halfpipe/rdbms/shared"
@@ -464,3 +465,52 @@ func TestColumnIsNumberOrDate(t *testing.T) {
 // TODO: test getMapper
 // TODO: test getRecord
 // TODO: Test TabDefinitionToChan
+
+func TestGetTableParquetFieldTypes(t *testing.T) {
+	log := logger.NewLogger("test-halfpipe", "info", false)
+	var db *shared.MockConnectionWithMockTx
+	// Create a mock list of columns.
+	fnGetColsMock := func(log logger.Logger, schemaTable string) (chan stream.Record, shared.Connector) {
+		records := make(chan stream.Record, 100)
+		conn, _ := shared.NewMockConnectionWithMockTx(log, constants.ConnectionTypeOracle)
+		db = conn.(*shared.MockConnectionWithMockTx)
+		for _, c := range []struct {
+			name, dataType   string
+			precision, scale interface{}
+		}{
+			{"ID", "NUMBER", 10, 0},
+			{"AMOUNT", "NUMBER", 10, 2},
+			{"BIG", "NUMBER", nil, nil},
+			{"HUGE", "NUMBER", 30, 0},
+			{"WIDE", "NUMBER", 40, 2},
+			{"CREATED", "DATE", nil, nil},
+			{"NAME", "VARCHAR2", nil, nil},
+		} {
+			r := stream.NewRecord()
+			r.SetData("COLUMN_NAME", c.name)
+			r.SetData("DATA_TYPE", c.dataType)
+			r.SetData("DATA_PRECISION", c.precision)
+			r.SetData("DATA_SCALE", c.scale)
+			records <- r
+		}
+		close(records)
+		return records, conn
+	}
+	mapper := MustGetMapper(&shared.ConnectionDetails{Type: constants.ConnectionTypeOracle})
+	// Test 1 - columns are mapped to Parquet types in order.
+	got, err := GetTableParquetFieldTypes(log, fnGetColsMock, mapper, &rdbms.SchemaTable{SchemaTable: "test.table"})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	expected := "ID:int64,AMOUNT:decimal_10_2,BIG:string,HUGE:decimal_30_0,WIDE:string,CREATED:timestamp,NAME:string"
+	if got != expected {
+		t.Fatalf("expected field types: %v; got: %v", expected, got)
+	}
+	if !db.DbHasBeenClosed {
+		t.Fatal("expected GetTableParquetFieldTypes to close the mock db")
+	}
+	// Test 2 - floating point numbers are doubles whatever their precision.
+	if got := getParquetType(NewPostgresToSnowflakeDataTypeMapper(), TableColumn{DataType: "double precision", DataPrecision: 53}); got != file.ParquetTypeDouble {
+		t.Fatalf("expected double precision to be %v; got: %v", file.ParquetTypeDouble, got)
+	}
+}
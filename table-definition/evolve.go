@@ -0,0 +1,130 @@
+package tabledefinition
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms"
+)
+
+const (
+	snowflakeMaxVarcharLen     = 16777216
+	snowflakeDefaultPrecision  = 38
+	snowflakeBaseTypeText      = "text"
+	snowflakeBaseTypeNumber    = "number"
+	snowflakeBaseTypeFloat     = "float"
+	snowflakeBaseTypeTimestamp = "timestamp_ntz"
+)
+
+// snowflakeBaseTypes maps Snowflake data type synonyms to the base type reported by information_schema.columns.
+var snowflakeBaseTypes = map[string]string{
+	"varchar":          snowflakeBaseTypeText,
+	"char":             snowflakeBaseTypeText,
+	"character":        snowflakeBaseTypeText,
+	"string":           snowflakeBaseTypeText,
+	"text":             snowflakeBaseTypeText,
+	"number":           snowflakeBaseTypeNumber,
+	"numeric":          snowflakeBaseTypeNumber,
+	"decimal":          snowflakeBaseTypeNumber,
+	"int":              snowflakeBaseTypeNumber,
+	"integer":          snowflakeBaseTypeNumber,
+	"bigint":           snowflakeBaseTypeNumber,
+	"smallint":         snowflakeBaseTypeNumber,
+	"tinyint":          snowflakeBaseTypeNumber,
+	"byteint":          snowflakeBaseTypeNumber,
+	"float":            snowflakeBaseTypeFloat,
+	"float4":           snowflakeBaseTypeFloat,
+	"float8":           snowflakeBaseTypeFloat,
+	"double":           snowflakeBaseTypeFloat,
+	"double precision": snowflakeBaseTypeFloat,
+	"real":             snowflakeBaseTypeFloat,
+	"datetime":         snowflakeBaseTypeTimestamp,
+	"timestamp":        snowflakeBaseTypeTimestamp,
+	"varbinary":        "binary",
+}
+
+var reDataTypeArgs = regexp.MustCompile(`^([^(]+)(?:\((\d+)(?:,\s*(\d+))?\))?$`)
+
+// SchemaChanges lists the differences between a source table definition and an existing Snowflake table.
+type SchemaChanges struct {
+	Statements   []string // ALTER TABLE statements that add new columns or widen existing ones.
+	Incompatible []string // descriptions of column changes that ALTER TABLE can't make.
+}
+
+// GetSnowflakeSchemaChanges compares the source table definition srcCols with the existing Snowflake table
+// definition tgtCols and returns the ALTER TABLE statements required to evolve snowSchemaTable so it can hold
+// the source data. The mapper converts source data types to Snowflake ones, as per
+// ConvertTableDefinitionToSnowflake().
+// New columns are added as nullable since the table may contain rows already.
+// VARCHAR lengths and NUMBER precisions are widened, while all other type changes are reported as incompatible.
+// Columns found in the target only are left alone.
+func GetSnowflakeSchemaChanges(log logger.Logger, srcCols TableColumns, tgtCols TableColumns, snowSchemaTable rdbms.SchemaTable, mapper Mapper) (changes SchemaChanges) {
+	if snowSchemaTable.SchemaTable == "" {
+		snowSchemaTable.SchemaTable = tgtCols.TableName
+	}
+	tgtByName := make(map[string]TableColumn)
+	for _, col := range tgtCols.Columns { // for each target column...
+		tgtByName[strings.ToUpper(col.ColName)] = col // Snowflake stores unquoted column names in upper case.
+	}
+	for _, col := range srcCols.Columns { // for each source column...
+		srcType := mapper.Map(col.DataType) + mapper.Sanitise(col.DataType, col.DataLen, col.DataPrecision, col.DataScale)
+		tgt, ok := tgtByName[strings.ToUpper(col.ColName)]
+		if !ok { // if the column is new...
+			changes.Statements = append(changes.Statements, fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v", snowSchemaTable.SchemaTable, col.ColName, srcType))
+			continue
+		}
+		srcBase, srcArg1, srcArg2 := parseSnowflakeDataType(srcType)
+		tgtBase, _, _ := parseSnowflakeDataType(tgt.DataType)
+		log.Debug("column = ", col.ColName, "; source type = ", srcType, "; target type = ", tgt.DataType,
+			"; target len = ", tgt.DataLen, "; target precision = ", tgt.DataPrecision, "; target scale = ", tgt.DataScale)
+		if srcBase != tgtBase { // if the types are not compatible...
+			changes.Incompatible = append(changes.Incompatible, fmt.Sprintf("column %v has type %v in the source and %v in the target", col.ColName, srcType, tgt.DataType))
+			continue
+		}
+		switch srcBase {
+		case snowflakeBaseTypeText:
+			srcLen := srcArg1
+			if srcLen <= 0 { // if there is no length...
+				srcLen = snowflakeMaxVarcharLen
+			}
+			if tgt.DataLen > 0 && srcLen > tgt.DataLen { // if the target is too short...
+				changes.Statements = append(changes.Statements, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET DATA TYPE VARCHAR(%v)", snowSchemaTable.SchemaTable, col.ColName, srcLen))
+			}
+		case snowflakeBaseTypeNumber:
+			srcPrecision, srcScale := srcArg1, srcArg2
+			if srcPrecision <= 0 { // if there is no precision...
+				srcPrecision, srcScale = snowflakeDefaultPrecision, 0
+			}
+			tgtPrecision := tgt.DataPrecision
+			if tgtPrecision <= 0 {
+				tgtPrecision = snowflakeDefaultPrecision
+			}
+			if srcScale != tgt.DataScale { // if the scale has changed...
+				// Snowflake can't change the scale of a NUMBER column.
+				changes.Incompatible = append(changes.Incompatible, fmt.Sprintf("column %v has scale %v in the source and %v in the target", col.ColName, srcScale, tgt.DataScale))
+			} else if srcPrecision > tgtPrecision { // else if the target precision is too small...
+				changes.Statements = append(changes.Statements, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET DATA TYPE NUMBER(%v,%v)", snowSchemaTable.SchemaTable, col.ColName, srcPrecision, srcScale))
+			}
+		}
+	}
+	return
+}
+
+// parseSnowflakeDataType splits a data type like "varchar(10)" or "number(10,2)" into its lower case base type,
+// as per snowflakeBaseTypes, and its arguments. Missing arguments are returned as 0.
+func parseSnowflakeDataType(dataType string) (base string, arg1 int, arg2 int) {
+	m := reDataTypeArgs.FindStringSubmatch(strings.ToLower(strings.TrimSpace(dataType)))
+	if m == nil {
+		return strings.ToLower(dataType), 0, 0
+	}
+	base = strings.TrimSpace(m[1])
+	if b, ok := snowflakeBaseTypes[base]; ok {
+		base = b
+	}
+	arg1, _ = strconv.Atoi(m[2])
+	arg2, _ = strconv.Atoi(m[3])
+	return
+}
@@ -0,0 +1,60 @@
+package tabledefinition
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms"
+)
+
+func TestGetSnowflakeSchemaChanges(t *testing.T) {
+	log := logger.NewLogger("halfpipe", "info", true)
+	src := TableColumns{TableName: "T1", Columns: []TableColumn{
+		{ColName: "ID", DataType: "NUMBER", DataPrecision: 12, DataScale: 0},
+		{ColName: "NAME", DataType: "VARCHAR2", DataLen: 100},
+		{ColName: "CODE", DataType: "VARCHAR2", DataLen: 10},
+		{ColName: "AMOUNT", DataType: "NUMBER", DataPrecision: 10, DataScale: 4},
+		{ColName: "CREATED", DataType: "DATE"},
+		{ColName: "NOTES", DataType: "VARCHAR2", DataLen: 20},
+		{ColName: "NEW_COL", DataType: "NUMBER"},
+	}}
+	tgt := TableColumns{TableName: "T1", Columns: []TableColumn{
+		{ColName: "ID", DataType: "NUMBER", DataPrecision: 10, DataScale: 0},
+		{ColName: "NAME", DataType: "TEXT", DataLen: 50},
+		{ColName: "CODE", DataType: "TEXT", DataLen: 20},
+		{ColName: "AMOUNT", DataType: "NUMBER", DataPrecision: 10, DataScale: 2},
+		{ColName: "CREATED", DataType: "TIMESTAMP_TZ", DataLen: 9},
+		{ColName: "NOTES", DataType: "NUMBER", DataPrecision: 38},
+		{ColName: "OLD_COL", DataType: "TEXT", DataLen: 10},
+	}}
+	changes := GetSnowflakeSchemaChanges(log, src, tgt, rdbms.SchemaTable{SchemaTable: "S1.T1"}, NewOracleToSnowflakeDataTypeMapper())
+	// Test 1 - new columns are added and shorter columns are widened.
+	expected := []string{
+		"ALTER TABLE S1.T1 ALTER COLUMN ID SET DATA TYPE NUMBER(12,0)",
+		"ALTER TABLE S1.T1 ALTER COLUMN NAME SET DATA TYPE VARCHAR(100)",
+		"ALTER TABLE S1.T1 ADD COLUMN NEW_COL number",
+	}
+	if !reflect.DeepEqual(changes.Statements, expected) {
+		t.Fatalf("unexpected statements: expected %v; got %v", expected, changes.Statements)
+	}
+	// Test 2 - scale and type changes are incompatible.
+	expected = []string{
+		"column AMOUNT has scale 4 in the source and 2 in the target",
+		"column NOTES has type varchar(20) in the source and NUMBER in the target",
+	}
+	if !reflect.DeepEqual(changes.Incompatible, expected) {
+		t.Fatalf("unexpected incompatible changes: expected %v; got %v", expected, changes.Incompatible)
+	}
+	// Test 3 - matching tables need no changes.
+	src.Columns = src.Columns[:3]
+	tgt.Columns = []TableColumn{
+		{ColName: "ID", DataType: "NUMBER", DataPrecision: 12, DataScale: 0},
+		{ColName: "name", DataType: "TEXT", DataLen: 100},
+		{ColName: "CODE", DataType: "TEXT", DataLen: 20},
+	}
+	changes = GetSnowflakeSchemaChanges(log, src, tgt, rdbms.SchemaTable{}, NewOracleToSnowflakeDataTypeMapper())
+	if len(changes.Statements) != 0 || len(changes.Incompatible) != 0 {
+		t.Fatal("expected no changes; got ", changes)
+	}
+}
@@ -53,6 +53,12 @@ func NewNetezzaToSnowflakeDataTypeMapper() Mapper {
 	return newDataTypeMapper(NetezzaToSnowflakeDataTypeMapping)
 }
 
+// NewPostgresToSnowflakeDataTypeMapper returns an instance of dataTypeMap{}
+// which implements interface Mapper.
+func NewPostgresToSnowflakeDataTypeMapper() Mapper {
+	return newDataTypeMapper(PostgresToSnowflakeDataTypeMapping)
+}
+
 // sanitiserFuncT converts data length, precision and scale into a string ready for use in CREATE TABLE DDL.
 type sanitiserFuncT func(dataLen, dataPrecision, dataScale int) string
 
@@ -235,6 +241,34 @@ var NetezzaToSnowflakeDataTypeMapping = []dataTypeLink{
 	{SourceDataType: "varchar", TargetDataType: "varchar", SanitiserFunc: sanitiseDataLen, DeltaDataType: DeltaTypeText},
 }
 
+// PostgresToSnowflakeDataTypeMapping contains a mapping of Postgres to Snowflake data types,
+// where source data types are those found in information_schema.columns.data_type.
+var PostgresToSnowflakeDataTypeMapping = []dataTypeLink{
+	{SourceDataType: "array", TargetDataType: "array", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified},
+	{SourceDataType: "bigint", TargetDataType: "bigint", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeNumber},
+	{SourceDataType: "boolean", TargetDataType: "boolean", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified},
+	{SourceDataType: "bytea", TargetDataType: "binary", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified},
+	{SourceDataType: "character", TargetDataType: "varchar", SanitiserFunc: sanitiseDataLen, DeltaDataType: DeltaTypeText},
+	{SourceDataType: "character varying", TargetDataType: "varchar", SanitiserFunc: sanitiseDataLen, DeltaDataType: DeltaTypeText},
+	{SourceDataType: "date", TargetDataType: "date", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeDateTime},
+	{SourceDataType: "double precision", TargetDataType: "float", SanitiserFunc: sanitiseDouble, DeltaDataType: DeltaTypeNumber},
+	{SourceDataType: "integer", TargetDataType: "integer", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeNumber},
+	{SourceDataType: "interval", TargetDataType: "varchar", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeText},
+	{SourceDataType: "json", TargetDataType: "variant", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified},
+	{SourceDataType: "jsonb", TargetDataType: "variant", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified},
+	{SourceDataType: "money", TargetDataType: "number", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeNumber},
+	{SourceDataType: "numeric", TargetDataType: "number", SanitiserFunc: sanitisePrecisionScale, DeltaDataType: DeltaTypeNumber},
+	{SourceDataType: "real", TargetDataType: "float", SanitiserFunc: sanitiseReal, DeltaDataType: DeltaTypeNumber},
+	{SourceDataType: "smallint", TargetDataType: "smallint", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeNumber},
+	{SourceDataType: "text", TargetDataType: "varchar", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeText},
+	{SourceDataType: "time with time zone", TargetDataType: "time", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified}, // Snowflake TIME has no time zone.
+	{SourceDataType: "time without time zone", TargetDataType: "time", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified},
+	{SourceDataType: "timestamp with time zone", TargetDataType: "timestamp_tz", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeDateTime},
+	{SourceDataType: "timestamp without time zone", TargetDataType: "timestamp_ntz", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeDateTime},
+	{SourceDataType: "uuid", TargetDataType: "varchar", SanitiserFunc: sanitiseUuid, DeltaDataType: DeltaTypeText},
+	{SourceDataType: "xml", TargetDataType: "varchar", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeText},
+}
+
 var SnowflakeToSnowflakeDataTypeMapping = []dataTypeLink{
 	{SourceDataType: "array", TargetDataType: "array", SanitiserFunc: sanitiseBlank, DeltaDataType: DeltaTypeUnclassified},
 	{SourceDataType: "bigint", TargetDataType: "bigint", SanitiserFunc: sanitiseDataLen, DeltaDataType: DeltaTypeNumber},
@@ -284,6 +318,10 @@ func sanitiseReal(dataLen, dataPrecision, dataScale int) string {
 	return "(24)"
 }
 
+func sanitiseUuid(dataLen, dataPrecision, dataScale int) string {
+	return "(36)"
+}
+
 func sanitiseTinyInt(dataLen, dataPrecision, dataScale int) string {
 	return "(3,0)"
 }
@@ -28,3 +28,19 @@ func TestSnowflakeToSnowflakeDataTypeMapping(t *testing.T) {
 		}
 	}
 }
+
+func TestPostgresToSnowflakeDataTypeMapper(t *testing.T) {
+	mapper := NewPostgresToSnowflakeDataTypeMapper()
+	if got := mapper.Map("TIMESTAMP WITH TIME ZONE"); got != "timestamp_tz" { // input types are case insensitive.
+		t.Fatalf("expected timestamp_tz; got %v", got)
+	}
+	if got := mapper.Sanitise("numeric", 0, 10, 2); got != "(10,2)" {
+		t.Fatalf("expected (10,2); got %v", got)
+	}
+	if got := mapper.GetDeltaDataType("timestamp without time zone"); got != DeltaTypeDateTime {
+		t.Fatalf("expected DeltaTypeDateTime; got %v", got)
+	}
+	if got := mapper.GetDeltaDataType("bigint"); got != DeltaTypeNumber {
+		t.Fatalf("expected DeltaTypeNumber; got %v", got)
+	}
+}
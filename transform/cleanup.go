@@ -37,14 +37,20 @@ func GetCleanupHandlerWithChannelsFunc(log logger.Logger, transformGuid string,
 		var e error
 		c := make(chan os.Signal, 1)
 		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-		select { // block until interrupt or shutdown request...
-		case x := <-c: // wait for interrupt...
-			fmt.Println()                   // add return char for a clean CLI look n feel.
-			log.Info("Caught ", x.String()) // log the interrupt.
-		case e = <-tc.chanShutdown: // OR wait for shutdown request (or channel closure)...
-			// Continue to shutdown...
-			if e != nil { // if there was an error...
-				log.Error(e) // log it now!
+		for waiting := true; waiting; {
+			select { // block until interrupt or shutdown request...
+			case x := <-c: // wait for interrupt...
+				fmt.Println()                   // add return char for a clean CLI look n feel.
+				log.Info("Caught ", x.String()) // log the interrupt.
+				waiting = false
+			case e = <-tc.chanShutdown: // OR wait for shutdown request (or channel closure)...
+				// Continue to shutdown...
+				if e != nil { // if there was an error...
+					log.Error(e) // log it now!
+				}
+				waiting = false
+			case a := <-tc.chanControl: // OR handle requests to pause or resume...
+				a.ResponseChan <- pauseOrResumeTransform(log, tm, tc, a.Action)
 			}
 		}
 		// TODO: issue: if a user sends ctrl-c before launch is complete then this shutdown call below will only cause those components that have launched so far to shutdown
@@ -65,6 +71,34 @@ func GetCleanupHandlerWithChannelsFunc(log logger.Logger, transformGuid string,
 	}
 }
 
+// pauseOrResumeTransform sends action components.Pause or components.Resume to all steps of tm and a status update
+// to tc if they all respond. If the pause fails, steps that did pause are resumed so the transform keeps running.
+func pauseOrResumeTransform(log logger.Logger, tm TransformManager, tc *TransformCloser, action components.Action) error {
+	switch action {
+	case components.Pause:
+		log.Info("Pausing transform ", tm.getTransformGuid(), "...")
+		if err := tm.pause(); err != nil {
+			log.Warn("Resuming transform ", tm.getTransformGuid(), " after failure to pause: ", err)
+			if errResume := tm.resume(); errResume != nil {
+				return fmt.Errorf("%v; error resuming after failure to pause: %v", err, errResume)
+			}
+			return err
+		}
+		tc.SendStatus(&TransformStatus{Status: StatusPaused})
+		log.Info("Paused transform ", tm.getTransformGuid())
+	case components.Resume:
+		log.Info("Resuming transform ", tm.getTransformGuid(), "...")
+		if err := tm.resume(); err != nil {
+			return err
+		}
+		tc.SendStatus(&TransformStatus{Status: StatusRunning})
+		log.Info("Resumed transform ", tm.getTransformGuid())
+	default:
+		return fmt.Errorf("unsupported control action %v", action)
+	}
+	return nil
+}
+
 // GetPanicHandlerWithChannelsFunc will create a func that can be deferred to handle recovery
 // and send the final TransformStatus{} error info to channel chanStatus.
 func GetPanicHandlerWithChannelsFunc(tc *TransformCloser) components.PanicHandlerFunc {
@@ -1,7 +1,6 @@
 package transform
 
 import (
-	"os"
 	"strconv"
 
 	"github.com/relloyd/halfpipe/components"
@@ -42,112 +41,6 @@ func startMetaDataInjection(log logger.Logger,
 	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
 }
 
-func startSqlExec(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	cfg := &components.SqlExecConfig{
-		Log:                      log,
-		Name:                     stepCanonicalName,
-		InputChan:                sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		SqlQueryFieldName:        sg.Steps[stepName].Data["sqlQueryFieldName"],
-		SqlRowsAffectedFieldName: sg.Steps[stepName].Data["sqlRowsAffectedFieldName"],
-		OutputDb:                 sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["databaseConnectionName"]),
-		StepWatcher:              stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:              sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:           panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)      // TODO: supply args from previous step as input here.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"]) // TODO: remove need to call consumeStep(); move this to getStepOutputChan()
-}
-
-func startTableInput(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Create and save the TableInput channel containing SQL results.
-	cfg := &components.SqlQueryWithArgsConfig{
-		Log:            log,
-		Name:           stepCanonicalName,
-		Db:             sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["databaseConnectionName"]),
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		Sqltext:        sg.Steps[stepName].Data["sqlText"],
-		Args:           nil,
-		PanicHandlerFn: panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)      // TODO: supply args from previous step as input here.
-}
-
-func startTableInputWithArgs(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(i interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Create and save the TableInput channel containing SQL results.
-	readDataFromStepName := sg.Steps[stepName].Data["readDataFromStep"]
-	log.Debug("Creating TableInputWithArgs (", stepName, ") reading data from step ", readDataFromStepName, "...")
-	cfg := &components.SqlQueryWithChanConfig{
-		Log:  log,
-		Name: stepCanonicalName,
-		Db:   sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["databaseConnectionName"]),
-		// InputChan:       sgm.mapOutputChans[readDataFromStepName],
-		InputChan:       sgm.getStepOutputChan(readDataFromStepName),
-		InputChanFields: helper.CsvToStringSliceTrimSpaces(sg.Steps[stepName].Data["readDataUsingFields"]),
-		Sqltext:         sg.Steps[stepName].Data["sqlText"],
-		StepWatcher:     stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:     sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:  panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)      // TODO: supply args from previous step as input here.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startTableInputWithReplacements(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Create and save the TableInput channel containing SQL results.
-	mapTokens, err := helper.CsvStringOfTokensToMap(log, sg.Steps[stepName].Data["replacements"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " unable to parse SQL string replacements: ", err)
-	}
-	cfg := &components.SqlQueryWithReplace{
-		Log:            log,
-		Name:           stepCanonicalName,
-		Db:             sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["databaseConnectionName"]),
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		Sqltext:        sg.Steps[stepName].Data["sqlText"],
-		Replacements:   mapTokens,
-		PanicHandlerFn: panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)      // TODO: supply args from previous step as input here.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
 func startTableInputCqnToRdbms(log logger.Logger,
 	stepName string,
 	stepCanonicalName string,
@@ -277,333 +170,6 @@ func startTableInputCqnToSnowflake(log logger.Logger,
 	sgm.setStepOutputChan(stepName, out)
 }
 
-func startMergeDiff(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Create and save the MergeDiff output channel.
-	outputIdenticalRows, _ := strconv.ParseBool(sg.Steps[stepName].Data["outputIdenticalRows"])
-	cfg := &components.MergeDiffConfig{
-		Log:                 log,
-		Name:                stepCanonicalName,
-		ChanOld:             sgm.getStepOutputChan(sg.Steps[stepName].Data["readOldDataFromStep"]),
-		ChanNew:             sgm.getStepOutputChan(sg.Steps[stepName].Data["readNewDataFromStep"]),
-		JoinKeys:            helper.TokensToOrderedMap(sg.Steps[stepName].Data["joinKeys"]),
-		CompareKeys:         helper.TokensToOrderedMap(sg.Steps[stepName].Data["compareKeys"]),
-		ResultFlagKeyName:   sg.Steps[stepName].Data["flagFieldName"],
-		OutputIdenticalRows: outputIdenticalRows,
-		StepWatcher:         stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:         sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:      panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readOldDataFromStep"])
-	sgm.consumeStep(sg.Steps[stepName].Data["readNewDataFromStep"])
-}
-
-func startTableSync(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Start a TableSync step using inputs.
-	commitBatchSize, err := strconv.Atoi(sg.Steps[stepName].Data["commitBatchSize"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " error extracting commitBatchSize from TableSync: ", err)
-	}
-	txtBatchNumRows, err := strconv.Atoi(sg.Steps[stepName].Data["txtBatchNumRows"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " error extracting txtBatchNumRows from TableSync: ", err)
-	}
-	cfg := &components.TableSyncConfig{
-		Log:             log,
-		Name:            stepCanonicalName,
-		CommitBatchSize: commitBatchSize,
-		TxtBatchNumRows: txtBatchNumRows,
-		FlagKeyName:     sg.Steps[stepName].Data["flagFieldName"],
-		InputChan:       sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		OutputDb:        sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["databaseConnectionName"]),
-		SqlStatementGeneratorConfig: shared.SqlStatementGeneratorConfig{
-			Log:             log,
-			OutputSchema:    sg.Steps[stepName].Data["outputSchemaName"],
-			SchemaSeparator: ".", // TODO: do we externalise this separator?
-			OutputTable:     sg.Steps[stepName].Data["outputTable"],
-			TargetKeyCols:   helper.TokensToOrderedMap(sg.Steps[stepName].Data["keyCols"]),
-			TargetOtherCols: helper.TokensToOrderedMap(sg.Steps[stepName].Data["otherCols"])},
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		PanicHandlerFn: panicHandlerFn}
-	// Create and save the TableSync output channel.
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startTableMerge(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Start a TableMerge step using inputs.
-	execBatchSize, err1 := strconv.Atoi(sg.Steps[stepName].Data["execBatchSize"])
-	if err1 != nil {
-		log.Panic(stepCanonicalName, " error extracting execBatchSize from TableMerge: ", err1)
-	}
-	commitBatchSize, err2 := strconv.Atoi(sg.Steps[stepName].Data["commitBatchSize"])
-	if err2 != nil {
-		log.Panic(stepCanonicalName, " error extracting commitBatchSize from TableMerge: ", err2)
-
-	}
-	cfg := &components.TableMergeConfig{
-		Log:             log,
-		Name:            stepCanonicalName,
-		InputChan:       sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		OutputDb:        sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["databaseConnectionName"]),
-		CommitBatchSize: commitBatchSize,
-		ExecBatchSize:   execBatchSize,
-		SqlStatementGeneratorConfig: shared.SqlStatementGeneratorConfig{
-			Log:             log,
-			SchemaSeparator: ".", // TODO: do we externalise this separator?
-			OutputSchema:    sg.Steps[stepName].Data["outputSchemaName"],
-			OutputTable:     sg.Steps[stepName].Data["outputTable"],
-			TargetKeyCols:   helper.TokensToOrderedMap(sg.Steps[stepName].Data["keyCols"]),
-			TargetOtherCols: helper.TokensToOrderedMap(sg.Steps[stepName].Data["otherCols"])},
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		PanicHandlerFn: panicHandlerFn}
-	// Create and save the TableSync output channel.
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startS3BucketList(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Set defaults if missing inputs.
-	if sg.Steps[stepName].Data["outputField4BucketName"] == "" {
-		sg.Steps[stepName].Data["outputField4BucketName"] = components.Defaults.ChanField4BucketName
-	}
-	if sg.Steps[stepName].Data["outputField4BucketPrefix"] == "" {
-		sg.Steps[stepName].Data["outputField4BucketPrefix"] = components.Defaults.ChanField4BucketPrefix
-	}
-	if sg.Steps[stepName].Data["outputField4BucketRegion"] == "" {
-		sg.Steps[stepName].Data["outputField4BucketRegion"] = components.Defaults.ChanField4BucketRegion
-	}
-	cfg := &components.S3BucketListerConfig{
-		Log:                               log,
-		Name:                              stepCanonicalName,
-		Region:                            sg.Steps[stepName].Data["bucketRegion"],
-		BucketName:                        sg.Steps[stepName].Data["bucketName"],
-		BucketPrefix:                      sg.Steps[stepName].Data["bucketPrefix"],
-		ObjectNamePrefix:                  sg.Steps[stepName].Data["fileNamePrefix"],
-		ObjectNameRegexp:                  sg.Steps[stepName].Data["fileNameRegexp"],
-		OutputField4BucketName:            sg.Steps[stepName].Data["outputField4BucketName"],
-		OutputField4BucketPrefix:          sg.Steps[stepName].Data["outputField4BucketPrefix"],
-		OutputField4BucketRegion:          sg.Steps[stepName].Data["outputField4BucketRegion"],
-		OutputField4FileName:              sg.Steps[stepName].Data["outputField4FileName"],
-		OutputField4FileNameWithoutPrefix: sg.Steps[stepName].Data["outputField4FileNameWithoutPrefix"],
-		StepWatcher:                       stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:                       sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:                    panicHandlerFn}
-	// Create and save new OS-files-to-S3 step.
-	out, control := componentFunc(cfg)
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	sgm.setStepOutputChan(stepName, out)
-}
-
-func startSnowflakeLoader(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Setup AUTOCOMMIT.
-	deleteAllRows := helper.GetTrueFalseStringAsBool(sg.Steps[stepName].Data["deleteAllRows"])
-	// Start step using inputs.
-	cfg := &components.SnowflakeLoaderConfig{
-		Log:                     log,
-		Name:                    stepCanonicalName,
-		InputChan:               sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		InputChanField4FileName: sg.Steps[stepName].Data["fieldName4FileName"],
-		Db:                      sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["logicalConnectionName"]),
-		TargetSchemaTableName:   rdbms.SchemaTable{SchemaTable: sg.Steps[stepName].Data["schemaTableName"]},
-		StageName:               sg.Steps[stepName].Data["stageName"],
-		DeleteAll:               deleteAllRows,
-		FnGetSnowflakeSqlSlice:  components.GetSqlSliceSnowflakeCopyInto,
-		StepWatcher:             stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:             sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:          panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startSnowflakeSync(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Setup AUTOCOMMIT.
-	// Start step using inputs.
-	cfg := &components.SnowflakeSyncConfig{
-		Log:                     log,
-		Name:                    stepCanonicalName,
-		InputChan:               sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		InputChanField4FileName: sg.Steps[stepName].Data["fieldName4FileName"],
-		Db:                      sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["logicalConnectionName"]),
-		TargetSchemaTableName:   rdbms.SchemaTable{SchemaTable: sg.Steps[stepName].Data["schemaTableName"]},
-		StageName:               sg.Steps[stepName].Data["stageName"],
-		FlagField:               sg.Steps[stepName].Data["flagFieldName"],
-		TargetOtherCols:         helper.TokensToOrderedMap(sg.Steps[stepName].Data["otherCols"]),
-		TargetKeyCols:           helper.TokensToOrderedMap(sg.Steps[stepName].Data["keyCols"]),
-		// TODO: CommitSequenceKeyName:
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		PanicHandlerFn: panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startSnowflakeMerge(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	// Setup AUTOCOMMIT.
-	// Start step using inputs.
-	cfg := &components.SnowflakeMergeConfig{
-		Log:                     log,
-		Name:                    stepCanonicalName,
-		InputChan:               sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		InputChanField4FileName: sg.Steps[stepName].Data["fieldName4FileName"],
-		Db:                      sgm.getGlobalTransformManager().getDBConnector(sg.Steps[stepName].Data["logicalConnectionName"]),
-		TargetSchemaTableName:   rdbms.SchemaTable{SchemaTable: sg.Steps[stepName].Data["schemaTableName"]},
-		StageName:               sg.Steps[stepName].Data["stageName"],
-		TargetOtherCols:         helper.TokensToOrderedMap(sg.Steps[stepName].Data["otherCols"]),
-		TargetKeyCols:           helper.TokensToOrderedMap(sg.Steps[stepName].Data["keyCols"]),
-		// TODO: CommitSequenceKeyName:
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		PanicHandlerFn: panicHandlerFn}
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startCSVFileWriter(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	maxFileBytes, err := strconv.Atoi(sg.Steps[stepName].Data["maxFileBytes"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " unable to convert maxFileBytes to integer - check it exists in the pipe config: ", err)
-	}
-	maxFileRows, err := strconv.Atoi(sg.Steps[stepName].Data["maxFileRows"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " unable to convert maxFileRows to integer - check it exists in the pipe config: ", err)
-	}
-	useGzip := helper.GetTrueFalseStringAsBool(sg.Steps[stepName].Data["useGzip"])
-	appendCreationStamp := helper.GetTrueFalseStringAsBool(sg.Steps[stepName].Data["fileNameSuffixAppendCreationStamp"])
-	// Set defaults if missing inputs.
-	if sg.Steps[stepName].Data["outputFieldName4FilePath"] == "" {
-		sg.Steps[stepName].Data["outputFieldName4FilePath"] = components.Defaults.ChanField4CSVFileName
-	}
-	// Setup component config.
-	cfg := &components.CsvFileWriterConfig{
-		Log:                               log,
-		Name:                              stepCanonicalName,
-		InputChan:                         sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		FileNamePrefix:                    sg.Steps[stepName].Data["fileNamePrefix"],
-		FileNameSuffixAppendCreationStamp: appendCreationStamp,
-		FileNameSuffixDateFormat:          sg.Steps[stepName].Data["fileNameSuffixDateTimeFormat"],
-		FileNameExtension:                 sg.Steps[stepName].Data["fileNameExtension"],
-		UseGzip:                           useGzip,
-		HeaderFields:                      helper.CsvToStringSliceTrimSpacesRemoveQuotes(sg.Steps[stepName].Data["headerFieldsCSV"]), // TODO: make this use a safe csv reader.
-		OutputDir:                         sg.Steps[stepName].Data["outputDir"],
-		MaxFileBytes:                      maxFileBytes,
-		MaxFileRows:                       maxFileRows,
-		OutputChanField4FilePath:          sg.Steps[stepName].Data["outputFieldName4FilePath"],
-		StepWatcher:                       stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:                       sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:                    panicHandlerFn}
-	// Create and save new CSV file generator step.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startCopyFilesToS3(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	removeInputFiles := helper.GetTrueFalseStringAsBool(sg.Steps[stepName].Data["removeInputFiles"])
-	cfg := &components.CopyFilesToS3Config{
-		Log:               log,
-		Name:              stepCanonicalName,
-		InputChan:         sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		FileNameChanField: sg.Steps[stepName].Data["inputFieldName4FilePath"],
-		BucketName:        sg.Steps[stepName].Data["bucketName"],
-		BucketPrefix:      sg.Steps[stepName].Data["bucketPrefix"],
-		Region:            sg.Steps[stepName].Data["bucketRegion"],
-		RemoveInputFiles:  removeInputFiles,
-		StepWatcher:       stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:       sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:    panicHandlerFn}
-	// Create and save new OS-files-to-S3 step.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
 func startChannelBridge(log logger.Logger,
 	stepName string,
 	stepCanonicalName string,
@@ -624,262 +190,6 @@ func startChannelBridge(log logger.Logger,
 	sgm.addBlockingStep(stepName, in)
 }
 
-func startChannelCombiner(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	cfg := &components.ChannelCombinerConfig{
-		Log:            log,
-		Name:           stepCanonicalName,
-		Chan1:          sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep1"]),
-		Chan2:          sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep2"]),
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		PanicHandlerFn: panicHandlerFn}
-	// Create and save new channel combiner step.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep1"])
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep2"])
-}
-
-func startManifestWriter(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	inputFieldName4FilePath := components.Defaults.ChanField4CSVFileName
-	if sg.Steps[stepName].Data["inputFieldName4FilePath"] != "" {
-		inputFieldName4FilePath = sg.Steps[stepName].Data["inputFieldName4FilePath"]
-	}
-	appendCreationStamp := helper.GetTrueFalseStringAsBool(sg.Steps[stepName].Data["fileNameSuffixAppendCreationStamp"])
-	cfg := &components.ManifestWriterConfig{
-		Log:                     log,
-		Name:                    stepCanonicalName,
-		InputChan:               sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		InputChanField4FilePath: inputFieldName4FilePath,
-		OutputDir:               sg.Steps[stepName].Data["outputDir"],
-		ManifestFileNamePrefix:  sg.Steps[stepName].Data["fileNamePrefix"],
-		ManifestFileNameSuffixAppendCreationStamp: appendCreationStamp,
-		ManifestFileNameSuffixDateFormat:          sg.Steps[stepName].Data["fileNameSuffixDateTimeFormat"],
-		ManifestFileNameExtension:                 sg.Steps[stepName].Data["fileNameExtension"],
-		OutputChanField4ManifestDir:               sg.Steps[stepName].Data["outputFieldName4ManifestDir"],
-		OutputChanField4ManifestName:              sg.Steps[stepName].Data["outputFieldName4ManifestName"],
-		OutputChanField4ManifestFullPath:          sg.Steps[stepName].Data["outputFieldName4ManifestFullPath"],
-		StepWatcher:                               stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:                               sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:                            panicHandlerFn}
-	// Create and save new manifest writer.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startManifestReader(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	cfg := &components.S3ManifestReaderConfig{
-		Log:                          log,
-		Name:                         stepCanonicalName,
-		InputChan:                    sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		InputChanField4ManifestName:  sg.Steps[stepName].Data["inputFieldName4ManifestName"],
-		BucketName:                   sg.Steps[stepName].Data["bucketName"],
-		BucketPrefix:                 sg.Steps[stepName].Data["bucketPrefix"],
-		Region:                       sg.Steps[stepName].Data["bucketRegion"],
-		OutputChanField4DataFileName: sg.Steps[stepName].Data["outputFieldName4DataFileName"],
-		StepWatcher:                  stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:                  sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:               panicHandlerFn}
-	// Create and save new manifest reader.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startDateRangeGenerator(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	intervalSec, err := strconv.Atoi(sg.Steps[stepName].Data["intervalSeconds"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " unable to fetch intervalSeconds required for DateRangeGenerator: ", err)
-	}
-	passInputFields, err := strconv.ParseBool(sg.Steps[stepName].Data["passInputFieldsToOutput"])
-	if err != nil {
-		log.Debug(stepCanonicalName, " unable to parse boolean value found in config field passInputFieldsToOutput: ", err, " - using default false")
-		passInputFields = false
-	}
-	useUTC := helper.GetTrueFalseStringAsBool(sg.Steps[stepName].Data["useUTC"])
-	cfg := &components.DateRangeGeneratorConfig{
-		Log:                         log,
-		Name:                        stepCanonicalName,
-		InputChan:                   sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		InputChanFieldName4FromDate: sg.Steps[stepName].Data["inputFieldName4FromDate"],
-		InputChanFieldName4ToDate:   sg.Steps[stepName].Data["inputFieldName4ToDate"],
-		ToDateRFC3339orNow:          sg.Steps[stepName].Data["toDate"],
-		UseUTC:                      useUTC,
-		IntervalSizeSeconds:         intervalSec,
-		OutputChanFieldName4LowDate: sg.Steps[stepName].Data["outputFieldName4LowDate"],
-		OutputChanFieldName4HiDate:  sg.Steps[stepName].Data["outputFieldName4HiDate"],
-		PassInputFieldsToOutput:     passInputFields,
-		StepWatcher:                 stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:                 sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:              panicHandlerFn}
-	// Create and save new date range generator.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startNumberRangeGenerator(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	intervalSizeInt, err := strconv.Atoi(sg.Steps[stepName].Data["intervalSize"])
-	intervalSize := float64(intervalSizeInt)
-	if err != nil {
-		log.Panic(stepCanonicalName, " unable to fetch interval size required for NumberRangeGenerator: ", err)
-	}
-	leftPadNumZeros, err := strconv.Atoi(sg.Steps[stepName].Data["outputLeftPaddedNumZeros"])
-	if err != nil {
-		leftPadNumZeros = 0
-	}
-	passInputFields, err := strconv.ParseBool(sg.Steps[stepName].Data["passInputFieldsToOutput"])
-	if err != nil {
-		log.Debug(stepCanonicalName, " unable to parse boolean value found in config field passInputFieldsToOutput: ", err, " - using default false")
-		passInputFields = false
-	}
-	cfg := &components.NumberRangeGeneratorConfig{
-		Log:                         log,
-		Name:                        stepCanonicalName,
-		InputChan:                   sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		InputChanFieldName4LowNum:   sg.Steps[stepName].Data["inputFieldName4LowNum"],
-		InputChanFieldName4HighNum:  sg.Steps[stepName].Data["inputFieldName4HighNum"],
-		IntervalSize:                intervalSize,
-		OutputLeftPaddedNumZeros:    leftPadNumZeros,
-		OutputChanFieldName4LowNum:  sg.Steps[stepName].Data["outputFieldName4LowNum"],
-		OutputChanFieldName4HighNum: sg.Steps[stepName].Data["outputFieldName4HighNum"],
-		PassInputFieldsToOutput:     passInputFields,
-		StepWatcher:                 stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:                 sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:              panicHandlerFn}
-	// Create and save new date range generator.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startGenerateRows(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	numRows, err := strconv.Atoi(sg.Steps[stepName].Data["numRows"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " error: ", err)
-	}
-	sleepIntervalSec, err := strconv.Atoi(sg.Steps[stepName].Data["sleepIntervalSeconds"])
-	if err != nil {
-		log.Panic(stepCanonicalName, " unable to read sleepIntervalSeconds required for GenerateRows: ", err)
-	}
-	cfg := &components.GenerateRowsConfig{
-		Log:                    log,
-		Name:                   stepCanonicalName,
-		MapFieldNamesValuesCSV: sg.Steps[stepName].Data["fieldNamesValuesCSV"],
-		FieldName4Sequence:     sg.Steps[stepName].Data["sequenceFieldName"],
-		SleepIntervalSeconds:   sleepIntervalSec,
-		NumRows:                numRows,
-		StepWatcher:            stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:            sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:         panicHandlerFn}
-	// Create and save new date range generator.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-}
-
-func startFilterRows(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	cfg := &components.FilterRowsConfig{
-		Log:            log,
-		Name:           stepCanonicalName,
-		InputChan:      sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		FilterType:     components.FilterType(sg.Steps[stepName].Data["filterType"]),
-		FilterMetadata: components.FilterMetadata(sg.Steps[stepName].Data["filterMetadata"]),
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		PanicHandlerFn: panicHandlerFn}
-	// Create and save new manifest reader.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
-func startFieldMapper(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	cfg := &components.FieldMapperConfig{
-		Log:            log,
-		Name:           stepCanonicalName,
-		InputChan:      sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		Steps:          sg.Steps[stepName].ComponentSteps,
-		StepWatcher:    stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:    sgm.getComponentWaiter(stepName),
-		PanicHandlerFn: panicHandlerFn}
-	// Create and save new manifest reader.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
-
 func startMergeNChannels(log logger.Logger,
 	stepName string,
 	stepCanonicalName string,
@@ -907,39 +217,3 @@ func startMergeNChannels(log logger.Logger,
 	sgm.setStepOutputChan(stepName, out)      // save the output channel.
 	sgm.setStepControlChan(stepName, control) // save the control channel.
 }
-
-func startStdOutPassThrough(log logger.Logger,
-	stepName string,
-	stepCanonicalName string,
-	sg *StepGroup,
-	sgm StepGroupManager,
-	stats StatsManager,
-	panicHandlerFn components.PanicHandlerFunc,
-	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
-	var i int
-	var err error
-	if sg.Steps[stepName].Data["abortAfterNumRecords"] == "" {
-		i = 0
-	} else {
-		i, err = strconv.Atoi(sg.Steps[stepName].Data["abortAfterNumRecords"])
-		if err != nil {
-			log.Panic(stepCanonicalName, " error reading abortAfterNumRecords required for StdOutPassThrough: ", err)
-		}
-	}
-	cfg := &components.StdOutPassThroughConfig{
-		Log:             log,
-		Name:            stepCanonicalName,
-		Writer:          os.Stdout,
-		OutputFields:    helper.CsvToStringSliceTrimSpaces2(sg.Steps[stepName].Data["outputFieldsCsv"]),
-		AbortAfterCount: int64(i),
-		InputChan:       sgm.getStepOutputChan(sg.Steps[stepName].Data["readDataFromStep"]),
-		StepWatcher:     stats.AddStepWatcher(stepCanonicalName),
-		WaitCounter:     sgm.getComponentWaiter(stepName),
-		PanicHandlerFn:  panicHandlerFn}
-	// Create and save new manifest reader.
-	out, control := componentFunc(cfg)
-	sgm.setStepOutputChan(stepName, out)      // save the output channel.
-	sgm.setStepControlChan(stepName, control) // save the control channel.
-	// Save that this step has consumed other channels.
-	sgm.consumeStep(sg.Steps[stepName].Data["readDataFromStep"])
-}
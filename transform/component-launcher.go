@@ -0,0 +1,358 @@
+package transform
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	om "github.com/cevaris/ordered_map"
+	"github.com/relloyd/halfpipe/components"
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+// Component config struct fields are populated from step data using struct tags data:"<key>" and mandatory:"yes".
+// The data tag names the key in the step data map whose value is converted to the field type.
+// The mandatory tag means the key must be supplied with a non-empty value.
+// Fields of type chan stream.Record are given the output channel of the step named by the value.
+// Fields of type shared.Connector are given the database connection named by the value.
+// Fields of type *om.OrderedMap and map[string]string expect a CSV of key:value tokens.
+// Fields of type []string expect a CSV of values, which may be quoted.
+// Fields of type int, float64 and bool are parsed using package strconv.
+// Fields whose type implements encoding.TextUnmarshaler are populated using UnmarshalText().
+// Untagged embedded structs are populated recursively.
+// Components whose config has a field of type *components.RowErrorHandler support the onError settings of a Step.
+const (
+	stepDataTag      = "data"
+	stepMandatoryTag = "mandatory"
+)
+
+var (
+	typeRecordChan      = reflect.TypeOf((chan stream.Record)(nil))
+	typeConnector       = reflect.TypeOf((*shared.Connector)(nil)).Elem()
+	typeOrderedMap      = reflect.TypeOf((*om.OrderedMap)(nil))
+	typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	typeRowErrorHandler = reflect.TypeOf((*components.RowErrorHandler)(nil))
+)
+
+// retiredStepDataKeys are keys that are no longer used by any component but may still be found in pipe definitions
+// exported by earlier versions, so they are ignored with a warning instead of being rejected as unknown.
+var retiredStepDataKeys = map[string]bool{
+	"use1Transaction": true, // SnowflakeLoader
+}
+
+// componentConfig registers a component constructor with the config struct that the generic launcher will populate.
+type componentConfig struct {
+	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)
+	cfg           interface{} // an empty instance of the component's config struct.
+}
+
+type mapComponentConfigs map[string]componentConfig
+
+// stepDataResolver fetches the channels and database connections that are referred to by name in step data.
+type stepDataResolver interface {
+	getStepOutputChan(name string) chan stream.Record
+	getDBConnector(name string) shared.Connector
+}
+
+// stepGroupResolver implements stepDataResolver using a StepGroupManager.
+type stepGroupResolver struct {
+	sgm StepGroupManager
+}
+
+func (r stepGroupResolver) getStepOutputChan(name string) chan stream.Record {
+	return r.sgm.getStepOutputChan(name)
+}
+
+func (r stepGroupResolver) getDBConnector(name string) shared.Connector {
+	return r.sgm.getGlobalTransformManager().getDBConnector(name)
+}
+
+// mergeComponentFuncs returns a copy of funcs with a type 2 registration added for each of the component configs,
+// where each uses the generic launcher.
+func mergeComponentFuncs(funcs MapComponentFuncs, configs mapComponentConfigs) MapComponentFuncs {
+	retval := make(MapComponentFuncs, len(funcs)+len(configs))
+	for k, v := range funcs {
+		retval[k] = v
+	}
+	for k, v := range configs {
+		retval[k] = ComponentRegistration{"2", ComponentRegistrationType2{v.componentFunc, newGenericLauncher(v.cfg)}}
+	}
+	return retval
+}
+
+// ValidateStepData checks the data supplied to a step of type stepType can populate the component's config struct.
+// Step types that are not launched by the generic launcher are not validated.
+func ValidateStepData(log logger.Logger, stepType string, data map[string]string) error {
+	c, ok := componentConfigs[stepType]
+	if !ok {
+		return nil
+	}
+	cfg := reflect.New(reflect.TypeOf(c.cfg))
+	_, err := setConfigFromStepData(log, cfg.Elem(), data, nil)
+	return err
+}
+
+// newGenericLauncher returns a type 2 launcher function that creates a new config struct of the same type as cfg,
+// populates it from step data and common step values, and then starts the component.
+func newGenericLauncher(cfg interface{}) func(
+	log logger.Logger,
+	stepName string,
+	stepCanonicalName string,
+	sg *StepGroup,
+	sgm StepGroupManager,
+	stats StatsManager,
+	panicHandlerFn components.PanicHandlerFunc,
+	componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
+	cfgType := reflect.TypeOf(cfg)
+	return func(log logger.Logger,
+		stepName string,
+		stepCanonicalName string,
+		sg *StepGroup,
+		sgm StepGroupManager,
+		stats StatsManager,
+		panicHandlerFn components.PanicHandlerFunc,
+		componentFunc func(cfg interface{}) (outputChan chan stream.Record, controlChan chan components.ControlAction)) {
+		step := sg.Steps[stepName]
+		v := reflect.New(cfgType)
+		consumedSteps, err := setConfigFromStepData(log, v.Elem(), step.Data, stepGroupResolver{sgm})
+		if err != nil {
+			log.Panic(stepCanonicalName, " ", err)
+		}
+		rowErrorHandler, err := newRowErrorHandler(log, stepCanonicalName, step, cfgType)
+		if err != nil {
+			log.Panic(stepCanonicalName, " ", err)
+		}
+		stepWatcher := stats.AddStepWatcher(stepCanonicalName)
+		if rowErrorHandler != nil && stepWatcher != nil { // if the step can reject records...
+			stepWatcher.WatchErrors(rowErrorHandler.ErrorCount)
+		}
+		setConfigCommonFields(v.Elem(), map[string]interface{}{
+			"Log":             log,
+			"Name":            stepCanonicalName,
+			"Steps":           step.ComponentSteps,
+			"StepWatcher":     stepWatcher,
+			"WaitCounter":     sgm.getComponentWaiter(stepName),
+			"PanicHandlerFn":  panicHandlerFn,
+			"RowErrorHandler": rowErrorHandler,
+		})
+		out, control := componentFunc(v.Interface())
+		sgm.setStepOutputChan(stepName, out)      // save the output channel.
+		sgm.setStepControlChan(stepName, control) // save the control channel.
+		if step.DeadLetterStep != "" {            // if rejected records should be available to other steps...
+			sgm.setStepOutputChan(step.DeadLetterStep, rowErrorHandler.DeadLetterChan())
+		}
+		// Save that this step has consumed other channels.
+		for _, s := range consumedSteps {
+			sgm.consumeStep(s)
+		}
+	}
+}
+
+// newRowErrorHandler returns a RowErrorHandler using the onError settings of step, or nil if the component config
+// struct type t does not support one.
+func newRowErrorHandler(log logger.Logger, stepCanonicalName string, step Step, t reflect.Type) (*components.RowErrorHandler, error) {
+	supported := hasFieldOfType(t, typeRowErrorHandler)
+	if err := validateStepOnError(step, supported); err != nil {
+		return nil, err
+	}
+	if !supported {
+		return nil, nil
+	}
+	return components.NewRowErrorHandler(log, stepCanonicalName, step.OnError, step.MaxErrors)
+}
+
+// validateStepOnError checks the onError settings of step where supported says whether the step type can use them.
+func validateStepOnError(step Step, supported bool) error {
+	if step.OnError == "" && step.DeadLetterStep == "" && step.MaxErrors == 0 { // if the defaults are used...
+		return nil
+	}
+	if !supported {
+		return fmt.Errorf("step type %q does not support onError, deadLetterStep or maxErrors", step.Type)
+	}
+	switch step.OnError {
+	case "", components.OnErrorFail, components.OnErrorSkip:
+		if step.DeadLetterStep != "" {
+			return fmt.Errorf("deadLetterStep requires onError %q", components.OnErrorDeadLetter)
+		}
+	case components.OnErrorDeadLetter:
+		if step.DeadLetterStep == "" {
+			return fmt.Errorf("missing deadLetterStep for onError %q", components.OnErrorDeadLetter)
+		}
+	default:
+		return fmt.Errorf("unsupported onError value %q: use %v, %v or %v", step.OnError, components.OnErrorFail, components.OnErrorSkip, components.OnErrorDeadLetter)
+	}
+	if step.MaxErrors < 0 {
+		return fmt.Errorf("maxErrors must not be negative")
+	}
+	return nil
+}
+
+// hasFieldOfType returns true if struct type t, or any untagged embedded struct, has a field of type typ.
+func hasFieldOfType(t reflect.Type, typ reflect.Type) bool {
+	for idx := 0; idx < t.NumField(); idx++ { // for each field in the struct...
+		sf := t.Field(idx)
+		if sf.Type == typ {
+			return true
+		}
+		if _, ok := sf.Tag.Lookup(stepDataTag); !ok && sf.Anonymous && sf.Type.Kind() == reflect.Struct && hasFieldOfType(sf.Type, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// setConfigCommonFields sets the untagged fields of struct v, and any embedded structs, whose names are found in
+// values and whose types can be assigned the value.
+func setConfigCommonFields(v reflect.Value, values map[string]interface{}) {
+	t := v.Type()
+	for idx := 0; idx < t.NumField(); idx++ { // for each field in the struct...
+		sf := t.Field(idx)
+		f := v.Field(idx)
+		if _, ok := sf.Tag.Lookup(stepDataTag); ok || !f.CanSet() {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct { // if this is an embedded struct...
+			setConfigCommonFields(f, values)
+			continue
+		}
+		val, ok := values[sf.Name]
+		if !ok || val == nil {
+			continue
+		}
+		rv := reflect.ValueOf(val)
+		if rv.Type().AssignableTo(f.Type()) {
+			f.Set(rv)
+		}
+	}
+}
+
+// setConfigFromStepData populates the tagged fields of struct v using the values found in data.
+// If resolver r is nil then values that name steps or database connections are not resolved, which allows step
+// data to be validated before a transform is launched.
+// All errors are returned together, including one for each key in data that does not match a tagged field, unless
+// the key is one of retiredStepDataKeys.
+// The names of steps whose output channels are read by the config are returned so the caller can consume them.
+func setConfigFromStepData(log logger.Logger, v reflect.Value, data map[string]string, r stepDataResolver) (consumedSteps []string, err error) {
+	errs := make([]string, 0)
+	usedKeys := make(map[string]bool)
+	consumedSteps = setStructFromStepData(log, v, data, r, usedKeys, &errs)
+	unknownKeys := make([]string, 0)
+	for k := range data { // for each key supplied...
+		if usedKeys[k] {
+			continue
+		}
+		if retiredStepDataKeys[k] { // if the key is no longer used...
+			log.Warn("ignoring retired step data key ", k)
+		} else {
+			unknownKeys = append(unknownKeys, k)
+		}
+	}
+	sort.Strings(unknownKeys)
+	for _, k := range unknownKeys {
+		errs = append(errs, fmt.Sprintf("unknown key %q", k))
+	}
+	if len(errs) > 0 {
+		err = fmt.Errorf("invalid step data: %v", strings.Join(errs, "; "))
+	}
+	return
+}
+
+func setStructFromStepData(log logger.Logger, v reflect.Value, data map[string]string, r stepDataResolver, usedKeys map[string]bool, errs *[]string) (consumedSteps []string) {
+	t := v.Type()
+	for idx := 0; idx < t.NumField(); idx++ { // for each field in the struct...
+		sf := t.Field(idx)
+		f := v.Field(idx)
+		key, ok := sf.Tag.Lookup(stepDataTag)
+		if !ok { // if the field is not populated from step data...
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct && f.CanSet() {
+				consumedSteps = append(consumedSteps, setStructFromStepData(log, f, data, r, usedKeys, errs)...)
+			}
+			continue
+		}
+		usedKeys[key] = true
+		val := data[key]
+		if val == "" {
+			if sf.Tag.Get(stepMandatoryTag) == "yes" {
+				*errs = append(*errs, fmt.Sprintf("missing value for mandatory key %q", key))
+			}
+			continue
+		}
+		if f.Type() == typeRecordChan {
+			consumedSteps = append(consumedSteps, val)
+		}
+		if err := setFieldFromString(log, f, val, r); err != nil {
+			*errs = append(*errs, fmt.Sprintf("bad value for key %q: %v", key, err))
+		}
+	}
+	return
+}
+
+// setFieldFromString converts string s to the type of field f and sets it.
+func setFieldFromString(log logger.Logger, f reflect.Value, s string, r stepDataResolver) error {
+	switch {
+	case f.Type() == typeRecordChan:
+		if r != nil {
+			f.Set(reflect.ValueOf(r.getStepOutputChan(s)))
+		}
+	case f.Type() == typeConnector:
+		if r != nil {
+			if c := r.getDBConnector(s); c != nil {
+				f.Set(reflect.ValueOf(c))
+			}
+		}
+	case f.Type() == typeOrderedMap:
+		f.Set(reflect.ValueOf(helper.TokensToOrderedMap(s)))
+	case reflect.PtrTo(f.Type()).Implements(typeTextUnmarshaler):
+		return f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	case f.Kind() == reflect.String:
+		f.SetString(s)
+	case f.Kind() == reflect.Int || f.Kind() == reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(i)
+	case f.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case f.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String:
+		c := csv.NewReader(strings.NewReader(s))
+		c.TrimLeadingSpace = true
+		all, err := c.ReadAll()
+		if err != nil {
+			return err
+		}
+		vals := make([]string, 0)
+		for _, rec := range all { // for each line in the CSV...
+			for _, val := range rec {
+				vals = append(vals, strings.TrimSpace(val))
+			}
+		}
+		f.Set(reflect.ValueOf(vals))
+	case f.Kind() == reflect.Map && f.Type().Key().Kind() == reflect.String && f.Type().Elem().Kind() == reflect.String:
+		m, err := helper.CsvStringOfTokensToMap(log, s)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported config field type %v", f.Type())
+	}
+	return nil
+}
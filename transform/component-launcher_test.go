@@ -0,0 +1,258 @@
+package transform
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relloyd/halfpipe/components"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+	"github.com/relloyd/halfpipe/stats"
+	"github.com/relloyd/halfpipe/stream"
+)
+
+func TestSetConfigFromStepData(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	// Test 1 - all supported conversions.
+	cfg := &components.CsvFileWriterConfig{}
+	data := map[string]string{
+		"readDataFromStep":                  "input",
+		"maxFileRows":                       "100",
+		"maxFileBytes":                      "200",
+		"useGzip":                           "true",
+		"fileNameSuffixAppendCreationStamp": "false",
+		"headerFieldsCSV":                   `"A", "B",C`,
+	}
+	steps, err := setConfigFromStepData(log, reflect.ValueOf(cfg).Elem(), data, nil)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if cfg.MaxFileRows != 100 || cfg.MaxFileBytes != 200 || !cfg.UseGzip || cfg.FileNameSuffixAppendCreationStamp {
+		t.Fatal("unexpected config values: ", cfg)
+	}
+	if !reflect.DeepEqual(cfg.HeaderFields, []string{"A", "B", "C"}) {
+		t.Fatal("unexpected header fields: ", cfg.HeaderFields)
+	}
+	if !reflect.DeepEqual(steps, []string{"input"}) {
+		t.Fatal("expected consumed step 'input'; got: ", steps)
+	}
+	// Test 2 - embedded structs, ordered maps and text unmarshalers.
+	cfgSync := &components.TableSyncConfig{}
+	data = map[string]string{
+		"readDataFromStep":       "input",
+		"databaseConnectionName": "db",
+		"commitBatchSize":        "1",
+		"txtBatchNumRows":        "2",
+		"outputTable":            "t",
+		"keyCols":                "a:b,c:d",
+	}
+	if _, err = setConfigFromStepData(log, reflect.ValueOf(cfgSync).Elem(), data, nil); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if cfgSync.OutputTable != "t" || cfgSync.TargetKeyCols.Len() != 2 {
+		t.Fatal("unexpected embedded config values: ", cfgSync.SqlStatementGeneratorConfig)
+	}
+	cfgLoader := &components.SnowflakeLoaderConfig{}
+	data = map[string]string{
+		"readDataFromStep":      "input",
+		"logicalConnectionName": "db",
+		"stageName":             "stage",
+		"schemaTableName":       "s.t",
+	}
+	if _, err = setConfigFromStepData(log, reflect.ValueOf(cfgLoader).Elem(), data, nil); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if cfgLoader.TargetSchemaTableName != (rdbms.SchemaTable{SchemaTable: "s.t"}) {
+		t.Fatal("unexpected schema table: ", cfgLoader.TargetSchemaTableName)
+	}
+	// Test 3 - missing mandatory keys, bad values and unknown keys are all reported.
+	cfg = &components.CsvFileWriterConfig{}
+	data = map[string]string{
+		"readDataFromStep": "input",
+		"maxFileRows":      "abc",
+		"useGzip":          "maybe",
+		"unknownKey":       "x",
+	}
+	_, err = setConfigFromStepData(log, reflect.ValueOf(cfg).Elem(), data, nil)
+	if err == nil {
+		t.Fatal("expected error for bad step data")
+	}
+	for _, s := range []string{`"maxFileRows"`, `"maxFileBytes"`, `"useGzip"`, `unknown key "unknownKey"`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Fatalf("expected error to contain %v; got: %v", s, err)
+		}
+	}
+}
+
+func TestValidateStepData(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	// Test 1 - valid data.
+	err := ValidateStepData(log, "GenerateRows", map[string]string{"numRows": "1", "sleepIntervalSeconds": "0"})
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	// Test 2 - missing mandatory key.
+	err = ValidateStepData(log, "GenerateRows", map[string]string{"numRows": "1"})
+	if err == nil || !strings.Contains(err.Error(), `"sleepIntervalSeconds"`) {
+		t.Fatal("expected error for missing sleepIntervalSeconds; got: ", err)
+	}
+	// Test 3 - components without a registered config are not validated.
+	if err = ValidateStepData(log, "ChannelBridge", map[string]string{"anything": "x"}); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+}
+
+// mockLauncherStepGroupManager supplies a MockTransformManager that has a logger so it can create mock connections.
+type mockLauncherStepGroupManager struct {
+	MockStepGroupManager
+	log         logger.Logger
+	outputChans map[string]chan stream.Record
+}
+
+func (s *mockLauncherStepGroupManager) setStepOutputChan(stepName string, c chan stream.Record) {
+	if s.outputChans == nil {
+		s.outputChans = make(map[string]chan stream.Record)
+	}
+	s.outputChans[stepName] = c
+}
+
+func (s *mockLauncherStepGroupManager) getGlobalTransformManager() TransformManager {
+	return &MockTransformManager{log: s.log}
+}
+
+func TestGenericLauncher(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	sg := &StepGroup{Steps: map[string]Step{
+		"exec": {Type: "SqlExec", Data: map[string]string{
+			"readDataFromStep":       "input",
+			"databaseConnectionName": "db",
+			"sqlQueryFieldName":      "sql",
+		}},
+	}}
+	var got *components.SqlExecConfig
+	componentFunc := func(i interface{}) (chan stream.Record, chan components.ControlAction) {
+		got = i.(*components.SqlExecConfig)
+		return make(chan stream.Record), make(chan components.ControlAction)
+	}
+	launcher := newGenericLauncher(components.SqlExecConfig{})
+	launcher(log, "exec", "canonicalExec", sg, &mockLauncherStepGroupManager{log: log}, stats.NewMockStatsManager(), nil, componentFunc)
+	if got == nil {
+		t.Fatal("expected component func to be called")
+	}
+	if got.Name != "canonicalExec" || got.Log == nil || got.WaitCounter == nil {
+		t.Fatal("expected common fields to be set: ", got)
+	}
+	if got.InputChan == nil || got.SqlQueryFieldName != "sql" {
+		t.Fatal("expected fields to be set from step data: ", got)
+	}
+	if _, ok := got.OutputDb.(shared.Connector); !ok {
+		t.Fatal("expected database connector to be set")
+	}
+}
+
+func TestGenericLauncherDeadLetter(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	sg := &StepGroup{Steps: map[string]Step{
+		"exec": {Type: "SqlExec", OnError: components.OnErrorDeadLetter, DeadLetterStep: "execRejected", MaxErrors: 10, Data: map[string]string{
+			"readDataFromStep":       "input",
+			"databaseConnectionName": "db",
+			"sqlQueryFieldName":      "sql",
+		}},
+	}}
+	var got *components.SqlExecConfig
+	componentFunc := func(i interface{}) (chan stream.Record, chan components.ControlAction) {
+		got = i.(*components.SqlExecConfig)
+		return make(chan stream.Record), make(chan components.ControlAction)
+	}
+	sgm := &mockLauncherStepGroupManager{log: log}
+	launcher := newGenericLauncher(components.SqlExecConfig{})
+	launcher(log, "exec", "canonicalExec", sg, sgm, stats.NewMockStatsManager(), nil, componentFunc)
+	if got == nil || got.RowErrorHandler == nil {
+		t.Fatal("expected RowErrorHandler to be set")
+	}
+	if c := sgm.outputChans["execRejected"]; c == nil || c != got.RowErrorHandler.DeadLetterChan() {
+		t.Fatal("expected the dead-letter channel to be saved as the output of step execRejected")
+	}
+	if sgm.outputChans["exec"] == nil {
+		t.Fatal("expected the output channel of step exec to be saved")
+	}
+}
+
+// baselineExportedPipe is a pipe exported by hp cp snap from S3 to Snowflake before key use1Transaction was retired.
+const baselineExportedPipe = `{
+  "schemaVersion": 3,
+  "description": "cp snapshot from S3 to Snowflake",
+  "connections": {
+    "target": {"type": "snowflake", "logicalName": "snowflake", "data": {"dsn": "user:pass@account/db/schema"}}
+  },
+  "type": "TransformOnce",
+  "transformGroups": {
+    "loadData": {
+      "type": "sequential",
+      "steps": {
+        "getS3Files": {
+          "type": "S3BucketList",
+          "data": {
+            "bucketRegion": "eu-west-2",
+            "bucketName": "bucket",
+            "bucketPrefix": "prefix",
+            "fileNamePrefix": "t",
+            "fileNameRegexp": "",
+            "outputField4BucketName": "#bucketName",
+            "outputField4BucketPrefix": "#bucketPrefix",
+            "outputField4BucketRegion": "#bucketRegion",
+            "outputField4FileName": "#dataFilePath",
+            "outputField4FileNameWithoutPrefix": "#dataFile"
+          }
+        },
+        "copyIntoSnowflake": {
+          "type": "SnowflakeLoader",
+          "data": {
+            "logicalConnectionName": "target",
+            "fieldName4FileName": "#dataFile",
+            "use1Transaction": "true",
+            "deleteAllRows": "true",
+            "readDataFromStep": "getS3Files",
+            "stageName": "stage",
+            "schemaTableName": "s.t"
+          }
+        }
+      },
+      "sequence": ["getS3Files", "copyIntoSnowflake"]
+    }
+  },
+  "sequence": ["loadData"]
+}`
+
+func TestRetiredStepDataKeys(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	def := &TransformDefinition{}
+	if err := json.Unmarshal([]byte(baselineExportedPipe), def); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	// Test 1 - the pipe is valid.
+	if errs := ValidateTransformDefinition(log, def); len(errs) != 0 {
+		t.Fatal("unexpected errors: ", errs)
+	}
+	// Test 2 - the step using the retired key launches.
+	sg := def.StepGroups["loadData"]
+	var got *components.SnowflakeLoaderConfig
+	componentFunc := func(i interface{}) (chan stream.Record, chan components.ControlAction) {
+		got = i.(*components.SnowflakeLoaderConfig)
+		return make(chan stream.Record), make(chan components.ControlAction)
+	}
+	launcher := newGenericLauncher(components.SnowflakeLoaderConfig{})
+	launcher(log, "copyIntoSnowflake", "canonicalLoader", &sg, &mockLauncherStepGroupManager{log: log}, stats.NewMockStatsManager(), nil, componentFunc)
+	if got == nil || !got.DeleteAll || got.StageName != "stage" {
+		t.Fatal("expected the loader to be launched with its step data: ", got)
+	}
+	// Test 3 - retired keys are not reported alongside other unknown keys.
+	data := map[string]string{"use1Transaction": "true", "unknownKey": "x"}
+	_, err := setConfigFromStepData(log, reflect.ValueOf(&components.SnowflakeLoaderConfig{}).Elem(), data, nil)
+	if err == nil || strings.Contains(err.Error(), "use1Transaction") || !strings.Contains(err.Error(), `unknown key "unknownKey"`) {
+		t.Fatal("expected an error for unknownKey only; got: ", err)
+	}
+}
@@ -5,35 +5,53 @@ import (
 )
 
 // TODO: add error return value from components and handle in launcher functions.
-// TODO: add generic launcher function that matches JSON keys to config struct field names sop we can have only one or two launchers.
-var componentFuncs = MapComponentFuncs{
+
+// componentConfigs registers components that are started by the generic launcher.
+// Each config struct is populated from step data using its struct tags - see component-launcher.go.
+var componentConfigs = mapComponentConfigs{
+	"SqlExec":                    {components.NewSqlExec, components.SqlExecConfig{}},
+	"TableInput":                 {components.NewSqlQueryWithArgs, components.SqlQueryWithArgsConfig{}},
+	"TableInputWithArgs":         {components.NewSqlQueryWithInputChan, components.SqlQueryWithChanConfig{}},
+	"TableInputWithReplacements": {components.NewSqlQueryWithReplace, components.SqlQueryWithReplace{}},
+	"SnowflakeLoader":            {components.NewSnowflakeLoader, components.SnowflakeLoaderConfig{}},
+	"SnowflakeSync":              {components.NewSnowflakeSync, components.SnowflakeSyncConfig{}},
+	"SnowflakeMerge":             {components.NewSnowflakeMerge, components.SnowflakeMergeConfig{}},
+	"MergeDiff":                  {components.NewMergeDiff, components.MergeDiffConfig{}},
+	"HashDiff":                   {components.NewHashDiff, components.HashDiffConfig{}},
+	"DiffReport":                 {components.NewDiffReport, components.DiffReportConfig{}},
+	"TableSync":                  {components.NewTableSync, components.TableSyncConfig{}},
+	"TableMerge":                 {components.NewTableMerge, components.TableMergeConfig{}},
+	"S3BucketList":               {components.NewS3BucketList, components.S3BucketListerConfig{}},
+	"CSVFileWriter":              {components.NewCsvFileWriter, components.CsvFileWriterConfig{}},
+	"ParquetFileWriter":          {components.NewParquetFileWriter, components.ParquetFileWriterConfig{}},
+	"CopyFilesToS3":              {components.NewCopyFilesToS3, components.CopyFilesToS3Config{}},
+	"ChannelCombiner":            {components.NewChannelCombiner, components.ChannelCombinerConfig{}},
+	"ManifestWriter":             {components.NewManifestWriter, components.ManifestWriterConfig{}},
+	"ManifestReader":             {components.NewS3ManifestReader, components.S3ManifestReaderConfig{}},
+	"DateRangeGenerator":         {components.NewDateRangeGenerator, components.DateRangeGeneratorConfig{}},
+	"NumberRangeGenerator":       {components.NewNumberRangeGenerator, components.NumberRangeGeneratorConfig{}},
+	"GenerateRows":               {components.NewGenerateRows, components.GenerateRowsConfig{}},
+	"StdOutPassThrough":          {components.NewStdOutPassThrough, components.StdOutPassThroughConfig{}},
+	"CheckpointRead":             {components.NewCheckpointRead, components.CheckpointReadConfig{}},
+	"CheckpointWrite":            {components.NewCheckpointWrite, components.CheckpointWriteConfig{}},
+	"KafkaProducer":              {components.NewKafkaProducer, components.KafkaProducerConfig{}},
+	"KafkaConsumer":              {components.NewKafkaConsumer, components.KafkaConsumerConfig{}},
+	"KafkaCommit":                {components.NewKafkaCommit, components.KafkaCommitConfig{}},
+	"DatabaseLookup":             {components.NewDatabaseLookup, components.DatabaseLookupConfig{}},
+	"FileLookup":                 {components.NewFileLookup, components.FileLookupConfig{}},
+	"GroupBy":                    {components.NewGroupBy, components.GroupByConfig{}},
+	"Sort":                       {components.NewSort, components.SortConfig{}},
+	// FieldMapper and FilterRows contain their own dynamic features.
+	"FieldMapper": {components.NewFieldMapper, components.FieldMapperConfig{}},
+	"FilterRows":  {components.NewFilterRows, components.FilterRowsConfig{}},
+}
+
+// componentFuncs registers all components including those that need their own launcher function.
+var componentFuncs = mergeComponentFuncs(MapComponentFuncs{
 	// Type 2 - returns 2 output channels of type stream.StreamRecordIface and ControlAction.
-	"SqlExec":                    ComponentRegistration{"2", ComponentRegistrationType2{components.NewSqlExec, startSqlExec}},
-	"TableInput":                 ComponentRegistration{"2", ComponentRegistrationType2{components.NewSqlQueryWithArgs, startTableInput}},
-	"TableInputWithArgs":         ComponentRegistration{"2", ComponentRegistrationType2{components.NewSqlQueryWithInputChan, startTableInputWithArgs}},
-	"TableInputWithReplacements": ComponentRegistration{"2", ComponentRegistrationType2{components.NewSqlQueryWithReplace, startTableInputWithReplacements}},
-	"SnowflakeLoader":            ComponentRegistration{"2", ComponentRegistrationType2{components.NewSnowflakeLoader, startSnowflakeLoader}},
-	"SnowflakeSync":              ComponentRegistration{"2", ComponentRegistrationType2{components.NewSnowflakeSync, startSnowflakeSync}},
-	"SnowflakeMerge":             ComponentRegistration{"2", ComponentRegistrationType2{components.NewSnowflakeMerge, startSnowflakeMerge}},
-	"MergeDiff":                  ComponentRegistration{"2", ComponentRegistrationType2{components.NewMergeDiff, startMergeDiff}},
-	"TableSync":                  ComponentRegistration{"2", ComponentRegistrationType2{components.NewTableSync, startTableSync}},
-	"TableMerge":                 ComponentRegistration{"2", ComponentRegistrationType2{components.NewTableMerge, startTableMerge}},
-	"S3BucketList":               ComponentRegistration{"2", ComponentRegistrationType2{components.NewS3BucketList, startS3BucketList}},
-	"CSVFileWriter":              ComponentRegistration{"2", ComponentRegistrationType2{components.NewCsvFileWriter, startCSVFileWriter}},
-	"CopyFilesToS3":              ComponentRegistration{"2", ComponentRegistrationType2{components.NewCopyFilesToS3, startCopyFilesToS3}},
-	"ChannelCombiner":            ComponentRegistration{"2", ComponentRegistrationType2{components.NewChannelCombiner, startChannelCombiner}},
-	"ManifestWriter":             ComponentRegistration{"2", ComponentRegistrationType2{components.NewManifestWriter, startManifestWriter}},
-	"ManifestReader":             ComponentRegistration{"2", ComponentRegistrationType2{components.NewS3ManifestReader, startManifestReader}},
-	"DateRangeGenerator":         ComponentRegistration{"2", ComponentRegistrationType2{components.NewDateRangeGenerator, startDateRangeGenerator}},
-	"NumberRangeGenerator":       ComponentRegistration{"2", ComponentRegistrationType2{components.NewNumberRangeGenerator, startNumberRangeGenerator}},
-	"GenerateRows":               ComponentRegistration{"2", ComponentRegistrationType2{components.NewGenerateRows, startGenerateRows}},
-	"MergeStreamsCartesian":      ComponentRegistration{"2", ComponentRegistrationType2{components.NewMergeNChannels, startMergeNChannels}},
-	"StdOutPassThrough":          ComponentRegistration{"2", ComponentRegistrationType2{components.NewStdOutPassThrough, startStdOutPassThrough}},
+	"MergeStreamsCartesian":                        ComponentRegistration{"2", ComponentRegistrationType2{components.NewMergeNChannels, startMergeNChannels}},
 	"OracleContinuousQueryNotificationToRdbms":     ComponentRegistration{"2", ComponentRegistrationType2{components.NewCqnWithArgs, startTableInputCqnToRdbms}},
 	"OracleContinuousQueryNotificationToSnowflake": ComponentRegistration{"2", ComponentRegistrationType2{components.NewCqnWithArgs, startTableInputCqnToSnowflake}},
-	// FieldMapper and FilterRows contain their own dynamic features.
-	"FieldMapper": ComponentRegistration{"2", ComponentRegistrationType2{components.NewFieldMapper, startFieldMapper}},
-	"FilterRows":  ComponentRegistration{"2", ComponentRegistrationType2{components.NewFilterRows, startFilterRows}},
 	// Type 3 - returns 1 output chan and 1 input chan of type stream.StreamRecordIface.
 	"ChannelBridge": ComponentRegistration{"3", ComponentRegistrationType3{components.NewChannelBridge, startChannelBridge}},
-}
+}, componentConfigs)
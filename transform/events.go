@@ -0,0 +1,79 @@
+package transform
+
+import (
+	"sync"
+)
+
+// Event types sent by EventBroker.
+const (
+	EventTypeStatus = "status" // Data is a TransformStatus
+	EventTypeStats  = "stats"  // Data is a []stats.Stats
+)
+
+// eventBufferSize is the number of events buffered per subscriber before further events are dropped.
+const eventBufferSize = 32
+
+// Event is a change in the status of a transform or a snapshot of its step stats.
+type Event struct {
+	Type string
+	Data interface{}
+}
+
+// EventBroker sends the events of a single transform to any number of subscribers.
+// Publish never blocks, so a subscriber that does not keep up will miss events.
+type EventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	closed      bool
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel of events that is closed when the broker is closed or cancel is called.
+// If the broker is already closed then the channel is closed immediately.
+func (b *EventBroker) Subscribe() (events <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan Event, eventBufferSize)
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok { // if the channel is not closed already...
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends e to all subscribers that have room in their buffer.
+func (b *EventBroker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default: // drop the event for slow subscribers.
+		}
+	}
+}
+
+// Close closes the channels of all subscribers. Later calls to Publish are ignored.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,68 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestEventBroker(t *testing.T) {
+	b := NewEventBroker()
+	ch1, cancel1 := b.Subscribe()
+	ch2, _ := b.Subscribe()
+	// Test 1 - events are sent to all subscribers.
+	b.Publish(Event{Type: EventTypeStatus, Data: StatusRunning})
+	for _, ch := range []<-chan Event{ch1, ch2} {
+		if e := <-ch; e.Type != EventTypeStatus || e.Data != StatusRunning {
+			t.Fatal("unexpected event: ", e)
+		}
+	}
+	// Test 2 - cancelled subscribers are closed and receive no more events.
+	cancel1()
+	cancel1() // cancel is safe to call twice.
+	if _, ok := <-ch1; ok {
+		t.Fatal("expected cancelled channel to be closed")
+	}
+	// Test 3 - publish does not block when a subscriber is full.
+	for i := 0; i < eventBufferSize+10; i++ {
+		b.Publish(Event{Type: EventTypeStats})
+	}
+	if len(ch2) != eventBufferSize {
+		t.Fatalf("expected %v buffered events; got %v", eventBufferSize, len(ch2))
+	}
+	// Test 4 - close ends all subscriptions, including later ones.
+	b.Close()
+	b.Publish(Event{Type: EventTypeStats})
+	count := 0
+	for range ch2 {
+		count++
+	}
+	if count != eventBufferSize {
+		t.Fatalf("expected %v events before close; got %v", eventBufferSize, count)
+	}
+	ch3, _ := b.Subscribe()
+	if _, ok := <-ch3; ok {
+		t.Fatal("expected subscription after close to be closed")
+	}
+}
+
+func TestConsumeTransformStatusChangesPublishesEvents(t *testing.T) {
+	ti := NewSafeMapTransformInfo()
+	b := NewEventBroker()
+	ti.Store("guid", TransformInfo{Events: b})
+	events, _ := b.Subscribe()
+	chanStatus := make(chan TransformStatus, 2)
+	chanStatus <- TransformStatus{Status: StatusRunning}
+	chanStatus <- TransformStatus{Status: StatusCompleteWithError, Error: "failed"}
+	close(chanStatus)
+	ti.ConsumeTransformStatusChanges("guid", chanStatus)
+	expected := []Status{StatusRunning, StatusCompleteWithError}
+	idx := 0
+	for e := range events { // until the broker is closed...
+		if s := e.Data.(TransformStatus); e.Type != EventTypeStatus || s.Status != expected[idx] {
+			t.Fatalf("unexpected event %v: %+v", idx, e)
+		}
+		idx++
+	}
+	if idx != len(expected) {
+		t.Fatalf("expected %v events; got %v", len(expected), idx)
+	}
+}
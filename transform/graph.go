@@ -0,0 +1,314 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	GraphFormatDot     = "dot"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatJSON    = "json"
+)
+
+// Edge styles used to render the step DAG.
+const (
+	graphEdgeData      = "data"     // a step reads the output of another step.
+	graphEdgeSequence  = "sequence" // a step group runs after another in the top-level sequence.
+	graphEdgeInjection = "inject"   // a MetadataInjection step executes a step group.
+	graphEdgeRejected  = "rejected" // a step reads the dead-letter output of another step.
+)
+
+type graphNode struct {
+	id        string
+	label     string
+	step      string
+	stepType  string
+	statsName string // the name of the step in stats.
+}
+
+type graphCluster struct {
+	id    string
+	label string
+	nodes []graphNode
+}
+
+type graphEdge struct {
+	from     string // node or cluster id.
+	to       string // node or cluster id.
+	edgeType string
+}
+
+type pipeGraph struct {
+	clusters []graphCluster
+	edges    []graphEdge
+}
+
+// WriteGraph renders the steps of TransformDefinition t as a directed graph to w using the given format
+// GraphFormatDot, GraphFormatMermaid or GraphFormatJSON.
+// Each step group is drawn as a cluster of its steps. Edges are drawn between steps that read data from each other,
+// between step groups in the top-level sequence, and from MetadataInjection steps to the step group they execute.
+// Steps that read the dead-letter output of another step are drawn with an edge labelled "rejected".
+func WriteGraph(w io.Writer, t *TransformDefinition, format string) error {
+	g := buildPipeGraph(t)
+	switch format {
+	case GraphFormatDot:
+		return writeGraphDot(w, g)
+	case GraphFormatMermaid:
+		return writeGraphMermaid(w, g)
+	case GraphFormatJSON:
+		return writeGraphJSON(w, g)
+	default:
+		return fmt.Errorf("unsupported graph format %q", format)
+	}
+}
+
+// buildPipeGraph walks the step groups of t in sequence order, followed by any others in name order, and returns
+// the clusters and edges to draw. Steps that refer to unknown steps or step groups are drawn without the edge.
+func buildPipeGraph(t *TransformDefinition) (g pipeGraph) {
+	// Order the step groups.
+	groupNames := make([]string, 0, len(t.StepGroups))
+	seen := make(map[string]bool)
+	for _, name := range t.Sequence {
+		if _, ok := t.StepGroups[name]; ok && !seen[name] {
+			groupNames = append(groupNames, name)
+			seen[name] = true
+		}
+	}
+	others := make([]string, 0)
+	for name := range t.StepGroups {
+		if !seen[name] {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	groupNames = append(groupNames, others...)
+	// Assign ids to step groups and steps.
+	groupIds := make(map[string]string)
+	stepIds := make(map[string]map[string]string)       // map[groupName]map[stepName]nodeId
+	stepOrder := make(map[string][]string)              // map[groupName]stepNames
+	deadLetterIds := make(map[string]map[string]string) // map[groupName]map[deadLetterStep]nodeId of the step that rejects records
+	nodeNum := 0
+	for idx, groupName := range groupNames { // for each step group...
+		sg := t.StepGroups[groupName]
+		groupIds[groupName] = fmt.Sprintf("g%v", idx)
+		stepIds[groupName] = make(map[string]string)
+		deadLetterIds[groupName] = make(map[string]string)
+		cluster := graphCluster{id: groupIds[groupName], label: fmt.Sprintf("%v (%v)", groupName, sg.Type)}
+		for _, stepName := range orderedStepNames(sg) { // for each step...
+			id := fmt.Sprintf("n%v", nodeNum)
+			nodeNum++
+			stepIds[groupName][stepName] = id
+			stepOrder[groupName] = append(stepOrder[groupName], stepName)
+			if d := sg.Steps[stepName].DeadLetterStep; d != "" {
+				deadLetterIds[groupName][d] = id
+			}
+			cluster.nodes = append(cluster.nodes, graphNode{
+				id:        id,
+				label:     fmt.Sprintf("%v\n%v", stepName, sg.Steps[stepName].Type),
+				step:      stepName,
+				stepType:  sg.Steps[stepName].Type,
+				statsName: getStepCanonicalName(t, groupName, stepName),
+			})
+		}
+		g.clusters = append(g.clusters, cluster)
+	}
+	// Add sequence edges.
+	var prev string
+	for _, name := range t.Sequence {
+		id, ok := groupIds[name]
+		if !ok {
+			continue
+		}
+		if prev != "" {
+			g.edges = append(g.edges, graphEdge{from: prev, to: id, edgeType: graphEdgeSequence})
+		}
+		prev = id
+	}
+	// Add data and injection edges.
+	for _, groupName := range groupNames { // for each step group...
+		sg := t.StepGroups[groupName]
+		for _, stepName := range stepOrder[groupName] { // for each step...
+			step := sg.Steps[stepName]
+			to := stepIds[groupName][stepName]
+			refs := getStepDataRefs(step.Type)
+			inputs := make([]string, 0)
+			for _, k := range refs.stepKeys {
+				if v := step.Data[k]; v != "" {
+					inputs = append(inputs, v)
+				}
+			}
+			if step.Type == "MergeStreamsCartesian" {
+				for _, cs := range step.ComponentSteps {
+					inputs = append(inputs, cs.Type)
+				}
+			}
+			for _, v := range inputs { // for each input step in this group...
+				if from, ok := stepIds[groupName][v]; ok {
+					g.edges = append(g.edges, graphEdge{from: from, to: to, edgeType: graphEdgeData})
+				} else if from, ok := deadLetterIds[groupName][v]; ok {
+					g.edges = append(g.edges, graphEdge{from: from, to: to, edgeType: graphEdgeRejected})
+				}
+			}
+			for _, k := range refs.globalStepKeys {
+				v := step.Data[k]
+				if v == "" {
+					continue
+				}
+				for _, otherGroup := range groupNames { // for each step group that may contain the input step...
+					if from, ok := stepIds[otherGroup][v]; ok {
+						g.edges = append(g.edges, graphEdge{from: from, to: to, edgeType: graphEdgeData})
+						break
+					} else if from, ok := deadLetterIds[otherGroup][v]; ok {
+						g.edges = append(g.edges, graphEdge{from: from, to: to, edgeType: graphEdgeRejected})
+						break
+					}
+				}
+			}
+			if step.Type == "MetadataInjection" {
+				if target, ok := groupIds[step.Data["executeTransformName"]]; ok {
+					g.edges = append(g.edges, graphEdge{from: to, to: target, edgeType: graphEdgeInjection})
+				}
+			}
+		}
+	}
+	return
+}
+
+// orderedStepNames returns the names of steps in sg in sequence order, followed by any others in name order.
+func orderedStepNames(sg StepGroup) []string {
+	retval := make([]string, 0, len(sg.Steps))
+	seen := make(map[string]bool)
+	for _, name := range sg.Sequence {
+		if _, ok := sg.Steps[name]; ok && !seen[name] {
+			retval = append(retval, name)
+			seen[name] = true
+		}
+	}
+	others := make([]string, 0)
+	for name := range sg.Steps {
+		if !seen[name] {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(retval, others...)
+}
+
+// writeGraphDot writes g in Graphviz DOT format.
+// Each cluster has an invisible anchor node so edges can start or end at the cluster itself.
+func writeGraphDot(w io.Writer, g pipeGraph) error {
+	b := &strings.Builder{}
+	b.WriteString("digraph pipe {\n  compound=true;\n  rankdir=LR;\n  node [shape=box];\n")
+	clusterIds := make(map[string]bool)
+	for _, c := range g.clusters {
+		clusterIds[c.id] = true
+		fmt.Fprintf(b, "  subgraph cluster_%v {\n    label=%v;\n", c.id, dotQuote(c.label))
+		fmt.Fprintf(b, "    %v [shape=point, style=invis];\n", c.id)
+		for _, n := range c.nodes {
+			fmt.Fprintf(b, "    %v [label=%v];\n", n.id, dotQuote(n.label))
+		}
+		b.WriteString("  }\n")
+	}
+	for _, e := range g.edges {
+		attrs := make([]string, 0)
+		if clusterIds[e.from] {
+			attrs = append(attrs, "ltail=cluster_"+e.from)
+		}
+		if clusterIds[e.to] {
+			attrs = append(attrs, "lhead=cluster_"+e.to)
+		}
+		switch e.edgeType {
+		case graphEdgeSequence:
+			attrs = append(attrs, "style=bold", `label="then"`)
+		case graphEdgeInjection:
+			attrs = append(attrs, "style=dashed", `label="inject"`)
+		case graphEdgeRejected:
+			attrs = append(attrs, "style=dotted", `label="rejected"`)
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(b, "  %v -> %v [%v];\n", e.from, e.to, strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(b, "  %v -> %v;\n", e.from, e.to)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGraphMermaid writes g in Mermaid flowchart format.
+func writeGraphMermaid(w io.Writer, g pipeGraph) error {
+	b := &strings.Builder{}
+	b.WriteString("flowchart LR\n")
+	for _, c := range g.clusters {
+		fmt.Fprintf(b, "  subgraph %v [%v]\n", c.id, mermaidQuote(c.label))
+		for _, n := range c.nodes {
+			fmt.Fprintf(b, "    %v[%v]\n", n.id, mermaidQuote(n.label))
+		}
+		b.WriteString("  end\n")
+	}
+	for _, e := range g.edges {
+		switch e.edgeType {
+		case graphEdgeSequence:
+			fmt.Fprintf(b, "  %v ==>|then| %v\n", e.from, e.to)
+		case graphEdgeInjection:
+			fmt.Fprintf(b, "  %v -.->|inject| %v\n", e.from, e.to)
+		case graphEdgeRejected:
+			fmt.Fprintf(b, "  %v -.->|rejected| %v\n", e.from, e.to)
+		default:
+			fmt.Fprintf(b, "  %v --> %v\n", e.from, e.to)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGraphJSON writes g as JSON for clients that draw the graph themselves, such as the hp serve web UI.
+// Nodes include the name of the step in stats so clients can show the rows processed by each step.
+func writeGraphJSON(w io.Writer, g pipeGraph) error {
+	type jsonNode struct {
+		Id        string `json:"id"`
+		Step      string `json:"step"`
+		StepType  string `json:"stepType"`
+		StatsName string `json:"statsName"`
+	}
+	type jsonCluster struct {
+		Id    string     `json:"id"`
+		Label string     `json:"label"`
+		Nodes []jsonNode `json:"nodes"`
+	}
+	type jsonEdge struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Type string `json:"type"`
+	}
+	out := struct {
+		Clusters []jsonCluster `json:"clusters"`
+		Edges    []jsonEdge    `json:"edges"`
+	}{Clusters: make([]jsonCluster, 0, len(g.clusters)), Edges: make([]jsonEdge, 0, len(g.edges))}
+	for _, c := range g.clusters {
+		jc := jsonCluster{Id: c.id, Label: c.label, Nodes: make([]jsonNode, 0, len(c.nodes))}
+		for _, n := range c.nodes {
+			jc.Nodes = append(jc.Nodes, jsonNode{Id: n.id, Step: n.step, StepType: n.stepType, StatsName: n.statsName})
+		}
+		out.Clusters = append(out.Clusters, jc)
+	}
+	for _, e := range g.edges {
+		out.Edges = append(out.Edges, jsonEdge{From: e.from, To: e.to, Type: e.edgeType})
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func mermaidQuote(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "\n", "<br/>")
+	return `"` + r.Replace(s) + `"`
+}
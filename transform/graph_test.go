@@ -0,0 +1,103 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteGraph(t *testing.T) {
+	d := newValidTransformDefinition()
+	// Test 1 - dot format.
+	b := &bytes.Buffer{}
+	if err := WriteGraph(b, d, GraphFormatDot); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	for _, s := range []string{
+		`subgraph cluster_g0 {`,
+		`label="g1 (sequential)";`,
+		`n0 [label="rows\nGenerateRows"];`,
+		`n0 -> n1;`, // exec reads from rows.
+		`g0 -> g1 [ltail=cluster_g0, lhead=cluster_g1, style=bold, label="then"];`, // g2 runs after g1.
+		`n2 -> g2 [lhead=cluster_g2, style=dashed, label="inject"];`,               // mdi executes g3.
+		`n1 -> n3;`, // bridge reads from exec in another group.
+		`n3 -> n4;`, // merge reads from bridge.
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Fatalf("expected dot output to contain %v; got:\n%v", s, b.String())
+		}
+	}
+	// Test 2 - mermaid format.
+	b.Reset()
+	if err := WriteGraph(b, d, GraphFormatMermaid); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	for _, s := range []string{
+		`subgraph g0 ["g1 (sequential)"]`,
+		`n0["rows<br/>GenerateRows"]`,
+		`n0 --> n1`,
+		`g0 ==>|then| g1`,
+		`n2 -.->|inject| g2`,
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Fatalf("expected mermaid output to contain %v; got:\n%v", s, b.String())
+		}
+	}
+	// Test 3 - dead-letter outputs are drawn as rejected edges.
+	exec := d.StepGroups["g1"].Steps["exec"]
+	exec.OnError = "deadLetter"
+	exec.DeadLetterStep = "execRejected"
+	d.StepGroups["g1"].Steps["exec"] = exec
+	d.StepGroups["g2"].Steps["bridge"].Data["readDataFromStep"] = "execRejected"
+	b.Reset()
+	if err := WriteGraph(b, d, GraphFormatDot); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if s := `n1 -> n3 [style=dotted, label="rejected"];`; !strings.Contains(b.String(), s) {
+		t.Fatalf("expected dot output to contain %v; got:\n%v", s, b.String())
+	}
+	b.Reset()
+	if err := WriteGraph(b, d, GraphFormatMermaid); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if s := `n1 -.->|rejected| n3`; !strings.Contains(b.String(), s) {
+		t.Fatalf("expected mermaid output to contain %v; got:\n%v", s, b.String())
+	}
+	// Test 4 - json format.
+	b.Reset()
+	if err := WriteGraph(b, d, GraphFormatJSON); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	g := struct {
+		Clusters []struct {
+			Id    string
+			Label string
+			Nodes []struct{ Id, Step, StepType, StatsName string }
+		}
+		Edges []struct{ From, To, Type string }
+	}{}
+	if err := json.Unmarshal(b.Bytes(), &g); err != nil {
+		t.Fatal("unexpected error unmarshalling json output: ", err)
+	}
+	if len(g.Clusters) != 3 || g.Clusters[0].Label != "g1 (sequential)" {
+		t.Fatalf("unexpected clusters in json output: %+v", g.Clusters)
+	}
+	n := g.Clusters[0].Nodes[0]
+	if n.Id != "n0" || n.Step != "rows" || n.StepType != "GenerateRows" || n.StatsName != getStepCanonicalName(d, "g1", "rows") {
+		t.Fatalf("unexpected node in json output: %+v", n)
+	}
+	foundInjection := false
+	for _, e := range g.Edges {
+		if e.From == "n2" && e.To == "g2" && e.Type == graphEdgeInjection {
+			foundInjection = true
+		}
+	}
+	if !foundInjection {
+		t.Fatalf("expected json output to contain an inject edge; got: %+v", g.Edges)
+	}
+	// Test 5 - unsupported format.
+	if err := WriteGraph(b, d, "png"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
@@ -30,6 +30,8 @@ type TransformManager interface {
 	sendOutputChanToRequesters(fromStepName string, c chan stream.Record)
 	transformGroupIsMdiTarget(transformGroupName string) bool
 	shutdown()
+	pause() error
+	resume() error
 }
 
 // StepGroupManager used to track individual transform step groups.
@@ -50,4 +52,6 @@ type StepGroupManager interface {
 	consumeUnusedOutputs()
 	waitForCompletion()
 	shutdown()
+	pause() error
+	resume() error
 }
@@ -31,21 +31,24 @@ func LaunchTransformJson(log logger.Logger, ti *SafeMapTransformInfo, transformJ
 		// err = errors.Wrap(err, "unable to unmarshal transform pipe")
 		return
 	}
-	return LaunchTransformDefinition(log, ti, t, blockUntilComplete, statsDumpFrequencySeconds)
+	return LaunchTransformDefinition(log, ti, t, "", blockUntilComplete, statsDumpFrequencySeconds)
 }
 
 // LaunchTransformJson validates the supplied TransformDefinition and launches the transform.
-// It stores the GUID of the new transform in ti and returns it.
+// It stores the GUID of the new transform in ti, along with the name of the credential in submittedBy, and returns it.
 // An error is returned if there is a problem validating the JSON.
 // If blockUntilComplete is false then the transform is launched in a goroutine.
-func LaunchTransformDefinition(log logger.Logger, ti *SafeMapTransformInfo, t *TransformDefinition, blockUntilComplete bool, statsDumpFrequencySeconds int) (guid string, err error) {
+func LaunchTransformDefinition(log logger.Logger, ti *SafeMapTransformInfo, t *TransformDefinition, submittedBy string, blockUntilComplete bool, statsDumpFrequencySeconds int) (guid string, err error) {
 	// Validate the transform.
 	err = helper.ValidateStructIsPopulated(t)
 	if err != nil { // if there was an error in validation...
 		return // guid, err
 	} else { // else the transform is okay, 1st parse...
 		// Save info about the new transform.
-		s := stats.NewTransformStats(log, stats.SetStatsDumpFrequency(statsDumpFrequencySeconds))
+		events := NewEventBroker()
+		s := stats.NewTransformStats(log,
+			stats.SetStatsDumpFrequency(statsDumpFrequencySeconds),
+			stats.SetStatsHandler(func(s []stats.Stats) { events.Publish(Event{Type: EventTypeStats, Data: s}) }))
 		chanStatus := make(chan TransformStatus, 1) // channel for us to receive status messages back from the transform
 		chanShutdown := make(chan error, 1)         // channel upon which we can stop the current transform
 		tc := NewTransformCloser(chanStatus, chanShutdown)
@@ -54,10 +57,13 @@ func LaunchTransformDefinition(log logger.Logger, ti *SafeMapTransformInfo, t *T
 			guid,
 			TransformInfo{ // save details about this transform
 				// Closer: tc,
-				ChanStop:  chanShutdown,
-				Stats:     s,
-				Transform: *t, // save value
-				Status:    TransformStatus{Status: StatusStarting, StartTime: time.Now()},
+				ChanStop:    chanShutdown,
+				ChanControl: tc.chanControl,
+				Stats:       s,
+				Transform:   *t, // save value
+				Status:      TransformStatus{Status: StatusStarting, StartTime: time.Now()},
+				SubmittedBy: submittedBy,
+				Events:      events,
 			})
 		// Launch a goroutine to consume status messages from the transform, saving them to our instance of TransformInfo.
 		go ti.ConsumeTransformStatusChanges(guid, chanStatus)
@@ -134,6 +140,7 @@ func LaunchTransform(log logger.Logger,
 			case <-ctx.Done():
 				quit = true
 			case <-time.After(getSleepDuration(log, lastStartTime, transformDefn.RepeatMeta.SleepSeconds)): // pause until next timeout.
+				quit = !tm.waitWhilePaused(ctx) // don't start the next iteration while the transform is paused.
 			}
 			if quit {
 				break
@@ -156,6 +163,9 @@ func startStepGroupsOfType(
 	panicHandlerFn components.PanicHandlerFunc,
 ) {
 	for _, stepGroupName := range transformDefn.Sequence { // for each enabled stepGroup...
+		if !tm.waitWhilePaused(ctx) { // if we were shutdown while paused...
+			return
+		}
 		// Launch the transform stepGroup in series (if it is not a MDI target).
 		if !tm.transformGroupIsMdiTarget(stepGroupName) { // if we have a valid transform group that we can launch...
 			sg := transformDefn.StepGroups[stepGroupName] // create a copy of the step group.
@@ -184,6 +194,9 @@ func startStepGroupsOfType(
 						log.Info("Repeating step group ", stepGroupName, " completed ", idx, " iteration(s)")
 						select {
 						case <-time.After(getSleepDuration(log, lastStartTime, sg.RepeatMeta.SleepSeconds)): // pause until next timeout.
+							if !tm.waitWhilePaused(ctxRepeat) { // if we were shutdown while paused...
+								return // don't start the next iteration.
+							}
 						case <-ctxRepeat.Done():
 							break
 						}
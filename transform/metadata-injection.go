@@ -117,6 +117,9 @@ func NewMetadataInjection(i interface{}) (outputChan chan stream.Record, control
 					s.StopDumping()
 				}
 			case controlAction := <-controlChan: // if we were asked to shutdown...
+				if controlAction = components.HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == components.Resume {
+					continue // carry on processing input.
+				}
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				cfg.Log.Info(cfg.Name, " shutdown")
 				return
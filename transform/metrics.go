@@ -0,0 +1,126 @@
+package transform
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// metricStatusLabels are the values of label "status" used by metric halfpipe_pipe_status.
+var metricStatusLabels = []struct {
+	status Status
+	label  string
+}{
+	{StatusStarting, "starting"},
+	{StatusRunning, "running"},
+	{StatusComplete, "complete"},
+	{StatusCompleteWithError, "error"},
+	{StatusShutdown, "shutdown"},
+	{StatusPaused, "paused"},
+}
+
+// metricDefinitions lists the HELP and TYPE of each metric written by WritePrometheusMetrics in output order.
+var metricDefinitions = []struct {
+	name       string
+	metricType string
+	help       string
+}{
+	{"halfpipe_pipe_status", "gauge", "Status of the pipe, where the series with value 1 is the current status."},
+	{"halfpipe_pipe_duration_seconds", "gauge", "Seconds since the pipe started, or its total run time if it has finished."},
+	{"halfpipe_step_rows_total", "counter", "Number of rows output by the step."},
+	{"halfpipe_step_rows_per_second", "gauge", "Rows per second output by the step since the last stats capture."},
+	{"halfpipe_step_rows_per_second_avg", "gauge", "Average rows per second output by the step since it started."},
+	{"halfpipe_step_output_buffer_length", "gauge", "Number of rows waiting in the output channel of the step."},
+	{"halfpipe_step_errors_total", "counter", "Number of rows rejected by the step due to onError skip or deadLetter."},
+}
+
+type stepNames struct {
+	stepGroup string
+	step      string
+}
+
+// WritePrometheusMetrics writes the status and step stats of all transforms in allTransformInfo to w using the
+// Prometheus text exposition format. Each series is labelled by pipe GUID and, for steps, the step group and step name.
+func WritePrometheusMetrics(w io.Writer, allTransformInfo *SafeMapTransformInfo) error {
+	samples := make(map[string][]string) // map[metric name]sample lines
+	add := func(name string, labels [][2]string, value interface{}) {
+		l := make([]string, 0, len(labels))
+		for _, kv := range labels {
+			l = append(l, fmt.Sprintf(`%v="%v"`, kv[0], escapeMetricLabel(kv[1])))
+		}
+		samples[name] = append(samples[name], fmt.Sprintf("%v{%v} %v", name, strings.Join(l, ","), value))
+	}
+	allTransformInfo.RLock()
+	guids := make([]string, 0, len(allTransformInfo.Internal))
+	for guid := range allTransformInfo.Internal {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+	for _, guid := range guids { // for each transform in a predictable order...
+		ti := allTransformInfo.Internal[guid]
+		pipe := [2]string{"pipe", guid}
+		// Add the transform status and duration.
+		for _, s := range metricStatusLabels {
+			value := 0
+			if ti.Status.Status == s.status {
+				value = 1
+			}
+			add("halfpipe_pipe_status", [][2]string{pipe, {"status", s.label}}, value)
+		}
+		if !ti.Status.StartTime.IsZero() {
+			end := time.Now()
+			if ti.Status.TransformIsFinished() && !ti.Status.EndTime.IsZero() {
+				end = ti.Status.EndTime
+			}
+			add("halfpipe_pipe_duration_seconds", [][2]string{pipe}, end.Sub(ti.Status.StartTime).Seconds())
+		}
+		if ti.Stats == nil {
+			continue
+		}
+		// Add the step stats.
+		names := getStepNamesByCanonicalName(&ti.Transform)
+		for _, s := range ti.Stats.GetStats() { // for each step...
+			n, ok := names[s.StepName]
+			if !ok { // if the step was not found in the definition (e.g. it was launched by metadata injection)...
+				n = stepNames{step: s.StepName}
+			}
+			labels := [][2]string{pipe, {"step_group", n.stepGroup}, {"step", n.step}}
+			add("halfpipe_step_rows_total", labels, s.TotalRowsProcessed)
+			add("halfpipe_step_rows_per_second", labels, s.RowsPerSecondDelta)
+			add("halfpipe_step_rows_per_second_avg", labels, s.RowsPerSecondAvg)
+			add("halfpipe_step_output_buffer_length", labels, s.OutputBufferLen)
+			add("halfpipe_step_errors_total", labels, s.ErrorCount)
+		}
+	}
+	allTransformInfo.RUnlock()
+	for _, m := range metricDefinitions { // for each metric...
+		if _, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", m.name, m.help, m.name, m.metricType); err != nil {
+			return err
+		}
+		for _, line := range samples[m.name] {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getStepNamesByCanonicalName returns a map of the canonical name used in stats to the step group and name of
+// each step in t.
+func getStepNamesByCanonicalName(t *TransformDefinition) map[string]stepNames {
+	retval := make(map[string]stepNames)
+	for groupName, sg := range t.StepGroups {
+		for stepName := range sg.Steps {
+			retval[getStepCanonicalName(t, groupName, stepName)] = stepNames{stepGroup: groupName, step: stepName}
+		}
+	}
+	return retval
+}
+
+// escapeMetricLabel escapes backslashes, double quotes and new lines in a label value, ready to be quoted.
+func escapeMetricLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
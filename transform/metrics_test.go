@@ -0,0 +1,50 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relloyd/halfpipe/stats"
+)
+
+type mockStatsFetcher []stats.Stats
+
+func (m mockStatsFetcher) GetStats() []stats.Stats {
+	return m
+}
+
+func TestWritePrometheusMetrics(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ti := NewSafeMapTransformInfo()
+	ti.Store("guid-1", TransformInfo{
+		Transform: *newValidTransformDefinition(),
+		Status:    TransformStatus{Status: StatusComplete, StartTime: start, EndTime: start.Add(90 * time.Second)},
+		Stats: mockStatsFetcher{
+			{StepName: "g1.rows (GenerateRows)", TotalRowsProcessed: 10, RowsPerSecondAvg: 5, RowsPerSecondDelta: 2, OutputBufferLen: 3},
+			{StepName: "g1.exec (SqlExec)", TotalRowsProcessed: 8, ErrorCount: 2},
+			{StepName: `unknown "step"`, TotalRowsProcessed: 1},
+		},
+	})
+	b := &bytes.Buffer{}
+	if err := WritePrometheusMetrics(b, ti); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	for _, s := range []string{
+		`# TYPE halfpipe_step_rows_total counter`,
+		`halfpipe_pipe_status{pipe="guid-1",status="complete"} 1`,
+		`halfpipe_pipe_status{pipe="guid-1",status="running"} 0`,
+		`halfpipe_pipe_duration_seconds{pipe="guid-1"} 90`,
+		`halfpipe_step_rows_total{pipe="guid-1",step_group="g1",step="rows"} 10`,
+		`halfpipe_step_rows_per_second{pipe="guid-1",step_group="g1",step="rows"} 2`,
+		`halfpipe_step_rows_per_second_avg{pipe="guid-1",step_group="g1",step="rows"} 5`,
+		`halfpipe_step_output_buffer_length{pipe="guid-1",step_group="g1",step="rows"} 3`,
+		`halfpipe_step_errors_total{pipe="guid-1",step_group="g1",step="exec"} 2`,
+		`halfpipe_step_rows_total{pipe="guid-1",step_group="",step="unknown \"step\""} 1`, // label values are escaped.
+	} {
+		if !strings.Contains(b.String(), s+"\n") {
+			t.Fatalf("expected metrics output to contain %v; got:\n%v", s, b.String())
+		}
+	}
+}
@@ -74,6 +74,14 @@ func (s *MockStepGroupManager) shutdown() {
 	return
 }
 
+func (s *MockStepGroupManager) pause() error {
+	return nil
+}
+
+func (s *MockStepGroupManager) resume() error {
+	return nil
+}
+
 func newMockStepGroupManager(responseChan chan string) *MockStepGroupManager {
 	return &MockStepGroupManager{responseChan: responseChan}
 }
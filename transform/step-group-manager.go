@@ -1,6 +1,7 @@
 package transform
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/relloyd/halfpipe/components"
@@ -8,6 +9,10 @@ import (
 	"github.com/relloyd/halfpipe/stream"
 )
 
+// controlResponseTimeout is how long steps have to respond to pause and resume requests, which may involve
+// committing work in progress.
+var controlResponseTimeout = 60 * time.Second
+
 // stepGroup to track steps in a transform group.
 type stepGroup struct {
 	log                    logger.Logger
@@ -145,6 +150,9 @@ func (sg *stepGroup) consumeUnusedOutputs() {
 					return
 				}
 			case controlAction := <-controlChan:
+				if controlAction = components.HandlePauseAction(controlAction, controlChan, nil); controlAction.Action == components.Resume {
+					continue
+				}
 				controlAction.ResponseChan <- nil // respond that we're done with a nil error.
 				sg.log.Debug("Auto consumer of unused output for step ", stepNameToConsume, " was shutdown")
 				// Do NOT call sg.waiter.Done() as we don't want a shutdown action to cause normal completion i.e. "success without error".
@@ -210,3 +218,54 @@ func (sg *stepGroup) shutdown() {
 	sg.shutdownChannelsInMap(sg.mapControlChans)     // shutdown all steps.
 	sg.shutdownChannelsInMap(sg.mapControlChansAuto) // shutdown all auto-created consumers of unused outputs.
 }
+
+// pause asks all steps that are not complete to stop processing rows and waits for them to confirm.
+func (sg *stepGroup) pause() error {
+	return sg.sendActionToAllSteps(components.Pause)
+}
+
+// resume asks all steps that are not complete to carry on after pause.
+func (sg *stepGroup) resume() error {
+	return sg.sendActionToAllSteps(components.Resume)
+}
+
+// sendActionToAllSteps sends action to the control channels of all steps, including auto-created consumers of
+// unused outputs, that are not complete. It then waits up to controlResponseTimeout for each of them to respond.
+// Steps that complete instead of responding are ignored.
+func (sg *stepGroup) sendActionToAllSteps(action components.Action) error {
+	sent := make(map[string]components.ControlAction)
+	for _, m := range []map[string]chan components.ControlAction{sg.mapControlChans, sg.mapControlChansAuto} {
+		for k, c := range m { // for each step that has registered its control channel...
+			if s, _ := sg.waiter.LoadStatus(k); s == StepStatusDone { // if the step is complete...
+				continue
+			}
+			a := components.ControlAction{Action: action, ResponseChan: make(chan error, 1)}
+			select {
+			case c <- a:
+				sent[k] = a
+			case <-time.After(controlResponseTimeout): // if the step has not read the last action sent to it...
+				return fmt.Errorf("step %v failed to accept %v request in a timely manner", sg.getStepCanonicalName(k), action)
+			}
+		}
+	}
+	timeout := time.After(controlResponseTimeout)
+	for k, a := range sent { // for each step that we sent the action to...
+		for waiting := true; waiting; {
+			select {
+			case err := <-a.ResponseChan:
+				if err != nil {
+					return fmt.Errorf("step %v failed to %v: %v", sg.getStepCanonicalName(k), action, err)
+				}
+				waiting = false
+			case <-time.After(time.Second): // check whether the step completed instead of responding...
+				if s, _ := sg.waiter.LoadStatus(k); s == StepStatusDone {
+					waiting = false
+				}
+			case <-timeout:
+				return fmt.Errorf("step %v failed to %v in a timely manner", sg.getStepCanonicalName(k), action)
+			}
+		}
+	}
+	sg.log.Debug("Sent ", action, " to all steps in transform step group ", sg.transformGroupName)
+	return nil
+}
@@ -3,6 +3,8 @@ package transform
 import (
 	"sync"
 	"sync/atomic"
+
+	"github.com/relloyd/halfpipe/components"
 )
 
 // TransformCloser tracks the channels used to maintain transform status and whether it is shutdown or not.
@@ -11,10 +13,20 @@ type TransformCloser struct {
 	mu                              sync.Mutex
 	chanStatus                      chan TransformStatus
 	chanShutdown                    chan error
+	chanControl                     chan components.ControlAction // requests to pause or resume the transform
 }
 
 func NewTransformCloser(chanStatus chan TransformStatus, chanShutdown chan error) *TransformCloser {
-	return &TransformCloser{chanStatus: chanStatus, chanShutdown: chanShutdown}
+	return &TransformCloser{chanStatus: chanStatus, chanShutdown: chanShutdown, chanControl: make(chan components.ControlAction)}
+}
+
+// SendStatus sends statusToSend to chanStatus inside a mutex unless the channels are already closed.
+func (c *TransformCloser) SendStatus(statusToSend *TransformStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if atomic.AddInt32(&c.flagClosedChanStatusAndShutdown, 0) == 0 { // if the status channel is still open...
+		c.chanStatus <- *statusToSend
+	}
 }
 
 // CloseChannels closes chanStatus and chanShutdown inside a mutex.
@@ -4,14 +4,18 @@ import (
 	"sync"
 	"time"
 
+	"github.com/relloyd/halfpipe/components"
 	"github.com/relloyd/halfpipe/stats"
 )
 
 type TransformInfo struct {
-	Transform TransformDefinition // TODO: implement transform "name" in TransformInfo{} and TransformDefinition{}
-	ChanStop  chan error
-	Status    TransformStatus `json:"transformStatus"`
-	Stats     stats.StatsFetcher
+	Transform   TransformDefinition // TODO: implement transform "name" in TransformInfo{} and TransformDefinition{}
+	ChanStop    chan error
+	Status      TransformStatus `json:"transformStatus"`
+	Stats       stats.StatsFetcher
+	SubmittedBy string // name of the credential that launched the transform, if any
+	Events      *EventBroker
+	ChanControl chan components.ControlAction // send components.Pause or components.Resume to control the transform
 }
 
 // TransformDefinition Manager to wrap a map[string]StepGroupManager with locking, via Load() and Store() methods.
@@ -32,6 +36,22 @@ func (t *SafeMapTransformInfo) Load(key string) (ti TransformInfo, ok bool) {
 	t.RUnlock()
 	return
 }
+
+// GetStepStats returns the stats of step stepName in step group stepGroupName.
+// ok is false if there are no stats for the step, for example if it has not been launched.
+func (ti TransformInfo) GetStepStats(stepGroupName string, stepName string) (s stats.Stats, ok bool) {
+	if ti.Stats == nil {
+		return s, false
+	}
+	name := getStepCanonicalName(&ti.Transform, stepGroupName, stepName)
+	for _, s = range ti.Stats.GetStats() { // for each step...
+		if s.StepName == name {
+			return s, true
+		}
+	}
+	return stats.Stats{}, false
+}
+
 func (t *SafeMapTransformInfo) Store(key string, value TransformInfo) {
 	t.Lock()
 	t.Internal[key] = value
@@ -46,14 +66,19 @@ func (t *SafeMapTransformInfo) Delete(key string) {
 
 // ConsumeTransformStatusChanges loops until chanStatus is closed
 // and updates t.Internal[transformGuid] with any statuses received.
+// Each new status is published to the transform's Events, which are closed at the end.
 func (t *SafeMapTransformInfo) ConsumeTransformStatusChanges(transformGuid string, chanStatus chan TransformStatus) {
 	for status := range chanStatus {
 		ti, _ := t.Load(transformGuid)
 		switch status.Status {
 		// case transform.StatusStarting:
 		case StatusRunning:
+			if ti.Status.Status != StatusPaused { // if we are not resuming...
+				ti.Status.StartTime = time.Now()
+			}
+			ti.Status.Status = status.Status
+		case StatusPaused:
 			ti.Status.Status = status.Status
-			ti.Status.StartTime = time.Now()
 		case StatusComplete:
 			ti.Status.Status = status.Status
 			ti.Status.EndTime = time.Now()
@@ -66,5 +91,11 @@ func (t *SafeMapTransformInfo) ConsumeTransformStatusChanges(transformGuid strin
 			ti.Status.EndTime = time.Now()
 		}
 		t.Store(transformGuid, ti)
+		if ti.Events != nil {
+			ti.Events.Publish(Event{Type: EventTypeStatus, Data: ti.Status})
+		}
+	}
+	if ti, ok := t.Load(transformGuid); ok && ti.Events != nil {
+		ti.Events.Close()
 	}
 }
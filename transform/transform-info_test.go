@@ -0,0 +1,31 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/relloyd/halfpipe/stats"
+)
+
+func TestGetStepStats(t *testing.T) {
+	ti := TransformInfo{
+		Transform: *newValidTransformDefinition(),
+		Stats: mockStatsFetcher{
+			{StepName: "g1.rows (GenerateRows)", TotalRowsProcessed: 10},
+			{StepName: "g1.rows (GenerateRows) copy", TotalRowsProcessed: 20},
+		},
+	}
+	// Test 1: the step is found by its exact canonical name.
+	s, ok := ti.GetStepStats("g1", "rows")
+	if !ok || s.TotalRowsProcessed != 10 {
+		t.Fatalf("expected stats for step rows with 10 rows; got ok = %v, stats = %+v", ok, s)
+	}
+	// Test 2: an unknown step is not found.
+	if s, ok = ti.GetStepStats("g1", "row"); ok {
+		t.Fatalf("expected no stats for an unknown step; got %+v", s)
+	}
+	// Test 3: no stats are found when the transform has no stats.
+	ti.Stats = nil
+	if s, ok = ti.GetStepStats("g1", "rows"); ok || s != (stats.Stats{}) {
+		t.Fatalf("expected no stats when there is no stats fetcher; got %+v", s)
+	}
+}
@@ -66,3 +66,11 @@ func (tm *MockTransformManager) transformGroupIsMdiTarget(transformGroupName str
 func (tm *MockTransformManager) shutdown() {
 	return
 }
+
+func (tm *MockTransformManager) pause() error {
+	return nil
+}
+
+func (tm *MockTransformManager) resume() error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package transform
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -19,6 +20,8 @@ type Transform struct {
 	mapMetadataInjectionTargets map[string]string // store the names of StepGroups that are the targets of metadata injection.
 	mapConsumers                consumers         // store the requested step as the first key, while the second key gives the requesting steps.
 	mapStepGroups               stepGroups        // map of child transforms spawned from this struct by calling newStepGroupManager().
+	muPause                     sync.Mutex
+	chanResume                  chan struct{} // closed by resume() after pause(); nil while the transform is not paused.
 }
 
 // TransformDefinition Manager to wrap a map[string]StepGroupManager with locking, via Load() and Store() methods.
@@ -111,10 +114,15 @@ func (tm *Transform) getTransformStepGroup(name string) StepGroup {
 
 // getStepCanonicalName will return the canonical name of a step.
 func (tm *Transform) getStepCanonicalName(transformGroupName string, stepName string) string {
+	return getStepCanonicalName(tm.trans, transformGroupName, stepName)
+}
+
+// getStepCanonicalName returns the name used to identify a step in logs and stats.
+func getStepCanonicalName(t *TransformDefinition, transformGroupName string, stepName string) string {
 	return fmt.Sprintf("%v.%v (%v)",
 		transformGroupName,
 		stepName,
-		tm.trans.StepGroups[transformGroupName].Steps[stepName].Type,
+		t.StepGroups[transformGroupName].Steps[stepName].Type,
 	)
 }
 
@@ -194,3 +202,57 @@ func (tm *Transform) shutdown() {
 	}
 	tm.mapStepGroups.Unlock()
 }
+
+// pause asks the steps of all running step groups to stop processing rows.
+// Step groups that are due to start are held back by waitWhilePaused() until resume is called.
+func (tm *Transform) pause() error {
+	tm.muPause.Lock()
+	if tm.chanResume == nil { // if we are not already paused...
+		tm.chanResume = make(chan struct{})
+	}
+	tm.muPause.Unlock()
+	tm.mapStepGroups.Lock() // stop anyone else making changes / adding new transforms.
+	defer tm.mapStepGroups.Unlock()
+	for _, sg := range tm.mapStepGroups.internal { // for each child transformation step group name...
+		if err := sg.pause(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resume releases callers of waitWhilePaused() and asks the steps of all running step groups to carry on after pause.
+func (tm *Transform) resume() error {
+	tm.muPause.Lock()
+	if tm.chanResume != nil { // if we were paused...
+		close(tm.chanResume)
+		tm.chanResume = nil
+	}
+	tm.muPause.Unlock()
+	tm.mapStepGroups.Lock()
+	defer tm.mapStepGroups.Unlock()
+	for _, sg := range tm.mapStepGroups.internal { // for each child transformation step group name...
+		if err := sg.resume(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitWhilePaused blocks while the transform is paused.
+// It returns false if ctx is cancelled before the transform is resumed.
+func (tm *Transform) waitWhilePaused(ctx context.Context) bool {
+	tm.muPause.Lock()
+	c := tm.chanResume
+	tm.muPause.Unlock()
+	if c == nil { // if we are not paused...
+		return true
+	}
+	tm.log.Info("Transform ", tm.transGuid, " is paused; waiting to resume...")
+	select {
+	case <-c:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
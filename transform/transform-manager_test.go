@@ -0,0 +1,118 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/relloyd/halfpipe/components"
+	"github.com/relloyd/halfpipe/logger"
+)
+
+func TestTransformPauseResume(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	tm := NewTransformManager(log, &TransformDefinition{}, "guid")
+	sg := tm.newStepGroupManager("group").(*stepGroup)
+	// Register a step that records the actions it receives.
+	received := make(chan components.Action, 10)
+	running := make(chan components.ControlAction, 1)
+	sg.setStepControlChan("running", running)
+	sg.getComponentWaiter("running").Add()
+	go func() {
+		for a := range running {
+			received <- a.Action
+			a.ResponseChan <- nil
+		}
+	}()
+	// Register a step that is complete so it should be skipped.
+	done := make(chan components.ControlAction, 1)
+	sg.setStepControlChan("done", done)
+	w := sg.getComponentWaiter("done")
+	w.Add()
+	w.Done()
+	// Test 1 - pause is sent to running steps and new step groups are held back.
+	if err := tm.pause(); err != nil {
+		t.Fatal("Test 1, unexpected error: ", err)
+	}
+	if a := <-received; a != components.Pause {
+		t.Fatal("Test 1, expected pause; got ", a)
+	}
+	if len(done) != 0 {
+		t.Fatal("Test 1, expected no action to be sent to the complete step")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if tm.waitWhilePaused(ctx) {
+		t.Fatal("Test 1, expected waitWhilePaused to block until cancelled")
+	}
+	// Test 2 - resume is sent to running steps and new step groups are released.
+	if err := tm.resume(); err != nil {
+		t.Fatal("Test 2, unexpected error: ", err)
+	}
+	if a := <-received; a != components.Resume {
+		t.Fatal("Test 2, expected resume; got ", a)
+	}
+	if !tm.waitWhilePaused(context.Background()) {
+		t.Fatal("Test 2, expected waitWhilePaused to return true")
+	}
+	close(running)
+}
+
+func TestTransformPauseTimeout(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	saved := controlResponseTimeout
+	controlResponseTimeout = 100 * time.Millisecond
+	defer func() { controlResponseTimeout = saved }()
+	tm := NewTransformManager(log, &TransformDefinition{}, "guid")
+	sg := tm.newStepGroupManager("group").(*stepGroup)
+	// Register a step that never responds.
+	sg.setStepControlChan("busy", make(chan components.ControlAction, 1))
+	sg.getComponentWaiter("busy").Add()
+	if err := tm.pause(); err == nil {
+		t.Fatal("expected error when a step fails to respond to pause")
+	}
+}
+
+func TestPauseOrResumeTransformFailure(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	tm := NewTransformManager(log, &TransformDefinition{}, "guid")
+	sg := tm.newStepGroupManager("group").(*stepGroup)
+	// Register a step that pauses and one that fails to pause.
+	received := make(chan components.Action, 10)
+	for _, name := range []string{"ok", "bad"} {
+		c := make(chan components.ControlAction, 1)
+		sg.setStepControlChan(name, c)
+		sg.getComponentWaiter(name).Add()
+		go func(name string, c chan components.ControlAction) {
+			for a := range c {
+				if name == "bad" && a.Action == components.Pause {
+					a.ResponseChan <- errors.New("commit failed")
+					continue
+				}
+				if name == "ok" {
+					received <- a.Action
+				}
+				a.ResponseChan <- nil
+			}
+		}(name, c)
+	}
+	chanStatus := make(chan TransformStatus, 1)
+	tc := NewTransformCloser(chanStatus, make(chan error, 1))
+	// Test 1 - the error is returned and steps that paused are resumed.
+	if err := pauseOrResumeTransform(log, tm, tc, components.Pause); err == nil {
+		t.Fatal("Test 1, expected error when a step fails to pause")
+	}
+	for _, expected := range []components.Action{components.Pause, components.Resume} {
+		if a := <-received; a != expected {
+			t.Fatalf("Test 1, expected %v; got %v", expected, a)
+		}
+	}
+	// Test 2 - the transform is not left paused.
+	if !tm.waitWhilePaused(context.Background()) {
+		t.Fatal("Test 2, expected waitWhilePaused to return true")
+	}
+	if len(chanStatus) != 0 {
+		t.Fatal("Test 2, expected no status to be sent")
+	}
+}
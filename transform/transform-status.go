@@ -15,6 +15,7 @@ const (
 	StatusComplete
 	StatusCompleteWithError
 	StatusShutdown
+	StatusPaused
 )
 
 // Richard - commented TransformStatus.String() - I think it is handled by MarshalJSON() instead!
@@ -37,6 +38,8 @@ func (s Status) MarshalJSON() ([]byte, error) {
 		retval = "complete with error"
 	case StatusShutdown:
 		retval = "shutdown by user"
+	case StatusPaused:
+		retval = "paused"
 	default:
 		err := fmt.Errorf("unhandled Status value %v in custom MarshalJSON() conversion", s)
 		return nil, err
@@ -44,6 +47,33 @@ func (s Status) MarshalJSON() ([]byte, error) {
 	return json.Marshal(retval)
 }
 
+// UnmarshalJSON is the reverse of MarshalJSON so that saved statuses can be read back.
+func (s *Status) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	switch str {
+	case "":
+		*s = StatusMissing
+	case "starting":
+		*s = StatusStarting
+	case "running":
+		*s = StatusRunning
+	case "complete":
+		*s = StatusComplete
+	case "complete with error":
+		*s = StatusCompleteWithError
+	case "shutdown by user":
+		*s = StatusShutdown
+	case "paused":
+		*s = StatusPaused
+	default:
+		return fmt.Errorf("unhandled Status value %q in custom UnmarshalJSON() conversion", str)
+	}
+	return nil
+}
+
 type TransformStatus struct {
 	StartTime time.Time `json:"startTime"`
 	EndTime   time.Time `json:"endTime"`
@@ -52,7 +82,7 @@ type TransformStatus struct {
 }
 
 func (t *TransformStatus) TransformIsFinished() bool {
-	if t.Status == StatusStarting || t.Status == StatusRunning { // if the transform is running...
+	if t.Status == StatusStarting || t.Status == StatusRunning || t.Status == StatusPaused { // if the transform is running...
 		return false // we're not finished!
 	} else { // else the transform is NOT running...
 		return true
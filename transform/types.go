@@ -39,6 +39,9 @@ type Step struct {
 	Type           string                     `json:"type" errorTxt:"step type" mandatory:"yes"`
 	Data           map[string]string          `json:"data" errorTxt:"step data" mandatory:"yes"`
 	ComponentSteps []components.ComponentStep `json:"steps" errorTxt:"extra steps" mandatory:"no"`
+	OnError        string                     `json:"onError,omitempty" errorTxt:"on error (fail|skip|deadLetter)" mandatory:"no"` // const components.OnErrorFail, OnErrorSkip, OnErrorDeadLetter
+	DeadLetterStep string                     `json:"deadLetterStep,omitempty" errorTxt:"dead-letter step" mandatory:"no"`         // the name under which rejected records are output for other steps to read.
+	MaxErrors      int                        `json:"maxErrors,omitempty" errorTxt:"max errors" mandatory:"no"`                    // abort once this many records are rejected; 0 means no limit.
 }
 
 type RepeatMetadata struct {
@@ -0,0 +1,214 @@
+package transform
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/relloyd/halfpipe/helper"
+	"github.com/relloyd/halfpipe/logger"
+)
+
+// ValidationError describes a problem found in a TransformDefinition at the given JSON path.
+type ValidationError struct {
+	Path string
+	Msg  string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Path, e.Msg)
+}
+
+// stepDataRefs lists the keys in step data whose values refer to other steps or to database connections.
+type stepDataRefs struct {
+	stepKeys       []string // keys that name an earlier step in the same step group.
+	globalStepKeys []string // keys that name a step in any step group.
+	connectionKeys []string // keys that name a database connection.
+}
+
+// bespokeStepDataRefs lists the references made by the step types that are not started by the generic launcher.
+// The references made by the other step types are found using the struct tags on their config.
+var bespokeStepDataRefs = map[string]stepDataRefs{
+	"MetadataInjection": {stepKeys: []string{"readDataFromStep"}},
+	"ChannelBridge":     {globalStepKeys: []string{"readDataFromStep"}},
+	"OracleContinuousQueryNotificationToRdbms": {
+		connectionKeys: []string{"sourceDatabaseConnectionName", "targetDatabaseConnectionName"}},
+	"OracleContinuousQueryNotificationToSnowflake": {
+		connectionKeys: []string{"sourceDatabaseConnectionName", "targetDatabaseConnectionName"}},
+}
+
+// ValidateTransformDefinition statically checks TransformDefinition t without opening any connections or starting
+// any steps. All problems found are returned together with the JSON path of the value that is in error.
+func ValidateTransformDefinition(log logger.Logger, t *TransformDefinition) (errs []ValidationError) {
+	errs = make([]ValidationError, 0)
+	if err := helper.ValidateStructIsPopulated(t); err != nil {
+		errs = append(errs, ValidationError{"$", err.Error()})
+	}
+	funcs := getComponentFuncsWithMetadataInjection()
+	// Check the top-level sequence.
+	for idx, name := range t.Sequence { // for each step group in the sequence...
+		if _, ok := t.StepGroups[name]; !ok {
+			errs = append(errs, ValidationError{fmt.Sprintf("$.sequence[%v]", idx), fmt.Sprintf("step group %q is not defined", name)})
+		}
+	}
+	// Check each step group in a predictable order.
+	groupNames := make([]string, 0, len(t.StepGroups))
+	for name := range t.StepGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, groupName := range groupNames { // for each step group...
+		sg := t.StepGroups[groupName]
+		groupPath := fmt.Sprintf("$.transformGroups.%v", groupName)
+		if sg.Type != StepGroupSequential && sg.Type != StepGroupRepeating && sg.Type != StepGroupBackground {
+			errs = append(errs, ValidationError{groupPath + ".type", fmt.Sprintf("unknown step group type %q", sg.Type)})
+		}
+		// Save the position of each step in the sequence so we can check steps only read from earlier ones.
+		seqIdx := make(map[string]int)
+		for idx, stepName := range sg.Sequence { // for each step in the sequence...
+			if _, ok := sg.Steps[stepName]; !ok {
+				errs = append(errs, ValidationError{fmt.Sprintf("%v.sequence[%v]", groupPath, idx), fmt.Sprintf("step %q is not defined", stepName)})
+				continue
+			}
+			if _, ok := seqIdx[stepName]; !ok {
+				seqIdx[stepName] = idx
+			}
+		}
+		// Dead-letter outputs can be read by steps that come after the step that produces them.
+		for idx, stepName := range sg.Sequence {
+			d := sg.Steps[stepName].DeadLetterStep
+			if d == "" {
+				continue
+			}
+			if _, ok := sg.Steps[d]; ok {
+				errs = append(errs, ValidationError{fmt.Sprintf("%v.steps.%v.deadLetterStep", groupPath, stepName), fmt.Sprintf("dead-letter step %q has the same name as a step", d)})
+			} else if _, ok := seqIdx[d]; ok {
+				errs = append(errs, ValidationError{fmt.Sprintf("%v.steps.%v.deadLetterStep", groupPath, stepName), fmt.Sprintf("dead-letter step %q is used more than once", d)})
+			} else {
+				seqIdx[d] = idx
+			}
+		}
+		stepNames := make([]string, 0, len(sg.Steps))
+		for name := range sg.Steps {
+			stepNames = append(stepNames, name)
+		}
+		sort.Strings(stepNames)
+		for _, stepName := range stepNames { // for each step...
+			errs = append(errs, validateStep(log, t, funcs, groupPath+".steps."+stepName, stepName, sg.Steps[stepName], seqIdx)...)
+		}
+	}
+	return
+}
+
+// validateStep checks a single step where seqIdx holds the position of each step in the step group sequence.
+func validateStep(log logger.Logger, t *TransformDefinition, funcs MapComponentFuncs, stepPath string, stepName string, step Step, seqIdx map[string]int) (errs []ValidationError) {
+	if _, ok := funcs[step.Type]; !ok {
+		return []ValidationError{{stepPath + ".type", fmt.Sprintf("unknown step type %q", step.Type)}}
+	}
+	if err := ValidateStepData(log, step.Type, step.Data); err != nil {
+		errs = append(errs, ValidationError{stepPath + ".data", err.Error()})
+	}
+	if err := validateStepOnError(step, stepSupportsRowErrors(step.Type)); err != nil {
+		errs = append(errs, ValidationError{stepPath + ".onError", err.Error()})
+	}
+	refs := getStepDataRefs(step.Type)
+	// Check steps read from earlier steps in the same group.
+	checkEarlierStep := func(path string, name string) {
+		idx, ok := seqIdx[name]
+		if !ok {
+			errs = append(errs, ValidationError{path, fmt.Sprintf("step %q is not found in the step group sequence", name)})
+		} else if thisIdx, ok := seqIdx[stepName]; ok && idx >= thisIdx {
+			errs = append(errs, ValidationError{path, fmt.Sprintf("step %q must come before step %q in the step group sequence", name, stepName)})
+		}
+	}
+	for _, k := range refs.stepKeys {
+		if v := step.Data[k]; v != "" {
+			checkEarlierStep(stepPath+".data."+k, v)
+		}
+	}
+	if step.Type == "MergeStreamsCartesian" { // if the input steps are supplied as component steps...
+		for idx, cs := range step.ComponentSteps {
+			checkEarlierStep(fmt.Sprintf("%v.steps[%v].type", stepPath, idx), cs.Type)
+		}
+	}
+	// Check steps that read from any step group.
+	for _, k := range refs.globalStepKeys {
+		if v := step.Data[k]; v != "" && !stepExistsInTransform(t, v) {
+			errs = append(errs, ValidationError{stepPath + ".data." + k, fmt.Sprintf("step %q is not defined in any step group", v)})
+		}
+	}
+	// Check database connections.
+	for _, k := range refs.connectionKeys {
+		if v := step.Data[k]; v != "" {
+			if _, ok := t.Connections[v]; !ok {
+				errs = append(errs, ValidationError{stepPath + ".data." + k, fmt.Sprintf("connection %q is not defined", v)})
+			}
+		}
+	}
+	// Check metadata injection targets.
+	if step.Type == "MetadataInjection" {
+		if v := step.Data["executeTransformName"]; v == "" {
+			errs = append(errs, ValidationError{stepPath + ".data.executeTransformName", "missing value for mandatory key"})
+		} else if _, ok := t.StepGroups[v]; !ok {
+			errs = append(errs, ValidationError{stepPath + ".data.executeTransformName", fmt.Sprintf("step group %q is not defined", v)})
+		}
+	}
+	return
+}
+
+// getStepDataRefs returns the keys in step data that refer to other steps or database connections for the given
+// step type.
+func getStepDataRefs(stepType string) (refs stepDataRefs) {
+	if r, ok := bespokeStepDataRefs[stepType]; ok {
+		return r
+	}
+	c, ok := componentConfigs[stepType]
+	if !ok {
+		return
+	}
+	refs.stepKeys = getStepDataKeysOfType(reflect.TypeOf(c.cfg), typeRecordChan)
+	refs.connectionKeys = getStepDataKeysOfType(reflect.TypeOf(c.cfg), typeConnector)
+	return
+}
+
+// getStepDataKeysOfType returns the data tag values of fields in struct type t, and any embedded structs, whose
+// type is typ.
+func getStepDataKeysOfType(t reflect.Type, typ reflect.Type) (keys []string) {
+	for idx := 0; idx < t.NumField(); idx++ { // for each field in the struct...
+		sf := t.Field(idx)
+		key, ok := sf.Tag.Lookup(stepDataTag)
+		if !ok {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				keys = append(keys, getStepDataKeysOfType(sf.Type, typ)...)
+			}
+			continue
+		}
+		if sf.Type == typ {
+			keys = append(keys, key)
+		}
+	}
+	return
+}
+
+// stepSupportsRowErrors returns true if the given step type supports the onError settings of a Step.
+func stepSupportsRowErrors(stepType string) bool {
+	c, ok := componentConfigs[stepType]
+	if !ok {
+		return false
+	}
+	return hasFieldOfType(reflect.TypeOf(c.cfg), typeRowErrorHandler)
+}
+
+func stepExistsInTransform(t *TransformDefinition, stepName string) bool {
+	for _, sg := range t.StepGroups {
+		if _, ok := sg.Steps[stepName]; ok {
+			return true
+		}
+		for _, step := range sg.Steps { // for each step that may have a dead-letter output...
+			if step.DeadLetterStep == stepName {
+				return true
+			}
+		}
+	}
+	return false
+}
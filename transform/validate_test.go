@@ -0,0 +1,127 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/relloyd/halfpipe/components"
+	"github.com/relloyd/halfpipe/logger"
+	"github.com/relloyd/halfpipe/rdbms/shared"
+)
+
+func newValidTransformDefinition() *TransformDefinition {
+	return &TransformDefinition{
+		Type:        TransformOnce,
+		Connections: shared.DBConnections{"db": shared.ConnectionDetails{Type: "mock", LogicalName: "db"}},
+		Sequence:    []string{"g1", "g2"},
+		StepGroups: map[string]StepGroup{
+			"g1": {
+				Type:     StepGroupSequential,
+				Sequence: []string{"rows", "exec", "mdi"},
+				Steps: map[string]Step{
+					"rows": {Type: "GenerateRows", Data: map[string]string{"numRows": "1", "sleepIntervalSeconds": "0"}},
+					"exec": {Type: "SqlExec", Data: map[string]string{
+						"readDataFromStep":       "rows",
+						"databaseConnectionName": "db",
+						"sqlQueryFieldName":      "sql",
+					}},
+					"mdi": {Type: "MetadataInjection", Data: map[string]string{
+						"readDataFromStep":     "exec",
+						"executeTransformName": "g3",
+					}},
+				},
+			},
+			"g2": {
+				Type:     StepGroupBackground,
+				Sequence: []string{"bridge", "merge"},
+				Steps: map[string]Step{
+					"bridge": {Type: "ChannelBridge", Data: map[string]string{"readDataFromStep": "exec"}},
+					"merge":  {Type: "MergeStreamsCartesian", Data: map[string]string{}, ComponentSteps: []components.ComponentStep{{Type: "bridge"}}},
+				},
+			},
+			"g3": {
+				Type:     StepGroupSequential,
+				Sequence: []string{"rows"},
+				Steps: map[string]Step{
+					"rows": {Type: "GenerateRows", Data: map[string]string{"numRows": "1", "sleepIntervalSeconds": "0"}},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateTransformDefinition(t *testing.T) {
+	log := logger.NewLogger("test", "info", true)
+	// Test 1 - a valid transform.
+	errs := ValidateTransformDefinition(log, newValidTransformDefinition())
+	if len(errs) != 0 {
+		t.Fatal("unexpected errors: ", errs)
+	}
+	// Test 2 - all problems are reported with their JSON paths.
+	d := newValidTransformDefinition()
+	d.Sequence = append(d.Sequence, "missingGroup")
+	g1 := d.StepGroups["g1"]
+	g1.Sequence = []string{"exec", "rows", "mdi", "missingStep"}
+	g1.Steps["exec"].Data["databaseConnectionName"] = "missingConn"
+	g1.Steps["mdi"].Data["executeTransformName"] = "missingTarget"
+	g1.Steps["bad"] = Step{Type: "NoSuchType", Data: map[string]string{}}
+	d.StepGroups["g1"] = g1
+	d.StepGroups["g2"].Steps["bridge"].Data["readDataFromStep"] = "missingBridgeInput"
+	d.StepGroups["g2"].Steps["merge"].ComponentSteps[0].Type = "missingMergeInput"
+	expected := []string{
+		`$.sequence[2]: step group "missingGroup" is not defined`,
+		`$.transformGroups.g1.sequence[3]: step "missingStep" is not defined`,
+		`$.transformGroups.g1.steps.bad.type: unknown step type "NoSuchType"`,
+		`$.transformGroups.g1.steps.exec.data.readDataFromStep: step "rows" must come before step "exec"`,
+		`$.transformGroups.g1.steps.exec.data.databaseConnectionName: connection "missingConn" is not defined`,
+		`$.transformGroups.g1.steps.mdi.data.executeTransformName: step group "missingTarget" is not defined`,
+		`$.transformGroups.g2.steps.bridge.data.readDataFromStep: step "missingBridgeInput" is not defined in any step group`,
+		`$.transformGroups.g2.steps.merge.steps[0].type: step "missingMergeInput" is not found in the step group sequence`,
+	}
+	errs = ValidateTransformDefinition(log, d)
+	all := make([]string, len(errs))
+	for idx, e := range errs {
+		all[idx] = e.Error()
+	}
+	got := strings.Join(all, "\n")
+	for _, s := range expected {
+		if !strings.Contains(got, s) {
+			t.Fatalf("expected errors to contain %v; got:\n%v", s, got)
+		}
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %v errors; got:\n%v", len(expected), got)
+	}
+	// Test 3 - bad step data is reported.
+	d = newValidTransformDefinition()
+	delete(d.StepGroups["g3"].Steps["rows"].Data, "sleepIntervalSeconds")
+	errs = ValidateTransformDefinition(log, d)
+	if len(errs) != 1 || errs[0].Path != "$.transformGroups.g3.steps.rows.data" {
+		t.Fatal("expected error for missing step data; got: ", errs)
+	}
+	// Test 4 - dead-letter steps can be read by later steps.
+	d = newValidTransformDefinition()
+	exec := d.StepGroups["g1"].Steps["exec"]
+	exec.OnError = components.OnErrorDeadLetter
+	exec.DeadLetterStep = "execRejected"
+	d.StepGroups["g1"].Steps["exec"] = exec
+	d.StepGroups["g2"].Steps["bridge"].Data["readDataFromStep"] = "execRejected"
+	if errs = ValidateTransformDefinition(log, d); len(errs) != 0 {
+		t.Fatal("unexpected errors for dead-letter step: ", errs)
+	}
+	// Test 5 - bad onError settings are reported.
+	for _, s := range []Step{
+		{Type: "SqlExec", OnError: "ignore"},
+		{Type: "SqlExec", OnError: components.OnErrorDeadLetter},
+		{Type: "SqlExec", OnError: components.OnErrorSkip, DeadLetterStep: "x"},
+		{Type: "SqlExec", OnError: components.OnErrorSkip, MaxErrors: -1},
+		{Type: "ChannelBridge", OnError: components.OnErrorSkip},
+	} {
+		d = newValidTransformDefinition()
+		s.Data = d.StepGroups["g1"].Steps["exec"].Data
+		d.StepGroups["g1"].Steps["exec"] = s
+		if errs = ValidateTransformDefinition(log, d); len(errs) != 1 || errs[0].Path != "$.transformGroups.g1.steps.exec.onError" {
+			t.Fatal("expected onError error for step ", s, "; got: ", errs)
+		}
+	}
+}